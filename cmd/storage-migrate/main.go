@@ -0,0 +1,50 @@
+// Command storage-migrate memindahkan file upload antar backend storage, misal
+// dari folder ./uploads ke bucket S3:
+//
+//	go run ./cmd/storage-migrate -from local -to s3 -delete-source
+//
+// Konfigurasi database dan storage dibaca dari .env yang sama dengan server.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"modul4crud/database"
+	"modul4crud/repositories/mongodb"
+	"modul4crud/services"
+	"modul4crud/storage"
+)
+
+func main() {
+	from := flag.String("from", storage.BackendLocal, "backend asal (local, s3)")
+	to := flag.String("to", storage.BackendS3, "backend tujuan (local, s3)")
+	deleteSource := flag.Bool("delete-source", false, "hapus file di backend asal setelah berhasil dipindah")
+	dryRun := flag.Bool("dry-run", false, "tampilkan file yang akan dipindah tanpa mengubah apa pun")
+	flag.Parse()
+
+	database.ConnectDB()
+	if !database.IsMongoDB() {
+		log.Fatalf("Metadata file hanya tersimpan di MongoDB, DB_TYPE saat ini: %s", database.DBType)
+	}
+
+	storages, err := storage.NewRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("Storage configuration failed: %v", err)
+	}
+
+	result, err := services.MigrateFiles(context.Background(), mongodb.NewFileRepository(database.MongoDB), mongodb.NewFileBlobRepository(database.MongoDB), storages, services.FileMigrationOptions{
+		From:         *from,
+		To:           *to,
+		DeleteSource: *deleteSource,
+		DryRun:       *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("Migrasi storage gagal: %v", err)
+	}
+
+	log.Printf("✓ %d file dipindah, %d dilewati, %d gagal", result.Moved, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		log.Fatal("Sebagian file gagal dipindah, jalankan ulang perintah yang sama untuk mencoba lagi")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"modul4crud/database"
+	"modul4crud/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -29,6 +30,16 @@ func RunMongoDBMigrations() {
 		"mahasiswas",
 		"alumnis",
 		"pekerjaan_alumnis",
+		"perusahaans",
+		"referensis",
+		"surveys",
+		"survey_responses",
+		"record_histories",
+		"files",
+		"file_blobs",
+		"upload_sessions",
+		"storage_quotas",
+		"file_links",
 	}
 
 	// Get existing collections
@@ -64,6 +75,12 @@ func RunMongoDBMigrations() {
 	// Create indexes (non-blocking - akan tetap lanjut meskipun gagal)
 	createMongoDBIndexes(ctx)
 
+	// Isi gaji terstruktur dari data gaji_range lama
+	backfillMongoDBGaji(ctx)
+
+	// Dokumen lama belum punya nomor versi untuk ETag/If-Match
+	backfillMongoDBVersion(ctx)
+
 	log.Println("MongoDB database migrations completed successfully!")
 	log.Println("⚠️  Note: If indexes failed due to disk space, the app will still work but queries may be slower.")
 }
@@ -91,10 +108,126 @@ func createMongoDBIndexes(ctx context.Context) {
 	pekerjaanCollection := database.MongoDB.Collection("pekerjaan_alumnis")
 	createMongoIndex(ctx, pekerjaanCollection, "alumni_id", false, "idx_pekerjaan_alumni_id")
 	createMongoIndex(ctx, pekerjaanCollection, "deleted_at", false, "idx_pekerjaan_deleted_at")
+	createMongoIndex(ctx, pekerjaanCollection, "perusahaan_id", false, "idx_pekerjaan_perusahaan_id")
+
+	// Index untuk perusahaans collection
+	perusahaansCollection := database.MongoDB.Collection("perusahaans")
+	createMongoIndex(ctx, perusahaansCollection, "id", true, "idx_perusahaans_id")
+	createMongoIndex(ctx, perusahaansCollection, "nama_normal", true, "idx_perusahaans_nama_normal")
+	createMongoIndex(ctx, perusahaansCollection, "alias_normals", false, "idx_perusahaans_alias_normals")
+
+	// Index untuk referensis collection
+	referensisCollection := database.MongoDB.Collection("referensis")
+	createMongoIndex(ctx, referensisCollection, "id", true, "idx_referensis_id")
+	createMongoIndex(ctx, referensisCollection, "kategori", false, "idx_referensis_kategori")
+
+	// Index untuk surveys & survey_responses collection
+	surveysCollection := database.MongoDB.Collection("surveys")
+	createMongoIndex(ctx, surveysCollection, "id", true, "idx_surveys_id")
+	createMongoIndex(ctx, surveysCollection, "kode", false, "idx_surveys_kode")
+	surveyResponsesCollection := database.MongoDB.Collection("survey_responses")
+	createMongoIndex(ctx, surveyResponsesCollection, "id", true, "idx_survey_responses_id")
+	createMongoCompoundIndex(ctx, surveyResponsesCollection, "idx_survey_responses_survey_alumni",
+		bson.D{{Key: "survey_id", Value: 1}, {Key: "alumni_id", Value: 1}}, true)
+	createMongoIndex(ctx, surveyResponsesCollection, "alumni_id", false, "idx_survey_responses_alumni_id")
+
+	// Index untuk record_histories collection
+	historiesCollection := database.MongoDB.Collection("record_histories")
+	createMongoIndex(ctx, historiesCollection, "id", true, "idx_record_histories_id")
+	createMongoIndex(ctx, historiesCollection, "record_id", false, "idx_record_histories_record_id")
+
+	// Index untuk files collection: daftar file per pemilik dan per entitas
+	filesCollection := database.MongoDB.Collection("files")
+	createMongoIndex(ctx, filesCollection, "owner_id", false, "idx_files_owner_id")
+	createMongoIndex(ctx, filesCollection, "entity_id", false, "idx_files_entity_id")
+	createMongoIndex(ctx, filesCollection, "uploaded_at", false, "idx_files_uploaded_at")
+
+	// Index untuk upload_sessions: pembersihan sesi kedaluwarsa
+	uploadSessionsCollection := database.MongoDB.Collection("upload_sessions")
+	createMongoIndex(ctx, uploadSessionsCollection, "expires_at", false, "idx_upload_sessions_expires_at")
+
+	// Index untuk storage_quotas: satu kuota khusus per user
+	storageQuotasCollection := database.MongoDB.Collection("storage_quotas")
+	createMongoIndex(ctx, storageQuotasCollection, "owner_id", true, "idx_storage_quotas_owner_id")
+
+	// Index untuk file_links: link per file dan daftar link yang masih berlaku
+	fileLinksCollection := database.MongoDB.Collection("file_links")
+	createMongoIndex(ctx, fileLinksCollection, "file_id", false, "idx_file_links_file_id")
+	createMongoIndex(ctx, fileLinksCollection, "expires_at", false, "idx_file_links_expires_at")
+
+	// Text index untuk full-text search ($text); bobot mengikuti SearchFields model
+	createMongoTextIndex(ctx, mahasiswasCollection, "idx_mahasiswas_search",
+		bson.D{{Key: "nim", Value: 10}, {Key: "nama", Value: 10}, {Key: "jurusan", Value: 4}, {Key: "email", Value: 2}})
+	createMongoTextIndex(ctx, alumnisCollection, "idx_alumnis_search",
+		bson.D{{Key: "nim", Value: 10}, {Key: "nama", Value: 10}, {Key: "jurusan", Value: 4}})
+	createMongoTextIndex(ctx, pekerjaanCollection, "idx_pekerjaan_search",
+		bson.D{{Key: "posisi_jabatan", Value: 10}, {Key: "nama_perusahaan", Value: 10}, {Key: "bidang_industri", Value: 4}, {Key: "lokasi_kerja", Value: 4}, {Key: "deskripsi_pekerjaan", Value: 1}})
+	createMongoTextIndex(ctx, perusahaansCollection, "idx_perusahaans_search",
+		bson.D{{Key: "nama", Value: 10}, {Key: "aliases", Value: 10}, {Key: "bidang_industri", Value: 4}, {Key: "lokasi", Value: 2}})
 
 	log.Println("MongoDB indexes creation completed!")
 }
 
+// createMongoTextIndex membuat text index atas beberapa field dengan bobotnya.
+// Satu collection hanya boleh punya satu text index; default_language "none"
+// supaya kata tidak di-stem dan tidak ada stop word bahasa Inggris.
+func createMongoTextIndex(ctx context.Context, collection *mongo.Collection, indexName string, weights bson.D) {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		log.Printf("Error listing indexes for %s: %v", collection.Name(), err)
+		return
+	}
+	for _, spec := range specs {
+		if spec.Name == indexName {
+			log.Printf("✓ Index %s on %s already exists", indexName, collection.Name())
+			return
+		}
+	}
+
+	keys := bson.D{}
+	weightMap := bson.M{}
+	for _, field := range weights {
+		keys = append(keys, bson.E{Key: field.Key, Value: "text"})
+		weightMap[field.Key] = field.Value
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetName(indexName).SetWeights(weightMap).SetDefaultLanguage("none"),
+	}
+	if _, err := collection.Indexes().CreateOne(ctx, indexModel); err != nil {
+		log.Printf("Error creating text index %s on %s: %v", indexName, collection.Name(), err)
+	} else {
+		log.Printf("✓ Created text index %s on %s", indexName, collection.Name())
+	}
+}
+
+// createMongoCompoundIndex membuat index atas beberapa field sekaligus, mis. unique
+// constraint (survey_id, alumni_id) yang juga melayani query per survey_id
+func createMongoCompoundIndex(ctx context.Context, collection *mongo.Collection, indexName string, keys bson.D, unique bool) {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		log.Printf("Error listing indexes for %s: %v", collection.Name(), err)
+		return
+	}
+	for _, spec := range specs {
+		if spec.Name == indexName {
+			log.Printf("✓ Index %s on %s already exists", indexName, collection.Name())
+			return
+		}
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(unique).SetName(indexName),
+	}
+	if _, err := collection.Indexes().CreateOne(ctx, indexModel); err != nil {
+		log.Printf("Error creating index %s on %s: %v", indexName, collection.Name(), err)
+	} else {
+		log.Printf("✓ Created index %s on %s", indexName, collection.Name())
+	}
+}
+
 // createMongoIndex helper function untuk membuat index di MongoDB
 func createMongoIndex(ctx context.Context, collection *mongo.Collection, field string, unique bool, indexName string) {
 	// Check if index already exists
@@ -132,6 +265,74 @@ func createMongoIndex(ctx context.Context, collection *mongo.Collection, field s
 	}
 }
 
+// backfillMongoDBGaji mem-parsing gaji_range lama menjadi field gaji terstruktur
+func backfillMongoDBGaji(ctx context.Context) {
+	collection := database.MongoDB.Collection("pekerjaan_alumnis")
+	filter := bson.M{
+		"gaji_min":   bson.M{"$eq": nil},
+		"gaji_max":   bson.M{"$eq": nil},
+		"gaji_range": bson.M{"$nin": []interface{}{nil, ""}},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error reading gaji_range for backfill: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID        uint   `bson:"id"`
+		GajiRange string `bson:"gaji_range"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		log.Printf("Error decoding gaji_range for backfill: %v", err)
+		return
+	}
+
+	updated := 0
+	for _, row := range rows {
+		parsed, ok := utils.ParseGajiRange(row.GajiRange)
+		if !ok {
+			continue
+		}
+		_, err := collection.UpdateOne(ctx, bson.M{"id": row.ID}, bson.M{"$set": bson.M{
+			"gaji_min":       parsed.Min,
+			"gaji_max":       parsed.Max,
+			"gaji_mata_uang": parsed.MataUang,
+			"gaji_periode":   parsed.Periode,
+		}})
+		if err != nil {
+			log.Printf("Error backfilling gaji for pekerjaan %d: %v", row.ID, err)
+			continue
+		}
+		updated++
+	}
+
+	if len(rows) > 0 {
+		log.Printf("✓ Gaji backfill: %d of %d pekerjaan parsed from gaji_range", updated, len(rows))
+	}
+}
+
+// backfillMongoDBVersion memberi versi 1 pada dokumen yang belum punya field
+// version, supaya update bersyarat versi di repository bisa menemukannya
+func backfillMongoDBVersion(ctx context.Context) {
+	versioned := []string{"users", "mahasiswas", "alumnis", "pekerjaan_alumnis", "perusahaans", "referensis", "surveys"}
+	for _, name := range versioned {
+		result, err := database.MongoDB.Collection(name).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}},
+		)
+		if err != nil {
+			log.Printf("Error backfilling version on %s: %v", name, err)
+			continue
+		}
+		if result.ModifiedCount > 0 {
+			log.Printf("✓ Version backfill: %d documents in %s", result.ModifiedCount, name)
+		}
+	}
+}
+
 // DropMongoDBCollections fungsi untuk menghapus semua collections (gunakan dengan hati-hati!)
 func DropMongoDBCollections() error {
 	if !database.IsMongoDB() {
@@ -142,7 +343,7 @@ func DropMongoDBCollections() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	collections := []string{"users", "mahasiswas", "alumnis", "pekerjaan_alumnis"}
+	collections := []string{"users", "mahasiswas", "alumnis", "pekerjaan_alumnis", "perusahaans", "referensis", "surveys", "survey_responses", "record_histories"}
 
 	for _, collectionName := range collections {
 		log.Printf("Dropping collection: %s...", collectionName)
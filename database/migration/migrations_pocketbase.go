@@ -7,7 +7,9 @@ import (
 	"io"
 	"log"
 	"modul4crud/database"
+	"modul4crud/utils"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 )
@@ -24,6 +26,7 @@ type PBCollection struct {
 	UpdateRule *string                  `json:"updateRule"`
 	DeleteRule *string                  `json:"deleteRule"`
 	Options    map[string]interface{}   `json:"options,omitempty"`
+	Indexes    []string                 `json:"indexes,omitempty"` // CREATE INDEX statements, mis. untuk unique constraint
 }
 
 type PBField struct {
@@ -33,6 +36,16 @@ type PBField struct {
 	Options  map[string]interface{} `json:"options,omitempty"`
 }
 
+// versionUpdateRule dan versionDeleteRule menjaga optimistic lock (kolom version)
+// di sisi PocketBase: PATCH harus membawa version yang lebih besar dari yang
+// tersimpan (rule tidak mendukung aritmetika; repository selalu mengirim versi
+// yang dibaca + 1), DELETE dengan ?version= hanya berjalan jika versinya sama.
+// Penolakan rule dijawab 404 dan dipetakan repository ke PRECONDITION_FAILED.
+const (
+	versionUpdateRule = "@request.body.version > version"
+	versionDeleteRule = "@request.query.version:isset = false || version = @request.query.version"
+)
+
 // RunPocketBaseMigrations membuat collections di PocketBase jika belum ada
 func RunPocketBaseMigrations() {
 	if !database.IsPocketBase() {
@@ -60,6 +73,14 @@ func RunPocketBaseMigrations() {
 	createMahasiswasCollection(token)
 	createAlumnisCollection(token)
 	createPekerjaanAlumnisCollection(token)
+	createPerusahaansCollection(token)
+	createReferensisCollection(token)
+	createSurveysCollection(token)
+	createSurveyResponsesCollection(token)
+	createRecordHistoriesCollection(token)
+
+	// Isi gaji terstruktur dari data gaji_range lama
+	backfillPocketBaseGaji(token)
 
 	log.Println("PocketBase database migrations completed successfully!")
 }
@@ -175,12 +196,13 @@ func createUsersCollection(token string) {
 			{Name: "username", Type: "text", Required: true, Options: map[string]interface{}{"min": 3, "max": 50}},
 			{Name: "role", Type: "text", Required: true, Options: map[string]interface{}{"min": 1, "max": 20}},
 			{Name: "is_active", Type: "bool", Required: false},
+			{Name: "version", Type: "number", Required: false},
 		},
 		ListRule:   stringPtr(""),
 		ViewRule:   stringPtr(""),
 		CreateRule: stringPtr(""),
-		UpdateRule: stringPtr(""),
-		DeleteRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
 	}
 
 	if err := createOrUpdateCollection(token, collection); err != nil {
@@ -205,12 +227,20 @@ func createMahasiswasCollection(token string) {
 			{Name: "jurusan", Type: "text", Required: true, Options: map[string]interface{}{"min": 1, "max": 50}},
 			{Name: "angkatan", Type: "number", Required: true},
 			{Name: "email", Type: "email", Required: true},
+			{Name: "version", Type: "number", Required: false},
+			// json (bukan date/number) supaya nilai kosong dikembalikan sebagai null
+			{Name: "deleted_at", Type: "json", Required: false},
+			{Name: "merged_into", Type: "json", Required: false},
+		},
+		Indexes: []string{
+			"CREATE UNIQUE INDEX `idx_mahasiswas_nim` ON `mahasiswas` (`nim`)",
+			"CREATE UNIQUE INDEX `idx_mahasiswas_email` ON `mahasiswas` (`email`)",
 		},
 		ListRule:   stringPtr(""),
 		ViewRule:   stringPtr(""),
 		CreateRule: stringPtr(""),
-		UpdateRule: stringPtr(""),
-		DeleteRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
 	}
 
 	if err := createOrUpdateCollection(token, collection); err != nil {
@@ -228,16 +258,23 @@ func createAlumnisCollection(token string) {
 			{Name: "nim", Type: "text", Required: true, Options: map[string]interface{}{"min": 1, "max": 20}},
 			{Name: "nama", Type: "text", Required: true, Options: map[string]interface{}{"min": 1, "max": 100}},
 			{Name: "jurusan", Type: "text", Required: true, Options: map[string]interface{}{"min": 1, "max": 50}},
+			{Name: "kode_prodi", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
 			{Name: "angkatan", Type: "number", Required: true},
 			{Name: "tahun_lulus", Type: "number", Required: true},
 			{Name: "no_telepon", Type: "text", Required: false, Options: map[string]interface{}{"max": 15}},
 			{Name: "alamat", Type: "text", Required: false},
+			{Name: "version", Type: "number", Required: false},
+			{Name: "deleted_at", Type: "json", Required: false},
+			{Name: "merged_into", Type: "json", Required: false},
+		},
+		Indexes: []string{
+			"CREATE UNIQUE INDEX `idx_alumnis_nim` ON `alumnis` (`nim`)",
 		},
 		ListRule:   stringPtr(""),
 		ViewRule:   stringPtr(""),
 		CreateRule: stringPtr(""),
-		UpdateRule: stringPtr(""),
-		DeleteRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
 	}
 
 	if err := createOrUpdateCollection(token, collection); err != nil {
@@ -252,15 +289,132 @@ func createPekerjaanAlumnisCollection(token string) {
 		Type: "base",
 		Schema: []PBField{
 			{Name: "alumni_id", Type: "number", Required: true},
+			{Name: "perusahaan_id", Type: "number", Required: false},
 			{Name: "nama_perusahaan", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
 			{Name: "posisi_jabatan", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
 			{Name: "bidang_industri", Type: "text", Required: true, Options: map[string]interface{}{"max": 50}},
+			{Name: "kode_industri", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
 			{Name: "lokasi_kerja", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
+			{Name: "kode_provinsi", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
+			{Name: "kode_kota", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
 			{Name: "gaji_range", Type: "text", Required: false, Options: map[string]interface{}{"max": 50}},
+			{Name: "gaji_min", Type: "number", Required: false},
+			{Name: "gaji_max", Type: "number", Required: false},
+			{Name: "gaji_mata_uang", Type: "text", Required: false, Options: map[string]interface{}{"max": 3}},
+			{Name: "gaji_periode", Type: "text", Required: false, Options: map[string]interface{}{"max": 10}},
 			{Name: "tanggal_mulai_kerja", Type: "date", Required: true},
 			{Name: "tanggal_selesai_kerja", Type: "date", Required: false},
 			{Name: "status_pekerjaan", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
 			{Name: "deskripsi_pekerjaan", Type: "text", Required: false},
+			{Name: "version", Type: "number", Required: false},
+		},
+		ListRule:   stringPtr(""),
+		ViewRule:   stringPtr(""),
+		CreateRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
+	}
+
+	if err := createOrUpdateCollection(token, collection); err != nil {
+		log.Printf("Error with pekerjaan_alumnis collection: %v", err)
+	}
+}
+
+// createPerusahaansCollection creates perusahaans collection
+func createPerusahaansCollection(token string) {
+	collection := PBCollection{
+		Name: "perusahaans",
+		Type: "base",
+		Schema: []PBField{
+			{Name: "nama", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
+			{Name: "nama_normal", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
+			{Name: "bidang_industri", Type: "text", Required: false, Options: map[string]interface{}{"max": 50}},
+			{Name: "lokasi", Type: "text", Required: false, Options: map[string]interface{}{"max": 100}},
+			{Name: "website", Type: "text", Required: false, Options: map[string]interface{}{"max": 255}},
+			{Name: "aliases", Type: "json", Required: false},
+			{Name: "alias_normals", Type: "json", Required: false},
+			{Name: "version", Type: "number", Required: false},
+		},
+		ListRule:   stringPtr(""),
+		ViewRule:   stringPtr(""),
+		CreateRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
+	}
+
+	if err := createOrUpdateCollection(token, collection); err != nil {
+		log.Printf("Error with perusahaans collection: %v", err)
+	}
+}
+
+// createReferensisCollection creates referensis collection (controlled vocabulary)
+func createReferensisCollection(token string) {
+	collection := PBCollection{
+		Name: "referensis",
+		Type: "base",
+		Schema: []PBField{
+			{Name: "kategori", Type: "text", Required: true, Options: map[string]interface{}{"max": 20}},
+			{Name: "kode", Type: "text", Required: true, Options: map[string]interface{}{"max": 20}},
+			{Name: "nama", Type: "text", Required: true, Options: map[string]interface{}{"max": 100}},
+			{Name: "kode_induk", Type: "text", Required: false, Options: map[string]interface{}{"max": 20}},
+			{Name: "aliases", Type: "json", Required: false},
+			{Name: "kode_terkait", Type: "json", Required: false},
+			{Name: "version", Type: "number", Required: false},
+		},
+		ListRule:   stringPtr(""),
+		ViewRule:   stringPtr(""),
+		CreateRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
+	}
+
+	if err := createOrUpdateCollection(token, collection); err != nil {
+		log.Printf("Error with referensis collection: %v", err)
+	}
+}
+
+// createSurveysCollection creates surveys collection (tracer study questionnaire)
+func createSurveysCollection(token string) {
+	collection := PBCollection{
+		Name: "surveys",
+		Type: "base",
+		Schema: []PBField{
+			{Name: "kode", Type: "text", Required: true, Options: map[string]interface{}{"max": 50}},
+			{Name: "versi", Type: "number", Required: true},
+			{Name: "judul", Type: "text", Required: true, Options: map[string]interface{}{"max": 200}},
+			{Name: "deskripsi", Type: "text", Required: false},
+			{Name: "status", Type: "text", Required: true, Options: map[string]interface{}{"max": 20}},
+			{Name: "target_tahun_lulus", Type: "json", Required: false},
+			{Name: "target_jurusan", Type: "json", Required: false},
+			{Name: "pertanyaan", Type: "json", Required: false},
+			{Name: "dibuka_at", Type: "date", Required: false},
+			{Name: "ditutup_at", Type: "date", Required: false},
+			{Name: "version", Type: "number", Required: false},
+		},
+		ListRule:   stringPtr(""),
+		ViewRule:   stringPtr(""),
+		CreateRule: stringPtr(""),
+		UpdateRule: stringPtr(versionUpdateRule),
+		DeleteRule: stringPtr(versionDeleteRule),
+	}
+
+	if err := createOrUpdateCollection(token, collection); err != nil {
+		log.Printf("Error with surveys collection: %v", err)
+	}
+}
+
+// createSurveyResponsesCollection creates survey_responses collection (satu jawaban per alumni per survey)
+func createSurveyResponsesCollection(token string) {
+	collection := PBCollection{
+		Name: "survey_responses",
+		Type: "base",
+		Schema: []PBField{
+			{Name: "survey_id", Type: "number", Required: true},
+			{Name: "alumni_id", Type: "number", Required: true},
+			{Name: "jawaban", Type: "json", Required: false},
+		},
+		Indexes: []string{
+			"CREATE UNIQUE INDEX `idx_survey_responses_survey_alumni` ON `survey_responses` (`survey_id`, `alumni_id`)",
 		},
 		ListRule:   stringPtr(""),
 		ViewRule:   stringPtr(""),
@@ -270,7 +424,107 @@ func createPekerjaanAlumnisCollection(token string) {
 	}
 
 	if err := createOrUpdateCollection(token, collection); err != nil {
-		log.Printf("Error with pekerjaan_alumnis collection: %v", err)
+		log.Printf("Error with survey_responses collection: %v", err)
+	}
+}
+
+// createRecordHistoriesCollection creates record_histories collection (snapshot sebelum update)
+func createRecordHistoriesCollection(token string) {
+	collection := PBCollection{
+		Name: "record_histories",
+		Type: "base",
+		Schema: []PBField{
+			{Name: "entity", Type: "text", Required: true, Options: map[string]interface{}{"max": 20}},
+			{Name: "record_id", Type: "number", Required: true},
+			{Name: "version", Type: "number", Required: true},
+			{Name: "snapshot", Type: "json", Required: false},
+			{Name: "changed_by", Type: "number", Required: false},
+		},
+		Indexes: []string{
+			"CREATE INDEX `idx_record_histories_record` ON `record_histories` (`entity`, `record_id`)",
+		},
+		ListRule:   stringPtr(""),
+		ViewRule:   stringPtr(""),
+		CreateRule: stringPtr(""),
+		UpdateRule: stringPtr(""),
+		DeleteRule: stringPtr(""),
+	}
+
+	if err := createOrUpdateCollection(token, collection); err != nil {
+		log.Printf("Error with record_histories collection: %v", err)
+	}
+}
+
+// backfillPocketBaseGaji mem-parsing gaji_range lama menjadi field gaji terstruktur
+func backfillPocketBaseGaji(token string) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	filter := url.QueryEscape("gaji_range!=''")
+	updated, total := 0, 0
+
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records?perPage=500&page=%d&filter=%s",
+			database.PocketBaseURL, page, filter)
+		req, _ := http.NewRequest("GET", endpoint, nil)
+		req.Header.Set("Authorization", token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("Error reading gaji_range for backfill: %v", err)
+			return
+		}
+
+		var result struct {
+			Items []struct {
+				ID        string  `json:"id"`
+				GajiRange string  `json:"gaji_range"`
+				GajiMin   float64 `json:"gaji_min"`
+				GajiMax   float64 `json:"gaji_max"`
+			} `json:"items"`
+			TotalPages int `json:"totalPages"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("Error decoding gaji_range for backfill: %v", err)
+			return
+		}
+
+		for _, item := range result.Items {
+			if item.GajiMin > 0 || item.GajiMax > 0 {
+				continue
+			}
+			total++
+			parsed, ok := utils.ParseGajiRange(item.GajiRange)
+			if !ok {
+				continue
+			}
+			payload, _ := json.Marshal(map[string]interface{}{
+				"gaji_min":       parsed.Min,
+				"gaji_max":       parsed.Max,
+				"gaji_mata_uang": parsed.MataUang,
+				"gaji_periode":   parsed.Periode,
+			})
+			patch, _ := http.NewRequest("PATCH", database.PocketBaseURL+"/api/collections/pekerjaan_alumnis/records/"+item.ID, bytes.NewBuffer(payload))
+			patch.Header.Set("Content-Type", "application/json")
+			patch.Header.Set("Authorization", token)
+			patchResp, err := client.Do(patch)
+			if err != nil {
+				log.Printf("Error backfilling gaji for pekerjaan %s: %v", item.ID, err)
+				continue
+			}
+			patchResp.Body.Close()
+			if patchResp.StatusCode == http.StatusOK {
+				updated++
+			}
+		}
+
+		if page >= result.TotalPages || len(result.Items) == 0 {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("✓ Gaji backfill: %d of %d pekerjaan parsed from gaji_range", updated, total)
 	}
 }
 
@@ -1,9 +1,12 @@
 package migration
 
 import (
+	"fmt"
 	"log"
 	"modul4crud/database"
 	"modul4crud/models"
+	"modul4crud/utils"
+	"strings"
 )
 
 // RunPostgresMigrations membuat tabel PostgreSQL jika belum ada
@@ -63,9 +66,112 @@ func RunPostgresMigrations() {
 		log.Println("✓ Pekerjaan_alumnis table already exists")
 	}
 
+	// Check and create perusahaans & perusahaan_aliases tables
+	if !database.DB.Migrator().HasTable(&models.Perusahaan{}) {
+		log.Println("Creating perusahaans table...")
+		if err := database.DB.Migrator().CreateTable(&models.Perusahaan{}); err != nil {
+			log.Printf("Error creating perusahaans table: %v", err)
+		} else {
+			log.Println("✓ Perusahaans table created successfully")
+		}
+	} else {
+		log.Println("✓ Perusahaans table already exists")
+	}
+
+	if !database.DB.Migrator().HasTable(&models.PerusahaanAlias{}) {
+		log.Println("Creating perusahaan_aliases table...")
+		if err := database.DB.Migrator().CreateTable(&models.PerusahaanAlias{}); err != nil {
+			log.Printf("Error creating perusahaan_aliases table: %v", err)
+		} else {
+			log.Println("✓ Perusahaan_aliases table created successfully")
+		}
+	} else {
+		log.Println("✓ Perusahaan_aliases table already exists")
+	}
+
+	// Check and create referensis table (controlled vocabulary)
+	if !database.DB.Migrator().HasTable(&models.Referensi{}) {
+		log.Println("Creating referensis table...")
+		if err := database.DB.Migrator().CreateTable(&models.Referensi{}); err != nil {
+			log.Printf("Error creating referensis table: %v", err)
+		} else {
+			log.Println("✓ Referensis table created successfully")
+		}
+	} else {
+		log.Println("✓ Referensis table already exists")
+	}
+
+	// Check and create surveys & survey_responses tables (tracer study questionnaire)
+	if !database.DB.Migrator().HasTable(&models.Survey{}) {
+		log.Println("Creating surveys table...")
+		if err := database.DB.Migrator().CreateTable(&models.Survey{}); err != nil {
+			log.Printf("Error creating surveys table: %v", err)
+		} else {
+			log.Println("✓ Surveys table created successfully")
+		}
+	} else {
+		log.Println("✓ Surveys table already exists")
+	}
+
+	if !database.DB.Migrator().HasTable(&models.SurveyResponse{}) {
+		log.Println("Creating survey_responses table...")
+		if err := database.DB.Migrator().CreateTable(&models.SurveyResponse{}); err != nil {
+			log.Printf("Error creating survey_responses table: %v", err)
+		} else {
+			log.Println("✓ Survey_responses table created successfully")
+		}
+	} else {
+		log.Println("✓ Survey_responses table already exists")
+	}
+
+	// Check and create record_histories table (riwayat versi mahasiswa/alumni/pekerjaan)
+	if !database.DB.Migrator().HasTable(&models.RecordHistory{}) {
+		log.Println("Creating record_histories table...")
+		if err := database.DB.Migrator().CreateTable(&models.RecordHistory{}); err != nil {
+			log.Printf("Error creating record_histories table: %v", err)
+		} else {
+			log.Println("✓ Record_histories table created successfully")
+		}
+	} else {
+		log.Println("✓ Record_histories table already exists")
+	}
+
+	// Tambahkan kolom baru pada tabel yang sudah ada
+	addPostgresColumnIfMissing(&models.Alumni{}, "KodeProdi", "kode_prodi")
+	addPostgresColumnIfMissing(&models.Referensi{}, "KodeTerkait", "kode_terkait")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "KodeIndustri", "kode_industri")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "KodeProvinsi", "kode_provinsi")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "KodeKota", "kode_kota")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "PerusahaanID", "perusahaan_id")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "GajiMin", "gaji_min")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "GajiMax", "gaji_max")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "GajiMataUang", "gaji_mata_uang")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "GajiPeriode", "gaji_periode")
+
+	// Nomor versi untuk ETag/If-Match; baris lama mulai dari versi 1
+	addPostgresColumnIfMissing(&models.User{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.Mahasiswa{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.Alumni{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.PekerjaanAlumni{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.Perusahaan{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.Referensi{}, "Version", "version")
+	addPostgresColumnIfMissing(&models.Survey{}, "Version", "version")
+
+	// Soft delete untuk record duplikat yang sudah digabung
+	addPostgresColumnIfMissing(&models.Mahasiswa{}, "DeletedAt", "deleted_at")
+	addPostgresColumnIfMissing(&models.Mahasiswa{}, "MergedInto", "merged_into")
+	addPostgresColumnIfMissing(&models.Alumni{}, "DeletedAt", "deleted_at")
+	addPostgresColumnIfMissing(&models.Alumni{}, "MergedInto", "merged_into")
+
 	// Create indexes if they don't exist
 	createPostgresIndexes()
 
+	// Kolom search_vector + GIN index untuk full-text search
+	createPostgresSearchIndexes()
+
+	// Isi gaji terstruktur dari data gaji_range lama
+	backfillPostgresGaji()
+
 	log.Println("PostgreSQL database migrations completed successfully!")
 }
 
@@ -112,5 +218,107 @@ func createPostgresIndexes() {
 		log.Println("✓ Created index on pekerjaan_alumnis.deleted_at")
 	}
 
+	if !database.DB.Migrator().HasIndex(&models.PekerjaanAlumni{}, "idx_pekerjaan_alumnis_perusahaan_id") {
+		database.DB.Migrator().CreateIndex(&models.PekerjaanAlumni{}, "PerusahaanID")
+		log.Println("✓ Created index on pekerjaan_alumnis.perusahaan_id")
+	}
+
 	log.Println("PostgreSQL database indexes creation completed!")
 }
+
+// searchColumn satu kolom sumber search_vector beserta label bobotnya (A paling tinggi)
+type searchColumn struct {
+	column string
+	weight string
+}
+
+// postgresSearchColumns kolom yang diindeks full-text per tabel; harus sejalan dengan
+// SearchFields pada model masing-masing
+var postgresSearchColumns = []struct {
+	table   string
+	columns []searchColumn
+}{
+	{"mahasiswas", []searchColumn{{"nim", "A"}, {"nama", "A"}, {"jurusan", "B"}, {"email", "C"}, {"angkatan", "D"}}},
+	{"alumnis", []searchColumn{{"nim", "A"}, {"nama", "A"}, {"jurusan", "B"}, {"tahun_lulus", "D"}}},
+	{"pekerjaan_alumnis", []searchColumn{{"posisi_jabatan", "A"}, {"nama_perusahaan", "A"}, {"bidang_industri", "B"}, {"lokasi_kerja", "B"}, {"deskripsi_pekerjaan", "D"}}},
+	{"perusahaans", []searchColumn{{"nama", "A"}, {"bidang_industri", "B"}, {"lokasi", "C"}}},
+	{"perusahaan_aliases", []searchColumn{{"alias", "A"}}},
+}
+
+// createPostgresSearchIndexes menambahkan kolom generated search_vector (tsvector)
+// dan GIN index-nya. Tanda baca diganti spasi sebelum to_tsvector supaya token
+// sama dengan models.SearchTerms.
+func createPostgresSearchIndexes() {
+	for _, t := range postgresSearchColumns {
+		parts := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			parts[i] = fmt.Sprintf(
+				"setweight(to_tsvector('simple', regexp_replace(coalesce(%s::text, ''), '[^[:alnum:]]+', ' ', 'g')), '%s')",
+				c.column, c.weight)
+		}
+
+		if !database.DB.Migrator().HasColumn(t.table, "search_vector") {
+			query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (%s) STORED",
+				t.table, strings.Join(parts, " || "))
+			if err := database.DB.Exec(query).Error; err != nil {
+				log.Printf("Error adding column %s.search_vector: %v", t.table, err)
+				continue
+			}
+			log.Printf("✓ Added column %s.search_vector", t.table)
+		}
+
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_search ON %s USING GIN (search_vector)", t.table, t.table)
+		if err := database.DB.Exec(query).Error; err != nil {
+			log.Printf("Error creating search index on %s: %v", t.table, err)
+		}
+	}
+}
+
+// addPostgresColumnIfMissing menambahkan kolom ke tabel yang sudah ada jika belum ada
+func addPostgresColumnIfMissing(model interface{}, field string, column string) {
+	if database.DB.Migrator().HasColumn(model, column) {
+		return
+	}
+	if err := database.DB.Migrator().AddColumn(model, field); err != nil {
+		log.Printf("Error adding column %s: %v", column, err)
+	} else {
+		log.Printf("✓ Added column %s", column)
+	}
+}
+
+// backfillPostgresGaji mem-parsing gaji_range lama menjadi kolom gaji terstruktur
+func backfillPostgresGaji() {
+	var rows []struct {
+		ID        uint
+		GajiRange string
+	}
+	query := `
+		SELECT id, gaji_range FROM pekerjaan_alumnis
+		WHERE gaji_min IS NULL AND gaji_max IS NULL AND COALESCE(gaji_range, '') <> ''
+	`
+	if err := database.DB.Raw(query).Scan(&rows).Error; err != nil {
+		log.Printf("Error reading gaji_range for backfill: %v", err)
+		return
+	}
+
+	updated := 0
+	for _, row := range rows {
+		parsed, ok := utils.ParseGajiRange(row.GajiRange)
+		if !ok {
+			continue
+		}
+		err := database.DB.Exec(
+			`UPDATE pekerjaan_alumnis SET gaji_min = ?, gaji_max = ?, gaji_mata_uang = ?, gaji_periode = ? WHERE id = ?`,
+			parsed.Min, parsed.Max, parsed.MataUang, parsed.Periode, row.ID,
+		).Error
+		if err != nil {
+			log.Printf("Error backfilling gaji for pekerjaan %d: %v", row.ID, err)
+			continue
+		}
+		updated++
+	}
+
+	if len(rows) > 0 {
+		log.Printf("✓ Gaji backfill: %d of %d pekerjaan parsed from gaji_range", updated, len(rows))
+	}
+}
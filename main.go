@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"modul4crud/database"
 	"modul4crud/database/migration"
+	"modul4crud/malware"
+	"modul4crud/middleware"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
 	"modul4crud/repositories/mongodb"
@@ -11,11 +14,16 @@ import (
 	"modul4crud/repositories/postgres"
 	"modul4crud/routes"
 	"modul4crud/services"
+	"modul4crud/storage"
 	"modul4crud/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// maxRequestBody batas ukuran body request; batas upload per purpose diperiksa di file service
+const maxRequestBody = 20 << 20
+
 // createDefaultAdmin membuat user admin default jika belum ada
 func createDefaultAdmin(userRepo repo.UserRepository) {
 	log.Println("Checking for default admin user...")
@@ -55,8 +63,71 @@ func createDefaultAdmin(userRepo repo.UserRepository) {
 	}
 }
 
+// seedReferensi mengisi data referensi awal untuk kategori yang masih kosong
+func seedReferensi(referensiRepo repo.ReferensiRepository) {
+	log.Println("Checking reference data...")
+
+	for _, kategori := range models.KategoriReferensi {
+		existing, err := referensiRepo.GetByKategori(kategori)
+		if err != nil {
+			log.Printf("Error checking referensi %s: %v", kategori, err)
+			continue
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		created := 0
+		for _, ref := range models.DefaultReferensi {
+			if ref.Kategori != kategori {
+				continue
+			}
+			if err := referensiRepo.Create(&ref); err != nil {
+				log.Printf("Warning: Could not create referensi %s %s: %v", kategori, ref.Kode, err)
+				continue
+			}
+			created++
+		}
+		if created > 0 {
+			log.Printf("✓ Seeded %d referensi %s", created, kategori)
+		}
+	}
+}
+
+// cleanupUploadSessions menghapus sesi upload bertahap yang kedaluwarsa setiap jam
+func cleanupUploadSessions(fileService services.FileService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		removed, err := fileService.CleanupExpiredUploads(context.Background())
+		if err != nil {
+			log.Printf("Warning: gagal membersihkan sesi upload: %v", err)
+			continue
+		}
+		if removed > 0 {
+			log.Printf("✓ %d sesi upload kedaluwarsa dibersihkan", removed)
+		}
+	}
+}
+
+// rescanPendingFiles memindai file yang masih pending saat start lalu setiap 5 menit
+func rescanPendingFiles(fileService services.FileService) {
+	for {
+		scanned, err := fileService.RescanPendingFiles(context.Background())
+		if err != nil {
+			log.Printf("Warning: gagal memindai ulang file pending: %v", err)
+		} else if scanned > 0 {
+			log.Printf("✓ %d file pending selesai dipindai", scanned)
+		}
+		time.Sleep(5 * time.Minute)
+	}
+}
+
 func main() {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: middleware.ErrorHandler,
+		BodyLimit:    maxRequestBody,
+	})
 
 	// Static files middleware
 	app.Static("/static", "./static")
@@ -112,39 +183,104 @@ func main() {
 	var mahasiswaRepo repo.MahasiswaRepository
 	var alumniRepo repo.AlumniRepository
 	var pekerjaanRepo repo.PekerjaanAlumniRepository
+	var perusahaanRepo repo.PerusahaanRepository
+	var referensiRepo repo.ReferensiRepository
+	var surveyRepo repo.SurveyRepository
 	var fileRepo repo.FileRepository
+	var fileBlobRepo repo.FileBlobRepository
+	var uploadSessionRepo repo.UploadSessionRepository
+	var storageQuotaRepo repo.StorageQuotaRepository
+	var fileLinkRepo repo.FileLinkRepository
+	var historyRepo repo.HistoryRepository
 
 	if database.IsPostgres() {
 		userRepo = postgre.NewUserRepository(database.DB)
 		mahasiswaRepo = postgre.NewMahasiswaRepository(database.DB)
 		alumniRepo = postgre.NewAlumniRepository(database.DB)
 		pekerjaanRepo = postgre.NewPekerjaanAlumniRepository(database.DB)
+		perusahaanRepo = postgre.NewPerusahaanRepository(database.DB)
+		referensiRepo = postgre.NewReferensiRepository(database.DB)
+		surveyRepo = postgre.NewSurveyRepository(database.DB)
+		historyRepo = postgre.NewHistoryRepository(database.DB)
 		// TODO: Tambahkan fileRepo Postgres jika ada
 	} else if database.IsMongoDB() {
 		userRepo = mongodb.NewUserRepositoryMongo(database.MongoDB)
 		mahasiswaRepo = mongodb.NewMahasiswaRepositoryMongo(database.MongoDB)
 		alumniRepo = mongodb.NewAlumniRepositoryMongo(database.MongoDB)
 		pekerjaanRepo = mongodb.NewPekerjaanAlumniRepositoryMongo(database.MongoDB)
+		perusahaanRepo = mongodb.NewPerusahaanRepositoryMongo(database.MongoDB)
+		referensiRepo = mongodb.NewReferensiRepositoryMongo(database.MongoDB)
+		surveyRepo = mongodb.NewSurveyRepositoryMongo(database.MongoDB)
+		historyRepo = mongodb.NewHistoryRepositoryMongo(database.MongoDB)
 		fileRepo = mongodb.NewFileRepository(database.MongoDB)
+		fileBlobRepo = mongodb.NewFileBlobRepository(database.MongoDB)
+		uploadSessionRepo = mongodb.NewUploadSessionRepository(database.MongoDB)
+		storageQuotaRepo = mongodb.NewStorageQuotaRepository(database.MongoDB)
+		fileLinkRepo = mongodb.NewFileLinkRepository(database.MongoDB)
 	} else if database.IsPocketBase() {
 		userRepo = pocketbase.NewUserRepository(database.PocketBaseURL)
 		mahasiswaRepo = pocketbase.NewMahasiswaRepository(database.PocketBaseURL)
 		alumniRepo = pocketbase.NewAlumniRepository(database.PocketBaseURL)
 		pekerjaanRepo = pocketbase.NewPekerjaanAlumniRepository(database.PocketBaseURL)
+		perusahaanRepo = pocketbase.NewPerusahaanRepository(database.PocketBaseURL)
+		referensiRepo = pocketbase.NewReferensiRepository(database.PocketBaseURL)
+		surveyRepo = pocketbase.NewSurveyRepository(database.PocketBaseURL)
+		historyRepo = pocketbase.NewHistoryRepository(database.PocketBaseURL)
 		// TODO: Tambahkan fileRepo PocketBase jika ada
 		log.Println("✓ All PocketBase repositories initialized successfully")
 	}
 
+	// Storage isi file upload: local dan/atau S3 sesuai STORAGE_DRIVER
+	fileStorage, err := storage.NewRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("Storage configuration failed: %v", err)
+	}
+	fileScanner, err := malware.NewScannerFromEnv()
+	if err != nil {
+		log.Fatalf("Scanner configuration failed: %v", err)
+	}
+	if clamd, ok := fileScanner.(*malware.Clamd); ok {
+		if err := clamd.Ping(context.Background()); err != nil {
+			log.Printf("Warning: clamd tidak bisa dihubungi, upload baru tetap pending sampai clamd aktif: %v", err)
+		}
+	}
+	fileConfig, err := services.FileConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Upload configuration failed: %v", err)
+	}
+	for purpose, rule := range fileConfig.UploadRules {
+		if rule.MaxSize > maxRequestBody {
+			log.Printf("Warning: batas upload %s (%d byte) melebihi batas request %d byte, gunakan upload bertahap /api/files/uploads", purpose, rule.MaxSize, maxRequestBody)
+		}
+	}
+	if len(fileConfig.LinkSecret) == 0 {
+		log.Println("FILE_LINK_SECRET belum diatur, link unduhan untuk pihak luar tidak aktif")
+	}
+
 	// Create default admin user
 	createDefaultAdmin(userRepo)
 
+	// Seed data referensi (industri, provinsi, kota)
+	seedReferensi(referensiRepo)
+
 	// Initialize services - all with direct repository access
 	authService := services.NewAuthService(userRepo)
-	mahasiswaService := services.NewMahasiswaService(mahasiswaRepo)       // Direct repository
-	alumniService := services.NewAlumniService(alumniRepo)                // Direct repository
-	pekerjaanService := services.NewPekerjaanAlumniService(pekerjaanRepo) // Direct repository
+	mahasiswaService := services.NewMahasiswaService(mahasiswaRepo, historyRepo) // Direct repository + version history
+	alumniService := services.NewAlumniService(alumniRepo, pekerjaanRepo, referensiRepo, historyRepo)   // Direct repository + version history
+	pekerjaanService := services.NewPekerjaanAlumniService(pekerjaanRepo, alumniRepo, perusahaanRepo, referensiRepo, historyRepo) // Direct repository + version history
+	perusahaanService := services.NewPerusahaanService(perusahaanRepo, pekerjaanRepo, historyRepo)     // Company entity + alias merge
+	referensiService := services.NewReferensiService(referensiRepo, alumniRepo, pekerjaanRepo) // Controlled vocabulary
+	analyticsService := services.NewAnalyticsService(alumniRepo, referensiRepo)             // Tracer study analytics
+	surveyService := services.NewSurveyService(surveyRepo, alumniRepo, referensiRepo)        // Tracer study questionnaire
 	trashService := services.NewTrashService(pekerjaanRepo)               // Trash service untuk data soft deleted
-	fileService := services.NewFileService(fileRepo, "./uploads")        // Path upload file
+	fileService := services.NewFileService(fileRepo, fileBlobRepo, uploadSessionRepo, storageQuotaRepo, fileLinkRepo, fileStorage, fileScanner, alumniRepo, mahasiswaRepo, pekerjaanRepo, fileConfig) // Storage backend dari STORAGE_DRIVER + lampiran entitas
+	if uploadSessionRepo != nil {
+		go cleanupUploadSessions(fileService) // sesi upload bertahap yang ditinggalkan
+	}
+	if fileRepo != nil && fileScanner != nil {
+		go rescanPendingFiles(fileService) // file yang belum sempat dipindai malware
+	}
+	searchService := services.NewSearchService(mahasiswaRepo, alumniRepo, pekerjaanRepo, perusahaanRepo, userRepo) // Global search lintas entitas
 
 	// Protected dashboard route - perlu autentikasi JWT
 	app.Get("/dashboard", func(c *fiber.Ctx) error {
@@ -154,8 +290,9 @@ func main() {
 	})
 
 	// Setup API routes with dependency injection
-	routes.SetupRoutes(app, mahasiswaService, alumniService, pekerjaanService, authService, trashService, fileService)
+	routes.SetupRoutes(app, mahasiswaService, alumniService, pekerjaanService, perusahaanService, referensiService, analyticsService, surveyService, authService, trashService, fileService, searchService)
 
 	log.Println("Server running on http://localhost:8080")
 	log.Fatal(app.Listen(":8080"))
 }
+
@@ -0,0 +1,138 @@
+package malware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize ukuran satu potongan INSTREAM
+const clamdChunkSize = 64 << 10
+
+// Clamd client ClamAV daemon lewat TCP dengan perintah INSTREAM, sehingga file
+// tidak perlu berada di server yang sama dengan clamd
+type Clamd struct {
+	address string
+	timeout time.Duration
+}
+
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	return &Clamd{address: address, timeout: timeout}
+}
+
+func (c *Clamd) Name() string {
+	return DriverClamd
+}
+
+// Ping memastikan clamd bisa dihubungi
+func (c *Clamd) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: balasan PING tidak dikenal: %q", reply)
+	}
+	return nil
+}
+
+// Scan mengirim isi r sebagai rangkaian chunk <panjang uint32 big-endian><data>,
+// diakhiri chunk kosong. Balasan "stream: OK" atau "stream: <nama> FOUND".
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := c.command(ctx, "zINSTREAM\x00", r)
+	if err != nil {
+		return Result{}, err
+	}
+
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	}
+	return Result{}, fmt.Errorf("clamd: %s", reply)
+}
+
+// command menjalankan satu perintah clamd (format z, diakhiri NUL) dan membaca balasannya
+func (c *Clamd) command(ctx context.Context, command string, stream io.Reader) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %v", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	writeErr := writeCommand(conn, command, stream)
+	var readErr sourceError
+	if errors.As(writeErr, &readErr) {
+		return "", readErr.err
+	}
+	// clamd bisa memutus stream lebih awal (misal StreamMaxLength terlampaui) dan
+	// tetap mengirim alasan; balasan itu lebih berguna dari error tulis
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		if writeErr != nil {
+			return "", fmt.Errorf("clamd: %v", writeErr)
+		}
+		return "", fmt.Errorf("clamd: %v", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	if writeErr != nil && !strings.HasSuffix(reply, "ERROR") {
+		return "", fmt.Errorf("clamd: %v", writeErr)
+	}
+	return reply, nil
+}
+
+// sourceError gagal membaca isi file yang dipindai (bukan masalah koneksi clamd)
+type sourceError struct{ err error }
+
+func (e sourceError) Error() string { return e.err.Error() }
+
+func writeCommand(conn net.Conn, command string, stream io.Reader) error {
+	if _, err := io.WriteString(conn, command); err != nil {
+		return err
+	}
+	if stream == nil {
+		return nil
+	}
+
+	writer := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	buf := make([]byte, clamdChunkSize)
+	var size [4]byte
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, werr := writer.Write(size[:]); werr != nil {
+				return werr
+			}
+			if _, werr := writer.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sourceError{err}
+		}
+	}
+	if _, err := writer.Write(bytes.Repeat([]byte{0}, 4)); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
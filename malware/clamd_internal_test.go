@@ -0,0 +1,124 @@
+package malware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readFrames membaca perintah dan chunk INSTREAM sampai chunk kosong penutup
+func readFrames(r io.Reader, command string) ([]int, []byte, error) {
+	head := make([]byte, len(command))
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, nil, err
+	}
+	if string(head) != command {
+		return nil, nil, errors.New("perintah = " + string(head))
+	}
+
+	var sizes []int
+	var data bytes.Buffer
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, nil, err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n == 0 {
+			return sizes, data.Bytes(), nil
+		}
+		sizes = append(sizes, int(n))
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func TestWriteCommandChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		wantSizes []int
+	}{
+		{name: "kosong", size: 0},
+		{name: "satu byte", size: 1, wantSizes: []int{1}},
+		{name: "tepat satu chunk", size: clamdChunkSize, wantSizes: []int{clamdChunkSize}},
+		{name: "lewat satu byte", size: clamdChunkSize + 1, wantSizes: []int{clamdChunkSize, 1}},
+		{name: "beberapa chunk", size: 3*clamdChunkSize + 5, wantSizes: []int{clamdChunkSize, clamdChunkSize, clamdChunkSize, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := bytes.Repeat([]byte("0123456789abcdef"), tt.size/16+1)[:tt.size]
+			client, server := net.Pipe()
+			defer client.Close()
+
+			type frames struct {
+				sizes []int
+				data  []byte
+				err   error
+			}
+			done := make(chan frames, 1)
+			go func() {
+				defer server.Close()
+				sizes, data, err := readFrames(server, "zINSTREAM\x00")
+				done <- frames{sizes, data, err}
+			}()
+
+			if err := writeCommand(client, "zINSTREAM\x00", bytes.NewReader(payload)); err != nil {
+				t.Fatalf("writeCommand: %v", err)
+			}
+			got := <-done
+			if got.err != nil {
+				t.Fatal(got.err)
+			}
+			if !slices.Equal(got.sizes, tt.wantSizes) {
+				t.Errorf("ukuran chunk = %v, want %v", got.sizes, tt.wantSizes)
+			}
+			if !bytes.Equal(got.data, payload) {
+				t.Errorf("isi yang diterima berbeda (%d byte, want %d)", len(got.data), len(payload))
+			}
+		})
+	}
+}
+
+func TestWriteCommandWithoutStream(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan string, 1)
+	go func() {
+		defer server.Close()
+		data, _ := io.ReadAll(server)
+		done <- string(data)
+	}()
+
+	if err := writeCommand(client, "zPING\x00", nil); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if got := <-done; got != "zPING\x00" {
+		t.Errorf("dikirim %q, want %q", got, "zPING\x00")
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+// Gagal membaca file yang dipindai dibedakan dari gagal menulis ke clamd
+func TestWriteCommandSourceError(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go io.Copy(io.Discard, server)
+
+	readErr := errors.New("disk rusak")
+	err := writeCommand(client, "zINSTREAM\x00", io.MultiReader(strings.NewReader("awal"), failingReader{readErr}))
+	var source sourceError
+	if !errors.As(err, &source) || source.err != readErr {
+		t.Errorf("err = %v, want sourceError(%v)", err, readErr)
+	}
+}
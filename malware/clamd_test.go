@@ -0,0 +1,189 @@
+package malware_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"modul4crud/malware"
+	"modul4crud/malware/malwaretest"
+)
+
+func newFakeClamd(t *testing.T, fake *malwaretest.Fake) *malware.Clamd {
+	t.Helper()
+	listener, err := malwaretest.NewClamdServer(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return malware.NewClamd(listener.Addr().String(), 5*time.Second)
+}
+
+// replyServer clamd yang selalu menjawab reply setelah membaca perintah dan
+// seluruh stream, untuk menguji parsing balasan yang tidak dibuat malwaretest
+func replyServer(t *testing.T, reply string) *malware.Clamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				if _, err := reader.ReadString(0); err != nil {
+					return
+				}
+				io.WriteString(conn, reply+"\x00")
+				// Sisa stream dibaca supaya koneksi ditutup tanpa RST
+				io.Copy(io.Discard, reader)
+			}()
+		}
+	}()
+	return malware.NewClamd(listener.Addr().String(), 5*time.Second)
+}
+
+// eicarAcross isi sebesar size dengan EICAR terpotong di batas chunk INSTREAM 64 KiB
+func eicarAcross(size int) string {
+	boundary := 64 << 10
+	start := boundary - len(malwaretest.EICAR)/2
+	return strings.Repeat("a", start) + malwaretest.EICAR + strings.Repeat("b", size-start-len(malwaretest.EICAR))
+}
+
+func TestClamdScan(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		signatures    map[string]string
+		scanErr       error
+		wantInfected  bool
+		wantSignature string
+		wantErr       string
+	}{
+		{name: "bersih", content: "%PDF-1.4 dokumen biasa"},
+		{name: "kosong", content: ""},
+		{name: "bersih beberapa chunk", content: strings.Repeat("x", 200<<10)},
+		{name: "eicar", content: malwaretest.EICAR, wantInfected: true, wantSignature: "Eicar-Signature"},
+		{name: "eicar di batas chunk", content: eicarAcross(150 << 10), wantInfected: true, wantSignature: "Eicar-Signature"},
+		{
+			name:          "signature lain",
+			content:       "awal TROJAN-UJI akhir",
+			signatures:    map[string]string{"TROJAN-UJI": "Win.Test.Trojan"},
+			wantInfected:  true,
+			wantSignature: "Win.Test.Trojan",
+		},
+		{name: "clamd error", content: "isi", scanErr: errors.New("lstat() failed"), wantErr: "lstat() failed ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := malwaretest.New()
+			for pattern, name := range tt.signatures {
+				fake.Signatures[pattern] = name
+			}
+			fake.Err = tt.scanErr
+			clamd := newFakeClamd(t, fake)
+
+			result, err := clamd.Scan(context.Background(), strings.NewReader(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want berisi %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if result.Infected != tt.wantInfected || result.Signature != tt.wantSignature {
+				t.Errorf("Scan = %+v, want Infected %v Signature %q", result, tt.wantInfected, tt.wantSignature)
+			}
+			if fake.Scanned() != 1 {
+				t.Errorf("Scanned = %d, want 1", fake.Scanned())
+			}
+		})
+	}
+}
+
+func TestClamdReplies(t *testing.T) {
+	tests := []struct {
+		reply         string
+		wantInfected  bool
+		wantSignature string
+		wantErr       string
+	}{
+		{reply: "stream: OK"},
+		{reply: "stream: Eicar-Signature FOUND", wantInfected: true, wantSignature: "Eicar-Signature"},
+		{reply: "stream: Win.Trojan.Agent-123 FOUND", wantInfected: true, wantSignature: "Win.Trojan.Agent-123"},
+		{reply: "stream: OK\n"},
+		{reply: "INSTREAM size limit exceeded. ERROR", wantErr: "clamd: INSTREAM size limit exceeded. ERROR"},
+		{reply: "stream: Can't allocate memory ERROR", wantErr: "clamd: Can't allocate memory ERROR"},
+		{reply: "UNKNOWN COMMAND", wantErr: "clamd: UNKNOWN COMMAND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			clamd := replyServer(t, tt.reply)
+			result, err := clamd.Scan(context.Background(), bytes.NewReader(make([]byte, 100<<10)))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if result.Infected != tt.wantInfected || result.Signature != tt.wantSignature {
+				t.Errorf("Scan = %+v, want Infected %v Signature %q", result, tt.wantInfected, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestClamdPing(t *testing.T) {
+	if err := newFakeClamd(t, malwaretest.New()).Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	if err := replyServer(t, "PANG").Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "PANG") {
+		t.Errorf("Ping balasan salah = %v, want error", err)
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	clamd := malware.NewClamd(address, time.Second)
+	if err := clamd.Ping(context.Background()); err == nil {
+		t.Error("Ping ke clamd yang mati berhasil")
+	}
+	if _, err := clamd.Scan(context.Background(), strings.NewReader("isi")); err == nil {
+		t.Error("Scan ke clamd yang mati berhasil, want error (bukan hasil bersih)")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("disk rusak") }
+
+func TestClamdSourceError(t *testing.T) {
+	clamd := newFakeClamd(t, malwaretest.New())
+	_, err := clamd.Scan(context.Background(), failingReader{})
+	if err == nil || err.Error() != "disk rusak" {
+		t.Errorf("err = %v, want error baca file apa adanya", err)
+	}
+}
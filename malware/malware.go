@@ -0,0 +1,57 @@
+// Package malware pemindai isi file yang diupload sebelum file boleh diunduh.
+package malware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DriverClamd nama driver untuk SCANNER_DRIVER
+const DriverClamd = "clamd"
+
+// Result hasil pemindaian satu file
+type Result struct {
+	Infected  bool
+	Signature string // nama malware yang terdeteksi, misal "Eicar-Signature"
+}
+
+// Scanner memindai isi r sampai habis. Error berarti file belum bisa dinilai
+// (scanner mati, timeout), bukan berarti file terinfeksi.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// NewScannerFromEnv membaca konfigurasi scanner dari environment:
+//
+//	SCANNER_DRIVER   kosong/none (default, tanpa pemindaian) atau clamd
+//	CLAMD_ADDRESS    alamat TCP clamd (default localhost:3310)
+//	CLAMD_TIMEOUT    batas waktu satu pemindaian (default 2m)
+//
+// Mengembalikan nil jika pemindaian tidak diaktifkan.
+func NewScannerFromEnv() (Scanner, error) {
+	driver := strings.ToLower(os.Getenv("SCANNER_DRIVER"))
+	switch driver {
+	case "", "none":
+		return nil, nil
+	case DriverClamd:
+		address := os.Getenv("CLAMD_ADDRESS")
+		if address == "" {
+			address = "localhost:3310"
+		}
+		timeout := 2 * time.Minute
+		if value := os.Getenv("CLAMD_TIMEOUT"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("CLAMD_TIMEOUT tidak valid: %q", value)
+			}
+			timeout = parsed
+		}
+		return NewClamd(address, timeout), nil
+	}
+	return nil, fmt.Errorf("SCANNER_DRIVER tidak dikenal: %q", driver)
+}
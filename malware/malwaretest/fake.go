@@ -0,0 +1,139 @@
+// Package malwaretest scanner palsu untuk mencoba alur pemindaian tanpa ClamAV.
+// Fake mendeteksi string uji EICAR dan pola tambahan di Signatures; NewClamdServer
+// menjalankan Fake di balik protokol clamd (PING, INSTREAM) untuk menguji malware.Clamd.
+package malwaretest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"modul4crud/malware"
+)
+
+// EICAR string uji antivirus standar; semua scanner mendeteksinya sebagai "Eicar-Signature"
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake malware.Scanner di memori
+type Fake struct {
+	// Signatures pola isi -> nama malware, selain EICAR
+	Signatures map[string]string
+	// Err jika diisi, setiap Scan gagal dengan error ini (mensimulasikan clamd mati)
+	Err error
+
+	mu      sync.Mutex
+	scanned int
+}
+
+func New() *Fake {
+	return &Fake{Signatures: map[string]string{}}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Scan(ctx context.Context, r io.Reader) (malware.Result, error) {
+	f.mu.Lock()
+	err := f.Err
+	f.mu.Unlock()
+	if err != nil {
+		return malware.Result{}, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return malware.Result{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scanned++
+	if bytes.Contains(data, []byte(EICAR)) {
+		return malware.Result{Infected: true, Signature: "Eicar-Signature"}, nil
+	}
+	for pattern, name := range f.Signatures {
+		if bytes.Contains(data, []byte(pattern)) {
+			return malware.Result{Infected: true, Signature: name}, nil
+		}
+	}
+	return malware.Result{}, nil
+}
+
+// SetErr mengubah Err dengan aman saat pemindaian berjalan di goroutine lain
+func (f *Fake) SetErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Err = err
+}
+
+// Scanned jumlah file yang sudah dipindai
+func (f *Fake) Scanned() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.scanned
+}
+
+// NewClamdServer listener TCP yang menjawab seperti clamd memakai hasil f.
+// Alamatnya dipakai sebagai CLAMD_ADDRESS; panggil Close setelah selesai.
+func NewClamdServer(f *Fake) (net.Listener, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, f)
+		}
+	}()
+	return listener, nil
+}
+
+func serveClamd(conn net.Conn, f *Fake) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	command, err := reader.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch strings.TrimSuffix(command, "\x00") {
+	case "zPING":
+		io.WriteString(conn, "PONG\x00")
+	case "zINSTREAM":
+		var data bytes.Buffer
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(reader, size[:]); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size[:])
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&data, reader, int64(n)); err != nil {
+				return
+			}
+		}
+		result, err := f.Scan(context.Background(), &data)
+		switch {
+		case err != nil:
+			io.WriteString(conn, "stream: "+err.Error()+" ERROR\x00")
+		case result.Infected:
+			io.WriteString(conn, "stream: "+result.Signature+" FOUND\x00")
+		default:
+			io.WriteString(conn, "stream: OK\x00")
+		}
+	default:
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
@@ -14,17 +14,13 @@ func ValidateJWT() fiber.Handler {
 		// Ambil token dari header Authorization
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Token tidak ditemukan",
-			})
+			return utils.Unauthorized("Token tidak ditemukan")
 		}
 
 		// Extract token dari "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Format token tidak valid",
-			})
+			return utils.Unauthorized("Format token tidak valid")
 		}
 
 		tokenString := tokenParts[1]
@@ -32,9 +28,7 @@ func ValidateJWT() fiber.Handler {
 		// Parse dan validasi token
 		claims, err := utils.ValidateJWT(tokenString)
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Token tidak valid",
-			})
+			return utils.Unauthorized("Token tidak valid")
 		}
 
 		// Set user info ke context untuk digunakan di handler selanjutnya
@@ -51,9 +45,7 @@ func RequireRole(allowedRoles ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userRole := c.Locals("role")
 		if userRole == nil {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "User tidak terautentikasi",
-			})
+			return utils.Unauthorized("User tidak terautentikasi")
 		}
 
 		role := userRole.(string)
@@ -65,11 +57,9 @@ func RequireRole(allowedRoles ...string) fiber.Handler {
 			}
 		}
 
-		return c.Status(403).JSON(fiber.Map{
-			"error":          "Akses ditolak: role tidak memiliki permission",
-			"required_roles": allowedRoles,
-			"user_role":      role,
-		})
+		return utils.Forbidden("Akses ditolak: role tidak memiliki permission").
+			WithDetail("required_roles", allowedRoles).
+			WithDetail("user_role", role)
 	}
 }
 
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"modul4crud/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler mengubah error yang dikembalikan handler menjadi satu format response:
+//
+//	{"error": "<pesan>", "code": "<KODE>", "errors": [...], ...detail}
+//
+// "errors" hanya ada untuk VALIDATION_FAILED. Error yang tidak dikenal dicatat
+// di log dan dijawab 500 tanpa membocorkan pesan aslinya.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var appErr *utils.AppError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+	case errors.As(err, &fiberErr):
+		// Error bawaan Fiber, mis. route tidak ditemukan atau body terlalu besar
+		appErr = &utils.AppError{Code: codeForStatus(fiberErr.Code), Status: fiberErr.Code, Message: fiberErr.Message}
+	default:
+		appErr = utils.ToAppError(err)
+	}
+
+	if appErr.Status >= fiber.StatusInternalServerError {
+		log.Printf("ERROR %s %s: %v", c.Method(), c.Path(), err)
+	}
+
+	body := fiber.Map{}
+	for key, value := range appErr.Details {
+		body[key] = value
+	}
+	body["error"] = appErr.Message
+	body["code"] = appErr.Code
+	if len(appErr.Fields) > 0 {
+		body["errors"] = appErr.Fields
+	}
+	return c.Status(appErr.Status).JSON(body)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return utils.ErrCodeBadRequest
+	case fiber.StatusUnauthorized:
+		return utils.ErrCodeUnauthorized
+	case fiber.StatusForbidden:
+		return utils.ErrCodeForbidden
+	case fiber.StatusNotFound:
+		return utils.ErrCodeNotFound
+	case fiber.StatusConflict:
+		return utils.ErrCodeConflict
+	case fiber.StatusPreconditionFailed:
+		return utils.ErrCodePrecondition
+	case fiber.StatusUnprocessableEntity:
+		return utils.ErrCodeValidation
+	case fiber.StatusRequestedRangeNotSatisfiable:
+		return utils.ErrCodeRange
+	}
+	if status >= fiber.StatusInternalServerError {
+		return utils.ErrCodeInternal
+	}
+	return utils.ErrCodeBadRequest
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"modul4crud/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag membentuk nilai header ETag dari nomor versi record
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// SetETag mengirim versi record sebagai header ETag
+func SetETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, ETag(version))
+}
+
+// CheckIfMatch mencocokkan header If-Match dengan versi record saat ini.
+// Tanpa If-Match request tetap diproses; "*" cocok dengan versi apa pun.
+func CheckIfMatch(c *fiber.Ctx, version int) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	current := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return utils.VersionMismatch().WithDetail("etag", current)
+}
+
+// CheckIfMatchWith seperti CheckIfMatch untuk handler yang belum membaca record
+// (mis. DELETE). Record hanya dibaca jika client mengirim If-Match. Versi yang
+// cocok dikembalikan supaya repository bisa menghapus dengan syarat versi yang
+// sama; 0 berarti tanpa syarat (tidak ada If-Match atau "*").
+func CheckIfMatchWith(c *fiber.Ctx, currentVersion func() (int, error)) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	version, err := currentVersion()
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckIfMatch(c, version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
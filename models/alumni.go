@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // Model Alumni
 
@@ -11,78 +14,224 @@ type Alumni struct {
 	NIM        string            `gorm:"type:varchar(20);unique;not null" json:"nim"`
 	Nama       string            `gorm:"type:varchar(100);not null" json:"nama"`
 	Jurusan    string            `gorm:"type:varchar(50);not null" json:"jurusan"`
+	KodeProdi  string            `gorm:"type:varchar(20);index" json:"kode_prodi"`
 	Angkatan   int               `gorm:"not null" json:"angkatan"`
 	TahunLulus int               `gorm:"not null" json:"tahun_lulus"`
 	NoTelepon  string            `gorm:"type:varchar(15)" json:"no_telepon"`
 	Alamat     string            `gorm:"type:text" json:"alamat"`
 	CreatedAt  time.Time         `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt  time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+	Version    int               `gorm:"not null;default:1" json:"version"`
+	DeletedAt  *time.Time        `gorm:"index" json:"deleted_at,omitempty"`
+	MergedInto *uint             `gorm:"index" json:"merged_into,omitempty"` // alumni tujuan jika record ini digabung sebagai duplikat
 	Pekerjaan  []PekerjaanAlumni `gorm:"foreignKey:AlumniID" json:"pekerjaan_alumni"`
+	Search     *SearchMatch      `gorm:"-" json:"search,omitempty" bson:"-"` // hanya diisi pada hasil pencarian
+}
+
+// SearchFields field alumni yang ikut full-text search
+func (a *Alumni) SearchFields() []SearchField {
+	return []SearchField{
+		{Name: "nim", Value: a.NIM, Weight: SearchWeightA},
+		{Name: "nama", Value: a.Nama, Weight: SearchWeightA},
+		{Name: "jurusan", Value: a.Jurusan, Weight: SearchWeightB},
+		{Name: "tahun_lulus", Value: strconv.Itoa(a.TahunLulus), Weight: SearchWeightD},
+	}
 }
 
 // Model Pekerjaan Alumni
 
 type PekerjaanAlumni struct {
-	ID                  uint       `gorm:"primaryKey" json:"id"`
-	AlumniID            uint       `gorm:"not null" json:"alumni_id"`
-	Alumni              Alumni     `gorm:"foreignKey:AlumniID" json:"alumni"`
-	NamaPerusahaan      string     `gorm:"type:varchar(100);not null" json:"nama_perusahaan"`
-	PosisiJabatan       string     `gorm:"type:varchar(100);not null" json:"posisi_jabatan"`
-	BidangIndustri      string     `gorm:"type:varchar(50);not null" json:"bidang_industri"`
-	LokasiKerja         string     `gorm:"type:varchar(100);not null" json:"lokasi_kerja"`
-	GajiRange           string     `gorm:"type:varchar(50)" json:"gaji_range"`
-	TanggalMulaiKerja   time.Time  `gorm:"type:date;not null" json:"tanggal_mulai_kerja"`
-	TanggalSelesaiKerja *time.Time `gorm:"type:date" json:"tanggal_selesai_kerja"`
-	StatusPekerjaan     string     `gorm:"type:varchar(20);default:'aktif'" json:"status_pekerjaan"`
-	DeskripsiPekerjaan  string     `gorm:"type:text" json:"deskripsi_pekerjaan"`
-	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt           *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+	ID                  uint         `gorm:"primaryKey" json:"id"`
+	AlumniID            uint         `gorm:"not null" json:"alumni_id"`
+	Alumni              Alumni       `gorm:"foreignKey:AlumniID" json:"alumni"`
+	PerusahaanID        *uint        `gorm:"index" json:"perusahaan_id"`
+	NamaPerusahaan      string       `gorm:"type:varchar(100);not null" json:"nama_perusahaan"`
+	PosisiJabatan       string       `gorm:"type:varchar(100);not null" json:"posisi_jabatan"`
+	BidangIndustri      string       `gorm:"type:varchar(50);not null" json:"bidang_industri"`
+	KodeIndustri        string       `gorm:"type:varchar(20);index" json:"kode_industri"`
+	LokasiKerja         string       `gorm:"type:varchar(100);not null" json:"lokasi_kerja"`
+	KodeProvinsi        string       `gorm:"type:varchar(20);index" json:"kode_provinsi"`
+	KodeKota            string       `gorm:"type:varchar(20);index" json:"kode_kota"`
+	GajiRange           string       `gorm:"type:varchar(50)" json:"gaji_range"`
+	GajiMin             *int64       `gorm:"type:bigint" json:"gaji_min"`
+	GajiMax             *int64       `gorm:"type:bigint" json:"gaji_max"`
+	GajiMataUang        string       `gorm:"type:varchar(3);default:'IDR'" json:"gaji_mata_uang"`
+	GajiPeriode         string       `gorm:"type:varchar(10);default:'bulanan'" json:"gaji_periode"`
+	TanggalMulaiKerja   time.Time    `gorm:"type:date;not null" json:"tanggal_mulai_kerja"`
+	TanggalSelesaiKerja *time.Time   `gorm:"type:date" json:"tanggal_selesai_kerja"`
+	StatusPekerjaan     string       `gorm:"type:varchar(20);default:'aktif'" json:"status_pekerjaan"`
+	DeskripsiPekerjaan  string       `gorm:"type:text" json:"deskripsi_pekerjaan"`
+	CreatedAt           time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+	Version             int          `gorm:"not null;default:1" json:"version"`
+	DeletedAt           *time.Time   `gorm:"index" json:"deleted_at,omitempty"`
+	Search              *SearchMatch `gorm:"-" json:"search,omitempty" bson:"-"` // hanya diisi pada hasil pencarian
+}
+
+// HideGaji mengosongkan nilai gaji. gaji_range ikut dikosongkan karena isinya
+// bisa berupa angka persis ("Rp 7.500.000"); mata uang dan periode tetap dikirim.
+func (p *PekerjaanAlumni) HideGaji() {
+	p.GajiRange = ""
+	p.GajiMin = nil
+	p.GajiMax = nil
+}
+
+// SearchFields field pekerjaan yang ikut full-text search (tanpa data alumni,
+// supaya pencarian tetap memakai index tabel/collection pekerjaan saja)
+func (p *PekerjaanAlumni) SearchFields() []SearchField {
+	return []SearchField{
+		{Name: "posisi_jabatan", Value: p.PosisiJabatan, Weight: SearchWeightA},
+		{Name: "nama_perusahaan", Value: p.NamaPerusahaan, Weight: SearchWeightA},
+		{Name: "bidang_industri", Value: p.BidangIndustri, Weight: SearchWeightB},
+		{Name: "lokasi_kerja", Value: p.LokasiKerja, Weight: SearchWeightB},
+		{Name: "deskripsi_pekerjaan", Value: p.DeskripsiPekerjaan, Weight: SearchWeightD},
+	}
+}
+
+// MergeAlumniRequest alumni duplikat yang digabung ke alumni tujuan. UserID memilih
+// akun yang ditautkan ke alumni tujuan (harus milik salah satu alumni yang digabung);
+// kosong berarti akun alumni tujuan tetap dipakai.
+type MergeAlumniRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required"`
+	UserID    *int   `json:"user_id"`
 }
 
 // Request struct untuk Alumni
 type CreateAlumniRequest struct {
-	UserID     int    `json:"user_id"`
-	NIM        string `json:"nim"`
-	Nama       string `json:"nama"`
-	Jurusan    string `json:"jurusan"`
-	Angkatan   int    `json:"angkatan"`
-	TahunLulus int    `json:"tahun_lulus"`
-	NoTelepon  string `json:"no_telepon"`
-	Alamat     string `json:"alamat"`
+	UserID     int    `json:"user_id" validate:"required,min=1"`
+	NIM        string `json:"nim" validate:"required,nim"`
+	Nama       string `json:"nama" validate:"required,max=100"`
+	Jurusan    string `json:"jurusan" validate:"required_without=KodeProdi,max=50"`
+	KodeProdi  string `json:"kode_prodi" validate:"max=20"`
+	Angkatan   int    `json:"angkatan" validate:"required,tahun"`
+	TahunLulus int    `json:"tahun_lulus" validate:"required,tahun,gtefield=Angkatan"`
+	NoTelepon  string `json:"no_telepon" validate:"omitempty,phone,max=15"`
+	Alamat     string `json:"alamat" validate:"max=255"`
 }
 
 type UpdateAlumniRequest struct {
-	Nama       string `json:"nama"`
-	Jurusan    string `json:"jurusan"`
-	Angkatan   int    `json:"angkatan"`
-	TahunLulus int    `json:"tahun_lulus"`
-	NoTelepon  string `json:"no_telepon"`
-	Alamat     string `json:"alamat"`
+	Nama       string `json:"nama" validate:"required,max=100"`
+	Jurusan    string `json:"jurusan" validate:"required_without=KodeProdi,max=50"`
+	KodeProdi  string `json:"kode_prodi" validate:"max=20"`
+	Angkatan   int    `json:"angkatan" validate:"required,tahun"`
+	TahunLulus int    `json:"tahun_lulus" validate:"required,tahun,gtefield=Angkatan"`
+	NoTelepon  string `json:"no_telepon" validate:"omitempty,phone,max=15"`
+	Alamat     string `json:"alamat" validate:"max=255"`
 }
 
 // Request struct untuk PekerjaanAlumni
 type CreatePekerjaanAlumniRequest struct {
-	AlumniID            uint       `json:"alumni_id"`
-	NamaPerusahaan      string     `json:"nama_perusahaan"`
-	PosisiJabatan       string     `json:"posisi_jabatan"`
-	BidangIndustri      string     `json:"bidang_industri"`
-	LokasiKerja         string     `json:"lokasi_kerja"`
-	GajiRange           string     `json:"gaji_range"`
-	TanggalMulaiKerja   time.Time  `json:"tanggal_mulai_kerja"`
+	AlumniID            uint       `json:"alumni_id" validate:"required"`
+	PerusahaanID        *uint      `json:"perusahaan_id"`
+	NamaPerusahaan      string     `json:"nama_perusahaan" validate:"required_without=PerusahaanID,max=100"`
+	PosisiJabatan       string     `json:"posisi_jabatan" validate:"required,max=100"`
+	BidangIndustri      string     `json:"bidang_industri" validate:"required_without=KodeIndustri,max=50"`
+	KodeIndustri        string     `json:"kode_industri" validate:"max=20"`
+	LokasiKerja         string     `json:"lokasi_kerja" validate:"max=100"`
+	KodeProvinsi        string     `json:"kode_provinsi"`
+	KodeKota            string     `json:"kode_kota"`
+	GajiRange           string     `json:"gaji_range" validate:"max=50"`
+	GajiMin             *int64     `json:"gaji_min" validate:"omitempty,min=0"`
+	GajiMax             *int64     `json:"gaji_max" validate:"omitempty,min=0,gtefield=GajiMin"`
+	GajiMataUang        string     `json:"gaji_mata_uang" validate:"omitempty,len=3"`
+	GajiPeriode         string     `json:"gaji_periode" validate:"omitempty,oneof=bulanan tahunan"`
+	TanggalMulaiKerja   time.Time  `json:"tanggal_mulai_kerja" validate:"required"`
 	TanggalSelesaiKerja *time.Time `json:"tanggal_selesai_kerja"`
-	StatusPekerjaan     string     `json:"status_pekerjaan"`
+	StatusPekerjaan     string     `json:"status_pekerjaan" validate:"omitempty,oneof=aktif tidak_aktif"`
 	DeskripsiPekerjaan  string     `json:"deskripsi_pekerjaan"`
 }
 
+// UpdatePekerjaanAlumniRequest isi lengkap pekerjaan untuk PUT/PATCH. AlumniID
+// hanya dicocokkan dengan data lama; pekerjaan tidak bisa dipindah ke alumni lain.
 type UpdatePekerjaanAlumniRequest struct {
+	AlumniID            *uint      `json:"alumni_id"`
+	PerusahaanID        *uint      `json:"perusahaan_id"`
+	NamaPerusahaan      string     `json:"nama_perusahaan" validate:"required_without=PerusahaanID,max=100"`
+	PosisiJabatan       string     `json:"posisi_jabatan" validate:"required,max=100"`
+	BidangIndustri      string     `json:"bidang_industri" validate:"required_without=KodeIndustri,max=50"`
+	KodeIndustri        string     `json:"kode_industri" validate:"max=20"`
+	LokasiKerja         string     `json:"lokasi_kerja" validate:"max=100"`
+	KodeProvinsi        string     `json:"kode_provinsi"`
+	KodeKota            string     `json:"kode_kota"`
+	GajiRange           string     `json:"gaji_range" validate:"max=50"`
+	GajiMin             *int64     `json:"gaji_min" validate:"omitempty,min=0"`
+	GajiMax             *int64     `json:"gaji_max" validate:"omitempty,min=0,gtefield=GajiMin"`
+	GajiMataUang        string     `json:"gaji_mata_uang" validate:"omitempty,len=3"`
+	GajiPeriode         string     `json:"gaji_periode" validate:"omitempty,oneof=bulanan tahunan"`
+	TanggalMulaiKerja   time.Time  `json:"tanggal_mulai_kerja" validate:"required"`
+	TanggalSelesaiKerja *time.Time `json:"tanggal_selesai_kerja"`
+	StatusPekerjaan     string     `json:"status_pekerjaan" validate:"omitempty,oneof=aktif tidak_aktif"`
+	DeskripsiPekerjaan  string     `json:"deskripsi_pekerjaan"`
+}
+
+// Status pekerjaan alumni
+const (
+	StatusPekerjaanAktif      = "aktif"
+	StatusPekerjaanTidakAktif = "tidak_aktif"
+)
+
+// StatusPekerjaan daftar status pekerjaan yang valid
+var StatusPekerjaan = []string{StatusPekerjaanAktif, StatusPekerjaanTidakAktif}
+
+// Periode dan mata uang gaji terstruktur
+const (
+	GajiPeriodeBulanan  = "bulanan"
+	GajiPeriodeTahunan  = "tahunan"
+	DefaultGajiMataUang = "IDR"
+)
+
+// SalarySample satu data gaji beserta kunci grup untuk statistik gaji
+type SalarySample struct {
+	GroupKey    string `json:"group_key"`
+	GajiMin     *int64 `json:"gaji_min"`
+	GajiMax     *int64 `json:"gaji_max"`
+	GajiPeriode string `json:"gaji_periode"`
+}
+
+// StatGroup hasil agregasi jumlah data per grup. Label berisi teks asli
+// (jurusan, bidang industri, lokasi) untuk data yang kodenya masih kosong.
+type StatGroup struct {
+	Kode  string `json:"kode" bson:"kode"`
+	Label string `json:"label" bson:"label"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// SalaryStat hasil statistik gaji per grup (nilai dalam satuan per bulan)
+type SalaryStat struct {
+	Group  string  `json:"group"`
+	Count  int     `json:"count"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+}
+
+// CareerTimeline riwayat karier satu alumni, urut dari pekerjaan pertama.
+// Semua durasi dalam bulan.
+type CareerTimeline struct {
+	AlumniID            uint                  `json:"alumni_id"`
+	Nama                string                `json:"nama"`
+	TahunLulus          int                   `json:"tahun_lulus"`
+	JumlahPekerjaan     int                   `json:"jumlah_pekerjaan"`
+	MasaTungguBulan     *float64              `json:"masa_tunggu_bulan"`
+	TotalMasaKerjaBulan float64               `json:"total_masa_kerja_bulan"`
+	TotalJedaBulan      float64               `json:"total_jeda_bulan"`
+	Pekerjaan           []CareerTimelineEntry `json:"pekerjaan"`
+}
+
+// CareerTimelineEntry satu pekerjaan di timeline. MasaKerjaBulan kosong untuk
+// pekerjaan tidak aktif yang tanggal selesainya tidak diisi.
+type CareerTimelineEntry struct {
+	PekerjaanID         uint       `json:"pekerjaan_id"`
 	NamaPerusahaan      string     `json:"nama_perusahaan"`
 	PosisiJabatan       string     `json:"posisi_jabatan"`
 	BidangIndustri      string     `json:"bidang_industri"`
-	LokasiKerja         string     `json:"lokasi_kerja"`
-	GajiRange           string     `json:"gaji_range"`
+	KodeIndustri        string     `json:"kode_industri"`
+	StatusPekerjaan     string     `json:"status_pekerjaan"`
 	TanggalMulaiKerja   time.Time  `json:"tanggal_mulai_kerja"`
 	TanggalSelesaiKerja *time.Time `json:"tanggal_selesai_kerja"`
-	StatusPekerjaan     string     `json:"status_pekerjaan"`
-	DeskripsiPekerjaan  string     `json:"deskripsi_pekerjaan"`
+	MasaKerjaBulan      *float64   `json:"masa_kerja_bulan"`
+	JedaSebelumnyaBulan *float64   `json:"jeda_sebelumnya_bulan"`
+	TumpangTindih       bool       `json:"tumpang_tindih"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// DuplicatePair pasangan record yang diduga duplikat. Signals berisi sinyal yang
+// menaikkan skor (nama, telepon, nim, user, email) beserta kontribusinya.
+type DuplicatePair[T any] struct {
+	Score   float64            `json:"score"`
+	Signals map[string]float64 `json:"signals"`
+	Records [2]T               `json:"records"`
+}
@@ -6,22 +6,161 @@ import (
     "go.mongodb.org/mongo-driver/bson/primitive" 
 ) 
  
+// Entitas yang bisa memiliki lampiran file 
+const ( 
+    FileEntityAlumni    = "alumni" 
+    FileEntityMahasiswa = "mahasiswa" 
+    FileEntityPekerjaan = "pekerjaan" 
+) 
+ 
+// Kegunaan lampiran. Foto boleh dilihat semua user yang bisa melihat datanya, 
+// selain itu hanya pemilik file, pemilik entitas, dan admin. 
+const ( 
+    FilePurposeFoto    = "foto" 
+    FilePurposeCV      = "cv" 
+    FilePurposeKontrak = "kontrak" 
+    FilePurposeDokumen = "dokumen" 
+) 
+ 
+// Status pemindaian malware. File tanpa status (lama, atau scanner tidak aktif) 
+// tidak pernah dipindai dan tetap bisa diunduh. 
+const ( 
+    FileScanPending  = "pending" 
+    FileScanClean    = "clean" 
+    FileScanInfected = "infected" 
+) 
+
+var FileEntities = []string{FileEntityAlumni, FileEntityMahasiswa, FileEntityPekerjaan} 
+ 
+var FilePurposes = []string{FilePurposeFoto, FilePurposeCV, FilePurposeKontrak, FilePurposeDokumen} 
+ 
 type File struct { 
     ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"` 
     FileName     string             `json:"file_name" bson:"file_name"` 
     OriginalName string             `json:"original_name" bson:"original_name"` 
-    FilePath     string             `json:"file_path" bson:"file_path"` 
+    FilePath     string             `json:"-" bson:"file_path,omitempty"` // lama, sebelum ada Backend/StorageKey; tidak pernah dikirim ke client 
+    Backend      string             `json:"backend" bson:"backend"` 
+    StorageKey   string             `json:"storage_key" bson:"storage_key"` 
     FileSize     int64              `json:"file_size" bson:"file_size"` 
     FileType     string             `json:"file_type" bson:"file_type"` 
+    SHA256       string             `json:"sha256,omitempty" bson:"sha256,omitempty"` // hash isi yang disimpan; kosong untuk file lama 
+    OwnerID      int                `json:"owner_id" bson:"owner_id"` // user yang mengupload; 0 untuk file lama 
+    EntityType   string             `json:"entity_type,omitempty" bson:"entity_type,omitempty"` 
+    EntityID     uint               `json:"entity_id,omitempty" bson:"entity_id,omitempty"` 
+    Purpose      string             `json:"purpose" bson:"purpose"` 
+    Variants     map[string]FileVariant `json:"variants,omitempty" bson:"variants,omitempty"` // thumb, medium untuk foto JPEG/PNG 
+    ScanStatus   string             `json:"scan_status,omitempty" bson:"scan_status,omitempty"` 
+    ScanSignature string            `json:"scan_signature,omitempty" bson:"scan_signature,omitempty"` // nama malware jika infected 
+    ScannedAt    *time.Time         `json:"scanned_at,omitempty" bson:"scanned_at,omitempty"` 
     UploadedAt   time.Time          `json:"uploaded_at" bson:"uploaded_at"` 
 } 
  
+// FileVariant versi gambar yang diperkecil, disimpan di backend yang sama dengan file asli 
+type FileVariant struct { 
+    StorageKey string `json:"-" bson:"storage_key"` 
+    FileSize   int64  `json:"file_size" bson:"file_size"` 
+    FileType   string `json:"file_type" bson:"file_type"` 
+    Width      int    `json:"width" bson:"width"` 
+    Height     int    `json:"height" bson:"height"` 
+} 
+ 
+type FileVariantResponse struct { 
+    FileSize    int64  `json:"file_size"` 
+    Width       int    `json:"width"` 
+    Height      int    `json:"height"` 
+    DownloadURL string `json:"download_url"` 
+} 
+ 
 type FileResponse struct { 
     ID           string    `json:"id"` 
     FileName     string    `json:"file_name"` 
     OriginalName string    `json:"original_name"` 
-    FilePath     string    `json:"file_path"` 
+    Backend      string    `json:"backend"` 
     FileSize     int64     `json:"file_size"` 
     FileType     string    `json:"file_type"` 
+    SHA256       string    `json:"sha256,omitempty"` 
+    OwnerID      int       `json:"owner_id"` 
+    EntityType   string    `json:"entity_type,omitempty"` 
+    EntityID     uint      `json:"entity_id,omitempty"` 
+    Purpose      string    `json:"purpose"` 
+    ScanStatus   string    `json:"scan_status,omitempty"` 
+    ScanSignature string   `json:"scan_signature,omitempty"` 
+    ScannedAt    *time.Time `json:"scanned_at,omitempty"` 
     UploadedAt   time.Time `json:"uploaded_at"` 
+    DownloadURL  string    `json:"download_url"` 
+    Variants     map[string]FileVariantResponse `json:"variants,omitempty"` 
+} 
+ 
+// FileBlob isi file yang disimpan sekali per hash SHA-256 di satu backend. Setiap
+// File dengan isi yang sama menunjuk ke blob ini; RefCount jumlah File tersebut,
+// dan isi baru dihapus dari storage saat RefCount mencapai 0. 
+type FileBlob struct { 
+    ID         string                 `json:"id" bson:"_id"` // <backend>:<sha256> 
+    Backend    string                 `json:"backend" bson:"backend"` 
+    StorageKey string                 `json:"storage_key" bson:"storage_key"` 
+    SHA256     string                 `json:"sha256" bson:"sha256"` 
+    FileSize   int64                  `json:"file_size" bson:"file_size"` 
+    FileType   string                 `json:"file_type" bson:"file_type"` 
+    Variants   map[string]FileVariant `json:"variants,omitempty" bson:"variants,omitempty"` 
+    RefCount   int                    `json:"ref_count" bson:"ref_count"` 
+    Stored     bool                   `json:"stored" bson:"stored"` // false selama isi belum selesai ditulis ke storage 
+    DeletingAt *time.Time             `json:"deleting_at,omitempty" bson:"deleting_at,omitempty"` // diisi selama isi dihapus dari storage (RefCount 0) 
+    ScanStatus    string              `json:"scan_status,omitempty" bson:"scan_status,omitempty"` 
+    ScanSignature string              `json:"scan_signature,omitempty" bson:"scan_signature,omitempty"` 
+    ScannedAt     *time.Time          `json:"scanned_at,omitempty" bson:"scanned_at,omitempty"` 
+    CreatedAt  time.Time              `json:"created_at" bson:"created_at"` 
+} 
+
+// FileScanResult hasil pemindaian yang disalin ke blob dan semua file yang memakainya. 
+// File infected dipindah ke StorageKey karantina dan variant-nya dihapus. 
+type FileScanResult struct { 
+    Status     string 
+    Signature  string 
+    StorageKey string // lokasi karantina, hanya untuk FileScanInfected 
+    ScannedAt  time.Time 
+} 
+
+// FileIntegrityIssue satu masalah hasil scan integritas 
+type FileIntegrityIssue struct { 
+    FileID     string `json:"file_id,omitempty"` 
+    Backend    string `json:"backend"` 
+    StorageKey string `json:"storage_key"` 
+    Variant    string `json:"variant,omitempty"` 
+    Reason     string `json:"reason"` 
+    Size       int64  `json:"size,omitempty"` 
+} 
+
+// FileIntegrityReport hasil scan: record tanpa isi (missing), isi yang berbeda dari 
+// record (mismatched), dan object di storage tanpa record (orphaned) 
+type FileIntegrityReport struct { 
+    ScannedFiles   int                  `json:"scanned_files"` 
+    ScannedObjects int                  `json:"scanned_objects"` 
+    Missing        []FileIntegrityIssue `json:"missing"` 
+    Mismatched     []FileIntegrityIssue `json:"mismatched"` 
+    Orphaned       []FileIntegrityIssue `json:"orphaned"` 
+    StartedAt      time.Time            `json:"started_at"` 
+    FinishedAt     time.Time            `json:"finished_at"` 
+} 
+
+// FileFilter filter daftar file GET /api/files. FileType boleh MIME type lengkap 
+// (application/pdf) atau tipe utamanya saja (image). UploadedTo batas atas eksklusif. 
+type FileFilter struct { 
+    OwnerID      int 
+    FileType     string 
+    Purpose      string 
+    EntityType   string 
+    UploadedFrom *time.Time 
+    UploadedTo   *time.Time 
+} 
+
+// FileSortFields kolom yang boleh dipakai sort_by pada daftar file 
+var FileSortFields = []string{"uploaded_at", "file_size", "original_name", "file_type"} 
+
+// Location backend dan key tempat isi file disimpan. Record lama (sebelum ada 
+// kolom backend) selalu berada di storage local dengan key = FileName. 
+func (f *File) Location() (string, string) { 
+    if f.Backend == "" { 
+        return "local", f.FileName 
+    } 
+    return f.Backend, f.StorageKey 
 }
\ No newline at end of file
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status link unduhan bertanda tangan
+const (
+	FileLinkActive  = "active"
+	FileLinkExpired = "expired"
+	FileLinkUsed    = "used"
+	FileLinkRevoked = "revoked"
+)
+
+// FileLink link unduhan bertanda tangan HMAC untuk pihak luar tanpa akun, misal
+// verifikator ijazah alumni. Tanda tangan mencakup ID, FileID dan ExpiresAt;
+// record ini menyimpan status pemakaian dan pencabutannya.
+type FileLink struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FileID     string             `json:"file_id" bson:"file_id"`
+	Recipient  string             `json:"recipient,omitempty" bson:"recipient,omitempty"` // catatan penerima, misal nama instansi
+	SingleUse  bool               `json:"single_use" bson:"single_use"`
+	UseCount   int                `json:"use_count" bson:"use_count"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	CreatedBy  int                `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	RevokedBy  int                `json:"revoked_by,omitempty" bson:"revoked_by,omitempty"`
+}
+
+// Status kondisi link pada waktu now
+func (l *FileLink) Status(now time.Time) string {
+	switch {
+	case l.RevokedAt != nil:
+		return FileLinkRevoked
+	case l.SingleUse && l.UseCount > 0:
+		return FileLinkUsed
+	case !now.Before(l.ExpiresAt):
+		return FileLinkExpired
+	}
+	return FileLinkActive
+}
+
+// CreateFileLinkRequest body POST /api/files/:id/links
+type CreateFileLinkRequest struct {
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1,max=720"` // default 72
+	SingleUse      bool   `json:"single_use"`
+	Recipient      string `json:"recipient" validate:"max=200"`
+}
+
+type FileLinkResponse struct {
+	FileLink
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"` // hanya saat link dibuat
+}
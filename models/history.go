@@ -0,0 +1,116 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Entitas yang riwayat versinya dicatat
+const (
+	HistoryEntityMahasiswa = "mahasiswa"
+	HistoryEntityAlumni    = "alumni"
+	HistoryEntityPekerjaan = "pekerjaan"
+)
+
+// Model RecordHistory - isi sebuah record sebelum diubah lewat PUT/PATCH/restore.
+// Version adalah versi record yang tersimpan di Snapshot, bukan versi sesudahnya.
+type RecordHistory struct {
+	ID        uint           `gorm:"primaryKey" json:"id" bson:"id"`
+	Entity    string         `gorm:"type:varchar(20);not null;index:idx_record_histories_record" json:"entity" bson:"entity"`
+	RecordID  uint           `gorm:"not null;index:idx_record_histories_record" json:"record_id" bson:"record_id"`
+	Version   int            `gorm:"not null" json:"version" bson:"version"`
+	Snapshot  RecordSnapshot `gorm:"type:text" json:"snapshot" bson:"snapshot"`
+	ChangedBy int            `json:"changed_by" bson:"changed_by"` // user_id yang melakukan perubahan
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at" bson:"created_at"`
+}
+
+// RecordHistoryEntry satu versi di daftar riwayat beserta perubahan menuju versi berikutnya
+type RecordHistoryEntry struct {
+	RecordHistory
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange perubahan nilai satu field di antara dua versi
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RecordSnapshot isi field yang bisa diubah dari sebuah record (bentuk request
+// update-nya), disimpan sebagai JSON di PostgreSQL dan sebagai dokumen/json
+// field di MongoDB/PocketBase
+type RecordSnapshot map[string]interface{}
+
+// NewRecordSnapshot membuat snapshot dari struct request update
+func NewRecordSnapshot(v interface{}) (RecordSnapshot, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot RecordSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// UnmarshalJSON menjaga angka tetap utuh (json.Number), supaya nominal gaji
+// tidak berubah menjadi notasi 5e+06 saat snapshot dikirim ulang ke client
+func (s *RecordSnapshot) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+	*s = fields
+	return nil
+}
+
+// Decode mengisi struct request update dari snapshot
+func (s RecordSnapshot) Decode(target interface{}) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// Diff membandingkan snapshot ini dengan snapshot yang lebih baru, urut nama field.
+// Nilai dibandingkan dalam bentuk JSON karena tipe angka bisa berbeda antar backend.
+func (s RecordSnapshot) Diff(to RecordSnapshot) []FieldChange {
+	fields := map[string]bool{}
+	for field := range s {
+		fields[field] = true
+	}
+	for field := range to {
+		fields[field] = true
+	}
+
+	changes := []FieldChange{}
+	for field := range fields {
+		from, _ := json.Marshal(s[field])
+		next, _ := json.Marshal(to[field])
+		if !bytes.Equal(from, next) {
+			changes = append(changes, FieldChange{Field: field, From: s[field], To: to[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func (s RecordSnapshot) Value() (driver.Value, error) {
+	if s == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]interface{}(s))
+	return string(data), err
+}
+
+func (s *RecordSnapshot) Scan(value interface{}) error {
+	return scanJSONColumn(value, s)
+}
@@ -1,29 +1,53 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type CreateMahasiswaRequest struct {
-	NIM      string `json:"nim"`
-	Nama     string `json:"nama"`
-	Jurusan  string `json:"jurusan"`
-	Angkatan int    `json:"angkatan"`
-	Email    string `json:"email"`
+	NIM      string `json:"nim" validate:"required,nim"`
+	Nama     string `json:"nama" validate:"required,max=100"`
+	Jurusan  string `json:"jurusan" validate:"required,max=50"`
+	Angkatan int    `json:"angkatan" validate:"required,tahun"`
+	Email    string `json:"email" validate:"required,email,max=100"`
 }
 
 type UpdateMahasiswaRequest struct {
-	Nama     string `json:"nama"`
-	Jurusan  string `json:"jurusan"`
-	Angkatan int    `json:"angkatan"`
-	Email    string `json:"email"`
+	Nama     string `json:"nama" validate:"required,max=100"`
+	Jurusan  string `json:"jurusan" validate:"required,max=50"`
+	Angkatan int    `json:"angkatan" validate:"required,tahun"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+}
+
+// MergeMahasiswaRequest mahasiswa duplikat yang digabung ke mahasiswa tujuan
+type MergeMahasiswaRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required"`
 }
 
 type Mahasiswa struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	NIM       string    `gorm:"type:varchar(20);unique;not null" json:"nim"`
-	Nama      string    `gorm:"type:varchar(100);not null" json:"nama"`
-	Jurusan   string    `gorm:"type:varchar(50);not null" json:"jurusan"`
-	Angkatan  int       `gorm:"not null" json:"angkatan"`
-	Email     string    `gorm:"type:varchar(100);unique;not null" json:"email"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	NIM        string       `gorm:"type:varchar(20);unique;not null" json:"nim"`
+	Nama       string       `gorm:"type:varchar(100);not null" json:"nama"`
+	Jurusan    string       `gorm:"type:varchar(50);not null" json:"jurusan"`
+	Angkatan   int          `gorm:"not null" json:"angkatan"`
+	Email      string       `gorm:"type:varchar(100);unique;not null" json:"email"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+	Version    int          `gorm:"not null;default:1" json:"version"`
+	DeletedAt  *time.Time   `gorm:"index" json:"deleted_at,omitempty"`
+	MergedInto *uint        `gorm:"index" json:"merged_into,omitempty"` // mahasiswa tujuan jika record ini digabung sebagai duplikat
+	Search     *SearchMatch `gorm:"-" json:"search,omitempty" bson:"-"` // hanya diisi pada hasil pencarian
+}
+
+// SearchFields field yang ikut full-text search; bobotnya sama dengan kolom
+// search_vector PostgreSQL dan text index MongoDB
+func (m *Mahasiswa) SearchFields() []SearchField {
+	return []SearchField{
+		{Name: "nim", Value: m.NIM, Weight: SearchWeightA},
+		{Name: "nama", Value: m.Nama, Weight: SearchWeightA},
+		{Name: "jurusan", Value: m.Jurusan, Weight: SearchWeightB},
+		{Name: "email", Value: m.Email, Weight: SearchWeightC},
+		{Name: "angkatan", Value: strconv.Itoa(m.Angkatan), Weight: SearchWeightD},
+	}
 }
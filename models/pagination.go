@@ -38,6 +38,22 @@ func (p *PaginationRequest) SetDefaults() {
 	}
 }
 
+// SetSearchDefaults seperti SetDefaults + ValidateSortOrder untuk list yang
+// mendukung full-text search: jika search berisi kata dan sort_by kosong, hasil
+// diurutkan berdasarkan relevansi. Mengembalikan true jika urutan relevansi dipakai.
+func (p *PaginationRequest) SetSearchDefaults() bool {
+	hasTerms := len(SearchTerms(p.Search)) > 0
+	if p.SortBy == SortByRelevance && !hasTerms {
+		p.SortBy = ""
+	}
+	if p.SortBy == "" && hasTerms {
+		p.SortBy = SortByRelevance
+	}
+	p.SetDefaults()
+	p.ValidateSortOrder()
+	return p.SortBy == SortByRelevance
+}
+
 // GetOffset menghitung offset untuk query database
 func (p *PaginationRequest) GetOffset() int {
 	return (p.Page - 1) * p.Limit
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Model Perusahaan - entitas perusahaan tempat alumni bekerja
+type Perusahaan struct {
+	ID             uint         `gorm:"primaryKey" json:"id" bson:"id"`
+	Nama           string       `gorm:"type:varchar(100);not null" json:"nama" bson:"nama"`
+	NamaNormal     string       `gorm:"type:varchar(100);uniqueIndex;not null" json:"-" bson:"nama_normal"`
+	BidangIndustri string       `gorm:"type:varchar(50)" json:"bidang_industri" bson:"bidang_industri"`
+	Lokasi         string       `gorm:"type:varchar(100)" json:"lokasi" bson:"lokasi"`
+	Website        string       `gorm:"type:varchar(255)" json:"website" bson:"website"`
+	Aliases        []string     `gorm:"-" json:"aliases" bson:"aliases"`
+	AliasNormals   []string     `gorm:"-" json:"-" bson:"alias_normals"`
+	JumlahAlumni   *int64       `gorm:"-" json:"jumlah_alumni,omitempty" bson:"-"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time    `gorm:"autoUpdateTime" json:"updated_at" bson:"updated_at"`
+	Version        int          `gorm:"not null;default:1" json:"version" bson:"version"`
+	Search         *SearchMatch `gorm:"-" json:"search,omitempty" bson:"-"` // hanya diisi pada hasil pencarian
+}
+
+// SearchFields field perusahaan yang ikut full-text search; alias digabung menjadi satu field
+func (p *Perusahaan) SearchFields() []SearchField {
+	return []SearchField{
+		{Name: "nama", Value: p.Nama, Weight: SearchWeightA},
+		{Name: "aliases", Value: strings.Join(p.Aliases, ", "), Weight: SearchWeightA},
+		{Name: "bidang_industri", Value: p.BidangIndustri, Weight: SearchWeightB},
+		{Name: "lokasi", Value: p.Lokasi, Weight: SearchWeightC},
+	}
+}
+
+// PerusahaanAlias menyimpan nama lain sebuah perusahaan (PostgreSQL)
+type PerusahaanAlias struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	PerusahaanID uint   `gorm:"not null;index" json:"perusahaan_id"`
+	Alias        string `gorm:"type:varchar(100);not null" json:"alias"`
+	AliasNormal  string `gorm:"type:varchar(100);uniqueIndex;not null" json:"-"`
+}
+
+// Request struct untuk Perusahaan
+type PerusahaanRequest struct {
+	Nama           string   `json:"nama" validate:"required,max=100"`
+	BidangIndustri string   `json:"bidang_industri" validate:"max=50"`
+	Lokasi         string   `json:"lokasi" validate:"max=100"`
+	Website        string   `json:"website" validate:"omitempty,url,max=255"`
+	Aliases        []string `json:"aliases"`
+}
+
+// MergePerusahaanRequest daftar perusahaan duplikat yang digabung ke perusahaan tujuan
+type MergePerusahaanRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required"`
+}
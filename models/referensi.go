@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kategori data referensi (controlled vocabulary)
+const (
+	KategoriIndustri = "industri"
+	KategoriProvinsi = "provinsi"
+	KategoriKota     = "kota" // KodeInduk = kode provinsi
+	KategoriFakultas = "fakultas"
+	KategoriProdi    = "prodi" // KodeInduk = kode fakultas, KodeTerkait = kode industri yang selaras
+)
+
+// KategoriReferensi daftar kategori yang valid
+var KategoriReferensi = []string{KategoriIndustri, KategoriProvinsi, KategoriKota, KategoriFakultas, KategoriProdi}
+
+// KategoriIndukReferensi kategori induk untuk kategori yang berjenjang
+var KategoriIndukReferensi = map[string]string{
+	KategoriKota:  KategoriProvinsi,
+	KategoriProdi: KategoriFakultas,
+}
+
+// Model Referensi - satu entri controlled vocabulary (industri, provinsi, kota, fakultas, prodi)
+type Referensi struct {
+	ID        uint       `gorm:"primaryKey" json:"id" bson:"id"`
+	Kategori  string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_referensi_kategori_kode" json:"kategori" bson:"kategori"`
+	Kode      string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_referensi_kategori_kode" json:"kode" bson:"kode"`
+	Nama      string     `gorm:"type:varchar(100);not null" json:"nama" bson:"nama"`
+	KodeInduk string     `gorm:"type:varchar(20)" json:"kode_induk,omitempty" bson:"kode_induk"`
+	Aliases   StringList `gorm:"type:text" json:"aliases" bson:"aliases"`
+	// KodeTerkait kode industri yang selaras dengan prodi (untuk tracer study)
+	KodeTerkait StringList `gorm:"type:text" json:"kode_terkait,omitempty" bson:"kode_terkait"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at" bson:"updated_at"`
+	Version     int        `gorm:"not null;default:1" json:"version" bson:"version"`
+}
+
+// Request struct untuk Referensi
+type ReferensiRequest struct {
+	Kode        string   `json:"kode" validate:"max=20"`
+	Nama        string   `json:"nama" validate:"required,max=100"`
+	KodeInduk   string   `json:"kode_induk" validate:"max=20"`
+	Aliases     []string `json:"aliases"`
+	KodeTerkait []string `json:"kode_terkait"`
+}
+
+// StringList daftar string yang disimpan sebagai JSON di kolom text (PostgreSQL)
+// dan sebagai array biasa di MongoDB/PocketBase
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+	if len(data) == 0 {
+		*l = StringList{}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
@@ -0,0 +1,99 @@
+package models
+
+// DefaultReferensi data referensi awal yang diisi saat kategori masih kosong.
+// Industri mengikuti kategori KBLI 2020, provinsi dan kota memakai kode wilayah
+// Kemendagri. Fakultas dan prodi bergantung pada kampus, jadi diisi oleh admin.
+var DefaultReferensi = []Referensi{
+	// Industri (KBLI 2020)
+	{Kategori: KategoriIndustri, Kode: "A", Nama: "Pertanian, Kehutanan dan Perikanan", Aliases: StringList{"Pertanian", "Agrikultur", "Perikanan", "Perkebunan"}},
+	{Kategori: KategoriIndustri, Kode: "B", Nama: "Pertambangan dan Penggalian", Aliases: StringList{"Pertambangan", "Mining", "Tambang", "Migas", "Oil and Gas"}},
+	{Kategori: KategoriIndustri, Kode: "C", Nama: "Industri Pengolahan", Aliases: StringList{"Manufaktur", "Manufacturing", "Pabrik", "Otomotif", "FMCG"}},
+	{Kategori: KategoriIndustri, Kode: "D", Nama: "Pengadaan Listrik dan Gas", Aliases: StringList{"Energi", "Energy", "Listrik", "Utilities"}},
+	{Kategori: KategoriIndustri, Kode: "E", Nama: "Pengelolaan Air, Limbah dan Daur Ulang", Aliases: StringList{"Pengelolaan Limbah", "Air Bersih"}},
+	{Kategori: KategoriIndustri, Kode: "F", Nama: "Konstruksi", Aliases: StringList{"Construction", "Kontraktor", "Properti", "Real Estate"}},
+	{Kategori: KategoriIndustri, Kode: "G", Nama: "Perdagangan Besar dan Eceran", Aliases: StringList{"Perdagangan", "Retail", "Ritel", "E-Commerce", "Ecommerce", "Trading"}},
+	{Kategori: KategoriIndustri, Kode: "H", Nama: "Transportasi dan Pergudangan", Aliases: StringList{"Transportasi", "Logistik", "Logistics", "Pergudangan"}},
+	{Kategori: KategoriIndustri, Kode: "I", Nama: "Penyediaan Akomodasi dan Makan Minum", Aliases: StringList{"Perhotelan", "Hospitality", "Kuliner", "F&B", "Pariwisata"}},
+	{Kategori: KategoriIndustri, Kode: "J", Nama: "Informasi dan Komunikasi", Aliases: StringList{"Teknologi Informasi", "IT", "TI", "Technology", "Teknologi", "Software", "Telekomunikasi", "Telecommunication", "Media", "Startup"}},
+	{Kategori: KategoriIndustri, Kode: "K", Nama: "Jasa Keuangan dan Asuransi", Aliases: StringList{"Keuangan", "Finance", "Perbankan", "Banking", "Bank", "Fintech", "Asuransi", "Insurance"}},
+	{Kategori: KategoriIndustri, Kode: "M", Nama: "Jasa Profesional, Ilmiah dan Teknis", Aliases: StringList{"Konsultan", "Consulting", "Konsultansi", "Riset", "Research"}},
+	{Kategori: KategoriIndustri, Kode: "N", Nama: "Jasa Persewaan dan Penunjang Usaha", Aliases: StringList{"Outsourcing", "Jasa Penunjang"}},
+	{Kategori: KategoriIndustri, Kode: "O", Nama: "Administrasi Pemerintahan dan Pertahanan", Aliases: StringList{"Pemerintahan", "Government", "Instansi Pemerintah", "BUMN"}},
+	{Kategori: KategoriIndustri, Kode: "P", Nama: "Jasa Pendidikan", Aliases: StringList{"Pendidikan", "Education", "Edtech", "Universitas", "Sekolah"}},
+	{Kategori: KategoriIndustri, Kode: "Q", Nama: "Jasa Kesehatan dan Kegiatan Sosial", Aliases: StringList{"Kesehatan", "Healthcare", "Rumah Sakit", "Farmasi"}},
+	{Kategori: KategoriIndustri, Kode: "R", Nama: "Kesenian, Hiburan dan Rekreasi", Aliases: StringList{"Hiburan", "Entertainment", "Kreatif", "Game", "Gaming"}},
+	{Kategori: KategoriIndustri, Kode: "S", Nama: "Kegiatan Jasa Lainnya", Aliases: StringList{"Jasa Lainnya", "Lainnya", "Lain-lain"}},
+
+	// Provinsi (kode wilayah Kemendagri)
+	{Kategori: KategoriProvinsi, Kode: "11", Nama: "Aceh", Aliases: StringList{"NAD", "Nanggroe Aceh Darussalam"}},
+	{Kategori: KategoriProvinsi, Kode: "12", Nama: "Sumatera Utara", Aliases: StringList{"Sumut"}},
+	{Kategori: KategoriProvinsi, Kode: "13", Nama: "Sumatera Barat", Aliases: StringList{"Sumbar"}},
+	{Kategori: KategoriProvinsi, Kode: "14", Nama: "Riau"},
+	{Kategori: KategoriProvinsi, Kode: "15", Nama: "Jambi"},
+	{Kategori: KategoriProvinsi, Kode: "16", Nama: "Sumatera Selatan", Aliases: StringList{"Sumsel"}},
+	{Kategori: KategoriProvinsi, Kode: "17", Nama: "Bengkulu"},
+	{Kategori: KategoriProvinsi, Kode: "18", Nama: "Lampung"},
+	{Kategori: KategoriProvinsi, Kode: "19", Nama: "Kepulauan Bangka Belitung", Aliases: StringList{"Bangka Belitung", "Babel"}},
+	{Kategori: KategoriProvinsi, Kode: "21", Nama: "Kepulauan Riau", Aliases: StringList{"Kepri"}},
+	{Kategori: KategoriProvinsi, Kode: "31", Nama: "DKI Jakarta", Aliases: StringList{"Jakarta", "DKI"}},
+	{Kategori: KategoriProvinsi, Kode: "32", Nama: "Jawa Barat", Aliases: StringList{"Jabar"}},
+	{Kategori: KategoriProvinsi, Kode: "33", Nama: "Jawa Tengah", Aliases: StringList{"Jateng"}},
+	{Kategori: KategoriProvinsi, Kode: "34", Nama: "DI Yogyakarta", Aliases: StringList{"DIY", "Daerah Istimewa Yogyakarta", "Jogja", "Jogjakarta"}},
+	{Kategori: KategoriProvinsi, Kode: "35", Nama: "Jawa Timur", Aliases: StringList{"Jatim"}},
+	{Kategori: KategoriProvinsi, Kode: "36", Nama: "Banten"},
+	{Kategori: KategoriProvinsi, Kode: "51", Nama: "Bali"},
+	{Kategori: KategoriProvinsi, Kode: "52", Nama: "Nusa Tenggara Barat", Aliases: StringList{"NTB"}},
+	{Kategori: KategoriProvinsi, Kode: "53", Nama: "Nusa Tenggara Timur", Aliases: StringList{"NTT"}},
+	{Kategori: KategoriProvinsi, Kode: "61", Nama: "Kalimantan Barat", Aliases: StringList{"Kalbar"}},
+	{Kategori: KategoriProvinsi, Kode: "62", Nama: "Kalimantan Tengah", Aliases: StringList{"Kalteng"}},
+	{Kategori: KategoriProvinsi, Kode: "63", Nama: "Kalimantan Selatan", Aliases: StringList{"Kalsel"}},
+	{Kategori: KategoriProvinsi, Kode: "64", Nama: "Kalimantan Timur", Aliases: StringList{"Kaltim"}},
+	{Kategori: KategoriProvinsi, Kode: "65", Nama: "Kalimantan Utara", Aliases: StringList{"Kaltara"}},
+	{Kategori: KategoriProvinsi, Kode: "71", Nama: "Sulawesi Utara", Aliases: StringList{"Sulut"}},
+	{Kategori: KategoriProvinsi, Kode: "72", Nama: "Sulawesi Tengah", Aliases: StringList{"Sulteng"}},
+	{Kategori: KategoriProvinsi, Kode: "73", Nama: "Sulawesi Selatan", Aliases: StringList{"Sulsel"}},
+	{Kategori: KategoriProvinsi, Kode: "74", Nama: "Sulawesi Tenggara", Aliases: StringList{"Sultra"}},
+	{Kategori: KategoriProvinsi, Kode: "75", Nama: "Gorontalo"},
+	{Kategori: KategoriProvinsi, Kode: "76", Nama: "Sulawesi Barat", Aliases: StringList{"Sulbar"}},
+	{Kategori: KategoriProvinsi, Kode: "81", Nama: "Maluku"},
+	{Kategori: KategoriProvinsi, Kode: "82", Nama: "Maluku Utara", Aliases: StringList{"Malut"}},
+	{Kategori: KategoriProvinsi, Kode: "91", Nama: "Papua"},
+	{Kategori: KategoriProvinsi, Kode: "92", Nama: "Papua Barat"},
+	{Kategori: KategoriProvinsi, Kode: "93", Nama: "Papua Selatan"},
+	{Kategori: KategoriProvinsi, Kode: "94", Nama: "Papua Tengah"},
+	{Kategori: KategoriProvinsi, Kode: "95", Nama: "Papua Pegunungan"},
+	{Kategori: KategoriProvinsi, Kode: "96", Nama: "Papua Barat Daya"},
+	{Kategori: KategoriProvinsi, Kode: "LN", Nama: "Luar Negeri", Aliases: StringList{"Overseas", "Abroad"}},
+
+	// Kota besar (kode wilayah Kemendagri); kota lain ditambahkan admin
+	{Kategori: KategoriKota, Kode: "11.71", KodeInduk: "11", Nama: "Kota Banda Aceh", Aliases: StringList{"Banda Aceh"}},
+	{Kategori: KategoriKota, Kode: "12.71", KodeInduk: "12", Nama: "Kota Medan", Aliases: StringList{"Medan"}},
+	{Kategori: KategoriKota, Kode: "13.71", KodeInduk: "13", Nama: "Kota Padang", Aliases: StringList{"Padang"}},
+	{Kategori: KategoriKota, Kode: "14.71", KodeInduk: "14", Nama: "Kota Pekanbaru", Aliases: StringList{"Pekanbaru"}},
+	{Kategori: KategoriKota, Kode: "16.71", KodeInduk: "16", Nama: "Kota Palembang", Aliases: StringList{"Palembang"}},
+	{Kategori: KategoriKota, Kode: "18.71", KodeInduk: "18", Nama: "Kota Bandar Lampung", Aliases: StringList{"Bandar Lampung"}},
+	{Kategori: KategoriKota, Kode: "21.71", KodeInduk: "21", Nama: "Kota Batam", Aliases: StringList{"Batam"}},
+	{Kategori: KategoriKota, Kode: "31.71", KodeInduk: "31", Nama: "Kota Jakarta Pusat", Aliases: StringList{"Jakarta Pusat", "Jakpus"}},
+	{Kategori: KategoriKota, Kode: "31.72", KodeInduk: "31", Nama: "Kota Jakarta Utara", Aliases: StringList{"Jakarta Utara", "Jakut"}},
+	{Kategori: KategoriKota, Kode: "31.73", KodeInduk: "31", Nama: "Kota Jakarta Barat", Aliases: StringList{"Jakarta Barat", "Jakbar"}},
+	{Kategori: KategoriKota, Kode: "31.74", KodeInduk: "31", Nama: "Kota Jakarta Selatan", Aliases: StringList{"Jakarta Selatan", "Jaksel"}},
+	{Kategori: KategoriKota, Kode: "31.75", KodeInduk: "31", Nama: "Kota Jakarta Timur", Aliases: StringList{"Jakarta Timur", "Jaktim"}},
+	{Kategori: KategoriKota, Kode: "32.71", KodeInduk: "32", Nama: "Kota Bogor", Aliases: StringList{"Bogor"}},
+	{Kategori: KategoriKota, Kode: "32.73", KodeInduk: "32", Nama: "Kota Bandung", Aliases: StringList{"Bandung"}},
+	{Kategori: KategoriKota, Kode: "32.75", KodeInduk: "32", Nama: "Kota Bekasi", Aliases: StringList{"Bekasi"}},
+	{Kategori: KategoriKota, Kode: "32.76", KodeInduk: "32", Nama: "Kota Depok", Aliases: StringList{"Depok"}},
+	{Kategori: KategoriKota, Kode: "33.72", KodeInduk: "33", Nama: "Kota Surakarta", Aliases: StringList{"Surakarta", "Solo"}},
+	{Kategori: KategoriKota, Kode: "33.74", KodeInduk: "33", Nama: "Kota Semarang", Aliases: StringList{"Semarang"}},
+	{Kategori: KategoriKota, Kode: "34.71", KodeInduk: "34", Nama: "Kota Yogyakarta", Aliases: StringList{"Yogyakarta", "Yogya"}},
+	{Kategori: KategoriKota, Kode: "35.73", KodeInduk: "35", Nama: "Kota Malang", Aliases: StringList{"Malang"}},
+	{Kategori: KategoriKota, Kode: "35.78", KodeInduk: "35", Nama: "Kota Surabaya", Aliases: StringList{"Surabaya"}},
+	{Kategori: KategoriKota, Kode: "36.71", KodeInduk: "36", Nama: "Kota Tangerang", Aliases: StringList{"Tangerang"}},
+	{Kategori: KategoriKota, Kode: "36.74", KodeInduk: "36", Nama: "Kota Tangerang Selatan", Aliases: StringList{"Tangerang Selatan", "Tangsel"}},
+	{Kategori: KategoriKota, Kode: "51.71", KodeInduk: "51", Nama: "Kota Denpasar", Aliases: StringList{"Denpasar"}},
+	{Kategori: KategoriKota, Kode: "61.71", KodeInduk: "61", Nama: "Kota Pontianak", Aliases: StringList{"Pontianak"}},
+	{Kategori: KategoriKota, Kode: "63.71", KodeInduk: "63", Nama: "Kota Banjarmasin", Aliases: StringList{"Banjarmasin"}},
+	{Kategori: KategoriKota, Kode: "64.71", KodeInduk: "64", Nama: "Kota Balikpapan", Aliases: StringList{"Balikpapan"}},
+	{Kategori: KategoriKota, Kode: "64.72", KodeInduk: "64", Nama: "Kota Samarinda", Aliases: StringList{"Samarinda"}},
+	{Kategori: KategoriKota, Kode: "71.71", KodeInduk: "71", Nama: "Kota Manado", Aliases: StringList{"Manado"}},
+	{Kategori: KategoriKota, Kode: "73.71", KodeInduk: "73", Nama: "Kota Makassar", Aliases: StringList{"Makassar"}},
+}
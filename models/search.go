@@ -0,0 +1,130 @@
+package models
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SortByRelevance nilai sort_by untuk mengurutkan hasil full-text search dari yang
+// paling relevan; dipakai otomatis jika search diisi tanpa sort_by
+const SortByRelevance = "relevance"
+
+// Bobot field pencarian, sama dengan bobot default ts_rank PostgreSQL untuk label A-D
+const (
+	SearchWeightA = 1.0
+	SearchWeightB = 0.4
+	SearchWeightC = 0.2
+	SearchWeightD = 0.1
+)
+
+// SearchMatch relevansi satu hasil pencarian beserta field yang cocok. Highlights
+// berisi nilai field (sudah di-escape HTML) dengan kata yang cocok dibungkus <mark>.
+type SearchMatch struct {
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchField satu field yang diindeks full-text beserta bobotnya
+type SearchField struct {
+	Name   string
+	Value  string
+	Weight float64
+}
+
+// SearchTerms memecah keyword menjadi kata huruf kecil tanpa tanda baca, tanpa
+// duplikat. Tokenisasi ini sama dengan kolom search_vector PostgreSQL, sehingga
+// NIM "2021-001" dicari sebagai kata "2021" dan "001".
+func SearchTerms(search string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(search), isSearchSeparator) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+func isSearchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// NewSearchMatch membuat SearchMatch dengan skor dari database (ts_rank/textScore);
+// highlights dihitung dari fields
+func NewSearchMatch(terms []string, fields []SearchField, score float64) *SearchMatch {
+	match := &SearchMatch{Score: score, Highlights: map[string]string{}}
+	for _, field := range fields {
+		if highlighted, ok := highlightField(terms, field.Value); ok {
+			match.Highlights[field.Name] = highlighted
+		}
+	}
+	return match
+}
+
+// MatchSearch menilai fields di sisi aplikasi (untuk backend tanpa ranking bawaan).
+// Setiap kata harus cocok sebagai awalan kata di salah satu field, seperti prefix
+// query PostgreSQL; nil jika ada kata yang tidak cocok.
+func MatchSearch(terms []string, fields []SearchField) *SearchMatch {
+	score := 0.0
+	for _, term := range terms {
+		found := false
+		for _, field := range fields {
+			if count := countPrefixMatches(term, field.Value); count > 0 {
+				score += field.Weight * float64(count)
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return NewSearchMatch(terms, fields, score/float64(len(terms)))
+}
+
+func countPrefixMatches(term, value string) int {
+	count := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(value), isSearchSeparator) {
+		if strings.HasPrefix(word, term) {
+			count++
+		}
+	}
+	return count
+}
+
+// highlightField membungkus kata di value yang diawali salah satu term dengan <mark>
+func highlightField(terms []string, value string) (string, bool) {
+	var b strings.Builder
+	runes := []rune(value)
+	matched := false
+	for i := 0; i < len(runes); {
+		if isSearchSeparator(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && !isSearchSeparator(runes[end]) {
+			end++
+		}
+		word := string(runes[i:end])
+		if hasTermPrefix(terms, strings.ToLower(word)) {
+			b.WriteString("<mark>" + word + "</mark>")
+			matched = true
+		} else {
+			b.WriteString(word)
+		}
+		i = end
+	}
+	return b.String(), matched
+}
+
+func hasTermPrefix(terms []string, word string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
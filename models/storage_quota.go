@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// StorageQuota batas penyimpanan khusus satu user, menggantikan batas bawaan
+// role-nya. MaxBytes 0 berarti tanpa batas.
+type StorageQuota struct {
+	OwnerID   int       `json:"owner_id" bson:"owner_id"`
+	MaxBytes  int64     `json:"max_bytes" bson:"max_bytes"`
+	UpdatedBy int       `json:"updated_by" bson:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// SetStorageQuotaRequest body PUT /api/users/:id/storage-quota, max_mb 0 = tanpa batas
+type SetStorageQuotaRequest struct {
+	MaxMB *float64 `json:"max_mb" validate:"required,min=0"`
+}
+
+// StorageUsageGroup pemakaian penyimpanan untuk satu purpose
+type StorageUsageGroup struct {
+	Purpose   string `json:"purpose" bson:"_id"`
+	UsedBytes int64  `json:"used_bytes" bson:"used_bytes"`
+	FileCount int64  `json:"file_count" bson:"file_count"`
+}
+
+// StorageUsage response GET /api/profile/storage. Ukuran dihitung dari setiap
+// file yang diupload, walaupun isinya berbagi blob dengan file lain.
+type StorageUsage struct {
+	UsedBytes      int64               `json:"used_bytes"`
+	FileCount      int64               `json:"file_count"`
+	QuotaBytes     int64               `json:"quota_bytes"`     // 0 = tanpa batas
+	RemainingBytes *int64              `json:"remaining_bytes"` // null jika tanpa batas
+	QuotaSource    string              `json:"quota_source"`    // "role" atau "user"
+	ByPurpose      []StorageUsageGroup `json:"by_purpose"`
+}
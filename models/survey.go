@@ -0,0 +1,194 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Status survey: draft masih bisa diubah, open bisa diisi alumni, closed hanya dibaca
+const (
+	SurveyStatusDraft  = "draft"
+	SurveyStatusOpen   = "open"
+	SurveyStatusClosed = "closed"
+)
+
+// Tipe pertanyaan survey
+const (
+	TipePertanyaanTeks         = "teks"
+	TipePertanyaanPilihan      = "pilihan"       // satu jawaban dari Pilihan
+	TipePertanyaanPilihanGanda = "pilihan_ganda" // beberapa jawaban dari Pilihan
+	TipePertanyaanSkala        = "skala"         // bilangan bulat SkalaMin..SkalaMax
+	TipePertanyaanAngka        = "angka"
+)
+
+// TipePertanyaan daftar tipe pertanyaan yang valid
+var TipePertanyaan = []string{
+	TipePertanyaanTeks, TipePertanyaanPilihan, TipePertanyaanPilihanGanda,
+	TipePertanyaanSkala, TipePertanyaanAngka,
+}
+
+// Model Survey - kuesioner tracer study. Satu Kode bisa punya beberapa Versi;
+// pertanyaan hanya bisa diubah selama status masih draft.
+type Survey struct {
+	ID               uint            `gorm:"primaryKey" json:"id" bson:"id"`
+	Kode             string          `gorm:"type:varchar(50);not null;uniqueIndex:idx_surveys_kode_versi" json:"kode" bson:"kode"`
+	Versi            int             `gorm:"not null;uniqueIndex:idx_surveys_kode_versi" json:"versi" bson:"versi"`
+	Judul            string          `gorm:"type:varchar(200);not null" json:"judul" bson:"judul"`
+	Deskripsi        string          `gorm:"type:text" json:"deskripsi" bson:"deskripsi"`
+	Status           string          `gorm:"type:varchar(20);not null;default:draft;index" json:"status" bson:"status"`
+	TargetTahunLulus IntList         `gorm:"type:text" json:"target_tahun_lulus" bson:"target_tahun_lulus"`
+	TargetJurusan    StringList      `gorm:"type:text" json:"target_jurusan" bson:"target_jurusan"`
+	Pertanyaan       SurveyQuestions `gorm:"type:text" json:"pertanyaan" bson:"pertanyaan"`
+	DibukaAt         *time.Time      `json:"dibuka_at" bson:"dibuka_at"`
+	DitutupAt        *time.Time      `json:"ditutup_at" bson:"ditutup_at"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at" bson:"updated_at"`
+	Version          int             `gorm:"not null;default:1" json:"version" bson:"version"` // revisi record untuk ETag, beda dengan Versi kuesioner
+}
+
+// SurveyQuestion satu pertanyaan survey
+type SurveyQuestion struct {
+	Kode     string   `json:"kode" bson:"kode" validate:"required,max=50"`
+	Teks     string   `json:"teks" bson:"teks" validate:"required"`
+	Tipe     string   `json:"tipe" bson:"tipe" validate:"required,oneof=teks pilihan pilihan_ganda skala angka"`
+	Wajib    bool     `json:"wajib" bson:"wajib"`
+	Pilihan  []string `json:"pilihan,omitempty" bson:"pilihan"`
+	SkalaMin int      `json:"skala_min,omitempty" bson:"skala_min"`
+	SkalaMax int      `json:"skala_max,omitempty" bson:"skala_max"`
+}
+
+// Model SurveyResponse - jawaban satu alumni untuk satu versi survey
+type SurveyResponse struct {
+	ID        uint          `gorm:"primaryKey" json:"id" bson:"id"`
+	SurveyID  uint          `gorm:"not null;uniqueIndex:idx_survey_responses_survey_alumni" json:"survey_id" bson:"survey_id"`
+	AlumniID  uint          `gorm:"not null;uniqueIndex:idx_survey_responses_survey_alumni;index" json:"alumni_id" bson:"alumni_id"`
+	Jawaban   SurveyAnswers `gorm:"type:text" json:"jawaban" bson:"jawaban"`
+	CreatedAt time.Time     `gorm:"autoCreateTime" json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `gorm:"autoUpdateTime" json:"updated_at" bson:"updated_at"`
+}
+
+// SurveyAnswer jawaban untuk satu pertanyaan; field yang dipakai tergantung tipe pertanyaan
+type SurveyAnswer struct {
+	Kode    string   `json:"kode" bson:"kode"`
+	Teks    string   `json:"teks,omitempty" bson:"teks"`
+	Pilihan []string `json:"pilihan,omitempty" bson:"pilihan"`
+	Nilai   *float64 `json:"nilai,omitempty" bson:"nilai"`
+}
+
+// Request struct untuk Survey
+type SurveyRequest struct {
+	Kode             string           `json:"kode" validate:"max=50"`
+	Judul            string           `json:"judul" validate:"required,max=200"`
+	Deskripsi        string           `json:"deskripsi"`
+	TargetTahunLulus []int            `json:"target_tahun_lulus"`
+	TargetJurusan    []string         `json:"target_jurusan"`
+	Pertanyaan       []SurveyQuestion `json:"pertanyaan" validate:"required"`
+}
+
+// Request struct untuk jawaban survey dari alumni
+type SurveyResponseRequest struct {
+	Jawaban []SurveyAnswer `json:"jawaban"`
+}
+
+// SurveyResponseRate tingkat respons survey untuk satu grup alumni target
+type SurveyResponseRate struct {
+	Grup      string  `json:"grup"`
+	Target    int     `json:"target"`
+	Responden int     `json:"responden"`
+	Persen    float64 `json:"persen"`
+}
+
+// SurveyCohortCount jumlah alumni dan responden survey untuk satu kombinasi
+// tahun lulus dan jurusan (dasar perhitungan tingkat respons)
+type SurveyCohortCount struct {
+	TahunLulus int    `json:"tahun_lulus" bson:"tahun_lulus"`
+	KodeProdi  string `json:"kode_prodi" bson:"kode_prodi"`
+	Jurusan    string `json:"jurusan" bson:"jurusan"`
+	Jumlah     int    `json:"jumlah" bson:"jumlah"`
+	Responden  int    `json:"responden" bson:"responden"`
+}
+
+// SurveyOptionCount jumlah jawaban untuk satu pilihan atau nilai skala
+type SurveyOptionCount struct {
+	Jawaban string  `json:"jawaban"`
+	Jumlah  int     `json:"jumlah"`
+	Persen  float64 `json:"persen"`
+}
+
+// SurveyQuestionResult hasil agregat satu pertanyaan (tanpa identitas responden)
+type SurveyQuestionResult struct {
+	Kode          string              `json:"kode"`
+	Teks          string              `json:"teks"`
+	Tipe          string              `json:"tipe"`
+	JumlahJawaban int                 `json:"jumlah_jawaban"`
+	Distribusi    []SurveyOptionCount `json:"distribusi,omitempty"`
+	RataRata      *float64            `json:"rata_rata,omitempty"`
+	Median        *float64            `json:"median,omitempty"`
+	JawabanTeks   []string            `json:"jawaban_teks,omitempty"`
+}
+
+// IntList daftar bilangan bulat yang disimpan sebagai JSON di kolom text (PostgreSQL)
+type IntList []int
+
+func (l IntList) Value() (driver.Value, error) {
+	return jsonColumnValue([]int(l))
+}
+
+func (l *IntList) Scan(value interface{}) error {
+	return scanJSONColumn(value, (*[]int)(l))
+}
+
+// SurveyQuestions daftar pertanyaan, disimpan sebagai JSON di PostgreSQL
+type SurveyQuestions []SurveyQuestion
+
+func (q SurveyQuestions) Value() (driver.Value, error) {
+	return jsonColumnValue([]SurveyQuestion(q))
+}
+
+func (q *SurveyQuestions) Scan(value interface{}) error {
+	return scanJSONColumn(value, (*[]SurveyQuestion)(q))
+}
+
+// SurveyAnswers daftar jawaban, disimpan sebagai JSON di PostgreSQL
+type SurveyAnswers []SurveyAnswer
+
+func (a SurveyAnswers) Value() (driver.Value, error) {
+	return jsonColumnValue([]SurveyAnswer(a))
+}
+
+func (a *SurveyAnswers) Scan(value interface{}) error {
+	return scanJSONColumn(value, (*[]SurveyAnswer)(a))
+}
+
+// jsonColumnValue meng-encode slice ke JSON; slice nil disimpan sebagai "[]"
+func jsonColumnValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return "[]", nil
+	}
+	return string(data), nil
+}
+
+// scanJSONColumn men-decode kolom text berisi JSON ke dest
+func scanJSONColumn(value interface{}, dest interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, dest)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, dest)
+}
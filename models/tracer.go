@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// TracerFilter filter cohort untuk analitik tracer study
+type TracerFilter struct {
+	TahunLulus int    `json:"tahun_lulus,omitempty" query:"tahun_lulus"`
+	Angkatan   int    `json:"angkatan,omitempty" query:"angkatan"`
+	KodeProdi  string `json:"kode_prodi,omitempty" query:"kode_prodi"`
+}
+
+// TracerRecord satu alumni beserta pekerjaan pertamanya. TanggalMulaiKerja nil
+// jika alumni belum punya data pekerjaan.
+type TracerRecord struct {
+	AlumniID          uint       `json:"alumni_id" bson:"alumni_id"`
+	Jurusan           string     `json:"jurusan" bson:"jurusan"`
+	KodeProdi         string     `json:"kode_prodi" bson:"kode_prodi"`
+	Angkatan          int        `json:"angkatan" bson:"angkatan"`
+	TahunLulus        int        `json:"tahun_lulus" bson:"tahun_lulus"`
+	TanggalMulaiKerja *time.Time `json:"tanggal_mulai_kerja" bson:"tanggal_mulai_kerja"`
+	KodeIndustri      string     `json:"kode_industri" bson:"kode_industri"`
+}
+
+// TracerMetrics indikator tracer study untuk satu grup alumni. Masa tunggu
+// dalam satuan bulan sejak lulus sampai pekerjaan pertama.
+type TracerMetrics struct {
+	Kode           string `json:"kode"`
+	Grup           string `json:"grup"`
+	JumlahLulusan  int    `json:"jumlah_lulusan"`
+	JumlahTerlacak int    `json:"jumlah_terlacak"`
+
+	Bekerja6Bulan        int     `json:"bekerja_6_bulan"`
+	Bekerja12Bulan       int     `json:"bekerja_12_bulan"`
+	PersenBekerja6Bulan  float64 `json:"persen_bekerja_6_bulan"`
+	PersenBekerja12Bulan float64 `json:"persen_bekerja_12_bulan"`
+
+	MasaTungguRataRata float64 `json:"masa_tunggu_rata_rata"`
+	MasaTungguMedian   float64 `json:"masa_tunggu_median"`
+	// Kelompok masa tunggu sesuai tabel LKPS BAN-PT
+	MasaTungguKurang6   int `json:"masa_tunggu_kurang_6"`
+	MasaTunggu6Sampai18 int `json:"masa_tunggu_6_sampai_18"`
+	MasaTungguLebih18   int `json:"masa_tunggu_lebih_18"`
+
+	// Keselarasan hanya dihitung untuk alumni yang prodinya punya kode industri
+	// terkait dan pekerjaan pertamanya punya kode industri. SelarasSedang berarti
+	// industrinya hanya selaras dengan prodi lain di fakultas yang sama
+	JumlahKeselarasan int     `json:"jumlah_keselarasan"`
+	Selaras           int     `json:"selaras"`
+	SelarasSedang     int     `json:"selaras_sedang"`
+	TidakSelaras      int     `json:"tidak_selaras"`
+	PersenSelaras     float64 `json:"persen_selaras"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status sesi upload. Sesi lama tanpa status dianggap uploading.
+const (
+	UploadSessionUploading = "uploading"
+	UploadSessionFinishing = "finishing" // chunk sedang digabung menjadi File
+)
+
+// UploadSession upload bertahap (resumable). Setiap chunk disimpan sebagai object
+// terpisah di backend storage sampai Offset == FileSize, lalu digabung menjadi File.
+type UploadSession struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OwnerID     int                `json:"owner_id" bson:"owner_id"`
+	FileName    string             `json:"file_name" bson:"file_name"`
+	FileSize    int64              `json:"file_size" bson:"file_size"`
+	ContentType string             `json:"content_type,omitempty" bson:"content_type,omitempty"`
+	Checksum    string             `json:"checksum,omitempty" bson:"checksum,omitempty"` // SHA-256 hex seluruh file, opsional
+	Purpose     string             `json:"purpose" bson:"purpose"`
+	EntityType  string             `json:"entity_type,omitempty" bson:"entity_type,omitempty"`
+	EntityID    uint               `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Backend     string             `json:"-" bson:"backend"`
+	Offset      int64              `json:"offset" bson:"offset"`
+	Status      string             `json:"status" bson:"status"`
+	Chunks      []UploadChunk      `json:"-" bson:"chunks"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time          `json:"expires_at" bson:"expires_at"`
+}
+
+type UploadChunk struct {
+	Offset     int64  `bson:"offset"`
+	Size       int64  `bson:"size"`
+	StorageKey string `bson:"storage_key"`
+	SHA256     string `bson:"sha256"`
+}
+
+// CreateUploadSessionRequest body POST /api/files/uploads
+type CreateUploadSessionRequest struct {
+	FileName    string `json:"file_name" validate:"required"`
+	FileSize    int64  `json:"file_size" validate:"required,min=1"`
+	ContentType string `json:"content_type"`
+	Checksum    string `json:"checksum"`
+	Purpose     string `json:"purpose"`
+	EntityType  string `json:"entity_type"`
+	EntityID    uint   `json:"entity_id"`
+}
+
+type UploadSessionResponse struct {
+	ID           string    `json:"id"`
+	FileName     string    `json:"file_name"`
+	FileSize     int64     `json:"file_size"`
+	Offset       int64     `json:"offset"`
+	Status       string    `json:"status"`
+	Purpose      string    `json:"purpose"`
+	EntityType   string    `json:"entity_type,omitempty"`
+	EntityID     uint      `json:"entity_id,omitempty"`
+	MaxChunkSize int64     `json:"max_chunk_size"`
+	UploadURL    string    `json:"upload_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -16,14 +16,33 @@ type User struct {
 	IsActive  bool      `gorm:"default:true" json:"-"` // Hide in JSON
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"-"` // Hide in JSON
+	Version   int       `gorm:"not null;default:1" json:"version"`
+}
+
+// SearchFields field akun yang dinilai pada pencarian global (khusus admin)
+func (u *User) SearchFields() []SearchField {
+	return []SearchField{
+		{Name: "username", Value: u.Username, Weight: SearchWeightA},
+		{Name: "email", Value: u.Email, Weight: SearchWeightB},
+	}
 }
 
 // Request struct untuk registrasi
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Email    string `json:"email" validate:"required,email"`
+	Email    string `json:"email" validate:"required,email,max=100"`
 	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role,omitempty"` // Optional, default to 'user'
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=admin user"` // Optional, default to 'user'
+}
+
+// Request struct untuk update user oleh admin (PUT/PATCH). Password kosong
+// berarti password lama tetap dipakai.
+type UpdateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"omitempty,min=6"`
+	Role     string `json:"role" validate:"required,oneof=admin user"`
+	IsActive *bool  `json:"is_active" validate:"required"`
 }
 
 // Request struct untuk login
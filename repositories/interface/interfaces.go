@@ -1,6 +1,9 @@
 package repositories
 
-import "modul4crud/models"
+import (
+	"modul4crud/models"
+	"time"
+)
 
 // UserRepository interface untuk operasi user
 type UserRepository interface {
@@ -11,7 +14,9 @@ type UserRepository interface {
 	GetByUsername(username string) (*models.User, error)
 	Create(user *models.User) error
 	Update(user *models.User) error
-	Delete(id int) error
+	// Delete dengan version > 0 hanya menghapus jika versi record masih sama
+	// (PRECONDITION_FAILED jika sudah berubah); 0 berarti tanpa syarat
+	Delete(id int, version int) error
 	Count() (int64, error)
 	// AuthenticateWithPassword verifies credentials (PocketBase specific)
 	// For PostgreSQL/MongoDB, this returns error since they use bcrypt
@@ -25,7 +30,10 @@ type MahasiswaRepository interface {
 	GetByID(id uint) (*models.Mahasiswa, error)
 	Create(mahasiswa *models.Mahasiswa) error
 	Update(mahasiswa *models.Mahasiswa) error
-	Delete(id uint) error
+	// Delete dengan version > 0 hanya menghapus jika versi record masih sama
+	Delete(id uint, version int) error
+	// Merge soft delete mahasiswa sumber dan mencatat targetID di merged_into
+	Merge(targetID uint, sourceIDs []uint) error
 	Count() (int64, error)
 }
 
@@ -37,8 +45,21 @@ type AlumniRepository interface {
 	GetByUserID(userID int) (*models.Alumni, error)
 	Create(alumni *models.Alumni) error
 	Update(alumni *models.Alumni) error
-	Delete(id uint) error
+	// Delete dengan version > 0 hanya menghapus jika versi record masih sama
+	Delete(id uint, version int) error
+	// Merge memindahkan pekerjaan alumni sumber ke alumni tujuan, menautkan userID
+	// ke alumni tujuan, lalu soft delete alumni sumber (merged_into = targetID)
+	Merge(targetID uint, sourceIDs []uint, userID int) error
 	Count() (int64, error)
+	// CountByGroup menghitung alumni per grup (tahun_lulus atau kode_prodi) dengan
+	// filter pencarian yang sama seperti GetWithPagination, urut jumlah terbanyak
+	CountByGroup(groupBy string, search string) ([]models.StatGroup, error)
+	// GetTracerRecords mengambil alumni sesuai filter beserta pekerjaan pertamanya
+	// (pekerjaan aktif dengan tanggal mulai kerja paling awal)
+	GetTracerRecords(filter models.TracerFilter) ([]models.TracerRecord, error)
+	// CountSurveyCohorts menghitung alumni dan yang sudah mengisi survey per
+	// tahun lulus dan jurusan; tahunLulus kosong berarti semua tahun
+	CountSurveyCohorts(surveyID uint, tahunLulus []int) ([]models.SurveyCohortCount, error)
 }
 
 // PekerjaanAlumniRepository interface untuk operasi pekerjaan alumni
@@ -47,22 +68,158 @@ type PekerjaanAlumniRepository interface {
 	GetWithPagination(pagination *models.PaginationRequest) ([]models.PekerjaanAlumni, int64, error)
 	GetByID(id uint) (*models.PekerjaanAlumni, error)
 	GetByAlumniID(alumniID uint) ([]models.PekerjaanAlumni, error)
+	// GetAllByAlumniIDs / GetAllByPerusahaanIDs mengembalikan pekerjaan milik
+	// alumni/perusahaan tersebut termasuk yang di-soft delete (yang ikut dipindahkan
+	// oleh Merge), urut ID
+	GetAllByAlumniIDs(alumniIDs []uint) ([]models.PekerjaanAlumni, error)
+	GetAllByPerusahaanIDs(perusahaanIDs []uint) ([]models.PekerjaanAlumni, error)
 	GetByUserID(userID int) ([]models.PekerjaanAlumni, error)
 	Create(pekerjaan *models.PekerjaanAlumni) error
 	Update(pekerjaan *models.PekerjaanAlumni) error
-	Delete(id uint) error
-	SoftDelete(id uint) error
+	// Delete dan SoftDelete dengan version > 0 hanya berjalan jika versi record masih sama
+	Delete(id uint, version int) error
+	SoftDelete(id uint, version int) error
 	SoftDeleteByAlumniID(alumniID uint) error
 	Restore(id uint) error
 	GetDeleted() ([]models.PekerjaanAlumni, error)
 	GetDeletedByUserID(userID int) ([]models.PekerjaanAlumni, error)
 	Count() (int64, error)
-	GetAlumniCountByCompany(namaPerusahaan string) (int64, error)
+	// GetSalarySamples mengembalikan data gaji per grup (jurusan, tahun_lulus,
+	// bidang_industri, lokasi_kerja, atau "" untuk semua) dalam mata uang tertentu
+	GetSalarySamples(groupBy string, mataUang string) ([]models.SalarySample, error)
+	// CountByGroup menghitung pekerjaan aktif per grup (kode_industri, kode_kota,
+	// atau kode_provinsi) dengan filter pencarian yang sama seperti GetWithPagination.
+	// Grup kode_kota memakai kode provinsi jika kota tidak diketahui.
+	CountByGroup(groupBy string, search string) ([]models.StatGroup, error)
+}
+
+// PerusahaanRepository interface untuk operasi perusahaan
+type PerusahaanRepository interface {
+	GetWithPagination(pagination *models.PaginationRequest) ([]models.Perusahaan, int64, error)
+	GetByID(id uint) (*models.Perusahaan, error)
+	// FindByNamaNormal mencari perusahaan berdasarkan nama atau alias yang sudah
+	// dinormalisasi. Mengembalikan nil jika tidak ditemukan.
+	FindByNamaNormal(namaNormal string) (*models.Perusahaan, error)
+	Create(perusahaan *models.Perusahaan) error
+	Update(perusahaan *models.Perusahaan) error
+	Delete(id uint) error
+	// Merge memindahkan pekerjaan dan alias dari perusahaan sumber ke perusahaan
+	// tujuan, lalu menghapus perusahaan sumber
+	Merge(targetID uint, sourceIDs []uint) error
+	CountAlumni(id uint) (int64, error)
+	CountPekerjaan(id uint) (int64, error)
+}
+
+// ReferensiRepository interface untuk operasi data referensi (controlled vocabulary)
+type ReferensiRepository interface {
+	GetByKategori(kategori string) ([]models.Referensi, error)
+	// GetByKode mengembalikan nil jika kode tidak ditemukan
+	GetByKode(kategori string, kode string) (*models.Referensi, error)
+	Create(referensi *models.Referensi) error
+	Update(referensi *models.Referensi) error
+	Delete(id uint) error
+	// CountUsage menghitung data alumni/pekerjaan yang memakai kode referensi,
+	// termasuk pekerjaan di trash dan alumni hasil merge, supaya restore tidak
+	// menghidupkan data dengan kode yang sudah dihapus
+	CountUsage(kategori string, kode string) (int64, error)
+}
+
+// SurveyRepository interface untuk operasi survey tracer study dan jawabannya
+type SurveyRepository interface {
+	// GetAll mengembalikan semua survey, atau hanya yang berstatus tertentu jika status diisi
+	GetAll(status string) ([]models.Survey, error)
+	GetByID(id uint) (*models.Survey, error)
+	// GetLatestVersi mengembalikan versi tertinggi sebuah kode survey (0 jika belum ada)
+	GetLatestVersi(kode string) (int, error)
+	Create(survey *models.Survey) error
+	Update(survey *models.Survey) error
+	Delete(id uint) error
+	GetResponses(surveyID uint) ([]models.SurveyResponse, error)
+	// GetResponse mengembalikan nil jika alumni belum mengisi survey
+	GetResponse(surveyID uint, alumniID uint) (*models.SurveyResponse, error)
+	// SaveResponse menyimpan jawaban baru atau mengganti jawaban alumni yang sudah ada
+	SaveResponse(response *models.SurveyResponse) error
+	CountResponses(surveyID uint) (int64, error)
+}
+
+// HistoryRepository interface untuk riwayat versi record (mahasiswa, alumni, pekerjaan)
+type HistoryRepository interface {
+	Create(history *models.RecordHistory) error
+	// GetByRecord mengembalikan riwayat sebuah record, urut versi terbaru dulu
+	GetByRecord(entity string, recordID uint) ([]models.RecordHistory, error)
+	// GetVersion mengembalikan nil jika versi tersebut tidak ada di riwayat
+	GetVersion(entity string, recordID uint, version int) (*models.RecordHistory, error)
 }
 
 type FileRepository interface {
 	Create(file *models.File) error
 	FindAll() ([]models.File, error)
+	// FindWithPagination daftar file sesuai filter; search mencari di nama file asli
+	FindWithPagination(pagination *models.PaginationRequest, filter models.FileFilter) ([]models.File, int64, error)
 	FindByID(id string) (*models.File, error)
+	FindByOwner(ownerID int) ([]models.File, error)
+	FindByEntity(entityType string, entityID uint) ([]models.File, error)
+	Update(file *models.File) error
 	Delete(id string) error
+	FindByScanStatus(status string) ([]models.File, error)
+	// UpdateScanResult menyimpan hasil pindai ke semua file dengan isi (blob) yang sama
+	UpdateScanResult(backend, sha256 string, result models.FileScanResult) error
+	// UsageByOwner total ukuran dan jumlah file milik user per purpose
+	UsageByOwner(ownerID int) ([]models.StorageUsageGroup, error)
+}
+
+// FileBlobRepository reference count isi file per hash. Acquire dan Release
+// atomik, sehingga upload dan hapus yang bersamaan tidak menghapus isi yang masih dipakai:
+// blob yang RefCount-nya 0 ditandai DeletingAt, isinya dihapus, lalu record-nya
+// di-Purge. Selama itu Acquire untuk isi yang sama ditolak dengan CONFLICT.
+type FileBlobRepository interface {
+	// Acquire menambah RefCount, membuat blob baru (Stored false) jika belum ada
+	Acquire(blob *models.FileBlob) (*models.FileBlob, error)
+	// MarkStored menandai isi dan variant-nya sudah tersimpan di storage
+	MarkStored(id string, variants map[string]models.FileVariant) error
+	// Release mengurangi RefCount; removed true jika blob tidak dipakai lagi dan
+	// sudah ditandai DeletingAt, pemanggil menghapus isinya lalu memanggil Purge
+	Release(id string) (blob *models.FileBlob, removed bool, err error)
+	// Purge menghapus record blob yang ditandai DeletingAt oleh Release
+	Purge(id string) error
+	FindByID(id string) (*models.FileBlob, error)
+	UpdateScanResult(id string, result models.FileScanResult) error
+}
+
+// UploadSessionRepository sesi upload bertahap. AppendChunk hanya berhasil jika
+// offset sesi masih sama dengan expectedOffset, sehingga dua chunk yang dikirim
+// bersamaan tidak saling menimpa. BeginFinish juga atomik: hanya satu request
+// yang bisa mengubah sesi lengkap menjadi finishing, yang lain mendapat Conflict.
+type UploadSessionRepository interface {
+	Create(session *models.UploadSession) error
+	FindByID(id string) (*models.UploadSession, error)
+	AppendChunk(id string, expectedOffset int64, chunk models.UploadChunk, expiresAt time.Time) (*models.UploadSession, error)
+	BeginFinish(id string, expiresAt time.Time) (*models.UploadSession, error)
+	// ResetFinish mengembalikan sesi finishing ke uploading setelah penyelesaian
+	// gagal, supaya bisa diulang
+	ResetFinish(id string) error
+	FindExpired(before time.Time) ([]models.UploadSession, error)
+	Delete(id string) error
+}
+
+// StorageQuotaRepository batas penyimpanan khusus per user. FindByOwner
+// mengembalikan NotFound jika user memakai batas bawaan role-nya.
+type StorageQuotaRepository interface {
+	FindByOwner(ownerID int) (*models.StorageQuota, error)
+	Upsert(quota *models.StorageQuota) error
+	Delete(ownerID int) error
+}
+
+// FileLinkRepository link unduhan bertanda tangan. MarkUsed atomik, sehingga
+// link sekali pakai tidak bisa dipakai oleh dua request yang bersamaan.
+type FileLinkRepository interface {
+	Create(link *models.FileLink) error
+	FindByID(id string) (*models.FileLink, error)
+	// FindOutstanding link yang belum dicabut, belum kedaluwarsa dan belum habis dipakai
+	FindOutstanding(now time.Time) ([]models.FileLink, error)
+	// MarkUsed mencatat satu pemakaian; Gone jika link sudah tidak berlaku
+	MarkUsed(id string, now time.Time) (*models.FileLink, error)
+	Revoke(id string, revokedBy int) (*models.FileLink, error)
+	// DeleteByFile menghapus semua link ke file yang dihapus
+	DeleteByFile(fileID string) error
 }
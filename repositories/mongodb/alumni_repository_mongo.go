@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,24 +13,32 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const alumniNotFound = "Alumni tidak ditemukan"
+
 type alumniRepositoryMongo struct {
-	collection     *mongo.Collection
-	userCollection *mongo.Collection
+	collection          *mongo.Collection
+	userCollection      *mongo.Collection
+	pekerjaanCollection *mongo.Collection
 }
 
 func NewAlumniRepositoryMongo(db *mongo.Database) repo.AlumniRepository {
 	return &alumniRepositoryMongo{
-		collection:     db.Collection("alumnis"),
-		userCollection: db.Collection("users"),
+		collection:          db.Collection("alumnis"),
+		userCollection:      db.Collection("users"),
+		pekerjaanCollection: db.Collection("pekerjaan_alumnis"),
 	}
 }
 
+// notMerged filter deleted_at untuk alumni/mahasiswa yang belum digabung ke record lain
+var notMerged = bson.M{"$eq": nil}
+
 func (r *alumniRepositoryMongo) GetAll() ([]models.Alumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Use aggregation pipeline to join with users collection
 	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": notMerged}}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "users"},
 			{Key: "localField", Value: "user_id"},
@@ -62,29 +71,18 @@ func (r *alumniRepositoryMongo) GetWithPagination(pagination *models.PaginationR
 	defer cancel()
 
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
-
-	// Build search filter
-	matchStage := bson.D{}
-	if pagination.Search != "" {
-		matchStage = bson.D{
-			{Key: "$match", Value: bson.M{
-				"$or": []bson.M{
-					{"nim": bson.M{"$regex": pagination.Search, "$options": "i"}},
-					{"nama": bson.M{"$regex": pagination.Search, "$options": "i"}},
-					{"jurusan": bson.M{"$regex": pagination.Search, "$options": "i"}},
-				},
-			}},
-		}
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Build search filter - $match dengan $text harus menjadi stage pertama
+	terms := models.SearchTerms(pagination.Search)
+	filter, text, err := alumniSearchFilter(ctx, r.collection, pagination.Search)
+	if err != nil {
+		return nil, 0, err
 	}
+	matchStage := bson.D{{Key: "$match", Value: filter}}
 
 	// Count pipeline
-	countPipeline := mongo.Pipeline{}
-	if len(matchStage) > 0 {
-		countPipeline = append(countPipeline, matchStage)
-	}
-	countPipeline = append(countPipeline, bson.D{{Key: "$count", Value: "total"}})
+	countPipeline := mongo.Pipeline{matchStage, bson.D{{Key: "$count", Value: "total"}}}
 
 	// Get total count
 	var total int64
@@ -111,9 +109,12 @@ func (r *alumniRepositoryMongo) GetWithPagination(pagination *models.PaginationR
 	}
 
 	// Data pipeline with lookup
-	dataPipeline := mongo.Pipeline{}
-	if len(matchStage) > 0 {
-		dataPipeline = append(dataPipeline, matchStage)
+	dataPipeline := mongo.Pipeline{matchStage,
+		bson.D{{Key: "$addFields", Value: bson.M{"score": scoreExpr(text)}}},
+	}
+	sortStage := bson.D{{Key: pagination.SortBy, Value: sortOrder}}
+	if byRelevance {
+		sortStage = bson.D{{Key: "score", Value: -1}, {Key: "id", Value: 1}}
 	}
 	dataPipeline = append(dataPipeline,
 		bson.D{{Key: "$lookup", Value: bson.D{
@@ -126,7 +127,7 @@ func (r *alumniRepositoryMongo) GetWithPagination(pagination *models.PaginationR
 			{Key: "path", Value: "$user"},
 			{Key: "preserveNullAndEmptyArrays", Value: true},
 		}}},
-		bson.D{{Key: "$sort", Value: bson.D{{Key: pagination.SortBy, Value: sortOrder}}}},
+		bson.D{{Key: "$sort", Value: sortStage}},
 		bson.D{{Key: "$skip", Value: pagination.GetOffset()}},
 		bson.D{{Key: "$limit", Value: pagination.Limit}},
 	)
@@ -137,10 +138,15 @@ func (r *alumniRepositoryMongo) GetWithPagination(pagination *models.PaginationR
 	}
 	defer cursor.Close(ctx)
 
-	var alumnis []models.Alumni
-	if err = cursor.All(ctx, &alumnis); err != nil {
+	alumnis, scores, err := decodeSearchResults[models.Alumni](ctx, cursor)
+	if err != nil {
 		return nil, 0, err
 	}
+	if len(terms) > 0 {
+		for i := range alumnis {
+			alumnis[i].Search = searchMatch(terms, alumnis[i].SearchFields(), scores[i], text)
+		}
+	}
 
 	return alumnis, total, nil
 }
@@ -150,7 +156,7 @@ func (r *alumniRepositoryMongo) GetByID(id uint) (*models.Alumni, error) {
 	defer cancel()
 
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"id": id}}},
+		{{Key: "$match", Value: bson.M{"id": id, "deleted_at": notMerged}}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "users"},
 			{Key: "localField", Value: "user_id"},
@@ -175,7 +181,7 @@ func (r *alumniRepositoryMongo) GetByID(id uint) (*models.Alumni, error) {
 	}
 
 	if len(alumnis) == 0 {
-		return nil, fmt.Errorf("alumni not found")
+		return nil, utils.NotFound(alumniNotFound)
 	}
 
 	return &alumnis[0], nil
@@ -186,7 +192,7 @@ func (r *alumniRepositoryMongo) GetByUserID(userID int) (*models.Alumni, error)
 	defer cancel()
 
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": notMerged}}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "users"},
 			{Key: "localField", Value: "user_id"},
@@ -211,7 +217,7 @@ func (r *alumniRepositoryMongo) GetByUserID(userID int) (*models.Alumni, error)
 	}
 
 	if len(alumnis) == 0 {
-		return nil, fmt.Errorf("alumni not found")
+		return nil, utils.NotFound(alumniNotFound)
 	}
 
 	return &alumnis[0], nil
@@ -225,6 +231,7 @@ func (r *alumniRepositoryMongo) Create(alumni *models.Alumni) error {
 	now := time.Now()
 	alumni.CreatedAt = now
 	alumni.UpdatedAt = now
+	alumni.Version = 1
 
 	// Get next ID
 	nextID, err := r.getNextSequenceID()
@@ -234,7 +241,7 @@ func (r *alumniRepositoryMongo) Create(alumni *models.Alumni) error {
 	alumni.ID = nextID
 
 	_, err = r.collection.InsertOne(ctx, alumni)
-	return err
+	return translateError(err, alumniNotFound)
 }
 
 func (r *alumniRepositoryMongo) Update(alumni *models.Alumni) error {
@@ -243,46 +250,80 @@ func (r *alumniRepositoryMongo) Update(alumni *models.Alumni) error {
 
 	alumni.UpdatedAt = time.Now()
 
-	filter := bson.M{"id": alumni.ID}
+	filter := bson.M{"id": alumni.ID, "version": alumni.Version, "deleted_at": notMerged}
 	update := bson.M{
 		"$set": bson.M{
 			"nim":         alumni.NIM,
 			"nama":        alumni.Nama,
 			"jurusan":     alumni.Jurusan,
+			"kode_prodi":  alumni.KodeProdi,
 			"angkatan":    alumni.Angkatan,
 			"tahun_lulus": alumni.TahunLulus,
 			"no_telepon":  alumni.NoTelepon,
 			"alamat":      alumni.Alamat,
 			"updated_at":  alumni.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, alumniNotFound)
 	}
-
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("alumni not found")
+	if err := matchedOrStale(ctx, r.collection, result, alumni.ID, alumniNotFound); err != nil {
+		return err
 	}
+	alumni.Version++
 
 	return nil
 }
 
-func (r *alumniRepositoryMongo) Delete(id uint) error {
+func (r *alumniRepositoryMongo) Delete(id uint, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"id": id}
+	filter := withVersion(bson.M{"id": id}, version)
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("alumni not found")
+	return deletedOrStale(ctx, r.collection, result, id, alumniNotFound)
+}
+
+// Merge memindahkan pekerjaan alumni sumber ke alumni tujuan, menautkan userID ke
+// alumni tujuan, lalu menandai alumni sumber deleted_at dan merged_into
+func (r *alumniRepositoryMongo) Merge(targetID uint, sourceIDs []uint, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.pekerjaanCollection.UpdateMany(ctx,
+		bson.M{"alumni_id": bson.M{"$in": sourceIDs}},
+		bson.M{"$set": bson.M{"alumni_id": targetID}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
 	}
 
+	now := time.Now()
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"id": bson.M{"$in": sourceIDs}, "deleted_at": notMerged},
+		bson.M{"$set": bson.M{"deleted_at": now, "merged_into": targetID, "updated_at": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"id": targetID, "deleted_at": notMerged},
+		bson.M{"$set": bson.M{"user_id": userID, "updated_at": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return utils.NotFound(alumniNotFound)
+	}
 	return nil
 }
 
@@ -290,7 +331,155 @@ func (r *alumniRepositoryMongo) Count() (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return r.collection.CountDocuments(ctx, bson.M{})
+	return r.collection.CountDocuments(ctx, bson.M{"deleted_at": notMerged})
+}
+
+// CountByGroup menghitung alumni per tahun lulus atau kode prodi dengan $group
+func (r *alumniRepositoryMongo) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	groupExprs := map[string][2]interface{}{
+		"tahun_lulus": {bson.M{"$toString": bson.M{"$ifNull": bson.A{"$tahun_lulus", ""}}}, ""},
+		"kode_prodi":  {bson.M{"$ifNull": bson.A{"$kode_prodi", ""}}, bson.M{"$ifNull": bson.A{"$jurusan", ""}}},
+	}
+	exprs, ok := groupExprs[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik alumni tidak valid: %s", groupBy)
+	}
+
+	filter, _, err := alumniSearchFilter(ctx, r.collection, search)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateStatGroups(ctx, r.collection, filter, exprs[0], exprs[1])
+}
+
+// GetTracerRecords mengambil alumni beserta pekerjaan pertamanya dengan $lookup
+func (r *alumniRepositoryMongo) GetTracerRecords(filter models.TracerFilter) ([]models.TracerRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{"deleted_at": notMerged}
+	if filter.TahunLulus > 0 {
+		match["tahun_lulus"] = filter.TahunLulus
+	}
+	if filter.Angkatan > 0 {
+		match["angkatan"] = filter.Angkatan
+	}
+	if filter.KodeProdi != "" {
+		match["kode_prodi"] = filter.KodeProdi
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "pekerjaan_alumnis"},
+			{Key: "let", Value: bson.M{"alumni_id": "$id"}},
+			{Key: "pipeline", Value: bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":      bson.M{"$eq": bson.A{"$alumni_id", "$$alumni_id"}},
+					"deleted_at": bson.M{"$eq": nil},
+				}},
+				bson.M{"$sort": bson.D{{Key: "tanggal_mulai_kerja", Value: 1}, {Key: "id", Value: 1}}},
+				bson.M{"$limit": 1},
+			}},
+			{Key: "as", Value: "pekerjaan_pertama"},
+		}}},
+		{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$pekerjaan_pertama"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":                 0,
+			"alumni_id":           "$id",
+			"jurusan":             1,
+			"kode_prodi":          bson.M{"$ifNull": bson.A{"$kode_prodi", ""}},
+			"angkatan":            1,
+			"tahun_lulus":         1,
+			"tanggal_mulai_kerja": "$pekerjaan_pertama.tanggal_mulai_kerja",
+			"kode_industri":       bson.M{"$ifNull": bson.A{"$pekerjaan_pertama.kode_industri", ""}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "alumni_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []models.TracerRecord{}
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// CountSurveyCohorts menghitung alumni dan responden survey per cohort dengan $lookup dan $group
+func (r *alumniRepositoryMongo) CountSurveyCohorts(surveyID uint, tahunLulus []int) ([]models.SurveyCohortCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{"deleted_at": notMerged}
+	if len(tahunLulus) > 0 {
+		match["tahun_lulus"] = bson.M{"$in": tahunLulus}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "survey_responses"},
+			{Key: "let", Value: bson.M{"alumni_id": "$id"}},
+			{Key: "pipeline", Value: bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":     bson.M{"$eq": bson.A{"$alumni_id", "$$alumni_id"}},
+					"survey_id": surveyID,
+				}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 1}},
+			}},
+			{Key: "as", Value: "jawaban"},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"tahun_lulus": "$tahun_lulus",
+				"kode_prodi":  bson.M{"$ifNull": bson.A{"$kode_prodi", ""}},
+				"jurusan":     "$jurusan",
+			},
+			"jumlah":    bson.M{"$sum": 1},
+			"responden": bson.M{"$sum": bson.M{"$size": "$jawaban"}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         0,
+			"tahun_lulus": "$_id.tahun_lulus",
+			"kode_prodi":  "$_id.kode_prodi",
+			"jurusan":     "$_id.jurusan",
+			"jumlah":      1,
+			"responden":   1,
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []models.SurveyCohortCount{}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// alumniSearchFilter filter pencarian alumni (text index idx_alumnis_search, atau
+// fallback prefix), dipakai bersama oleh list dan statistik. Alumni yang sudah
+// digabung tidak ikut.
+func alumniSearchFilter(ctx context.Context, collection *mongo.Collection, search string) (bson.M, bool, error) {
+	return searchFilter(ctx, collection, bson.M{"deleted_at": notMerged}, models.SearchTerms(search), alumniSearchFields)
 }
 
 // Helper function to get next sequence ID
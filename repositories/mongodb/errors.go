@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"modul4crud/utils"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Pesan duplicate key MongoDB berisi: ... dup key: { nim: "123" }
+var mongoDuplicateKeyPattern = regexp.MustCompile(`dup key: \{ "?([A-Za-z0-9_]+)"?:`)
+
+// translateError mengubah error MongoDB menjadi error domain: duplicate key
+// menjadi CONFLICT, dokumen tidak ditemukan menjadi NOT_FOUND
+func translateError(err error, notFoundMessage string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return utils.NotFound(notFoundMessage)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		field := ""
+		if match := mongoDuplicateKeyPattern.FindStringSubmatch(err.Error()); match != nil {
+			field = match[1]
+		}
+		appErr := utils.DuplicateError(field)
+		appErr.Err = err
+		return appErr
+	}
+	return err
+}
+
+// matchedOrStale untuk UpdateOne dengan filter id + version. Jika tidak ada
+// dokumen yang cocok, dicek apakah dokumennya memang tidak ada (NOT_FOUND) atau
+// versinya sudah berubah sejak dibaca (PRECONDITION_FAILED).
+func matchedOrStale(ctx context.Context, collection *mongo.Collection, result *mongo.UpdateResult, id interface{}, notFoundMessage string) error {
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	return missingOrStale(ctx, collection, id, notFoundMessage)
+}
+
+// deletedOrStale seperti matchedOrStale untuk DeleteOne dengan filter id + version
+func deletedOrStale(ctx context.Context, collection *mongo.Collection, result *mongo.DeleteResult, id interface{}, notFoundMessage string) error {
+	if result.DeletedCount > 0 {
+		return nil
+	}
+	return missingOrStale(ctx, collection, id, notFoundMessage)
+}
+
+func missingOrStale(ctx context.Context, collection *mongo.Collection, id interface{}, notFoundMessage string) error {
+	count, err := collection.CountDocuments(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return utils.NotFound(notFoundMessage)
+	}
+	return utils.VersionMismatch()
+}
+
+// withVersion menambahkan syarat versi ke filter; version 0 berarti tanpa syarat
+func withVersion(filter bson.M, version int) bson.M {
+	if version > 0 {
+		filter["version"] = version
+	}
+	return filter
+}
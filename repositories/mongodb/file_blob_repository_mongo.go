@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const fileBlobNotFound = "Blob file tidak ditemukan"
+
+// blobDeletingTimeout batas umur tanda DeletingAt; tanda yang lebih lama dianggap
+// tertinggal (proses mati di tengah penghapusan) dan diambil alih oleh Acquire
+const blobDeletingTimeout = 10 * time.Minute
+
+type fileBlobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFileBlobRepository(db *mongo.Database) repo.FileBlobRepository {
+	return &fileBlobRepository{
+		collection: db.Collection("file_blobs"),
+	}
+}
+
+// Acquire upsert dengan $inc, sehingga dua upload isi yang sama bersamaan
+// tetap menghasilkan satu blob dengan RefCount 2. Blob yang sedang dihapus tidak
+// cocok dengan filter, upsert-nya bentrok di _id dan Acquire mengembalikan CONFLICT.
+func (r *fileBlobRepository) Acquire(blob *models.FileBlob) (*models.FileBlob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Tanda hapus yang tertinggal diambil alih; isinya mungkin sudah terhapus
+	// sebagian, jadi ditulis ulang oleh upload ini
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": blob.ID, "deleting_at": bson.M{"$lt": time.Now().Add(-blobDeletingTimeout)}},
+		bson.M{"$set": bson.M{"stored": false}, "$unset": bson.M{"deleting_at": ""}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"ref_count": 1},
+		"$setOnInsert": bson.M{
+			"backend":     blob.Backend,
+			"storage_key": blob.StorageKey,
+			"sha256":      blob.SHA256,
+			"file_size":   blob.FileSize,
+			"file_type":   blob.FileType,
+			"stored":      false,
+			"created_at":  time.Now(),
+		},
+	}
+	var acquired models.FileBlob
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": blob.ID, "deleting_at": bson.M{"$exists": false}}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&acquired)
+	if mongo.IsDuplicateKeyError(err) {
+		appErr := utils.Conflict("Isi file yang sama sedang dihapus, coba lagi")
+		appErr.Err = err
+		return nil, appErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &acquired, nil
+}
+
+func (r *fileBlobRepository) MarkStored(id string, variants map[string]models.FileVariant) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"stored": true}
+	if len(variants) > 0 {
+		set["variants"] = variants
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// Release menandai blob DeletingAt hanya jika RefCount masih 0 saat ditandai;
+// Acquire yang masuk di antaranya membuat blob tetap dipakai
+func (r *fileBlobRepository) Release(id string) (*models.FileBlob, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var blob models.FileBlob
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"ref_count": -1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&blob)
+	if err != nil {
+		return nil, false, translateError(err, fileBlobNotFound)
+	}
+	if blob.RefCount > 0 {
+		return &blob, false, nil
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "ref_count": bson.M{"$lte": 0}, "deleting_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleting_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	return &blob, result.ModifiedCount == 1, nil
+}
+
+func (r *fileBlobRepository) Purge(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "ref_count": bson.M{"$lte": 0}, "deleting_at": bson.M{"$exists": true}})
+	return err
+}
+
+func (r *fileBlobRepository) FindByID(id string) (*models.FileBlob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var blob models.FileBlob
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&blob); err != nil {
+		return nil, translateError(err, fileBlobNotFound)
+	}
+	return &blob, nil
+}
+
+func (r *fileBlobRepository) UpdateScanResult(id string, result models.FileScanResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updated, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, scanResultUpdate(result))
+	if err != nil {
+		return err
+	}
+	if updated.MatchedCount == 0 {
+		return utils.NotFound(fileBlobNotFound)
+	}
+	return nil
+}
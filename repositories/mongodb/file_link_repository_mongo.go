@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const fileLinkNotFound = "Link unduhan tidak ditemukan"
+
+type fileLinkRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFileLinkRepository(db *mongo.Database) repo.FileLinkRepository {
+	return &fileLinkRepository{
+		collection: db.Collection("file_links"),
+	}
+}
+
+func (r *fileLinkRepository) Create(link *models.FileLink) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	link.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	link.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *fileLinkRepository) FindByID(id string) (*models.FileLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(fileLinkNotFound)
+	}
+
+	var link models.FileLink
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&link)
+	if err != nil {
+		return nil, translateError(err, fileLinkNotFound)
+	}
+
+	return &link, nil
+}
+
+func (r *fileLinkRepository) FindOutstanding(now time.Time) ([]models.FileLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	links := []models.FileLink{}
+	cursor, err := r.collection.Find(ctx, usableLinkFilter(now), options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// usableLinkFilter link yang masih bisa dipakai pada waktu now
+func usableLinkFilter(now time.Time) bson.M {
+	return bson.M{
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+		"$or": bson.A{
+			bson.M{"single_use": false},
+			bson.M{"use_count": 0},
+		},
+	}
+}
+
+// MarkUsed syarat berlaku dan penambahan use_count dalam satu update, sehingga
+// link sekali pakai hanya lolos untuk satu request
+func (r *fileLinkRepository) MarkUsed(id string, now time.Time) (*models.FileLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(fileLinkNotFound)
+	}
+
+	filter := usableLinkFilter(now)
+	filter["_id"] = objectID
+	update := bson.M{
+		"$inc": bson.M{"use_count": 1},
+		"$set": bson.M{"last_used_at": now},
+	}
+	var link models.FileLink
+	err = r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, findErr := r.FindByID(id); findErr != nil {
+			return nil, findErr
+		}
+		return nil, utils.Gone("Link unduhan sudah tidak berlaku")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *fileLinkRepository) Revoke(id string, revokedBy int) (*models.FileLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(fileLinkNotFound)
+	}
+
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_by": revokedBy}}
+	var link models.FileLink
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objectID, "revoked_at": bson.M{"$exists": false}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, findErr := r.FindByID(id); findErr != nil {
+			return nil, findErr
+		}
+		return nil, utils.Conflict("Link unduhan sudah dicabut")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *fileLinkRepository) DeleteByFile(fileID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"file_id": fileID})
+	return err
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const fileNotFound = "File tidak ditemukan"
+
 type fileRepository struct {
 	collection *mongo.Collection
 }
@@ -36,11 +43,108 @@ func (r *fileRepository) Create(file *models.File) error {
 }
 
 func (r *fileRepository) FindAll() ([]models.File, error) {
+	return r.find(bson.M{})
+}
+
+// FindWithPagination default urut uploaded_at terbaru; sort_by selain
+// models.FileSortFields diabaikan
+func (r *fileRepository) FindWithPagination(pagination *models.PaginationRequest, filter models.FileFilter) ([]models.File, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !slices.Contains(models.FileSortFields, pagination.SortBy) {
+		pagination.SortBy = "uploaded_at"
+		if pagination.SortOrder == "" {
+			pagination.SortOrder = "DESC"
+		}
+	}
+	pagination.SetDefaults()
+	pagination.ValidateSortOrder()
+
+	query := fileFilterQuery(filter)
+	if search := strings.TrimSpace(pagination.Search); search != "" {
+		query["original_name"] = bson.M{"$regex": regexp.QuoteMeta(search), "$options": "i"}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortOrder := 1
+	if pagination.SortOrder == "DESC" {
+		sortOrder = -1
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: pagination.SortBy, Value: sortOrder}, {Key: "_id", Value: sortOrder}}).
+		SetSkip(int64(pagination.GetOffset())).
+		SetLimit(int64(pagination.Limit))
+
+	files := []models.File{}
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &files); err != nil {
+		return nil, 0, err
+	}
+
+	return files, total, nil
+}
+
+func fileFilterQuery(filter models.FileFilter) bson.M {
+	query := bson.M{}
+	if filter.OwnerID > 0 {
+		query["owner_id"] = filter.OwnerID
+	}
+	if filter.FileType != "" {
+		if strings.Contains(filter.FileType, "/") {
+			query["file_type"] = filter.FileType
+		} else {
+			query["file_type"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.FileType) + "/"}
+		}
+	}
+	if filter.Purpose != "" {
+		query["purpose"] = filter.Purpose
+	}
+	if filter.EntityType != "" {
+		query["entity_type"] = filter.EntityType
+	}
+	uploadedAt := bson.M{}
+	if filter.UploadedFrom != nil {
+		uploadedAt["$gte"] = *filter.UploadedFrom
+	}
+	if filter.UploadedTo != nil {
+		uploadedAt["$lt"] = *filter.UploadedTo
+	}
+	if len(uploadedAt) > 0 {
+		query["uploaded_at"] = uploadedAt
+	}
+	return query
+}
+
+func (r *fileRepository) FindByOwner(ownerID int) ([]models.File, error) {
+	return r.find(bson.M{"owner_id": ownerID})
+}
+
+// FindByEntity lampiran milik satu entitas (alumni, mahasiswa, pekerjaan)
+func (r *fileRepository) FindByEntity(entityType string, entityID uint) ([]models.File, error) {
+	return r.find(bson.M{"entity_type": entityType, "entity_id": entityID})
+}
+
+// FindByScanStatus misal file yang masih pending untuk dipindai ulang
+func (r *fileRepository) FindByScanStatus(status string) ([]models.File, error) {
+	return r.find(bson.M{"scan_status": status})
+}
+
+func (r *fileRepository) find(filter bson.M) ([]models.File, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var files []models.File
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	files := []models.File{}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "uploaded_at", Value: -1}}))
 	if err != nil {
 		return nil, err
 	}
@@ -59,27 +163,100 @@ func (r *fileRepository) FindByID(id string) (*models.File, error) {
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, utils.NotFound(fileNotFound)
 	}
 
 	var file models.File
 	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&file)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, fileNotFound)
 	}
 
 	return &file, nil
 }
 
+// Update menyimpan ulang seluruh metadata file, misal setelah dipindah ke backend storage lain
+func (r *fileRepository) Update(file *models.File) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": file.ID}, file)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return utils.NotFound(fileNotFound)
+	}
+	return nil
+}
+
 func (r *fileRepository) Delete(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.NotFound(fileNotFound)
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
 	if err != nil {
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(fileNotFound)
+	}
+	return nil
+}
+
+// UpdateScanResult file dengan isi yang sama selalu berbagi hasil pindai yang sama
+func (r *fileRepository) UpdateScanResult(backend, sha256 string, result models.FileScanResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	_, err := r.collection.UpdateMany(ctx, bson.M{"backend": backend, "sha256": sha256}, scanResultUpdate(result))
 	return err
 }
+
+// UsageByOwner dihitung dari ukuran tiap record, bukan ukuran blob yang dipakai bersama
+func (r *fileRepository) UsageByOwner(ownerID int) ([]models.StorageUsageGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"owner_id": ownerID}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":        "$purpose",
+			"used_bytes": bson.M{"$sum": "$file_size"},
+			"file_count": bson.M{"$sum": 1},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []models.StorageUsageGroup{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// scanResultUpdate update hasil pindai untuk files dan file_blobs; isi yang
+// dikarantina pindah key dan tidak lagi punya variant
+func scanResultUpdate(result models.FileScanResult) bson.M {
+	set := bson.M{
+		"scan_status":    result.Status,
+		"scan_signature": result.Signature,
+		"scanned_at":     result.ScannedAt,
+	}
+	update := bson.M{"$set": set}
+	if result.Status == models.FileScanInfected {
+		set["storage_key"] = result.StorageKey
+		update["$unset"] = bson.M{"variants": ""}
+	}
+	return update
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type historyRepositoryMongo struct {
+	collection *mongo.Collection
+}
+
+func NewHistoryRepositoryMongo(db *mongo.Database) repo.HistoryRepository {
+	return &historyRepositoryMongo{
+		collection: db.Collection("record_histories"),
+	}
+}
+
+func (r *historyRepositoryMongo) Create(history *models.RecordHistory) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nextID, err := getNextSequenceID(r.collection)
+	if err != nil {
+		return err
+	}
+	history.ID = nextID
+	history.CreatedAt = time.Now()
+
+	_, err = r.collection.InsertOne(ctx, history)
+	return err
+}
+
+func (r *historyRepositoryMongo) GetByRecord(entity string, recordID uint) ([]models.RecordHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "version", Value: -1}, {Key: "id", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"entity": entity, "record_id": recordID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	histories := []models.RecordHistory{}
+	if err = cursor.All(ctx, &histories); err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
+
+func (r *historyRepositoryMongo) GetVersion(entity string, recordID uint, version int) (*models.RecordHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"entity": entity, "record_id": recordID, "version": version}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+
+	var history models.RecordHistory
+	err := r.collection.FindOne(ctx, filter, findOptions).Decode(&history)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &history, nil
+}
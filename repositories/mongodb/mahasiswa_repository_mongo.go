@@ -2,9 +2,9 @@ package mongodb
 
 import (
 	"context"
-	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +12,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const mahasiswaNotFound = "Mahasiswa tidak ditemukan"
+
 type mahasiswaRepositoryMongo struct {
 	collection *mongo.Collection
 }
@@ -26,7 +28,7 @@ func (r *mahasiswaRepositoryMongo) GetAll() ([]models.Mahasiswa, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	cursor, err := r.collection.Find(ctx, bson.M{"deleted_at": notMerged})
 	if err != nil {
 		return nil, err
 	}
@@ -45,20 +47,13 @@ func (r *mahasiswaRepositoryMongo) GetWithPagination(pagination *models.Paginati
 	defer cancel()
 
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
-
-	// Build search filter
-	filter := bson.M{}
-	if pagination.Search != "" {
-		filter = bson.M{
-			"$or": []bson.M{
-				{"nim": bson.M{"$regex": pagination.Search, "$options": "i"}},
-				{"nama": bson.M{"$regex": pagination.Search, "$options": "i"}},
-				{"jurusan": bson.M{"$regex": pagination.Search, "$options": "i"}},
-				{"email": bson.M{"$regex": pagination.Search, "$options": "i"}},
-			},
-		}
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Build search filter - $text memakai text index idx_mahasiswas_search
+	terms := models.SearchTerms(pagination.Search)
+	filter, text, err := searchFilter(ctx, r.collection, bson.M{"deleted_at": notMerged}, terms, mahasiswaSearchFields)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Count total documents
@@ -76,8 +71,17 @@ func (r *mahasiswaRepositoryMongo) GetWithPagination(pagination *models.Paginati
 	// Query options with pagination and sorting
 	findOptions := options.Find().
 		SetLimit(int64(pagination.Limit)).
-		SetSkip(int64(pagination.GetOffset())).
-		SetSort(bson.D{{Key: pagination.SortBy, Value: sortOrder}})
+		SetSkip(int64(pagination.GetOffset()))
+	if text {
+		findOptions.SetProjection(bson.M{"score": textScore})
+	}
+	if byRelevance && text {
+		findOptions.SetSort(bson.D{{Key: "score", Value: textScore}, {Key: "id", Value: 1}})
+	} else if byRelevance {
+		findOptions.SetSort(bson.D{{Key: "id", Value: 1}})
+	} else {
+		findOptions.SetSort(bson.D{{Key: pagination.SortBy, Value: sortOrder}})
+	}
 
 	// Execute query
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
@@ -86,10 +90,15 @@ func (r *mahasiswaRepositoryMongo) GetWithPagination(pagination *models.Paginati
 	}
 	defer cursor.Close(ctx)
 
-	var mahasiswas []models.Mahasiswa
-	if err = cursor.All(ctx, &mahasiswas); err != nil {
+	mahasiswas, scores, err := decodeSearchResults[models.Mahasiswa](ctx, cursor)
+	if err != nil {
 		return nil, 0, err
 	}
+	if len(terms) > 0 {
+		for i := range mahasiswas {
+			mahasiswas[i].Search = searchMatch(terms, mahasiswas[i].SearchFields(), scores[i], text)
+		}
+	}
 
 	return mahasiswas, total, nil
 }
@@ -99,12 +108,12 @@ func (r *mahasiswaRepositoryMongo) GetByID(id uint) (*models.Mahasiswa, error) {
 	defer cancel()
 
 	var mahasiswa models.Mahasiswa
-	filter := bson.M{"id": id}
+	filter := bson.M{"id": id, "deleted_at": notMerged}
 	
 	err := r.collection.FindOne(ctx, filter).Decode(&mahasiswa)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("mahasiswa not found")
+			return nil, utils.NotFound(mahasiswaNotFound)
 		}
 		return nil, err
 	}
@@ -120,6 +129,7 @@ func (r *mahasiswaRepositoryMongo) Create(mahasiswa *models.Mahasiswa) error {
 	now := time.Now()
 	mahasiswa.CreatedAt = now
 	mahasiswa.UpdatedAt = now
+	mahasiswa.Version = 1
 
 	// Get next ID
 	nextID, err := r.getNextSequenceID()
@@ -129,7 +139,7 @@ func (r *mahasiswaRepositoryMongo) Create(mahasiswa *models.Mahasiswa) error {
 	mahasiswa.ID = nextID
 
 	_, err = r.collection.InsertOne(ctx, mahasiswa)
-	return err
+	return translateError(err, mahasiswaNotFound)
 }
 
 func (r *mahasiswaRepositoryMongo) Update(mahasiswa *models.Mahasiswa) error {
@@ -138,7 +148,7 @@ func (r *mahasiswaRepositoryMongo) Update(mahasiswa *models.Mahasiswa) error {
 
 	mahasiswa.UpdatedAt = time.Now()
 
-	filter := bson.M{"id": mahasiswa.ID}
+	filter := bson.M{"id": mahasiswa.ID, "version": mahasiswa.Version, "deleted_at": notMerged}
 	update := bson.M{
 		"$set": bson.M{
 			"nim":        mahasiswa.NIM,
@@ -148,34 +158,58 @@ func (r *mahasiswaRepositoryMongo) Update(mahasiswa *models.Mahasiswa) error {
 			"email":      mahasiswa.Email,
 			"updated_at": mahasiswa.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, mahasiswaNotFound)
 	}
-
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("mahasiswa not found")
+	if err := matchedOrStale(ctx, r.collection, result, mahasiswa.ID, mahasiswaNotFound); err != nil {
+		return err
 	}
+	mahasiswa.Version++
 
 	return nil
 }
 
-func (r *mahasiswaRepositoryMongo) Delete(id uint) error {
+func (r *mahasiswaRepositoryMongo) Delete(id uint, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"id": id}
+	filter := withVersion(bson.M{"id": id}, version)
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("mahasiswa not found")
+	return deletedOrStale(ctx, r.collection, result, id, mahasiswaNotFound)
+}
+
+// Merge menandai mahasiswa sumber deleted_at dan merged_into = targetID
+func (r *mahasiswaRepositoryMongo) Merge(targetID uint, sourceIDs []uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"id": bson.M{"$in": sourceIDs}, "deleted_at": notMerged},
+		bson.M{"$set": bson.M{"deleted_at": now, "merged_into": targetID, "updated_at": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
 	}
 
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"id": targetID, "deleted_at": notMerged},
+		bson.M{"$set": bson.M{"updated_at": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return utils.NotFound(mahasiswaNotFound)
+	}
 	return nil
 }
 
@@ -183,7 +217,7 @@ func (r *mahasiswaRepositoryMongo) Count() (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return r.collection.CountDocuments(ctx, bson.M{})
+	return r.collection.CountDocuments(ctx, bson.M{"deleted_at": notMerged})
 }
 
 // Helper function to get next sequence ID
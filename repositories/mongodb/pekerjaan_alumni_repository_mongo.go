@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +13,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const pekerjaanNotFound = "Pekerjaan alumni tidak ditemukan"
+
 type pekerjaanAlumniRepositoryMongo struct {
 	collection        *mongo.Collection
 	alumniCollection  *mongo.Collection
@@ -75,24 +78,15 @@ func (r *pekerjaanAlumniRepositoryMongo) GetWithPagination(pagination *models.Pa
 	defer cancel()
 
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
+	byRelevance := pagination.SetSearchDefaults()
+	terms := models.SearchTerms(pagination.Search)
 
 	// Build search filter
-	matchStage := bson.D{{Key: "$match", Value: bson.M{"deleted_at": bson.M{"$eq": nil}}}}
-	if pagination.Search != "" {
-		matchStage = bson.D{
-			{Key: "$match", Value: bson.M{
-				"deleted_at": bson.M{"$eq": nil},
-				"$or": []bson.M{
-					{"posisi_jabatan": bson.M{"$regex": pagination.Search, "$options": "i"}},
-					{"nama_perusahaan": bson.M{"$regex": pagination.Search, "$options": "i"}},
-					{"bidang_industri": bson.M{"$regex": pagination.Search, "$options": "i"}},
-					{"lokasi_kerja": bson.M{"$regex": pagination.Search, "$options": "i"}},
-				},
-			}},
-		}
+	filter, text, err := pekerjaanSearchFilter(ctx, r.collection, pagination.Search)
+	if err != nil {
+		return nil, 0, err
 	}
+	matchStage := bson.D{{Key: "$match", Value: filter}}
 
 	// Count pipeline
 	countPipeline := mongo.Pipeline{matchStage, bson.D{{Key: "$count", Value: "total"}}}
@@ -121,9 +115,15 @@ func (r *pekerjaanAlumniRepositoryMongo) GetWithPagination(pagination *models.Pa
 		sortOrder = -1
 	}
 
+	sortStage := bson.D{{Key: pagination.SortBy, Value: sortOrder}}
+	if byRelevance {
+		sortStage = bson.D{{Key: "score", Value: -1}, {Key: "id", Value: 1}}
+	}
+
 	// Data pipeline with lookup
 	dataPipeline := mongo.Pipeline{
 		matchStage,
+		{{Key: "$addFields", Value: bson.M{"score": scoreExpr(text)}}},
 		{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "alumnis"},
 			{Key: "localField", Value: "alumni_id"},
@@ -144,7 +144,7 @@ func (r *pekerjaanAlumniRepositoryMongo) GetWithPagination(pagination *models.Pa
 			{Key: "path", Value: "$alumni.user"},
 			{Key: "preserveNullAndEmptyArrays", Value: true},
 		}}},
-		{{Key: "$sort", Value: bson.D{{Key: pagination.SortBy, Value: sortOrder}}}},
+		{{Key: "$sort", Value: sortStage}},
 		{{Key: "$skip", Value: pagination.GetOffset()}},
 		{{Key: "$limit", Value: pagination.Limit}},
 	}
@@ -155,10 +155,15 @@ func (r *pekerjaanAlumniRepositoryMongo) GetWithPagination(pagination *models.Pa
 	}
 	defer cursor.Close(ctx)
 
-	var pekerjaans []models.PekerjaanAlumni
-	if err = cursor.All(ctx, &pekerjaans); err != nil {
+	pekerjaans, scores, err := decodeSearchResults[models.PekerjaanAlumni](ctx, cursor)
+	if err != nil {
 		return nil, 0, err
 	}
+	if len(terms) > 0 {
+		for i := range pekerjaans {
+			pekerjaans[i].Search = searchMatch(terms, pekerjaans[i].SearchFields(), scores[i], text)
+		}
+	}
 
 	return pekerjaans, total, nil
 }
@@ -203,7 +208,7 @@ func (r *pekerjaanAlumniRepositoryMongo) GetByID(id uint) (*models.PekerjaanAlum
 	}
 
 	if len(pekerjaans) == 0 {
-		return nil, fmt.Errorf("pekerjaan alumni not found")
+		return nil, utils.NotFound(pekerjaanNotFound)
 	}
 
 	return &pekerjaans[0], nil
@@ -252,6 +257,33 @@ func (r *pekerjaanAlumniRepositoryMongo) GetByAlumniID(alumniID uint) ([]models.
 	return pekerjaans, nil
 }
 
+func (r *pekerjaanAlumniRepositoryMongo) GetAllByAlumniIDs(alumniIDs []uint) ([]models.PekerjaanAlumni, error) {
+	return r.findAllIn("alumni_id", alumniIDs)
+}
+
+func (r *pekerjaanAlumniRepositoryMongo) GetAllByPerusahaanIDs(perusahaanIDs []uint) ([]models.PekerjaanAlumni, error) {
+	return r.findAllIn("perusahaan_id", perusahaanIDs)
+}
+
+// findAllIn pekerjaan yang field-nya berisi salah satu ids, termasuk yang di-soft delete
+func (r *pekerjaanAlumniRepositoryMongo) findAllIn(field string, ids []uint) ([]models.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{field: bson.M{"$in": ids}}, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pekerjaans := []models.PekerjaanAlumni{}
+	if err = cursor.All(ctx, &pekerjaans); err != nil {
+		return nil, err
+	}
+
+	return pekerjaans, nil
+}
+
 func (r *pekerjaanAlumniRepositoryMongo) GetByUserID(userID int) ([]models.PekerjaanAlumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -326,6 +358,7 @@ func (r *pekerjaanAlumniRepositoryMongo) Create(pekerjaan *models.PekerjaanAlumn
 	now := time.Now()
 	pekerjaan.CreatedAt = now
 	pekerjaan.UpdatedAt = now
+	pekerjaan.Version = 1
 
 	// Get next ID
 	nextID, err := r.getNextSequenceID()
@@ -335,7 +368,7 @@ func (r *pekerjaanAlumniRepositoryMongo) Create(pekerjaan *models.PekerjaanAlumn
 	pekerjaan.ID = nextID
 
 	_, err = r.collection.InsertOne(ctx, pekerjaan)
-	return err
+	return translateError(err, pekerjaanNotFound)
 }
 
 func (r *pekerjaanAlumniRepositoryMongo) Update(pekerjaan *models.PekerjaanAlumni) error {
@@ -344,35 +377,44 @@ func (r *pekerjaanAlumniRepositoryMongo) Update(pekerjaan *models.PekerjaanAlumn
 
 	pekerjaan.UpdatedAt = time.Now()
 
-	filter := bson.M{"id": pekerjaan.ID, "deleted_at": bson.M{"$eq": nil}}
+	filter := bson.M{"id": pekerjaan.ID, "version": pekerjaan.Version, "deleted_at": bson.M{"$eq": nil}}
 	update := bson.M{
 		"$set": bson.M{
+			"perusahaan_id":         pekerjaan.PerusahaanID,
 			"nama_perusahaan":       pekerjaan.NamaPerusahaan,
 			"posisi_jabatan":        pekerjaan.PosisiJabatan,
 			"bidang_industri":       pekerjaan.BidangIndustri,
+			"kode_industri":         pekerjaan.KodeIndustri,
 			"lokasi_kerja":          pekerjaan.LokasiKerja,
+			"kode_provinsi":         pekerjaan.KodeProvinsi,
+			"kode_kota":             pekerjaan.KodeKota,
 			"gaji_range":            pekerjaan.GajiRange,
+			"gaji_min":              pekerjaan.GajiMin,
+			"gaji_max":              pekerjaan.GajiMax,
+			"gaji_mata_uang":        pekerjaan.GajiMataUang,
+			"gaji_periode":          pekerjaan.GajiPeriode,
 			"tanggal_mulai_kerja":   pekerjaan.TanggalMulaiKerja,
 			"tanggal_selesai_kerja": pekerjaan.TanggalSelesaiKerja,
 			"status_pekerjaan":      pekerjaan.StatusPekerjaan,
 			"deskripsi_pekerjaan":   pekerjaan.DeskripsiPekerjaan,
 			"updated_at":            pekerjaan.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, pekerjaanNotFound)
 	}
-
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("pekerjaan alumni not found")
+	if err := matchedOrStale(ctx, r.collection, result, pekerjaan.ID, pekerjaanNotFound); err != nil {
+		return err
 	}
+	pekerjaan.Version++
 
 	return nil
 }
 
-func (r *pekerjaanAlumniRepositoryMongo) Delete(id uint) error {
+func (r *pekerjaanAlumniRepositoryMongo) Delete(id uint, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -383,43 +425,42 @@ func (r *pekerjaanAlumniRepositoryMongo) Delete(id uint) error {
 	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&pekerjaan)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return fmt.Errorf("data pekerjaan alumni tidak ditemukan")
+			return utils.NotFound(pekerjaanNotFound)
 		}
 		return err
 	}
 
 	if pekerjaan.DeletedAt == nil {
-		return fmt.Errorf("tidak bisa hard delete: data belum di-soft delete terlebih dahulu")
+		return utils.Conflict("Tidak bisa hard delete: data belum di-soft delete terlebih dahulu")
 	}
 
-	filter := bson.M{"id": id, "deleted_at": bson.M{"$ne": nil}}
+	filter := withVersion(bson.M{"id": id, "deleted_at": bson.M{"$ne": nil}}, version)
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("tidak ada data yang dihapus - pastikan data sudah di-soft delete")
-	}
-
-	return nil
+	return deletedOrStale(ctx, r.collection, result, id, pekerjaanNotFound)
 }
 
-func (r *pekerjaanAlumniRepositoryMongo) SoftDelete(id uint) error {
+func (r *pekerjaanAlumniRepositoryMongo) SoftDelete(id uint, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	now := time.Now()
-	filter := bson.M{"id": id, "deleted_at": bson.M{"$eq": nil}}
-	update := bson.M{"$set": bson.M{"deleted_at": now}}
+	filter := withVersion(bson.M{"id": id, "deleted_at": bson.M{"$eq": nil}}, version)
+	update := bson.M{"$set": bson.M{"deleted_at": now}, "$inc": bson.M{"version": 1}}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, pekerjaanNotFound)
 	}
 
+	if version > 0 {
+		return matchedOrStale(ctx, r.collection, result, id, pekerjaanNotFound)
+	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("pekerjaan alumni not found or already deleted")
+		return utils.NotFound(pekerjaanNotFound)
 	}
 
 	return nil
@@ -431,7 +472,7 @@ func (r *pekerjaanAlumniRepositoryMongo) SoftDeleteByAlumniID(alumniID uint) err
 
 	now := time.Now()
 	filter := bson.M{"alumni_id": alumniID, "deleted_at": bson.M{"$eq": nil}}
-	update := bson.M{"$set": bson.M{"deleted_at": now}}
+	update := bson.M{"$set": bson.M{"deleted_at": now}, "$inc": bson.M{"version": 1}}
 
 	_, err := r.collection.UpdateMany(ctx, filter, update)
 	return err
@@ -442,15 +483,15 @@ func (r *pekerjaanAlumniRepositoryMongo) Restore(id uint) error {
 	defer cancel()
 
 	filter := bson.M{"id": id}
-	update := bson.M{"$set": bson.M{"deleted_at": nil}}
+	update := bson.M{"$set": bson.M{"deleted_at": nil}, "$inc": bson.M{"version": 1}}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, pekerjaanNotFound)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("pekerjaan alumni not found")
+		return utils.NotFound(pekerjaanNotFound)
 	}
 
 	return nil
@@ -572,34 +613,77 @@ func (r *pekerjaanAlumniRepositoryMongo) Count() (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{"deleted_at": bson.M{"$eq": nil}})
 }
 
-func (r *pekerjaanAlumniRepositoryMongo) GetAlumniCountByCompany(namaPerusahaan string) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetSalarySamples mengambil data gaji terstruktur beserta kunci grup untuk statistik gaji
+func (r *pekerjaanAlumniRepositoryMongo) GetSalarySamples(groupBy string, mataUang string) ([]models.SalarySample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	groupFields := map[string]interface{}{
+		"jurusan":         "$alumni.jurusan",
+		"tahun_lulus":     bson.M{"$toString": "$alumni.tahun_lulus"},
+		"bidang_industri": "$bidang_industri",
+		"lokasi_kerja":    "$lokasi_kerja",
+		"":                "semua",
+	}
+	groupField, ok := groupFields[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik gaji tidak valid: %s", groupBy)
+	}
+
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"nama_perusahaan": namaPerusahaan, "deleted_at": bson.M{"$eq": nil}}}},
-		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$alumni_id"}}}},
-		{{Key: "$count", Value: "total"}},
+		{{Key: "$match", Value: bson.M{
+			"deleted_at":     bson.M{"$eq": nil},
+			"gaji_mata_uang": mataUang,
+			"$or": []bson.M{
+				{"gaji_min": bson.M{"$ne": nil}},
+				{"gaji_max": bson.M{"$ne": nil}},
+			},
+		}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "alumnis"},
+			{Key: "localField", Value: "alumni_id"},
+			{Key: "foreignField", Value: "id"},
+			{Key: "as", Value: "alumni"},
+		}}},
+		{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$alumni"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"group_key":    groupField,
+			"gaji_min":     1,
+			"gaji_max":     1,
+			"gaji_periode": 1,
+		}}},
 	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var result []struct {
-		Total int64 `bson:"total"`
+	var rows []struct {
+		GroupKey    string `bson:"group_key"`
+		GajiMin     *int64 `bson:"gaji_min"`
+		GajiMax     *int64 `bson:"gaji_max"`
+		GajiPeriode string `bson:"gaji_periode"`
 	}
-	if err = cursor.All(ctx, &result); err != nil {
-		return 0, err
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
 	}
 
-	if len(result) > 0 {
-		return result[0].Total, nil
+	samples := make([]models.SalarySample, len(rows))
+	for i, row := range rows {
+		samples[i] = models.SalarySample{
+			GroupKey:    row.GroupKey,
+			GajiMin:     row.GajiMin,
+			GajiMax:     row.GajiMax,
+			GajiPeriode: row.GajiPeriode,
+		}
 	}
 
-	return 0, nil
+	return samples, nil
 }
 
 // Helper function to get next sequence ID
@@ -623,3 +707,36 @@ func (r *pekerjaanAlumniRepositoryMongo) getNextSequenceID() (uint, error) {
 
 	return result.ID + 1, nil
 }
+
+// CountByGroup menghitung pekerjaan aktif per kode industri, kota, atau provinsi dengan $group
+func (r *pekerjaanAlumniRepositoryMongo) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lokasi := bson.M{"$ifNull": bson.A{"$lokasi_kerja", ""}}
+	groupExprs := map[string][2]interface{}{
+		"kode_industri": {bson.M{"$ifNull": bson.A{"$kode_industri", ""}}, bson.M{"$ifNull": bson.A{"$bidang_industri", ""}}},
+		"kode_kota": {bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$kode_kota", ""}}, ""}},
+			"$kode_kota",
+			bson.M{"$ifNull": bson.A{"$kode_provinsi", ""}},
+		}}, lokasi},
+		"kode_provinsi": {bson.M{"$ifNull": bson.A{"$kode_provinsi", ""}}, lokasi},
+	}
+	exprs, ok := groupExprs[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik pekerjaan tidak valid: %s", groupBy)
+	}
+
+	filter, _, err := pekerjaanSearchFilter(ctx, r.collection, search)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateStatGroups(ctx, r.collection, filter, exprs[0], exprs[1])
+}
+
+// pekerjaanSearchFilter filter pekerjaan aktif beserta pencarian (text index
+// idx_pekerjaan_search, atau fallback prefix), dipakai bersama oleh list dan statistik
+func pekerjaanSearchFilter(ctx context.Context, collection *mongo.Collection, search string) (bson.M, bool, error) {
+	return searchFilter(ctx, collection, bson.M{"deleted_at": bson.M{"$eq": nil}}, models.SearchTerms(search), pekerjaanSearchFields)
+}
@@ -0,0 +1,287 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const perusahaanNotFound = "Perusahaan tidak ditemukan"
+
+type perusahaanRepositoryMongo struct {
+	collection          *mongo.Collection
+	pekerjaanCollection *mongo.Collection
+}
+
+func NewPerusahaanRepositoryMongo(db *mongo.Database) repo.PerusahaanRepository {
+	return &perusahaanRepositoryMongo{
+		collection:          db.Collection("perusahaans"),
+		pekerjaanCollection: db.Collection("pekerjaan_alumnis"),
+	}
+}
+
+func (r *perusahaanRepositoryMongo) GetWithPagination(pagination *models.PaginationRequest) ([]models.Perusahaan, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Set default values
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Build search filter - nama, alias, industri, lokasi lewat text index
+	terms := models.SearchTerms(pagination.Search)
+	filter, text, err := searchFilter(ctx, r.collection, bson.M{}, terms, perusahaanSearchFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Build sort order
+	sortOrder := 1
+	if pagination.SortOrder == "DESC" {
+		sortOrder = -1
+	}
+
+	findOptions := options.Find().
+		SetLimit(int64(pagination.Limit)).
+		SetSkip(int64(pagination.GetOffset()))
+	if text {
+		findOptions.SetProjection(bson.M{"score": textScore})
+	}
+	if byRelevance && text {
+		findOptions.SetSort(bson.D{{Key: "score", Value: textScore}, {Key: "id", Value: 1}})
+	} else if byRelevance {
+		findOptions.SetSort(bson.D{{Key: "id", Value: 1}})
+	} else {
+		findOptions.SetSort(bson.D{{Key: pagination.SortBy, Value: sortOrder}})
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	perusahaans, scores, err := decodeSearchResults[models.Perusahaan](ctx, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(terms) > 0 {
+		for i := range perusahaans {
+			perusahaans[i].Search = searchMatch(terms, perusahaans[i].SearchFields(), scores[i], text)
+		}
+	}
+
+	return perusahaans, total, nil
+}
+
+func (r *perusahaanRepositoryMongo) GetByID(id uint) (*models.Perusahaan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var perusahaan models.Perusahaan
+	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&perusahaan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound(perusahaanNotFound)
+		}
+		return nil, err
+	}
+
+	return &perusahaan, nil
+}
+
+func (r *perusahaanRepositoryMongo) FindByNamaNormal(namaNormal string) (*models.Perusahaan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$or": []bson.M{
+		{"nama_normal": namaNormal},
+		{"alias_normals": namaNormal},
+	}}
+
+	var perusahaan models.Perusahaan
+	err := r.collection.FindOne(ctx, filter).Decode(&perusahaan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &perusahaan, nil
+}
+
+func (r *perusahaanRepositoryMongo) Create(perusahaan *models.Perusahaan) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Set timestamps
+	now := time.Now()
+	perusahaan.CreatedAt = now
+	perusahaan.UpdatedAt = now
+	perusahaan.Version = 1
+	perusahaan.AliasNormals = utils.NormalizeAliases(perusahaan.Aliases)
+
+	// Get next ID
+	nextID, err := r.getNextSequenceID()
+	if err != nil {
+		return err
+	}
+	perusahaan.ID = nextID
+
+	_, err = r.collection.InsertOne(ctx, perusahaan)
+	return translateError(err, perusahaanNotFound)
+}
+
+func (r *perusahaanRepositoryMongo) Update(perusahaan *models.Perusahaan) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	perusahaan.UpdatedAt = time.Now()
+	perusahaan.AliasNormals = utils.NormalizeAliases(perusahaan.Aliases)
+
+	filter := bson.M{"id": perusahaan.ID, "version": perusahaan.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"nama":            perusahaan.Nama,
+			"nama_normal":     perusahaan.NamaNormal,
+			"bidang_industri": perusahaan.BidangIndustri,
+			"lokasi":          perusahaan.Lokasi,
+			"website":         perusahaan.Website,
+			"aliases":         perusahaan.Aliases,
+			"alias_normals":   perusahaan.AliasNormals,
+			"updated_at":      perusahaan.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err, perusahaanNotFound)
+	}
+	if err := matchedOrStale(ctx, r.collection, result, perusahaan.ID, perusahaanNotFound); err != nil {
+		return err
+	}
+	perusahaan.Version++
+
+	// Nama yang tampil pada pekerjaan ikut diperbarui
+	_, err = r.pekerjaanCollection.UpdateMany(ctx,
+		bson.M{"perusahaan_id": perusahaan.ID, "nama_perusahaan": bson.M{"$ne": perusahaan.Nama}},
+		bson.M{"$set": bson.M{"nama_perusahaan": perusahaan.Nama}, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
+
+func (r *perusahaanRepositoryMongo) Delete(id uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(perusahaanNotFound)
+	}
+
+	return nil
+}
+
+func (r *perusahaanRepositoryMongo) Merge(targetID uint, sourceIDs []uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	target, err := r.GetByID(targetID)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"id": bson.M{"$in": sourceIDs}})
+	if err != nil {
+		return err
+	}
+	var sources []models.Perusahaan
+	if err = cursor.All(ctx, &sources); err != nil {
+		return err
+	}
+
+	// Nama dan alias perusahaan sumber menjadi alias perusahaan tujuan
+	aliases := append([]string{}, target.Aliases...)
+	for _, source := range sources {
+		aliases = append(aliases, source.Nama)
+		aliases = append(aliases, source.Aliases...)
+	}
+	target.Aliases = utils.UniqueAliases(aliases, target.NamaNormal)
+
+	// Tanpa transaksi (MongoDB standalone tidak mendukungnya), jadi urutannya dibuat
+	// supaya menjalankan ulang merge yang sama memperbaiki kegagalan di tengah jalan:
+	// alias dulu (UniqueAliases membuang duplikat), lalu pekerjaan (yang sudah pindah
+	// tidak cocok lagi dengan filter), dan perusahaan sumber dihapus paling akhir
+	// sehingga selama belum selesai, sumbernya masih ada untuk merge ulang.
+	if err := r.Update(target); err != nil {
+		return err
+	}
+
+	_, err = r.pekerjaanCollection.UpdateMany(ctx,
+		bson.M{"perusahaan_id": bson.M{"$in": sourceIDs}},
+		bson.M{"$set": bson.M{"perusahaan_id": targetID, "nama_perusahaan": target.Nama}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteMany(ctx, bson.M{"id": bson.M{"$in": sourceIDs}})
+	return err
+}
+
+func (r *perusahaanRepositoryMongo) CountAlumni(id uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumniIDs, err := r.pekerjaanCollection.Distinct(ctx, "alumni_id",
+		bson.M{"perusahaan_id": id, "deleted_at": bson.M{"$eq": nil}})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(alumniIDs)), nil
+}
+
+func (r *perusahaanRepositoryMongo) CountPekerjaan(id uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.pekerjaanCollection.CountDocuments(ctx, bson.M{"perusahaan_id": id})
+}
+
+// Helper function to get next sequence ID
+func (r *perusahaanRepositoryMongo) getNextSequenceID() (uint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Find the document with the highest ID
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	var result struct {
+		ID uint `bson:"id"`
+	}
+
+	err := r.collection.FindOne(ctx, bson.M{}, findOptions).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil // Start from 1 if no documents exist
+		}
+		return 0, err
+	}
+
+	return result.ID + 1, nil
+}
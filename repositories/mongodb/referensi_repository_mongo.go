@@ -0,0 +1,178 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// referensiUsageFields collection dan field yang menyimpan kode referensi untuk setiap
+// kategori. CountUsage sengaja tanpa filter deleted_at: dokumen yang di-soft delete
+// masih bisa di-restore.
+var referensiUsageFields = map[string][2]string{
+	models.KategoriIndustri: {"pekerjaan_alumnis", "kode_industri"},
+	models.KategoriProvinsi: {"pekerjaan_alumnis", "kode_provinsi"},
+	models.KategoriKota:     {"pekerjaan_alumnis", "kode_kota"},
+	models.KategoriProdi:    {"alumnis", "kode_prodi"},
+}
+
+const referensiNotFound = "Referensi tidak ditemukan"
+
+type referensiRepositoryMongo struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewReferensiRepositoryMongo(db *mongo.Database) repo.ReferensiRepository {
+	return &referensiRepositoryMongo{
+		db:         db,
+		collection: db.Collection("referensis"),
+	}
+}
+
+func (r *referensiRepositoryMongo) GetByKategori(kategori string) ([]models.Referensi, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "kode", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"kategori": kategori}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var referensis []models.Referensi
+	if err = cursor.All(ctx, &referensis); err != nil {
+		return nil, err
+	}
+
+	return referensis, nil
+}
+
+func (r *referensiRepositoryMongo) GetByKode(kategori string, kode string) (*models.Referensi, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var referensi models.Referensi
+	err := r.collection.FindOne(ctx, bson.M{"kategori": kategori, "kode": kode}).Decode(&referensi)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &referensi, nil
+}
+
+func (r *referensiRepositoryMongo) Create(referensi *models.Referensi) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Set timestamps
+	now := time.Now()
+	referensi.CreatedAt = now
+	referensi.UpdatedAt = now
+	referensi.Version = 1
+	if referensi.Aliases == nil {
+		referensi.Aliases = models.StringList{}
+	}
+	if referensi.KodeTerkait == nil {
+		referensi.KodeTerkait = models.StringList{}
+	}
+
+	// Get next ID
+	nextID, err := r.getNextSequenceID()
+	if err != nil {
+		return err
+	}
+	referensi.ID = nextID
+
+	_, err = r.collection.InsertOne(ctx, referensi)
+	return translateError(err, referensiNotFound)
+}
+
+func (r *referensiRepositoryMongo) Update(referensi *models.Referensi) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	referensi.UpdatedAt = time.Now()
+
+	filter := bson.M{"id": referensi.ID, "version": referensi.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"nama":         referensi.Nama,
+			"kode_induk":   referensi.KodeInduk,
+			"aliases":      referensi.Aliases,
+			"kode_terkait": referensi.KodeTerkait,
+			"updated_at":   referensi.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err, referensiNotFound)
+	}
+	if err := matchedOrStale(ctx, r.collection, result, referensi.ID, referensiNotFound); err != nil {
+		return err
+	}
+	referensi.Version++
+
+	return nil
+}
+
+func (r *referensiRepositoryMongo) Delete(id uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(referensiNotFound)
+	}
+
+	return nil
+}
+
+func (r *referensiRepositoryMongo) CountUsage(kategori string, kode string) (int64, error) {
+	usage, ok := referensiUsageFields[kategori]
+	if !ok {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.db.Collection(usage[0]).CountDocuments(ctx, bson.M{usage[1]: kode})
+}
+
+// Helper function to get next sequence ID
+func (r *referensiRepositoryMongo) getNextSequenceID() (uint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Find the document with the highest ID
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	var result struct {
+		ID uint `bson:"id"`
+	}
+
+	err := r.collection.FindOne(ctx, bson.M{}, findOptions).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil // Start from 1 if no documents exist
+		}
+		return 0, err
+	}
+
+	return result.ID + 1, nil
+}
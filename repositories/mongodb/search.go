@@ -0,0 +1,130 @@
+package mongodb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"modul4crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Field text index per collection (lihat migrations_mongodb.go), dipakai juga
+// oleh filter prefix
+var (
+	mahasiswaSearchFields  = []string{"nim", "nama", "jurusan", "email"}
+	alumniSearchFields     = []string{"nim", "nama", "jurusan"}
+	pekerjaanSearchFields  = []string{"posisi_jabatan", "nama_perusahaan", "bidang_industri", "lokasi_kerja", "deskripsi_pekerjaan"}
+	perusahaanSearchFields = []string{"nama", "aliases", "bidang_industri", "lokasi"}
+)
+
+// textScore ekspresi skor relevansi $text, dipakai untuk projection dan sort
+var textScore = bson.M{"$meta": "textScore"}
+
+// scoreExpr nilai field score di pipeline: textScore saat filter memakai $text, 0 jika tidak
+// ($meta textScore hanya boleh dipakai setelah $match dengan $text)
+func scoreExpr(text bool) interface{} {
+	if !text {
+		return 0
+	}
+	return textScore
+}
+
+// searchResult dokumen hasil pencarian $text beserta skornya
+type searchResult[T any] struct {
+	Item  T       `bson:",inline"`
+	Score float64 `bson:"score"`
+}
+
+// textSearch operator $text untuk kata pencarian. Setiap kata dikutip sebagai
+// frasa sehingga semua kata wajib ada (AND), sama seperti PostgreSQL; text index
+// memakai default_language "none" jadi kata dicocokkan utuh tanpa stemming.
+func textSearch(terms []string) bson.M {
+	phrases := make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = `"` + term + `"`
+	}
+	return bson.M{"$search": strings.Join(phrases, " ")}
+}
+
+// searchFilter menambahkan pencarian ke filter dasar. Setiap kata dicocokkan
+// sebagai awalan kata seperti prefix query PostgreSQL, sehingga "budi" juga
+// menemukan "Budiman". $text hanya mencocokkan kata utuh dan tidak bisa digabung
+// dengan regex dalam satu $or (semua cabang $or harus memakai index), jadi $text
+// (dengan textScore untuk ranking) hanya dipakai jika hasilnya sama dengan hasil
+// prefixSearch; selain itu filter prefix yang dipakai dan text=false.
+func searchFilter(ctx context.Context, collection *mongo.Collection, base bson.M, terms []string, fields []string) (bson.M, bool, error) {
+	if len(terms) == 0 {
+		return base, false, nil
+	}
+
+	prefix := bson.M{"$and": prefixSearch(terms, fields)}
+	text := bson.M{"$text": textSearch(terms)}
+	for key, value := range base {
+		prefix[key] = value
+		text[key] = value
+	}
+
+	textCount, err := collection.CountDocuments(ctx, text)
+	if err != nil {
+		return nil, false, err
+	}
+	if textCount == 0 {
+		return prefix, false, nil
+	}
+	// Setiap dokumen hasil $text juga cocok dengan prefix, jadi jumlah yang sama
+	// berarti tidak ada kata yang hanya cocok sebagai awalan
+	prefixCount, err := collection.CountDocuments(ctx, prefix)
+	if err != nil {
+		return nil, false, err
+	}
+	if prefixCount > textCount {
+		return prefix, false, nil
+	}
+	return text, true, nil
+}
+
+// prefixSearch kondisi regex (tanpa index): setiap kata harus menjadi awalan kata
+// di salah satu field, case-insensitive. Kata di-escape sebelum masuk regex.
+func prefixSearch(terms []string, fields []string) bson.A {
+	conditions := make(bson.A, len(terms))
+	for i, term := range terms {
+		pattern := primitive.Regex{Pattern: `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(term), Options: "i"}
+		fieldConditions := make(bson.A, len(fields))
+		for j, field := range fields {
+			fieldConditions[j] = bson.M{field: pattern}
+		}
+		conditions[i] = bson.M{"$or": fieldConditions}
+	}
+	return conditions
+}
+
+// searchMatch skor dan highlight satu hasil: textScore jika $text dipakai, atau
+// skor bobot field dari aplikasi (seperti PocketBase) untuk hasil filter prefix
+func searchMatch(terms []string, fields []models.SearchField, score float64, text bool) *models.SearchMatch {
+	if !text {
+		if match := models.MatchSearch(terms, fields); match != nil {
+			return match
+		}
+	}
+	return models.NewSearchMatch(terms, fields, score)
+}
+
+// decodeSearchResults membaca seluruh dokumen cursor beserta field score-nya
+func decodeSearchResults[T any](ctx context.Context, cursor *mongo.Cursor) ([]T, []float64, error) {
+	var results []searchResult[T]
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]T, len(results))
+	scores := make([]float64, len(results))
+	for i := range results {
+		items[i] = results[i].Item
+		scores[i] = results[i].Score
+	}
+	return items, scores, nil
+}
@@ -0,0 +1,41 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// aggregateStatGroups menghitung dokumen per kode dengan $group. Label (teks asli)
+// hanya dipakai untuk dokumen yang kodenya kosong, hasil diurutkan jumlah
+// terbanyak lalu kode dan label agar urutannya stabil.
+func aggregateStatGroups(ctx context.Context, collection *mongo.Collection, filter bson.M, kodeExpr, labelExpr interface{}) ([]models.StatGroup, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{"kode": kodeExpr, "label": labelExpr}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"kode":  "$kode",
+				"label": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$kode", ""}}, "$label", ""}},
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "kode": "$_id.kode", "label": "$_id.label", "count": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "kode", Value: 1}, {Key: "label", Value: 1}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []models.StatGroup{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const storageQuotaNotFound = "Kuota khusus user tidak ditemukan"
+
+type storageQuotaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewStorageQuotaRepository(db *mongo.Database) repo.StorageQuotaRepository {
+	return &storageQuotaRepository{
+		collection: db.Collection("storage_quotas"),
+	}
+}
+
+func (r *storageQuotaRepository) FindByOwner(ownerID int) (*models.StorageQuota, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var quota models.StorageQuota
+	err := r.collection.FindOne(ctx, bson.M{"owner_id": ownerID}).Decode(&quota)
+	if err != nil {
+		return nil, translateError(err, storageQuotaNotFound)
+	}
+
+	return &quota, nil
+}
+
+// Upsert satu dokumen per user (unique index owner_id)
+func (r *storageQuotaRepository) Upsert(quota *models.StorageQuota) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quota.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"owner_id": quota.OwnerID}, quota, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *storageQuotaRepository) Delete(ownerID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"owner_id": ownerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(storageQuotaNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package mongodb
+
+import (
+	"context"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const surveyNotFound = "Survey tidak ditemukan"
+
+type surveyRepositoryMongo struct {
+	collection         *mongo.Collection
+	responseCollection *mongo.Collection
+}
+
+func NewSurveyRepositoryMongo(db *mongo.Database) repo.SurveyRepository {
+	return &surveyRepositoryMongo{
+		collection:         db.Collection("surveys"),
+		responseCollection: db.Collection("survey_responses"),
+	}
+}
+
+func (r *surveyRepositoryMongo) GetAll(status string) ([]models.Survey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "kode", Value: 1}, {Key: "versi", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	surveys := []models.Survey{}
+	if err = cursor.All(ctx, &surveys); err != nil {
+		return nil, err
+	}
+
+	return surveys, nil
+}
+
+func (r *surveyRepositoryMongo) GetByID(id uint) (*models.Survey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var survey models.Survey
+	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&survey)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound(surveyNotFound)
+		}
+		return nil, err
+	}
+
+	return &survey, nil
+}
+
+func (r *surveyRepositoryMongo) GetLatestVersi(kode string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "versi", Value: -1}})
+	var result struct {
+		Versi int `bson:"versi"`
+	}
+
+	err := r.collection.FindOne(ctx, bson.M{"kode": kode}, findOptions).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return result.Versi, nil
+}
+
+func (r *surveyRepositoryMongo) Create(survey *models.Survey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Set timestamps
+	now := time.Now()
+	survey.CreatedAt = now
+	survey.UpdatedAt = now
+	survey.Version = 1
+
+	// Get next ID
+	nextID, err := getNextSequenceID(r.collection)
+	if err != nil {
+		return err
+	}
+	survey.ID = nextID
+
+	_, err = r.collection.InsertOne(ctx, survey)
+	return translateError(err, surveyNotFound)
+}
+
+func (r *surveyRepositoryMongo) Update(survey *models.Survey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	survey.UpdatedAt = time.Now()
+
+	filter := bson.M{"id": survey.ID, "version": survey.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"judul":              survey.Judul,
+			"deskripsi":          survey.Deskripsi,
+			"status":             survey.Status,
+			"target_tahun_lulus": survey.TargetTahunLulus,
+			"target_jurusan":     survey.TargetJurusan,
+			"pertanyaan":         survey.Pertanyaan,
+			"dibuka_at":          survey.DibukaAt,
+			"ditutup_at":         survey.DitutupAt,
+			"updated_at":         survey.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err, surveyNotFound)
+	}
+	if err := matchedOrStale(ctx, r.collection, result, survey.ID, surveyNotFound); err != nil {
+		return err
+	}
+	survey.Version++
+
+	return nil
+}
+
+func (r *surveyRepositoryMongo) Delete(id uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(surveyNotFound)
+	}
+
+	return nil
+}
+
+func (r *surveyRepositoryMongo) GetResponses(surveyID uint) ([]models.SurveyResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := r.responseCollection.Find(ctx, bson.M{"survey_id": surveyID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	responses := []models.SurveyResponse{}
+	if err = cursor.All(ctx, &responses); err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+func (r *surveyRepositoryMongo) GetResponse(surveyID uint, alumniID uint) (*models.SurveyResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response models.SurveyResponse
+	err := r.responseCollection.FindOne(ctx, bson.M{"survey_id": surveyID, "alumni_id": alumniID}).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (r *surveyRepositoryMongo) SaveResponse(response *models.SurveyResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nextID, err := getNextSequenceID(r.responseCollection)
+	if err != nil {
+		return err
+	}
+
+	// Upsert atas unique index (survey_id, alumni_id): jawaban yang sudah ada diganti,
+	// ID dan waktu pertama kali mengisi dipertahankan
+	now := time.Now()
+	update := bson.M{
+		"$set":         bson.M{"jawaban": response.Jawaban, "updated_at": now},
+		"$setOnInsert": bson.M{"id": nextID, "created_at": now},
+	}
+	return r.responseCollection.FindOneAndUpdate(ctx,
+		bson.M{"survey_id": response.SurveyID, "alumni_id": response.AlumniID}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(response)
+}
+
+func (r *surveyRepositoryMongo) CountResponses(surveyID uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.responseCollection.CountDocuments(ctx, bson.M{"survey_id": surveyID})
+}
+
+// getNextSequenceID mencari ID berikutnya untuk collection yang memakai ID integer
+func getNextSequenceID(collection *mongo.Collection) (uint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Find the document with the highest ID
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	var result struct {
+		ID uint `bson:"id"`
+	}
+
+	err := collection.FindOne(ctx, bson.M{}, findOptions).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil // Start from 1 if no documents exist
+		}
+		return 0, err
+	}
+
+	return result.ID + 1, nil
+}
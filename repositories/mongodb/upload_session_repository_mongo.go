@@ -0,0 +1,194 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const uploadSessionNotFound = "Sesi upload tidak ditemukan"
+
+type uploadSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUploadSessionRepository(db *mongo.Database) repo.UploadSessionRepository {
+	return &uploadSessionRepository{
+		collection: db.Collection("upload_sessions"),
+	}
+}
+
+func (r *uploadSessionRepository) Create(session *models.UploadSession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session.CreatedAt = time.Now()
+	session.Status = models.UploadSessionUploading
+	if session.Chunks == nil {
+		session.Chunks = []models.UploadChunk{}
+	}
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *uploadSessionRepository) FindByID(id string) (*models.UploadSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(uploadSessionNotFound)
+	}
+
+	var session models.UploadSession
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&session)
+	if err != nil {
+		return nil, translateError(err, uploadSessionNotFound)
+	}
+
+	return &session, nil
+}
+
+// AppendChunk menambah chunk dan menggeser offset dalam satu update atomik
+func (r *uploadSessionRepository) AppendChunk(id string, expectedOffset int64, chunk models.UploadChunk, expiresAt time.Time) (*models.UploadSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(uploadSessionNotFound)
+	}
+
+	update := bson.M{
+		"$push": bson.M{"chunks": chunk},
+		"$inc":  bson.M{"offset": chunk.Size},
+		"$set":  bson.M{"expires_at": expiresAt},
+	}
+	var session models.UploadSession
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objectID, "offset": expectedOffset},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Sesi hilang atau offset sudah bergeser karena chunk lain
+		if _, findErr := r.FindByID(id); findErr != nil {
+			return nil, findErr
+		}
+		return nil, utils.Conflict("Upload-Offset tidak sesuai dengan offset sesi")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// BeginFinish menandai sesi yang semua chunk-nya sudah diterima sebagai finishing
+// dalam satu update atomik; masa berlakunya diperpanjang supaya tidak ikut
+// dibersihkan selama chunk digabung
+func (r *uploadSessionRepository) BeginFinish(id string, expiresAt time.Time) (*models.UploadSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NotFound(uploadSessionNotFound)
+	}
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": bson.M{"$ne": models.UploadSessionFinishing},
+		"$expr":  bson.M{"$eq": bson.A{"$offset", "$file_size"}},
+	}
+	update := bson.M{"$set": bson.M{"status": models.UploadSessionFinishing, "expires_at": expiresAt}}
+	var session models.UploadSession
+	err = r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Sesi hilang, atau request lain sedang menyelesaikannya
+		if _, findErr := r.FindByID(id); findErr != nil {
+			return nil, findErr
+		}
+		return nil, utils.Conflict("Upload sedang diselesaikan oleh request lain")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *uploadSessionRepository) ResetFinish(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.NotFound(uploadSessionNotFound)
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "status": models.UploadSessionFinishing},
+		bson.M{"$set": bson.M{"status": models.UploadSessionUploading}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return utils.NotFound(uploadSessionNotFound)
+	}
+	return nil
+}
+
+// FindExpired sesi yang sudah lewat masa berlakunya, untuk dibersihkan
+func (r *uploadSessionRepository) FindExpired(before time.Time) ([]models.UploadSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sessions := []models.UploadSession{}
+	cursor, err := r.collection.Find(ctx, bson.M{"expires_at": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *uploadSessionRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.NotFound(uploadSessionNotFound)
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return utils.NotFound(uploadSessionNotFound)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +13,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const userNotFound = "User tidak ditemukan"
+
 type userRepositoryMongo struct {
 	collection *mongo.Collection
 }
@@ -103,7 +106,7 @@ func (r *userRepositoryMongo) GetByID(id int) (*models.User, error) {
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil // Return nil when no record found
+			return nil, utils.NotFound(userNotFound)
 		}
 		return nil, err
 	}
@@ -121,7 +124,7 @@ func (r *userRepositoryMongo) GetByEmail(email string) (*models.User, error) {
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil // Return nil when no record found
+			return nil, utils.NotFound(userNotFound)
 		}
 		return nil, err
 	}
@@ -139,7 +142,7 @@ func (r *userRepositoryMongo) GetByUsername(username string) (*models.User, erro
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil // Return nil when no record found
+			return nil, utils.NotFound(userNotFound)
 		}
 		return nil, err
 	}
@@ -155,6 +158,7 @@ func (r *userRepositoryMongo) Create(user *models.User) error {
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	user.Version = 1
 
 	// Get next ID
 	nextID, err := r.getNextSequenceID()
@@ -164,7 +168,7 @@ func (r *userRepositoryMongo) Create(user *models.User) error {
 	user.ID = nextID
 
 	_, err = r.collection.InsertOne(ctx, user)
-	return err
+	return translateError(err, userNotFound)
 }
 
 func (r *userRepositoryMongo) Update(user *models.User) error {
@@ -173,7 +177,7 @@ func (r *userRepositoryMongo) Update(user *models.User) error {
 
 	user.UpdatedAt = time.Now()
 
-	filter := bson.M{"id": user.ID}
+	filter := bson.M{"id": user.ID, "version": user.Version}
 	update := bson.M{
 		"$set": bson.M{
 			"username":   user.Username,
@@ -183,35 +187,32 @@ func (r *userRepositoryMongo) Update(user *models.User) error {
 			"is_active":  user.IsActive,
 			"updated_at": user.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return translateError(err, userNotFound)
 	}
-
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("user not found")
+	if err := matchedOrStale(ctx, r.collection, result, user.ID, userNotFound); err != nil {
+		return err
 	}
+	user.Version++
 
 	return nil
 }
 
-func (r *userRepositoryMongo) Delete(id int) error {
+func (r *userRepositoryMongo) Delete(id int, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"id": id}
+	filter := withVersion(bson.M{"id": id}, version)
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("user not found")
-	}
-
-	return nil
+	return deletedOrStale(ctx, r.collection, result, id, userNotFound)
 }
 
 func (r *userRepositoryMongo) Count() (int64, error) {
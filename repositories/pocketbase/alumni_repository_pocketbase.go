@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"modul4crud/models"
+	"modul4crud/utils"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
+const alumniNotFound = "Alumni tidak ditemukan"
+
 type AlumniRepositoryPocketBase struct {
 	baseURL string
 	client  *http.Client
@@ -30,10 +34,12 @@ func (r *AlumniRepositoryPocketBase) Create(alumni *models.Alumni) error {
 		"nim":         alumni.NIM,
 		"nama":        alumni.Nama,
 		"jurusan":     alumni.Jurusan,
+		"kode_prodi":  alumni.KodeProdi,
 		"angkatan":    alumni.Angkatan,
 		"tahun_lulus": alumni.TahunLulus,
 		"no_telepon":  alumni.NoTelepon,
 		"alamat":      alumni.Alamat,
+		"version":     1,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -44,8 +50,7 @@ func (r *AlumniRepositoryPocketBase) Create(alumni *models.Alumni) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("create alumni failed (status %d): %s", resp.StatusCode, string(body))
+		return responseError(resp, "create alumni", alumniNotFound)
 	}
 
 	var result map[string]interface{}
@@ -58,6 +63,7 @@ func (r *AlumniRepositoryPocketBase) Create(alumni *models.Alumni) error {
 		fmt.Printf("Created alumni with ID: %s\n", id)
 	}
 
+	alumni.Version = 1
 	return nil
 }
 
@@ -71,7 +77,7 @@ func (r *AlumniRepositoryPocketBase) GetByID(id uint) (*models.Alumni, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+		return nil, utils.NotFound(alumniNotFound)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -82,12 +88,15 @@ func (r *AlumniRepositoryPocketBase) GetByID(id uint) (*models.Alumni, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&alumni); err != nil {
 		return nil, err
 	}
+	if alumni.DeletedAt != nil {
+		return nil, utils.NotFound(alumniNotFound)
+	}
 
 	return &alumni, nil
 }
 
 func (r *AlumniRepositoryPocketBase) GetByUserID(userID int) (*models.Alumni, error) {
-	url := fmt.Sprintf("%s/api/collections/alumnis/records?filter=(user_id=%d)", r.baseURL, userID)
+	url := fmt.Sprintf("%s/api/collections/alumnis/records?filter=(user_id=%d&&%s)", r.baseURL, userID, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -108,7 +117,7 @@ func (r *AlumniRepositoryPocketBase) GetByUserID(userID int) (*models.Alumni, er
 	}
 
 	if len(result.Items) == 0 {
-		return nil, nil
+		return nil, utils.NotFound(alumniNotFound)
 	}
 
 	return &result.Items[0], nil
@@ -116,16 +125,21 @@ func (r *AlumniRepositoryPocketBase) GetByUserID(userID int) (*models.Alumni, er
 
 func (r *AlumniRepositoryPocketBase) Update(alumni *models.Alumni) error {
 	url := fmt.Sprintf("%s/api/collections/alumnis/records/%d", r.baseURL, alumni.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := alumni.Version + 1
 	
 	payload := map[string]interface{}{
 		"user_id":     alumni.UserID,
 		"nim":         alumni.NIM,
 		"nama":        alumni.Nama,
 		"jurusan":     alumni.Jurusan,
+		"kode_prodi":  alumni.KodeProdi,
 		"angkatan":    alumni.Angkatan,
 		"tahun_lulus": alumni.TahunLulus,
 		"no_telepon":  alumni.NoTelepon,
 		"alamat":      alumni.Alamat,
+		"version":     version,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -139,32 +153,66 @@ func (r *AlumniRepositoryPocketBase) Update(alumni *models.Alumni) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("update alumni failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "update alumni", alumniNotFound)
 	}
 
+	alumni.Version = version
 	return nil
 }
 
-func (r *AlumniRepositoryPocketBase) Delete(id uint) error {
+func (r *AlumniRepositoryPocketBase) Delete(id uint, version int) error {
 	url := fmt.Sprintf("%s/api/collections/alumnis/records/%d", r.baseURL, id)
-	
-	req, _ := http.NewRequest("DELETE", url, nil)
-	resp, err := r.client.Do(req)
+	return deleteRecord(r.client, url, version, "delete alumni", alumniNotFound)
+}
+
+// Merge memindahkan pekerjaan alumni sumber ke alumni tujuan, menautkan userID ke
+// alumni tujuan, lalu menandai alumni sumber sebagai hasil merge
+func (r *AlumniRepositoryPocketBase) Merge(targetID uint, sourceIDs []uint, userID int) error {
+	targetURL := fmt.Sprintf("%s/api/collections/alumnis/records/%d", r.baseURL, targetID)
+	version, err := recordVersion(r.client, targetURL, alumniNotFound)
 	if err != nil {
-		return fmt.Errorf("failed to delete alumni: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete alumni failed (status %d)", resp.StatusCode)
+	for _, sourceID := range sourceIDs {
+		if err := r.relinkPekerjaan(sourceID, targetID); err != nil {
+			return err
+		}
+		sourceURL := fmt.Sprintf("%s/api/collections/alumnis/records/%d", r.baseURL, sourceID)
+		if err := markMerged(r.client, sourceURL, targetID, alumniNotFound); err != nil {
+			return err
+		}
+	}
+
+	return patchRecord(r.client, targetURL, map[string]interface{}{
+		"user_id": userID,
+		"version": version + 1,
+	}, "merge alumni", alumniNotFound)
+}
+
+// relinkPekerjaan memindahkan semua pekerjaan (termasuk yang di trash) ke alumni lain
+func (r *AlumniRepositoryPocketBase) relinkPekerjaan(fromID, toID uint) error {
+	type pbRecord struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	}
+	records, err := listAllRecords[pbRecord](r.client, r.baseURL, "pekerjaan_alumnis", fmt.Sprintf("alumni_id=%d", fromID))
+	if err != nil {
+		return err
 	}
 
+	for _, record := range records {
+		endpoint := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%s", r.baseURL, record.ID)
+		payload := map[string]interface{}{"alumni_id": toID, "version": record.Version + 1}
+		if err := patchRecord(r.client, endpoint, payload, "relink pekerjaan", pekerjaanNotFound); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (r *AlumniRepositoryPocketBase) GetAll() ([]models.Alumni, error) {
-	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=500&expand=user", r.baseURL)
+	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=500&expand=user&filter=(%s)", r.baseURL, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -188,13 +236,28 @@ func (r *AlumniRepositoryPocketBase) GetAll() ([]models.Alumni, error) {
 }
 
 func (r *AlumniRepositoryPocketBase) GetWithPagination(pagination *models.PaginationRequest) ([]models.Alumni, int64, error) {
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Pencarian: filter ~ per kata, lalu dinilai dan dipaginasi di sisi aplikasi
+	if terms := models.SearchTerms(pagination.Search); len(terms) > 0 {
+		candidates, err := listAllRecords[models.Alumni](r.client, r.baseURL, "alumnis", alumniFilter(pagination.Search))
+		if err != nil {
+			return nil, 0, err
+		}
+		items, matches, total := searchPage(candidates, terms, pagination, byRelevance, (*models.Alumni).SearchFields)
+		for i := range items {
+			items[i].Search = matches[i]
+		}
+		return items, total, nil
+	}
+
 	page := pagination.Page
 	if page < 1 {
 		page = 1
 	}
 	
-	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=%d&page=%d&expand=user", 
-		r.baseURL, pagination.Limit, page)
+	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=%d&page=%d&expand=user&filter=(%s)", 
+		r.baseURL, pagination.Limit, page, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -219,7 +282,7 @@ func (r *AlumniRepositoryPocketBase) GetWithPagination(pagination *models.Pagina
 }
 
 func (r *AlumniRepositoryPocketBase) Count() (int64, error) {
-	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=1", r.baseURL)
+	url := fmt.Sprintf("%s/api/collections/alumnis/records?perPage=1&filter=(%s)", r.baseURL, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -241,3 +304,143 @@ func (r *AlumniRepositoryPocketBase) Count() (int64, error) {
 
 	return result.TotalItems, nil
 }
+
+// CountByGroup menghitung alumni per tahun lulus atau kode prodi dari seluruh record
+func (r *AlumniRepositoryPocketBase) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	if groupBy != "tahun_lulus" && groupBy != "kode_prodi" {
+		return nil, fmt.Errorf("grup statistik alumni tidak valid: %s", groupBy)
+	}
+
+	alumnis, err := listAllRecords[models.Alumni](r.client, r.baseURL, "alumnis", alumniFilter(search))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.StatGroup, 0, len(alumnis))
+	for _, a := range alumnis {
+		if groupBy == "tahun_lulus" {
+			keys = append(keys, models.StatGroup{Kode: fmt.Sprint(a.TahunLulus)})
+			continue
+		}
+		keys = append(keys, models.StatGroup{Kode: a.KodeProdi, Label: a.Jurusan})
+	}
+
+	return countStatGroups(keys), nil
+}
+
+// GetTracerRecords mengambil alumni beserta pekerjaan pertamanya; pekerjaan
+// dicocokkan di sisi aplikasi karena PocketBase tidak mendukung join
+func (r *AlumniRepositoryPocketBase) GetTracerRecords(filter models.TracerFilter) ([]models.TracerRecord, error) {
+	conditions := []string{notMergedFilter}
+	if filter.TahunLulus > 0 {
+		conditions = append(conditions, fmt.Sprintf("tahun_lulus=%d", filter.TahunLulus))
+	}
+	if filter.Angkatan > 0 {
+		conditions = append(conditions, fmt.Sprintf("angkatan=%d", filter.Angkatan))
+	}
+	if filter.KodeProdi != "" {
+		conditions = append(conditions, fmt.Sprintf("kode_prodi='%s'", escapeFilterValue(filter.KodeProdi)))
+	}
+
+	alumnis, err := listAllRecords[models.Alumni](r.client, r.baseURL, "alumnis", strings.Join(conditions, "&&"))
+	if err != nil {
+		return nil, err
+	}
+	pekerjaans, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", "(deleted_at=null||deleted_at='')")
+	if err != nil {
+		return nil, err
+	}
+
+	// Pekerjaan pertama per alumni: tanggal mulai paling awal, lalu ID terkecil
+	pertama := map[uint]models.PekerjaanAlumni{}
+	for _, p := range pekerjaans {
+		current, ok := pertama[p.AlumniID]
+		if !ok || p.TanggalMulaiKerja.Before(current.TanggalMulaiKerja) ||
+			(p.TanggalMulaiKerja.Equal(current.TanggalMulaiKerja) && p.ID < current.ID) {
+			pertama[p.AlumniID] = p
+		}
+	}
+
+	records := make([]models.TracerRecord, 0, len(alumnis))
+	for _, a := range alumnis {
+		record := models.TracerRecord{
+			AlumniID:   a.ID,
+			Jurusan:    a.Jurusan,
+			KodeProdi:  a.KodeProdi,
+			Angkatan:   a.Angkatan,
+			TahunLulus: a.TahunLulus,
+		}
+		if p, ok := pertama[a.ID]; ok {
+			mulai := p.TanggalMulaiKerja
+			record.TanggalMulaiKerja = &mulai
+			record.KodeIndustri = p.KodeIndustri
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].AlumniID < records[j].AlumniID })
+
+	return records, nil
+}
+
+// CountSurveyCohorts menghitung alumni dan responden survey per cohort di sisi
+// aplikasi karena PocketBase tidak mendukung join maupun GROUP BY
+func (r *AlumniRepositoryPocketBase) CountSurveyCohorts(surveyID uint, tahunLulus []int) ([]models.SurveyCohortCount, error) {
+	filter := notMergedFilter
+	if len(tahunLulus) > 0 {
+		tahun := make([]string, 0, len(tahunLulus))
+		for _, t := range tahunLulus {
+			tahun = append(tahun, fmt.Sprintf("tahun_lulus=%d", t))
+		}
+		filter += "&&(" + strings.Join(tahun, "||") + ")"
+	}
+
+	alumnis, err := listAllRecords[models.Alumni](r.client, r.baseURL, "alumnis", filter)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := listAllRecords[models.SurveyResponse](r.client, r.baseURL, "survey_responses", fmt.Sprintf("survey_id=%d", surveyID))
+	if err != nil {
+		return nil, err
+	}
+
+	responden := map[uint]bool{}
+	for _, response := range responses {
+		responden[response.AlumniID] = true
+	}
+
+	type cohortKey struct {
+		tahunLulus int
+		kodeProdi  string
+		jurusan    string
+	}
+	groups := map[cohortKey]*models.SurveyCohortCount{}
+	counts := []*models.SurveyCohortCount{}
+	for _, a := range alumnis {
+		key := cohortKey{a.TahunLulus, a.KodeProdi, a.Jurusan}
+		count, ok := groups[key]
+		if !ok {
+			count = &models.SurveyCohortCount{TahunLulus: a.TahunLulus, KodeProdi: a.KodeProdi, Jurusan: a.Jurusan}
+			groups[key] = count
+			counts = append(counts, count)
+		}
+		count.Jumlah++
+		if responden[a.ID] {
+			count.Responden++
+		}
+	}
+
+	result := make([]models.SurveyCohortCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	return result, nil
+}
+
+// alumniFilter filter alumni yang belum digabung ditambah filter pencarian,
+// dipakai bersama oleh list dan statistik
+func alumniFilter(search string) string {
+	if filter := searchFilter(search, "nim", "nama", "jurusan"); filter != "" {
+		return notMergedFilter + "&&" + filter
+	}
+	return notMergedFilter
+}
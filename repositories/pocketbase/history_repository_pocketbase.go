@@ -0,0 +1,129 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"modul4crud/models"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const historyNotFound = "Riwayat tidak ditemukan"
+
+type HistoryRepositoryPocketBase struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHistoryRepository(baseURL string) *HistoryRepositoryPocketBase {
+	return &HistoryRepositoryPocketBase{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// pbRecordHistory struktur record riwayat di PocketBase; waktu dibuat memakai field bawaan "created"
+type pbRecordHistory struct {
+	ID        uint                  `json:"id"`
+	Entity    string                `json:"entity"`
+	RecordID  uint                  `json:"record_id"`
+	Version   int                   `json:"version"`
+	Snapshot  models.RecordSnapshot `json:"snapshot"`
+	ChangedBy int                   `json:"changed_by"`
+	Created   string                `json:"created"`
+}
+
+func (pb *pbRecordHistory) toRecordHistory() models.RecordHistory {
+	history := models.RecordHistory{
+		ID:        pb.ID,
+		Entity:    pb.Entity,
+		RecordID:  pb.RecordID,
+		Version:   pb.Version,
+		Snapshot:  pb.Snapshot,
+		ChangedBy: pb.ChangedBy,
+	}
+	if created := parsePBDate(pb.Created); created != nil {
+		history.CreatedAt = *created
+	}
+	return history
+}
+
+func (r *HistoryRepositoryPocketBase) Create(history *models.RecordHistory) error {
+	endpoint := r.baseURL + "/api/collections/record_histories/records"
+
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"entity":     history.Entity,
+		"record_id":  history.RecordID,
+		"version":    history.Version,
+		"snapshot":   history.Snapshot,
+		"changed_by": history.ChangedBy,
+	})
+	resp, err := r.client.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create record history: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "create record history", historyNotFound)
+	}
+
+	var record pbRecordHistory
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return err
+	}
+	*history = record.toRecordHistory()
+
+	return nil
+}
+
+func (r *HistoryRepositoryPocketBase) GetByRecord(entity string, recordID uint) ([]models.RecordHistory, error) {
+	filter := fmt.Sprintf("entity='%s'&&record_id=%d", escapeFilterValue(entity), recordID)
+	records, err := listAllRecords[pbRecordHistory](r.client, r.baseURL, "record_histories", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]models.RecordHistory, 0, len(records))
+	for i := range records {
+		histories = append(histories, records[i].toRecordHistory())
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		if histories[i].Version != histories[j].Version {
+			return histories[i].Version > histories[j].Version
+		}
+		return histories[i].ID > histories[j].ID
+	})
+	return histories, nil
+}
+
+func (r *HistoryRepositoryPocketBase) GetVersion(entity string, recordID uint, version int) (*models.RecordHistory, error) {
+	filter := fmt.Sprintf("entity='%s'&&record_id=%d&&version=%d", escapeFilterValue(entity), recordID, version)
+	endpoint := fmt.Sprintf("%s/api/collections/record_histories/records?perPage=1&sort=-created&filter=%s", r.baseURL, url.QueryEscape(filter))
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get record history: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get record history failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items []pbRecordHistory `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	history := result.Items[0].toRecordHistory()
+	return &history, nil
+}
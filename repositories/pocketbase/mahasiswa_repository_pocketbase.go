@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"modul4crud/models"
+	"modul4crud/utils"
 	"net/http"
 	"time"
 )
 
+const mahasiswaNotFound = "Mahasiswa tidak ditemukan"
+
 type MahasiswaRepositoryPocketBase struct {
 	baseURL string
 	client  *http.Client
@@ -31,6 +33,7 @@ func (r *MahasiswaRepositoryPocketBase) Create(mahasiswa *models.Mahasiswa) erro
 		"jurusan":  mahasiswa.Jurusan,
 		"angkatan": mahasiswa.Angkatan,
 		"email":    mahasiswa.Email,
+		"version":  1,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -41,8 +44,7 @@ func (r *MahasiswaRepositoryPocketBase) Create(mahasiswa *models.Mahasiswa) erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("create mahasiswa failed (status %d): %s", resp.StatusCode, string(body))
+		return responseError(resp, "create mahasiswa", mahasiswaNotFound)
 	}
 
 	var result map[string]interface{}
@@ -55,6 +57,7 @@ func (r *MahasiswaRepositoryPocketBase) Create(mahasiswa *models.Mahasiswa) erro
 		fmt.Printf("Created mahasiswa with ID: %s\n", id)
 	}
 
+	mahasiswa.Version = 1
 	return nil
 }
 
@@ -68,7 +71,7 @@ func (r *MahasiswaRepositoryPocketBase) GetByID(id uint) (*models.Mahasiswa, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+		return nil, utils.NotFound(mahasiswaNotFound)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -79,12 +82,18 @@ func (r *MahasiswaRepositoryPocketBase) GetByID(id uint) (*models.Mahasiswa, err
 	if err := json.NewDecoder(resp.Body).Decode(&mahasiswa); err != nil {
 		return nil, err
 	}
+	if mahasiswa.DeletedAt != nil {
+		return nil, utils.NotFound(mahasiswaNotFound)
+	}
 
 	return &mahasiswa, nil
 }
 
 func (r *MahasiswaRepositoryPocketBase) Update(mahasiswa *models.Mahasiswa) error {
 	url := fmt.Sprintf("%s/api/collections/mahasiswas/records/%d", r.baseURL, mahasiswa.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := mahasiswa.Version + 1
 	
 	payload := map[string]interface{}{
 		"nim":      mahasiswa.NIM,
@@ -92,6 +101,7 @@ func (r *MahasiswaRepositoryPocketBase) Update(mahasiswa *models.Mahasiswa) erro
 		"jurusan":  mahasiswa.Jurusan,
 		"angkatan": mahasiswa.Angkatan,
 		"email":    mahasiswa.Email,
+		"version":  version,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -105,32 +115,38 @@ func (r *MahasiswaRepositoryPocketBase) Update(mahasiswa *models.Mahasiswa) erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("update mahasiswa failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "update mahasiswa", mahasiswaNotFound)
 	}
 
+	mahasiswa.Version = version
 	return nil
 }
 
-func (r *MahasiswaRepositoryPocketBase) Delete(id uint) error {
+func (r *MahasiswaRepositoryPocketBase) Delete(id uint, version int) error {
 	url := fmt.Sprintf("%s/api/collections/mahasiswas/records/%d", r.baseURL, id)
-	
-	req, _ := http.NewRequest("DELETE", url, nil)
-	resp, err := r.client.Do(req)
+	return deleteRecord(r.client, url, version, "delete mahasiswa", mahasiswaNotFound)
+}
+
+// Merge menandai mahasiswa sumber sebagai hasil merge lalu menaikkan versi mahasiswa tujuan
+func (r *MahasiswaRepositoryPocketBase) Merge(targetID uint, sourceIDs []uint) error {
+	targetURL := fmt.Sprintf("%s/api/collections/mahasiswas/records/%d", r.baseURL, targetID)
+	version, err := recordVersion(r.client, targetURL, mahasiswaNotFound)
 	if err != nil {
-		return fmt.Errorf("failed to delete mahasiswa: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete mahasiswa failed (status %d)", resp.StatusCode)
+	for _, sourceID := range sourceIDs {
+		sourceURL := fmt.Sprintf("%s/api/collections/mahasiswas/records/%d", r.baseURL, sourceID)
+		if err := markMerged(r.client, sourceURL, targetID, mahasiswaNotFound); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return patchRecord(r.client, targetURL, map[string]interface{}{"version": version + 1}, "merge mahasiswa", mahasiswaNotFound)
 }
 
 func (r *MahasiswaRepositoryPocketBase) GetAll() ([]models.Mahasiswa, error) {
-	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=500", r.baseURL)
+	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=500&filter=(%s)", r.baseURL, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -154,13 +170,28 @@ func (r *MahasiswaRepositoryPocketBase) GetAll() ([]models.Mahasiswa, error) {
 }
 
 func (r *MahasiswaRepositoryPocketBase) GetWithPagination(pagination *models.PaginationRequest) ([]models.Mahasiswa, int64, error) {
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Pencarian: filter ~ per kata, lalu dinilai dan dipaginasi di sisi aplikasi
+	if terms := models.SearchTerms(pagination.Search); len(terms) > 0 {
+		candidates, err := listAllRecords[models.Mahasiswa](r.client, r.baseURL, "mahasiswas", notMergedFilter+"&&"+searchFilter(pagination.Search, "nim", "nama", "jurusan", "email"))
+		if err != nil {
+			return nil, 0, err
+		}
+		items, matches, total := searchPage(candidates, terms, pagination, byRelevance, (*models.Mahasiswa).SearchFields)
+		for i := range items {
+			items[i].Search = matches[i]
+		}
+		return items, total, nil
+	}
+
 	page := pagination.Page
 	if page < 1 {
 		page = 1
 	}
 	
-	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=%d&page=%d", 
-		r.baseURL, pagination.Limit, page)
+	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=%d&page=%d&filter=(%s)", 
+		r.baseURL, pagination.Limit, page, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
@@ -185,7 +216,7 @@ func (r *MahasiswaRepositoryPocketBase) GetWithPagination(pagination *models.Pag
 }
 
 func (r *MahasiswaRepositoryPocketBase) Count() (int64, error) {
-	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=1", r.baseURL)
+	url := fmt.Sprintf("%s/api/collections/mahasiswas/records?perPage=1&filter=(%s)", r.baseURL, notMergedFilter)
 	
 	resp, err := r.client.Get(url)
 	if err != nil {
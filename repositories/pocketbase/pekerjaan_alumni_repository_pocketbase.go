@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"modul4crud/models"
+	"modul4crud/utils"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
+const pekerjaanNotFound = "Pekerjaan alumni tidak ditemukan"
+
 type PekerjaanAlumniRepositoryPocketBase struct {
 	baseURL string
 	client  *http.Client
@@ -27,15 +31,24 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Create(pekerjaan *models.Pekerjaan
 	
 	payload := map[string]interface{}{
 		"alumni_id":               pekerjaan.AlumniID,
+		"perusahaan_id":           pekerjaan.PerusahaanID,
 		"nama_perusahaan":         pekerjaan.NamaPerusahaan,
 		"posisi_jabatan":          pekerjaan.PosisiJabatan,
 		"bidang_industri":         pekerjaan.BidangIndustri,
+		"kode_industri":           pekerjaan.KodeIndustri,
 		"lokasi_kerja":            pekerjaan.LokasiKerja,
+		"kode_provinsi":           pekerjaan.KodeProvinsi,
+		"kode_kota":               pekerjaan.KodeKota,
 		"gaji_range":              pekerjaan.GajiRange,
+		"gaji_min":                pekerjaan.GajiMin,
+		"gaji_max":                pekerjaan.GajiMax,
+		"gaji_mata_uang":          pekerjaan.GajiMataUang,
+		"gaji_periode":            pekerjaan.GajiPeriode,
 		"tanggal_mulai_kerja":     pekerjaan.TanggalMulaiKerja,
 		"tanggal_selesai_kerja":   pekerjaan.TanggalSelesaiKerja,
 		"status_pekerjaan":        pekerjaan.StatusPekerjaan,
 		"deskripsi_pekerjaan":     pekerjaan.DeskripsiPekerjaan,
+		"version":                 1,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -46,8 +59,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Create(pekerjaan *models.Pekerjaan
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("create pekerjaan failed (status %d): %s", resp.StatusCode, string(body))
+		return responseError(resp, "create pekerjaan", pekerjaanNotFound)
 	}
 
 	var result map[string]interface{}
@@ -60,6 +72,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Create(pekerjaan *models.Pekerjaan
 		fmt.Printf("Created pekerjaan with ID: %s\n", id)
 	}
 
+	pekerjaan.Version = 1
 	return nil
 }
 
@@ -73,7 +86,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) GetByID(id uint) (*models.Pekerjaa
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+		return nil, utils.NotFound(pekerjaanNotFound)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -112,6 +125,33 @@ func (r *PekerjaanAlumniRepositoryPocketBase) GetByAlumniID(alumniID uint) ([]mo
 	return result.Items, nil
 }
 
+func (r *PekerjaanAlumniRepositoryPocketBase) GetAllByAlumniIDs(alumniIDs []uint) ([]models.PekerjaanAlumni, error) {
+	return r.listAllIn("alumni_id", alumniIDs)
+}
+
+func (r *PekerjaanAlumniRepositoryPocketBase) GetAllByPerusahaanIDs(perusahaanIDs []uint) ([]models.PekerjaanAlumni, error) {
+	return r.listAllIn("perusahaan_id", perusahaanIDs)
+}
+
+// listAllIn pekerjaan yang field-nya berisi salah satu ids, termasuk yang di-soft delete
+func (r *PekerjaanAlumniRepositoryPocketBase) listAllIn(field string, ids []uint) ([]models.PekerjaanAlumni, error) {
+	if len(ids) == 0 {
+		return []models.PekerjaanAlumni{}, nil
+	}
+
+	conditions := make([]string, len(ids))
+	for i, id := range ids {
+		conditions[i] = fmt.Sprintf("%s=%d", field, id)
+	}
+	pekerjaans, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", strings.Join(conditions, "||"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pekerjaans, func(i, j int) bool { return pekerjaans[i].ID < pekerjaans[j].ID })
+	return pekerjaans, nil
+}
+
 func (r *PekerjaanAlumniRepositoryPocketBase) GetByUserID(userID int) ([]models.PekerjaanAlumni, error) {
 	// First, get alumni by user_id
 	alumniURL := fmt.Sprintf("%s/api/collections/alumnis/records?filter=(user_id=%d)", r.baseURL, userID)
@@ -144,18 +184,30 @@ func (r *PekerjaanAlumniRepositoryPocketBase) GetByUserID(userID int) ([]models.
 
 func (r *PekerjaanAlumniRepositoryPocketBase) Update(pekerjaan *models.PekerjaanAlumni) error {
 	url := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%d", r.baseURL, pekerjaan.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := pekerjaan.Version + 1
 	
 	payload := map[string]interface{}{
 		"alumni_id":               pekerjaan.AlumniID,
+		"perusahaan_id":           pekerjaan.PerusahaanID,
 		"nama_perusahaan":         pekerjaan.NamaPerusahaan,
 		"posisi_jabatan":          pekerjaan.PosisiJabatan,
 		"bidang_industri":         pekerjaan.BidangIndustri,
+		"kode_industri":           pekerjaan.KodeIndustri,
 		"lokasi_kerja":            pekerjaan.LokasiKerja,
+		"kode_provinsi":           pekerjaan.KodeProvinsi,
+		"kode_kota":               pekerjaan.KodeKota,
 		"gaji_range":              pekerjaan.GajiRange,
+		"gaji_min":                pekerjaan.GajiMin,
+		"gaji_max":                pekerjaan.GajiMax,
+		"gaji_mata_uang":          pekerjaan.GajiMataUang,
+		"gaji_periode":            pekerjaan.GajiPeriode,
 		"tanggal_mulai_kerja":     pekerjaan.TanggalMulaiKerja,
 		"tanggal_selesai_kerja":   pekerjaan.TanggalSelesaiKerja,
 		"status_pekerjaan":        pekerjaan.StatusPekerjaan,
 		"deskripsi_pekerjaan":     pekerjaan.DeskripsiPekerjaan,
+		"version":                 version,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -169,37 +221,37 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Update(pekerjaan *models.Pekerjaan
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("update pekerjaan failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "update pekerjaan", pekerjaanNotFound)
 	}
 
+	pekerjaan.Version = version
 	return nil
 }
 
-func (r *PekerjaanAlumniRepositoryPocketBase) Delete(id uint) error {
+func (r *PekerjaanAlumniRepositoryPocketBase) Delete(id uint, version int) error {
 	url := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%d", r.baseURL, id)
-	
-	req, _ := http.NewRequest("DELETE", url, nil)
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to delete pekerjaan: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete pekerjaan failed (status %d)", resp.StatusCode)
-	}
-
-	return nil
+	return deleteRecord(r.client, url, version, "delete pekerjaan", pekerjaanNotFound)
 }
 
 // Soft delete in PocketBase - using deleted_at field
-func (r *PekerjaanAlumniRepositoryPocketBase) SoftDelete(id uint) error {
+func (r *PekerjaanAlumniRepositoryPocketBase) SoftDelete(id uint, version int) error {
 	url := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%d", r.baseURL, id)
+
+	// Tanpa version versi terbaru yang dipakai; updateRule tetap menolak PATCH
+	// jika versi berubah di antaranya
+	if version == 0 {
+		current, err := recordVersion(r.client, url, pekerjaanNotFound)
+		if err != nil {
+			return err
+		}
+		version = current
+	}
+	next := version + 1
 	
 	now := time.Now()
 	payload := map[string]interface{}{
 		"deleted_at": now.Format(time.RFC3339),
+		"version":    next,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -213,8 +265,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) SoftDelete(id uint) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("soft delete pekerjaan failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "soft delete pekerjaan", pekerjaanNotFound)
 	}
 
 	return nil
@@ -229,7 +280,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) SoftDeleteByAlumniID(alumniID uint
 
 	// Soft delete each one
 	for _, p := range pekerjaans {
-		if err := r.SoftDelete(p.ID); err != nil {
+		if err := r.SoftDelete(p.ID, 0); err != nil {
 			return err
 		}
 	}
@@ -239,9 +290,15 @@ func (r *PekerjaanAlumniRepositoryPocketBase) SoftDeleteByAlumniID(alumniID uint
 
 func (r *PekerjaanAlumniRepositoryPocketBase) Restore(id uint) error {
 	url := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%d", r.baseURL, id)
+
+	version, err := recordVersion(r.client, url, pekerjaanNotFound)
+	if err != nil {
+		return err
+	}
 	
 	payload := map[string]interface{}{
 		"deleted_at": nil,
+		"version":    version + 1,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -255,8 +312,7 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Restore(id uint) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("restore pekerjaan failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "restore pekerjaan", pekerjaanNotFound)
 	}
 
 	return nil
@@ -362,6 +418,21 @@ func (r *PekerjaanAlumniRepositoryPocketBase) GetAll() ([]models.PekerjaanAlumni
 }
 
 func (r *PekerjaanAlumniRepositoryPocketBase) GetWithPagination(pagination *models.PaginationRequest) ([]models.PekerjaanAlumni, int64, error) {
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Pencarian: filter ~ per kata, lalu dinilai dan dipaginasi di sisi aplikasi
+	if terms := models.SearchTerms(pagination.Search); len(terms) > 0 {
+		candidates, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", "(deleted_at=null||deleted_at='')&&" + searchFilter(pagination.Search, "posisi_jabatan", "nama_perusahaan", "bidang_industri", "lokasi_kerja", "deskripsi_pekerjaan"))
+		if err != nil {
+			return nil, 0, err
+		}
+		items, matches, total := searchPage(candidates, terms, pagination, byRelevance, (*models.PekerjaanAlumni).SearchFields)
+		for i := range items {
+			items[i].Search = matches[i]
+		}
+		return items, total, nil
+	}
+
 	page := pagination.Page
 	if page < 1 {
 		page = 1
@@ -416,27 +487,88 @@ func (r *PekerjaanAlumniRepositoryPocketBase) Count() (int64, error) {
 	return result.TotalItems, nil
 }
 
-func (r *PekerjaanAlumniRepositoryPocketBase) GetAlumniCountByCompany(namaPerusahaan string) (int64, error) {
-	url := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records?perPage=1&filter=(nama_perusahaan='%s'&&(deleted_at=null||deleted_at=''))", 
-		r.baseURL, namaPerusahaan)
-	
-	resp, err := r.client.Get(url)
+// GetSalarySamples mengambil data gaji terstruktur beserta kunci grup untuk statistik gaji
+func (r *PekerjaanAlumniRepositoryPocketBase) GetSalarySamples(groupBy string, mataUang string) ([]models.SalarySample, error) {
+	switch groupBy {
+	case "", "jurusan", "tahun_lulus", "bidang_industri", "lokasi_kerja":
+	default:
+		return nil, fmt.Errorf("grup statistik gaji tidak valid: %s", groupBy)
+	}
+
+	filter := fmt.Sprintf("(deleted_at=null||deleted_at='')&&gaji_mata_uang='%s'&&(gaji_min>0||gaji_max>0)", escapeFilterValue(mataUang))
+	pekerjaans, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", filter)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count alumni by company: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("count alumni by company failed (status %d)", resp.StatusCode)
+	// Jurusan dan tahun lulus ada di collection alumnis
+	alumniByID := map[uint]models.Alumni{}
+	if groupBy == "jurusan" || groupBy == "tahun_lulus" {
+		alumnis, err := listAllRecords[models.Alumni](r.client, r.baseURL, "alumnis", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range alumnis {
+			alumniByID[a.ID] = a
+		}
 	}
 
-	var result struct {
-		TotalItems int64 `json:"totalItems"`
+	samples := make([]models.SalarySample, 0, len(pekerjaans))
+	for _, p := range pekerjaans {
+		sample := models.SalarySample{
+			GroupKey:    "semua",
+			GajiMin:     p.GajiMin,
+			GajiMax:     p.GajiMax,
+			GajiPeriode: p.GajiPeriode,
+		}
+		switch groupBy {
+		case "jurusan":
+			sample.GroupKey = alumniByID[p.AlumniID].Jurusan
+		case "tahun_lulus":
+			sample.GroupKey = fmt.Sprint(alumniByID[p.AlumniID].TahunLulus)
+		case "bidang_industri":
+			sample.GroupKey = p.BidangIndustri
+		case "lokasi_kerja":
+			sample.GroupKey = p.LokasiKerja
+		}
+		samples = append(samples, sample)
 	}
-	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, err
+
+	return samples, nil
+}
+
+// CountByGroup menghitung pekerjaan aktif per kode industri, kota, atau provinsi dari seluruh record
+func (r *PekerjaanAlumniRepositoryPocketBase) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	switch groupBy {
+	case "kode_industri", "kode_kota", "kode_provinsi":
+	default:
+		return nil, fmt.Errorf("grup statistik pekerjaan tidak valid: %s", groupBy)
 	}
 
-	return result.TotalItems, nil
+	filter := "(deleted_at=null||deleted_at='')"
+	if search := searchFilter(search, "posisi_jabatan", "nama_perusahaan", "bidang_industri", "lokasi_kerja"); search != "" {
+		filter += "&&" + search
+	}
+	pekerjaans, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.StatGroup, 0, len(pekerjaans))
+	for _, p := range pekerjaans {
+		switch groupBy {
+		case "kode_industri":
+			keys = append(keys, models.StatGroup{Kode: p.KodeIndustri, Label: p.BidangIndustri})
+		case "kode_kota":
+			kode := p.KodeKota
+			if kode == "" {
+				kode = p.KodeProvinsi
+			}
+			keys = append(keys, models.StatGroup{Kode: kode, Label: p.LokasiKerja})
+		case "kode_provinsi":
+			keys = append(keys, models.StatGroup{Kode: p.KodeProvinsi, Label: p.LokasiKerja})
+		}
+	}
+
+	return countStatGroups(keys), nil
 }
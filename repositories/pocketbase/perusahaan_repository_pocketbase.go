@@ -0,0 +1,355 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const perusahaanNotFound = "Perusahaan tidak ditemukan"
+
+type PerusahaanRepositoryPocketBase struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewPerusahaanRepository(baseURL string) *PerusahaanRepositoryPocketBase {
+	return &PerusahaanRepositoryPocketBase{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// pbPerusahaan struktur record perusahaan di PocketBase
+type pbPerusahaan struct {
+	ID             uint      `json:"id"`
+	Nama           string    `json:"nama"`
+	NamaNormal     string    `json:"nama_normal"`
+	BidangIndustri string    `json:"bidang_industri"`
+	Lokasi         string    `json:"lokasi"`
+	Website        string    `json:"website"`
+	Aliases        []string  `json:"aliases"`
+	AliasNormals   []string  `json:"alias_normals"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Version        int       `json:"version"`
+}
+
+func (pb *pbPerusahaan) toPerusahaan() models.Perusahaan {
+	aliases := pb.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return models.Perusahaan{
+		ID:             pb.ID,
+		Nama:           pb.Nama,
+		NamaNormal:     pb.NamaNormal,
+		BidangIndustri: pb.BidangIndustri,
+		Lokasi:         pb.Lokasi,
+		Website:        pb.Website,
+		Aliases:        aliases,
+		AliasNormals:   pb.AliasNormals,
+		CreatedAt:      pb.CreatedAt,
+		UpdatedAt:      pb.UpdatedAt,
+		Version:        pb.Version,
+	}
+}
+
+func perusahaanPayload(perusahaan *models.Perusahaan) map[string]interface{} {
+	return map[string]interface{}{
+		"nama":            perusahaan.Nama,
+		"nama_normal":     perusahaan.NamaNormal,
+		"bidang_industri": perusahaan.BidangIndustri,
+		"lokasi":          perusahaan.Lokasi,
+		"website":         perusahaan.Website,
+		"aliases":         perusahaan.Aliases,
+		"alias_normals":   utils.NormalizeAliases(perusahaan.Aliases),
+	}
+}
+
+func (r *PerusahaanRepositoryPocketBase) GetWithPagination(pagination *models.PaginationRequest) ([]models.Perusahaan, int64, error) {
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Pencarian: filter ~ per kata, lalu dinilai dan dipaginasi di sisi aplikasi
+	if terms := models.SearchTerms(pagination.Search); len(terms) > 0 {
+		records, err := listAllRecords[pbPerusahaan](r.client, r.baseURL, "perusahaans", searchFilter(pagination.Search, "nama", "aliases", "bidang_industri", "lokasi"))
+		if err != nil {
+			return nil, 0, err
+		}
+		candidates := make([]models.Perusahaan, len(records))
+		for i := range records {
+			candidates[i] = records[i].toPerusahaan()
+		}
+		items, matches, total := searchPage(candidates, terms, pagination, byRelevance, (*models.Perusahaan).SearchFields)
+		for i := range items {
+			items[i].Search = matches[i]
+		}
+		return items, total, nil
+	}
+
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(pagination.Page))
+	query.Set("perPage", fmt.Sprint(pagination.Limit))
+	endpoint := fmt.Sprintf("%s/api/collections/perusahaans/records?%s", r.baseURL, query.Encode())
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get perusahaans: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("get perusahaans failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items      []pbPerusahaan `json:"items"`
+		TotalItems int64          `json:"totalItems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, err
+	}
+
+	perusahaans := make([]models.Perusahaan, len(result.Items))
+	for i := range result.Items {
+		perusahaans[i] = result.Items[i].toPerusahaan()
+	}
+	return perusahaans, result.TotalItems, nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) GetByID(id uint) (*models.Perusahaan, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/perusahaans/records/%d", r.baseURL, id)
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get perusahaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, utils.NotFound(perusahaanNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get perusahaan failed (status %d)", resp.StatusCode)
+	}
+
+	var record pbPerusahaan
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	perusahaan := record.toPerusahaan()
+	return &perusahaan, nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) FindByNamaNormal(namaNormal string) (*models.Perusahaan, error) {
+	value := escapeFilterValue(namaNormal)
+	// alias_normals berupa JSON array, jadi elemen dicari lengkap dengan tanda kutipnya
+	filter := fmt.Sprintf(`nama_normal='%s'||alias_normals~'"%s"'`, value, value)
+	endpoint := fmt.Sprintf("%s/api/collections/perusahaans/records?perPage=1&filter=%s", r.baseURL, url.QueryEscape(filter))
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find perusahaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("find perusahaan failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items []pbPerusahaan `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	perusahaan := result.Items[0].toPerusahaan()
+	return &perusahaan, nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) Create(perusahaan *models.Perusahaan) error {
+	endpoint := r.baseURL + "/api/collections/perusahaans/records"
+
+	payload := perusahaanPayload(perusahaan)
+	payload["version"] = 1
+	jsonData, _ := json.Marshal(payload)
+	resp, err := r.client.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create perusahaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "create perusahaan", perusahaanNotFound)
+	}
+
+	var record pbPerusahaan
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return err
+	}
+	*perusahaan = record.toPerusahaan()
+
+	return nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) Update(perusahaan *models.Perusahaan) error {
+	endpoint := fmt.Sprintf("%s/api/collections/perusahaans/records/%d", r.baseURL, perusahaan.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := perusahaan.Version + 1
+
+	payload := perusahaanPayload(perusahaan)
+	payload["version"] = version
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update perusahaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return versionedError(r.client, resp, endpoint, "update perusahaan", perusahaanNotFound)
+	}
+
+	perusahaan.Version = version
+
+	// Nama yang tampil pada pekerjaan ikut diperbarui
+	return r.relinkPekerjaan(perusahaan.ID, perusahaan.ID, perusahaan.Nama)
+}
+
+func (r *PerusahaanRepositoryPocketBase) Delete(id uint) error {
+	endpoint := fmt.Sprintf("%s/api/collections/perusahaans/records/%d", r.baseURL, id)
+
+	req, _ := http.NewRequest("DELETE", endpoint, nil)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete perusahaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, "delete perusahaan", perusahaanNotFound)
+	}
+
+	return nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) Merge(targetID uint, sourceIDs []uint) error {
+	target, err := r.GetByID(targetID)
+	if err != nil {
+		return err
+	}
+
+	// Nama dan alias perusahaan sumber menjadi alias perusahaan tujuan
+	aliases := append([]string{}, target.Aliases...)
+	for _, sourceID := range sourceIDs {
+		source, err := r.GetByID(sourceID)
+		if err != nil {
+			return err
+		}
+		aliases = append(aliases, source.Nama)
+		aliases = append(aliases, source.Aliases...)
+	}
+
+	// Alias disimpan lebih dulu dan sumber dihapus paling akhir, supaya merge
+	// yang gagal di tengah jalan bisa dijalankan ulang (lihat versi MongoDB)
+	target.Aliases = utils.UniqueAliases(aliases, target.NamaNormal)
+	if err := r.Update(target); err != nil {
+		return err
+	}
+
+	for _, sourceID := range sourceIDs {
+		if err := r.relinkPekerjaan(sourceID, targetID, target.Nama); err != nil {
+			return err
+		}
+		if err := r.Delete(sourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) CountAlumni(id uint) (int64, error) {
+	filter := fmt.Sprintf("perusahaan_id=%d&&(deleted_at=null||deleted_at='')", id)
+	pekerjaans, err := listAllRecords[models.PekerjaanAlumni](r.client, r.baseURL, "pekerjaan_alumnis", filter)
+	if err != nil {
+		return 0, err
+	}
+
+	alumniIDs := make(map[uint]bool)
+	for _, p := range pekerjaans {
+		alumniIDs[p.AlumniID] = true
+	}
+	return int64(len(alumniIDs)), nil
+}
+
+func (r *PerusahaanRepositoryPocketBase) CountPekerjaan(id uint) (int64, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records?perPage=1&filter=(perusahaan_id=%d)", r.baseURL, id)
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pekerjaan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("count pekerjaan failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		TotalItems int64 `json:"totalItems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.TotalItems, nil
+}
+
+// relinkPekerjaan memindahkan semua pekerjaan dari satu perusahaan ke perusahaan lain
+func (r *PerusahaanRepositoryPocketBase) relinkPekerjaan(fromID, toID uint, nama string) error {
+	type pbRecord struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	}
+	records, err := listAllRecords[pbRecord](r.client, r.baseURL, "pekerjaan_alumnis", fmt.Sprintf("perusahaan_id=%d", fromID))
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"perusahaan_id":   toID,
+			"nama_perusahaan": nama,
+			"version":         record.Version + 1,
+		})
+		endpoint := fmt.Sprintf("%s/api/collections/pekerjaan_alumnis/records/%s", r.baseURL, record.ID)
+		req, _ := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to relink pekerjaan: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := versionedError(r.client, resp, endpoint, "relink pekerjaan", pekerjaanNotFound)
+			resp.Body.Close()
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
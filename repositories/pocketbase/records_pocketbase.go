@@ -0,0 +1,285 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pocketBaseMaxPerPage batas maksimal perPage yang diterima PocketBase list API
+const pocketBaseMaxPerPage = 500
+
+// listAllRecords mengambil seluruh record sebuah collection halaman demi halaman,
+// karena PocketBase membatasi jumlah record per request
+func listAllRecords[T any](client *http.Client, baseURL, collection, filter string) ([]T, error) {
+	var all []T
+	page := 1
+
+	for {
+		query := url.Values{}
+		query.Set("page", fmt.Sprint(page))
+		query.Set("perPage", fmt.Sprint(pocketBaseMaxPerPage))
+		if filter != "" {
+			query.Set("filter", filter)
+		}
+		endpoint := fmt.Sprintf("%s/api/collections/%s/records?%s", baseURL, collection, query.Encode())
+
+		resp, err := client.Get(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", collection, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("list %s failed (status %d)", collection, resp.StatusCode)
+		}
+
+		var result struct {
+			Items      []T `json:"items"`
+			TotalPages int `json:"totalPages"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, result.Items...)
+		if page >= result.TotalPages || len(result.Items) == 0 {
+			return all, nil
+		}
+		page++
+	}
+}
+
+// responseError membaca response gagal dari PocketBase dan mengubahnya menjadi
+// error domain: 404 menjadi NOT_FOUND, validation_not_unique menjadi CONFLICT
+func responseError(resp *http.Response, action string, notFoundMessage string) error {
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		return utils.NotFound(notFoundMessage)
+	}
+
+	var result struct {
+		Data map[string]struct {
+			Code string `json:"code"`
+		} `json:"data"`
+	}
+	if resp.StatusCode == http.StatusBadRequest && json.Unmarshal(body, &result) == nil {
+		for field, fieldErr := range result.Data {
+			if fieldErr.Code == "validation_not_unique" {
+				appErr := utils.DuplicateError(field)
+				appErr.Err = fmt.Errorf("%s failed (status %d): %s", action, resp.StatusCode, string(body))
+				return appErr
+			}
+		}
+	}
+	return fmt.Errorf("%s failed (status %d): %s", action, resp.StatusCode, string(body))
+}
+
+// recordVersion membaca nomor versi terbaru sebuah record, untuk tulis tanpa
+// If-Match (merge, restore) yang tetap harus menaikkan versi
+func recordVersion(client *http.Client, recordURL string, notFoundMessage string) (int, error) {
+	resp, err := client.Get(recordURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read record version: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError(resp, "read record version", notFoundMessage)
+	}
+
+	var record struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return 0, err
+	}
+	return record.Version, nil
+}
+
+// versionedError seperti responseError untuk PATCH/DELETE ke collection yang
+// punya updateRule/deleteRule versi (lihat migrasi PocketBase). Rule yang menolak
+// versi basi dijawab PocketBase dengan 404, jadi record dibaca ulang: jika masih
+// ada berarti PRECONDITION_FAILED, bukan NOT_FOUND.
+func versionedError(client *http.Client, resp *http.Response, recordURL string, action string, notFoundMessage string) error {
+	if resp.StatusCode != http.StatusNotFound {
+		return responseError(resp, action, notFoundMessage)
+	}
+	if _, err := recordVersion(client, recordURL, notFoundMessage); err != nil {
+		return err
+	}
+	return utils.VersionMismatch()
+}
+
+// notMergedFilter filter alumni/mahasiswa yang belum digabung ke record lain.
+// deleted_at dan merged_into di PocketBase bertipe json supaya nilai kosongnya null.
+const notMergedFilter = "deleted_at=null"
+
+// patchRecord mengirim PATCH berisi payload ke sebuah record
+func patchRecord(client *http.Client, recordURL string, payload map[string]interface{}, action string, notFoundMessage string) error {
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PATCH", recordURL, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %v", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return versionedError(client, resp, recordURL, action, notFoundMessage)
+	}
+	return nil
+}
+
+// deleteRecord menghapus sebuah record. Dengan version > 0 versi dikirim sebagai
+// query ?version= yang dicek deleteRule, sehingga record hanya dihapus jika
+// versinya masih sama dengan yang dibaca service.
+func deleteRecord(client *http.Client, recordURL string, version int, action string, notFoundMessage string) error {
+	endpoint := recordURL
+	if version > 0 {
+		endpoint = fmt.Sprintf("%s?version=%d", recordURL, version)
+	}
+
+	req, _ := http.NewRequest("DELETE", endpoint, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %v", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return versionedError(client, resp, recordURL, action, notFoundMessage)
+	}
+	return nil
+}
+
+// markMerged soft delete record duplikat: deleted_at diisi dan merged_into menunjuk record tujuan
+func markMerged(client *http.Client, recordURL string, targetID uint, notFoundMessage string) error {
+	version, err := recordVersion(client, recordURL, notFoundMessage)
+	if err != nil {
+		return err
+	}
+	return patchRecord(client, recordURL, map[string]interface{}{
+		"deleted_at":  time.Now().UTC().Format(time.RFC3339),
+		"merged_into": targetID,
+		"version":     version + 1,
+	}, "merge record", notFoundMessage)
+}
+
+// escapeFilterValue meng-escape tanda kutip agar nilai aman dipakai di filter PocketBase
+func escapeFilterValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `'`, `\'`)
+}
+
+// pocketBaseDateLayout format field date yang dikembalikan PocketBase
+const pocketBaseDateLayout = "2006-01-02 15:04:05.000Z"
+
+// parsePBDate mengubah field date PocketBase ke *time.Time; string kosong berarti nil
+func parsePBDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range []string{pocketBaseDateLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// formatPBDate mengubah *time.Time ke format field date PocketBase; nil dikirim sebagai ""
+func formatPBDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(pocketBaseDateLayout)
+}
+
+// searchFilter membangun filter pencarian "contains" per kata: setiap kata harus ada
+// di salah satu field, seperti pencarian full-text di PostgreSQL/MongoDB
+func searchFilter(search string, fields ...string) string {
+	terms := models.SearchTerms(search)
+	if len(terms) == 0 {
+		return ""
+	}
+	groups := make([]string, 0, len(terms))
+	for _, term := range terms {
+		conditions := make([]string, 0, len(fields))
+		for _, field := range fields {
+			conditions = append(conditions, fmt.Sprintf("%s~'%s'", field, escapeFilterValue(term)))
+		}
+		groups = append(groups, "("+strings.Join(conditions, "||")+")")
+	}
+	return strings.Join(groups, "&&")
+}
+
+// searchPage menilai kandidat hasil filter searchFilter di sisi aplikasi (PocketBase
+// tidak punya ranking): kata harus cocok sebagai awalan kata, hasil diurutkan
+// relevansi jika diminta, lalu dipotong sesuai halaman
+func searchPage[T any](items []T, terms []string, pagination *models.PaginationRequest, byRelevance bool, fields func(*T) []models.SearchField) ([]T, []*models.SearchMatch, int64) {
+	type hit struct {
+		item  T
+		match *models.SearchMatch
+	}
+	hits := make([]hit, 0, len(items))
+	for i := range items {
+		if match := models.MatchSearch(terms, fields(&items[i])); match != nil {
+			hits = append(hits, hit{item: items[i], match: match})
+		}
+	}
+	if byRelevance {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].match.Score > hits[j].match.Score })
+	}
+
+	start := min(pagination.GetOffset(), len(hits))
+	end := min(start+pagination.Limit, len(hits))
+	page := make([]T, 0, end-start)
+	matches := make([]*models.SearchMatch, 0, end-start)
+	for _, h := range hits[start:end] {
+		page = append(page, h.item)
+		matches = append(matches, h.match)
+	}
+	return page, matches, int64(len(hits))
+}
+
+// countStatGroups menjumlahkan grup hasil pembacaan record (PocketBase tidak punya
+// GROUP BY di list API). Label hanya dipertahankan untuk grup yang kodenya kosong.
+func countStatGroups(keys []models.StatGroup) []models.StatGroup {
+	counts := map[models.StatGroup]int64{}
+	for _, key := range keys {
+		if key.Kode != "" {
+			key.Label = ""
+		}
+		key.Count = 0
+		counts[key]++
+	}
+
+	groups := make([]models.StatGroup, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		groups = append(groups, key)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if groups[i].Kode != groups[j].Kode {
+			return groups[i].Kode < groups[j].Kode
+		}
+		return groups[i].Label < groups[j].Label
+	})
+	return groups
+}
@@ -0,0 +1,186 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"modul4crud/models"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// referensiUsageFields collection dan field yang menyimpan kode referensi untuk setiap kategori
+var referensiUsageFields = map[string][2]string{
+	models.KategoriIndustri: {"pekerjaan_alumnis", "kode_industri"},
+	models.KategoriProvinsi: {"pekerjaan_alumnis", "kode_provinsi"},
+	models.KategoriKota:     {"pekerjaan_alumnis", "kode_kota"},
+	models.KategoriProdi:    {"alumnis", "kode_prodi"},
+}
+
+const referensiNotFound = "Referensi tidak ditemukan"
+
+type ReferensiRepositoryPocketBase struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewReferensiRepository(baseURL string) *ReferensiRepositoryPocketBase {
+	return &ReferensiRepositoryPocketBase{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func referensiPayload(referensi *models.Referensi) map[string]interface{} {
+	aliases := referensi.Aliases
+	if aliases == nil {
+		aliases = models.StringList{}
+	}
+	kodeTerkait := referensi.KodeTerkait
+	if kodeTerkait == nil {
+		kodeTerkait = models.StringList{}
+	}
+	return map[string]interface{}{
+		"kategori":     referensi.Kategori,
+		"kode":         referensi.Kode,
+		"nama":         referensi.Nama,
+		"kode_induk":   referensi.KodeInduk,
+		"aliases":      aliases,
+		"kode_terkait": kodeTerkait,
+	}
+}
+
+func (r *ReferensiRepositoryPocketBase) GetByKategori(kategori string) ([]models.Referensi, error) {
+	filter := fmt.Sprintf("kategori='%s'", escapeFilterValue(kategori))
+	referensis, err := listAllRecords[models.Referensi](r.client, r.baseURL, "referensis", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range referensis {
+		if referensis[i].Aliases == nil {
+			referensis[i].Aliases = models.StringList{}
+		}
+	}
+	return referensis, nil
+}
+
+func (r *ReferensiRepositoryPocketBase) GetByKode(kategori string, kode string) (*models.Referensi, error) {
+	filter := fmt.Sprintf("kategori='%s'&&kode='%s'", escapeFilterValue(kategori), escapeFilterValue(kode))
+	endpoint := fmt.Sprintf("%s/api/collections/referensis/records?perPage=1&filter=%s", r.baseURL, url.QueryEscape(filter))
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referensi: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get referensi failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items []models.Referensi `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	return &result.Items[0], nil
+}
+
+func (r *ReferensiRepositoryPocketBase) Create(referensi *models.Referensi) error {
+	endpoint := r.baseURL + "/api/collections/referensis/records"
+
+	payload := referensiPayload(referensi)
+	payload["version"] = 1
+	jsonData, _ := json.Marshal(payload)
+	resp, err := r.client.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create referensi: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "create referensi", referensiNotFound)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(referensi)
+}
+
+func (r *ReferensiRepositoryPocketBase) Update(referensi *models.Referensi) error {
+	endpoint := fmt.Sprintf("%s/api/collections/referensis/records/%d", r.baseURL, referensi.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := referensi.Version + 1
+
+	payload := referensiPayload(referensi)
+	payload["version"] = version
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update referensi: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return versionedError(r.client, resp, endpoint, "update referensi", referensiNotFound)
+	}
+
+	referensi.Version = version
+	return nil
+}
+
+func (r *ReferensiRepositoryPocketBase) Delete(id uint) error {
+	endpoint := fmt.Sprintf("%s/api/collections/referensis/records/%d", r.baseURL, id)
+
+	req, _ := http.NewRequest("DELETE", endpoint, nil)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete referensi: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, "delete referensi", referensiNotFound)
+	}
+
+	return nil
+}
+
+// CountUsage sengaja tanpa filter deleted_at: record yang di-soft delete masih bisa di-restore
+func (r *ReferensiRepositoryPocketBase) CountUsage(kategori string, kode string) (int64, error) {
+	usage, ok := referensiUsageFields[kategori]
+	if !ok {
+		return 0, nil
+	}
+
+	filter := fmt.Sprintf("%s='%s'", usage[1], escapeFilterValue(kode))
+	endpoint := fmt.Sprintf("%s/api/collections/%s/records?perPage=1&filter=%s", r.baseURL, usage[0], url.QueryEscape(filter))
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %v", usage[0], err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("count %s failed (status %d)", usage[0], resp.StatusCode)
+	}
+
+	var result struct {
+		TotalItems int64 `json:"totalItems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.TotalItems, nil
+}
@@ -0,0 +1,333 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const surveyNotFound = "Survey tidak ditemukan"
+
+type SurveyRepositoryPocketBase struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewSurveyRepository(baseURL string) *SurveyRepositoryPocketBase {
+	return &SurveyRepositoryPocketBase{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// pbSurvey struktur record survey di PocketBase; field date kosong dikirim sebagai ""
+type pbSurvey struct {
+	ID               uint                    `json:"id"`
+	Kode             string                  `json:"kode"`
+	Versi            int                     `json:"versi"`
+	Judul            string                  `json:"judul"`
+	Deskripsi        string                  `json:"deskripsi"`
+	Status           string                  `json:"status"`
+	TargetTahunLulus []int                   `json:"target_tahun_lulus"`
+	TargetJurusan    []string                `json:"target_jurusan"`
+	Pertanyaan       []models.SurveyQuestion `json:"pertanyaan"`
+	DibukaAt         string                  `json:"dibuka_at"`
+	DitutupAt        string                  `json:"ditutup_at"`
+	Version          int                     `json:"version"`
+}
+
+func (pb *pbSurvey) toSurvey() models.Survey {
+	return models.Survey{
+		ID:               pb.ID,
+		Kode:             pb.Kode,
+		Versi:            pb.Versi,
+		Judul:            pb.Judul,
+		Deskripsi:        pb.Deskripsi,
+		Status:           pb.Status,
+		TargetTahunLulus: pb.TargetTahunLulus,
+		TargetJurusan:    pb.TargetJurusan,
+		Pertanyaan:       pb.Pertanyaan,
+		DibukaAt:         parsePBDate(pb.DibukaAt),
+		DitutupAt:        parsePBDate(pb.DitutupAt),
+		Version:          pb.Version,
+	}
+}
+
+func surveyPayload(survey *models.Survey) map[string]interface{} {
+	return map[string]interface{}{
+		"kode":               survey.Kode,
+		"versi":              survey.Versi,
+		"judul":              survey.Judul,
+		"deskripsi":          survey.Deskripsi,
+		"status":             survey.Status,
+		"target_tahun_lulus": survey.TargetTahunLulus,
+		"target_jurusan":     survey.TargetJurusan,
+		"pertanyaan":         survey.Pertanyaan,
+		"dibuka_at":          formatPBDate(survey.DibukaAt),
+		"ditutup_at":         formatPBDate(survey.DitutupAt),
+	}
+}
+
+func (r *SurveyRepositoryPocketBase) GetAll(status string) ([]models.Survey, error) {
+	filter := ""
+	if status != "" {
+		filter = fmt.Sprintf("status='%s'", escapeFilterValue(status))
+	}
+	records, err := listAllRecords[pbSurvey](r.client, r.baseURL, "surveys", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	surveys := make([]models.Survey, len(records))
+	for i := range records {
+		surveys[i] = records[i].toSurvey()
+	}
+	sort.Slice(surveys, func(i, j int) bool {
+		if surveys[i].Kode != surveys[j].Kode {
+			return surveys[i].Kode < surveys[j].Kode
+		}
+		return surveys[i].Versi > surveys[j].Versi
+	})
+	return surveys, nil
+}
+
+func (r *SurveyRepositoryPocketBase) GetByID(id uint) (*models.Survey, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/surveys/records/%d", r.baseURL, id)
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, utils.NotFound(surveyNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get survey failed (status %d)", resp.StatusCode)
+	}
+
+	var record pbSurvey
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	survey := record.toSurvey()
+	return &survey, nil
+}
+
+func (r *SurveyRepositoryPocketBase) GetLatestVersi(kode string) (int, error) {
+	query := url.Values{}
+	query.Set("perPage", "1")
+	query.Set("sort", "-versi")
+	query.Set("filter", fmt.Sprintf("kode='%s'", escapeFilterValue(kode)))
+	endpoint := fmt.Sprintf("%s/api/collections/surveys/records?%s", r.baseURL, query.Encode())
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get survey versi: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("get survey versi failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items []pbSurvey `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	if len(result.Items) == 0 {
+		return 0, nil
+	}
+	return result.Items[0].Versi, nil
+}
+
+func (r *SurveyRepositoryPocketBase) Create(survey *models.Survey) error {
+	endpoint := r.baseURL + "/api/collections/surveys/records"
+
+	payload := surveyPayload(survey)
+	payload["version"] = 1
+	jsonData, _ := json.Marshal(payload)
+	resp, err := r.client.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create survey: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "create survey", surveyNotFound)
+	}
+
+	var record pbSurvey
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return err
+	}
+	*survey = record.toSurvey()
+
+	return nil
+}
+
+func (r *SurveyRepositoryPocketBase) Update(survey *models.Survey) error {
+	endpoint := fmt.Sprintf("%s/api/collections/surveys/records/%d", r.baseURL, survey.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := survey.Version + 1
+
+	payload := surveyPayload(survey)
+	payload["version"] = version
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update survey: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return versionedError(r.client, resp, endpoint, "update survey", surveyNotFound)
+	}
+
+	survey.Version = version
+	return nil
+}
+
+func (r *SurveyRepositoryPocketBase) Delete(id uint) error {
+	endpoint := fmt.Sprintf("%s/api/collections/surveys/records/%d", r.baseURL, id)
+
+	req, _ := http.NewRequest("DELETE", endpoint, nil)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete survey: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp, "delete survey", surveyNotFound)
+	}
+
+	return nil
+}
+
+func (r *SurveyRepositoryPocketBase) GetResponses(surveyID uint) ([]models.SurveyResponse, error) {
+	filter := fmt.Sprintf("survey_id=%d", surveyID)
+	responses, err := listAllRecords[models.SurveyResponse](r.client, r.baseURL, "survey_responses", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(responses, func(i, j int) bool { return responses[i].ID < responses[j].ID })
+	return responses, nil
+}
+
+func (r *SurveyRepositoryPocketBase) GetResponse(surveyID uint, alumniID uint) (*models.SurveyResponse, error) {
+	filter := fmt.Sprintf("survey_id=%d&&alumni_id=%d", surveyID, alumniID)
+	endpoint := fmt.Sprintf("%s/api/collections/survey_responses/records?perPage=1&filter=%s", r.baseURL, url.QueryEscape(filter))
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get survey response failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Items []models.SurveyResponse `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	return &result.Items[0], nil
+}
+
+func (r *SurveyRepositoryPocketBase) SaveResponse(response *models.SurveyResponse) error {
+	existing, err := r.GetResponse(response.SurveyID, response.AlumniID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		err = r.writeResponse("POST", r.baseURL+"/api/collections/survey_responses/records", response)
+		// Jawaban yang dikirim bersamaan: unique index (survey_id, alumni_id) menolak
+		// create kedua, yang kemudian mengganti jawaban pada record yang sudah dibuat
+		if !errors.Is(err, utils.ErrConflict) {
+			return err
+		}
+		if existing, err = r.GetResponse(response.SurveyID, response.AlumniID); err != nil {
+			return err
+		}
+		if existing == nil {
+			return utils.NotFound(surveyNotFound)
+		}
+	}
+
+	// Jawaban yang sudah ada diganti pada record yang sama
+	endpoint := fmt.Sprintf("%s/api/collections/survey_responses/records/%d", r.baseURL, existing.ID)
+	return r.writeResponse("PATCH", endpoint, response)
+}
+
+// writeResponse mengirim jawaban survey ke PocketBase (POST untuk record baru, PATCH untuk mengganti)
+func (r *SurveyRepositoryPocketBase) writeResponse(method, endpoint string, response *models.SurveyResponse) error {
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"survey_id": response.SurveyID,
+		"alumni_id": response.AlumniID,
+		"jawaban":   response.Jawaban,
+	})
+
+	req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to save survey response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp, "save survey response", surveyNotFound)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+func (r *SurveyRepositoryPocketBase) CountResponses(surveyID uint) (int64, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/survey_responses/records?perPage=1&filter=(survey_id=%d)", r.baseURL, surveyID)
+
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count survey responses: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("count survey responses failed (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		TotalItems int64 `json:"totalItems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.TotalItems, nil
+}
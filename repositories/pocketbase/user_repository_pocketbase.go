@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"modul4crud/models"
+	"modul4crud/utils"
 	"net/http"
 	"time"
 )
@@ -34,6 +35,8 @@ func (pb *pbUser) ToUser() *models.User {
 	}
 }
 
+const userNotFound = "User tidak ditemukan"
+
 type UserRepositoryPocketBase struct {
 	baseURL string
 	client  *http.Client
@@ -99,6 +102,7 @@ func (r *UserRepositoryPocketBase) Create(user *models.User) error {
 		"passwordConfirm": user.Password,
 		"role":            user.Role,
 		"is_active":       user.IsActive,
+		"version":         1,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -109,8 +113,7 @@ func (r *UserRepositoryPocketBase) Create(user *models.User) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("create user failed (status %d): %s", resp.StatusCode, string(body))
+		return responseError(resp, "create user", userNotFound)
 	}
 
 	var result map[string]interface{}
@@ -124,6 +127,7 @@ func (r *UserRepositoryPocketBase) Create(user *models.User) error {
 		fmt.Printf("Created user with ID: %s\n", id)
 	}
 
+	user.Version = 1
 	return nil
 }
 
@@ -137,7 +141,7 @@ func (r *UserRepositoryPocketBase) GetByID(id int) (*models.User, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+		return nil, utils.NotFound(userNotFound)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -175,7 +179,7 @@ func (r *UserRepositoryPocketBase) GetByEmail(email string) (*models.User, error
 	}
 
 	if len(result.Items) == 0 {
-		return nil, nil // User not found
+		return nil, utils.NotFound(userNotFound)
 	}
 
 	// Convert pbUser to models.User
@@ -205,7 +209,7 @@ func (r *UserRepositoryPocketBase) GetByUsername(username string) (*models.User,
 	}
 
 	if len(result.Items) == 0 {
-		return nil, nil // User not found
+		return nil, utils.NotFound(userNotFound)
 	}
 
 	// Convert pbUser to models.User
@@ -214,11 +218,15 @@ func (r *UserRepositoryPocketBase) GetByUsername(username string) (*models.User,
 
 func (r *UserRepositoryPocketBase) Update(user *models.User) error {
 	url := fmt.Sprintf("%s/api/collections/users/records/%d", r.baseURL, user.ID)
+
+	// updateRule menolak PATCH ini (404) jika versi record sudah berubah
+	version := user.Version + 1
 	
 	payload := map[string]interface{}{
 		"username": user.Username,
 		"email":    user.Email,
 		"role":     user.Role,
+		"version":  version,
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -232,28 +240,16 @@ func (r *UserRepositoryPocketBase) Update(user *models.User) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("update user failed (status %d): %s", resp.StatusCode, string(body))
+		return versionedError(r.client, resp, url, "update user", userNotFound)
 	}
 
+	user.Version = version
 	return nil
 }
 
-func (r *UserRepositoryPocketBase) Delete(id int) error {
+func (r *UserRepositoryPocketBase) Delete(id int, version int) error {
 	url := fmt.Sprintf("%s/api/collections/users/records/%d", r.baseURL, id)
-	
-	req, _ := http.NewRequest("DELETE", url, nil)
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to delete user: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete user failed (status %d)", resp.StatusCode)
-	}
-
-	return nil
+	return deleteRecord(r.client, url, version, "delete user", userNotFound)
 }
 
 func (r *UserRepositoryPocketBase) GetAll() ([]models.User, error) {
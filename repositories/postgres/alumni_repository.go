@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+const alumniNotFound = "Alumni tidak ditemukan"
+
 type alumniRepository struct {
 	db *gorm.DB
 }
@@ -21,15 +25,16 @@ func (r *alumniRepository) GetAll() ([]models.Alumni, error) {
 
 	query := `
 		SELECT 
-			a.id, a.user_id, a.nim, a.nama, a.jurusan, 
+			a.id, a.user_id, a.nim, a.nama, a.jurusan, a.kode_prodi, 
 			a.angkatan, a.tahun_lulus, a.no_telepon, a.alamat, 
-			a.created_at, a.updated_at,
+			a.created_at, a.updated_at, a.version,
 			u.id as "User__id", u.username as "User__username", 
 			u.email as "User__email", u.role as "User__role", 
 			u.is_active as "User__is_active", u.created_at as "User__created_at", 
 			u.updated_at as "User__updated_at"
 		FROM alumnis a
 		LEFT JOIN users u ON a.user_id = u.id
+		WHERE a.deleted_at IS NULL
 		ORDER BY a.id DESC
 	`
 
@@ -42,8 +47,7 @@ func (r *alumniRepository) GetWithPagination(pagination *models.PaginationReques
 	var total int64
 
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
+	byRelevance := pagination.SetSearchDefaults()
 
 	// Count query
 	countQuery := `
@@ -53,19 +57,7 @@ func (r *alumniRepository) GetWithPagination(pagination *models.PaginationReques
 	`
 
 	// Search filter
-	searchCondition := ""
-	searchArgs := []interface{}{}
-	if pagination.Search != "" {
-		searchPattern := "%" + pagination.Search + "%"
-		searchCondition = ` WHERE (
-			a.nim ILIKE ? OR 
-			a.nama ILIKE ? OR 
-			a.jurusan ILIKE ? OR 
-			CAST(a.tahun_lulus AS TEXT) ILIKE ? OR 
-			u.email ILIKE ?
-		)`
-		searchArgs = []interface{}{searchPattern, searchPattern, searchPattern, searchPattern, searchPattern}
-	}
+	searchCondition, searchArgs := alumniSearchCondition(pagination.Search)
 
 	// Execute count query
 	err := r.db.Raw(countQuery+searchCondition, searchArgs...).Scan(&total).Error
@@ -76,9 +68,9 @@ func (r *alumniRepository) GetWithPagination(pagination *models.PaginationReques
 	// Data query
 	dataQuery := `
 		SELECT 
-			a.id, a.user_id, a.nim, a.nama, a.jurusan, 
+			a.id, a.user_id, a.nim, a.nama, a.jurusan, a.kode_prodi, 
 			a.angkatan, a.tahun_lulus, a.no_telepon, a.alamat, 
-			a.created_at, a.updated_at,
+			a.created_at, a.updated_at, a.version,
 			u.id as "User__id", u.username as "User__username", 
 			u.email as "User__email", u.role as "User__role", 
 			u.is_active as "User__is_active", u.created_at as "User__created_at", 
@@ -90,14 +82,42 @@ func (r *alumniRepository) GetWithPagination(pagination *models.PaginationReques
 	// Add search condition to data query
 	dataQuery += searchCondition
 
+	dataArgs := append([]interface{}{}, searchArgs...)
+
 	// Add sorting and pagination
-	dataQuery += fmt.Sprintf(" ORDER BY a.%s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	if byRelevance {
+		dataQuery += " ORDER BY ts_rank(a.search_vector, to_tsquery('simple', ?)) DESC, a.id ASC LIMIT ? OFFSET ?"
+		dataArgs = append(dataArgs, tsQuery(models.SearchTerms(pagination.Search)))
+	} else {
+		dataQuery += fmt.Sprintf(" ORDER BY a.%s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	}
 
 	// Prepare arguments for data query
-	dataArgs := append(searchArgs, pagination.Limit, pagination.GetOffset())
+	dataArgs = append(dataArgs, pagination.Limit, pagination.GetOffset())
 
-	err = r.db.Raw(dataQuery, dataArgs...).Scan(&alumnis).Error
-	return alumnis, total, err
+	if err = r.db.Raw(dataQuery, dataArgs...).Scan(&alumnis).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if len(searchArgs) > 0 && len(alumnis) > 0 {
+		ids := make([]uint, len(alumnis))
+		for i := range alumnis {
+			ids[i] = alumnis[i].ID
+		}
+		terms := models.SearchTerms(pagination.Search)
+		ranks, err := searchRanks(r.db, `
+			SELECT id, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank
+			FROM alumnis WHERE id IN ?
+		`, tsQuery(terms), ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range alumnis {
+			alumnis[i].Search = models.NewSearchMatch(terms, alumnis[i].SearchFields(), ranks[alumnis[i].ID])
+		}
+	}
+
+	return alumnis, total, nil
 }
 
 func (r *alumniRepository) GetByID(id uint) (*models.Alumni, error) {
@@ -105,22 +125,25 @@ func (r *alumniRepository) GetByID(id uint) (*models.Alumni, error) {
 
 	query := `
 		SELECT 
-			a.id, a.user_id, a.nim, a.nama, a.jurusan, 
+			a.id, a.user_id, a.nim, a.nama, a.jurusan, a.kode_prodi, 
 			a.angkatan, a.tahun_lulus, a.no_telepon, a.alamat, 
-			a.created_at, a.updated_at,
+			a.created_at, a.updated_at, a.version,
 			u.id as "User__id", u.username as "User__username", 
 			u.email as "User__email", u.role as "User__role", 
 			u.is_active as "User__is_active", u.created_at as "User__created_at", 
 			u.updated_at as "User__updated_at"
 		FROM alumnis a
 		LEFT JOIN users u ON a.user_id = u.id
-		WHERE a.id = ?
+		WHERE a.id = ? AND a.deleted_at IS NULL
 	`
 
 	err := r.db.Raw(query, id).Scan(&alumni).Error
 	if err != nil {
 		return nil, err
 	}
+	if alumni.ID == 0 {
+		return nil, utils.NotFound(alumniNotFound)
+	}
 	return &alumni, nil
 }
 
@@ -129,75 +152,219 @@ func (r *alumniRepository) GetByUserID(userID int) (*models.Alumni, error) {
 
 	query := `
 		SELECT 
-			a.id, a.user_id, a.nim, a.nama, a.jurusan, 
+			a.id, a.user_id, a.nim, a.nama, a.jurusan, a.kode_prodi, 
 			a.angkatan, a.tahun_lulus, a.no_telepon, a.alamat, 
-			a.created_at, a.updated_at,
+			a.created_at, a.updated_at, a.version,
 			u.id as "User__id", u.username as "User__username", 
 			u.email as "User__email", u.role as "User__role", 
 			u.is_active as "User__is_active", u.created_at as "User__created_at", 
 			u.updated_at as "User__updated_at"
 		FROM alumnis a
 		LEFT JOIN users u ON a.user_id = u.id
-		WHERE a.user_id = ?
+		WHERE a.user_id = ? AND a.deleted_at IS NULL
 	`
 
 	err := r.db.Raw(query, userID).Scan(&alumni).Error
 	if err != nil {
 		return nil, err
 	}
+	if alumni.ID == 0 {
+		return nil, utils.NotFound(alumniNotFound)
+	}
 	return &alumni, nil
 }
 
 func (r *alumniRepository) Create(alumni *models.Alumni) error {
 	query := `
 		INSERT INTO alumnis 
-		(user_id, nim, nama, jurusan, angkatan, tahun_lulus, no_telepon, alamat, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
-		RETURNING id, created_at, updated_at
+		(user_id, nim, nama, jurusan, kode_prodi, angkatan, tahun_lulus, no_telepon, alamat, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		RETURNING id, created_at, updated_at, version
 	`
 
-	return r.db.Raw(query,
+	err := r.db.Raw(query,
 		alumni.UserID,
 		alumni.NIM,
 		alumni.Nama,
 		alumni.Jurusan,
+		alumni.KodeProdi,
 		alumni.Angkatan,
 		alumni.TahunLulus,
 		alumni.NoTelepon,
 		alumni.Alamat,
 	).Scan(alumni).Error
+	return translateError(err, alumniNotFound)
 }
 
 func (r *alumniRepository) Update(alumni *models.Alumni) error {
 	query := `
 		UPDATE alumnis 
-		SET nim = ?, nama = ?, jurusan = ?, angkatan = ?, 
-		    tahun_lulus = ?, no_telepon = ?, alamat = ?, updated_at = NOW()
-		WHERE id = ?
-		RETURNING updated_at
+		SET nim = ?, nama = ?, jurusan = ?, kode_prodi = ?, angkatan = ?, 
+		    tahun_lulus = ?, no_telepon = ?, alamat = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+		RETURNING updated_at, version
 	`
 
-	return r.db.Raw(query,
+	result := r.db.Raw(query,
 		alumni.NIM,
 		alumni.Nama,
 		alumni.Jurusan,
+		alumni.KodeProdi,
 		alumni.Angkatan,
 		alumni.TahunLulus,
 		alumni.NoTelepon,
 		alumni.Alamat,
 		alumni.ID,
-	).Scan(alumni).Error
+		alumni.Version,
+	).Scan(alumni)
+	return affectedOrStale(r.db, result, "alumnis", alumni.ID, alumniNotFound)
+}
+
+func (r *alumniRepository) Delete(id uint, version int) error {
+	query := `DELETE FROM alumnis WHERE id = ? AND (? = 0 OR version = ?)`
+	result := r.db.Exec(query, id, version, version)
+	return affectedOrStale(r.db, result, "alumnis", id, alumniNotFound)
 }
 
-func (r *alumniRepository) Delete(id uint) error {
-	query := `DELETE FROM alumnis WHERE id = ?`
-	result := r.db.Exec(query, id)
-	return result.Error
+// Merge memindahkan pekerjaan alumni sumber ke alumni tujuan, menautkan userID ke
+// alumni tujuan, lalu soft delete alumni sumber dengan merged_into = targetID
+func (r *alumniRepository) Merge(targetID uint, sourceIDs []uint, userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE pekerjaan_alumnis SET alumni_id = ?, version = version + 1
+			WHERE alumni_id IN ?
+		`, targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`
+			UPDATE alumnis SET deleted_at = NOW(), merged_into = ?, updated_at = NOW(), version = version + 1
+			WHERE id IN ? AND deleted_at IS NULL
+		`, targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Exec(`
+			UPDATE alumnis SET user_id = ?, updated_at = NOW(), version = version + 1
+			WHERE id = ? AND deleted_at IS NULL
+		`, userID, targetID)
+		return affectedOrNotFound(result, alumniNotFound)
+	})
 }
 
 func (r *alumniRepository) Count() (int64, error) {
 	var count int64
-	query := `SELECT COUNT(*) FROM alumnis`
+	query := `SELECT COUNT(*) FROM alumnis WHERE deleted_at IS NULL`
 	err := r.db.Raw(query).Scan(&count).Error
 	return count, err
 }
+
+// CountByGroup menghitung alumni per tahun lulus atau kode prodi dengan GROUP BY
+func (r *alumniRepository) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	groupColumns := map[string][2]string{
+		"tahun_lulus": {"CAST(a.tahun_lulus AS TEXT)", "''"},
+		"kode_prodi":  {"COALESCE(a.kode_prodi, '')", "COALESCE(a.jurusan, '')"},
+	}
+	columns, ok := groupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik alumni tidak valid: %s", groupBy)
+	}
+
+	searchCondition, searchArgs := alumniSearchCondition(search)
+
+	// Label hanya dipakai untuk data yang kodenya kosong
+	query := fmt.Sprintf(`
+		SELECT g.kode, CASE WHEN g.kode = '' THEN g.label ELSE '' END AS label, COUNT(*) AS count
+		FROM (
+			SELECT %s AS kode, %s AS label
+			FROM alumnis a
+			LEFT JOIN users u ON a.user_id = u.id
+			%s
+		) g
+		GROUP BY 1, 2
+		ORDER BY count DESC, kode ASC, label ASC
+	`, columns[0], columns[1], searchCondition)
+
+	var groups []models.StatGroup
+	err := r.db.Raw(query, searchArgs...).Scan(&groups).Error
+	return groups, err
+}
+
+// GetTracerRecords mengambil alumni beserta pekerjaan pertamanya dengan LATERAL join
+func (r *alumniRepository) GetTracerRecords(filter models.TracerFilter) ([]models.TracerRecord, error) {
+	conditions := []string{"a.deleted_at IS NULL"}
+	args := []interface{}{}
+	if filter.TahunLulus > 0 {
+		conditions = append(conditions, "a.tahun_lulus = ?")
+		args = append(args, filter.TahunLulus)
+	}
+	if filter.Angkatan > 0 {
+		conditions = append(conditions, "a.angkatan = ?")
+		args = append(args, filter.Angkatan)
+	}
+	if filter.KodeProdi != "" {
+		conditions = append(conditions, "a.kode_prodi = ?")
+		args = append(args, filter.KodeProdi)
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(`
+		SELECT 
+			a.id AS alumni_id, a.jurusan, COALESCE(a.kode_prodi, '') AS kode_prodi, 
+			a.angkatan, a.tahun_lulus, 
+			pj.tanggal_mulai_kerja, COALESCE(pj.kode_industri, '') AS kode_industri
+		FROM alumnis a
+		LEFT JOIN LATERAL (
+			SELECT pa.tanggal_mulai_kerja, pa.kode_industri
+			FROM pekerjaan_alumnis pa
+			WHERE pa.alumni_id = a.id AND pa.deleted_at IS NULL
+			ORDER BY pa.tanggal_mulai_kerja ASC, pa.id ASC
+			LIMIT 1
+		) pj ON TRUE
+		%s
+		ORDER BY a.id
+	`, whereClause)
+
+	var records []models.TracerRecord
+	err := r.db.Raw(query, args...).Scan(&records).Error
+	return records, err
+}
+
+// CountSurveyCohorts menghitung alumni dan responden survey per cohort dengan GROUP BY
+func (r *alumniRepository) CountSurveyCohorts(surveyID uint, tahunLulus []int) ([]models.SurveyCohortCount, error) {
+	conditions := []string{"a.deleted_at IS NULL"}
+	args := []interface{}{surveyID}
+	if len(tahunLulus) > 0 {
+		conditions = append(conditions, "a.tahun_lulus IN ?")
+		args = append(args, tahunLulus)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			a.tahun_lulus, COALESCE(a.kode_prodi, '') AS kode_prodi, a.jurusan, 
+			COUNT(*) AS jumlah, COUNT(sr.id) AS responden
+		FROM alumnis a
+		LEFT JOIN survey_responses sr ON sr.alumni_id = a.id AND sr.survey_id = ?
+		WHERE %s
+		GROUP BY a.tahun_lulus, COALESCE(a.kode_prodi, ''), a.jurusan
+	`, strings.Join(conditions, " AND "))
+
+	var counts []models.SurveyCohortCount
+	err := r.db.Raw(query, args...).Scan(&counts).Error
+	return counts, err
+}
+
+// alumniSearchCondition kondisi full-text pencarian alumni (kolom search_vector)
+// ditambah email user yang tertaut (u.email, query harus LEFT JOIN users u),
+// dipakai bersama oleh list dan statistik. Alumni yang sudah digabung tidak ikut.
+func alumniSearchCondition(search string) (string, []interface{}) {
+	terms := models.SearchTerms(search)
+	if len(terms) == 0 {
+		return ` WHERE a.deleted_at IS NULL`, []interface{}{}
+	}
+	return ` WHERE a.deleted_at IS NULL AND (a.search_vector @@ to_tsquery('simple', ?) OR u.email ILIKE ?)`,
+		[]interface{}{tsQuery(terms), likePattern(search)}
+}
@@ -0,0 +1,66 @@
+package postgre
+
+import (
+	"errors"
+	"modul4crud/utils"
+	"regexp"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Kode SQLSTATE unique_violation
+const pgUniqueViolation = "23505"
+
+// Detail error unique violation berbentuk: Key (nim)=(123) already exists.
+var pgDuplicateKeyPattern = regexp.MustCompile(`Key \(([^)]+)\)=`)
+
+// translateError mengubah error database menjadi error domain: unique violation
+// menjadi CONFLICT, record not found menjadi NOT_FOUND
+func translateError(err error, notFoundMessage string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.NotFound(notFoundMessage)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		field := ""
+		if match := pgDuplicateKeyPattern.FindStringSubmatch(pgErr.Detail); match != nil {
+			field = match[1]
+		}
+		appErr := utils.DuplicateError(field)
+		appErr.Err = err
+		return appErr
+	}
+	return err
+}
+
+// affectedOrNotFound mengembalikan NOT_FOUND jika query tidak mengenai satu baris pun
+func affectedOrNotFound(result *gorm.DB, notFoundMessage string) error {
+	if result.Error != nil {
+		return translateError(result.Error, notFoundMessage)
+	}
+	if result.RowsAffected == 0 {
+		return utils.NotFound(notFoundMessage)
+	}
+	return nil
+}
+
+// affectedOrStale untuk UPDATE/DELETE bersyarat "WHERE id = ? AND version = ?". Jika
+// tidak ada baris yang berubah, record dicek ulang untuk membedakan NOT_FOUND
+// dari versi yang sudah berubah sejak dibaca (PRECONDITION_FAILED).
+func affectedOrStale(db *gorm.DB, result *gorm.DB, table string, id interface{}, notFoundMessage string) error {
+	if result.Error != nil || result.RowsAffected > 0 {
+		return affectedOrNotFound(result, notFoundMessage)
+	}
+	var exists bool
+	if err := db.Raw(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = ?)`, id).Scan(&exists).Error; err != nil {
+		return err
+	}
+	if !exists {
+		return utils.NotFound(notFoundMessage)
+	}
+	return utils.VersionMismatch()
+}
@@ -0,0 +1,65 @@
+package postgre
+
+import (
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+
+	"gorm.io/gorm"
+)
+
+type historyRepository struct {
+	db *gorm.DB
+}
+
+func NewHistoryRepository(db *gorm.DB) repo.HistoryRepository {
+	return &historyRepository{db: db}
+}
+
+func (r *historyRepository) Create(history *models.RecordHistory) error {
+	query := `
+		INSERT INTO record_histories (entity, record_id, version, snapshot, changed_by, created_at)
+		VALUES (?, ?, ?, ?, ?, NOW())
+		RETURNING id, created_at
+	`
+
+	return r.db.Raw(query,
+		history.Entity,
+		history.RecordID,
+		history.Version,
+		history.Snapshot,
+		history.ChangedBy,
+	).Scan(history).Error
+}
+
+func (r *historyRepository) GetByRecord(entity string, recordID uint) ([]models.RecordHistory, error) {
+	var histories []models.RecordHistory
+
+	query := `
+		SELECT id, entity, record_id, version, snapshot, changed_by, created_at
+		FROM record_histories
+		WHERE entity = ? AND record_id = ?
+		ORDER BY version DESC, id DESC
+	`
+
+	err := r.db.Raw(query, entity, recordID).Scan(&histories).Error
+	return histories, err
+}
+
+func (r *historyRepository) GetVersion(entity string, recordID uint, version int) (*models.RecordHistory, error) {
+	var histories []models.RecordHistory
+
+	query := `
+		SELECT id, entity, record_id, version, snapshot, changed_by, created_at
+		FROM record_histories
+		WHERE entity = ? AND record_id = ? AND version = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	if err := r.db.Raw(query, entity, recordID, version).Scan(&histories).Error; err != nil {
+		return nil, err
+	}
+	if len(histories) == 0 {
+		return nil, nil
+	}
+	return &histories[0], nil
+}
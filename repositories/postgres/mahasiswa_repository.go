@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 
 	"gorm.io/gorm"
 )
 
+const mahasiswaNotFound = "Mahasiswa tidak ditemukan"
+
 type mahasiswaRepository struct {
 	db *gorm.DB
 }
@@ -20,8 +23,9 @@ func (r *mahasiswaRepository) GetAll() ([]models.Mahasiswa, error) {
 	var mahasiswas []models.Mahasiswa
 	
 	query := `
-		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at
+		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at, version
 		FROM mahasiswas
+		WHERE deleted_at IS NULL
 		ORDER BY id DESC
 	`
 	
@@ -34,25 +38,18 @@ func (r *mahasiswaRepository) GetWithPagination(pagination *models.PaginationReq
 	var total int64
 	
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
+	byRelevance := pagination.SetSearchDefaults()
 	
 	// Count query
 	countQuery := `SELECT COUNT(*) FROM mahasiswas`
 	
-	// Search filter
-	searchCondition := ""
+	// Search filter - full-text pada kolom search_vector (GIN index)
+	terms := models.SearchTerms(pagination.Search)
+	searchCondition := ` WHERE deleted_at IS NULL`
 	searchArgs := []interface{}{}
-	if pagination.Search != "" {
-		searchPattern := "%" + pagination.Search + "%"
-		searchCondition = ` WHERE (
-			nim ILIKE ? OR 
-			nama ILIKE ? OR 
-			jurusan ILIKE ? OR 
-			CAST(angkatan AS TEXT) ILIKE ? OR 
-			email ILIKE ?
-		)`
-		searchArgs = []interface{}{searchPattern, searchPattern, searchPattern, searchPattern, searchPattern}
+	if len(terms) > 0 {
+		searchCondition += ` AND search_vector @@ to_tsquery('simple', ?)`
+		searchArgs = []interface{}{tsQuery(terms)}
 	}
 	
 	// Execute count query
@@ -63,36 +60,65 @@ func (r *mahasiswaRepository) GetWithPagination(pagination *models.PaginationReq
 	
 	// Data query
 	dataQuery := `
-		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at
+		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at, version
 		FROM mahasiswas
 	`
 	
 	// Add search condition to data query
 	dataQuery += searchCondition
+	dataArgs := append([]interface{}{}, searchArgs...)
 	
 	// Add sorting and pagination
-	dataQuery += fmt.Sprintf(" ORDER BY %s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	if byRelevance {
+		dataQuery += " ORDER BY ts_rank(search_vector, to_tsquery('simple', ?)) DESC, id ASC LIMIT ? OFFSET ?"
+		dataArgs = append(dataArgs, tsQuery(terms))
+	} else {
+		dataQuery += fmt.Sprintf(" ORDER BY %s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	}
 	
 	// Prepare arguments for data query
-	dataArgs := append(searchArgs, pagination.Limit, pagination.GetOffset())
+	dataArgs = append(dataArgs, pagination.Limit, pagination.GetOffset())
+	
+	if err = r.db.Raw(dataQuery, dataArgs...).Scan(&mahasiswas).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	if len(terms) > 0 && len(mahasiswas) > 0 {
+		ids := make([]uint, len(mahasiswas))
+		for i := range mahasiswas {
+			ids[i] = mahasiswas[i].ID
+		}
+		ranks, err := searchRanks(r.db, `
+			SELECT id, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank
+			FROM mahasiswas WHERE id IN ?
+		`, tsQuery(terms), ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range mahasiswas {
+			mahasiswas[i].Search = models.NewSearchMatch(terms, mahasiswas[i].SearchFields(), ranks[mahasiswas[i].ID])
+		}
+	}
 	
-	err = r.db.Raw(dataQuery, dataArgs...).Scan(&mahasiswas).Error
-	return mahasiswas, total, err
+	return mahasiswas, total, nil
 }
 
 func (r *mahasiswaRepository) GetByID(id uint) (*models.Mahasiswa, error) {
 	var mahasiswa models.Mahasiswa
 	
 	query := `
-		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at
+		SELECT id, nim, nama, jurusan, angkatan, email, created_at, updated_at, version
 		FROM mahasiswas
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	
 	err := r.db.Raw(query, id).Scan(&mahasiswa).Error
 	if err != nil {
 		return nil, err
 	}
+	if mahasiswa.ID == 0 {
+		return nil, utils.NotFound(mahasiswaNotFound)
+	}
 	return &mahasiswa, nil
 }
 
@@ -101,45 +127,64 @@ func (r *mahasiswaRepository) Create(mahasiswa *models.Mahasiswa) error {
 		INSERT INTO mahasiswas 
 		(nim, nama, jurusan, angkatan, email, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, NOW(), NOW())
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, version
 	`
 	
-	return r.db.Raw(query,
+	err := r.db.Raw(query,
 		mahasiswa.NIM,
 		mahasiswa.Nama,
 		mahasiswa.Jurusan,
 		mahasiswa.Angkatan,
 		mahasiswa.Email,
 	).Scan(mahasiswa).Error
+	return translateError(err, mahasiswaNotFound)
 }
 
 func (r *mahasiswaRepository) Update(mahasiswa *models.Mahasiswa) error {
 	query := `
 		UPDATE mahasiswas 
-		SET nim = ?, nama = ?, jurusan = ?, angkatan = ?, email = ?, updated_at = NOW()
-		WHERE id = ?
-		RETURNING updated_at
+		SET nim = ?, nama = ?, jurusan = ?, angkatan = ?, email = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+		RETURNING updated_at, version
 	`
 	
-	return r.db.Raw(query,
+	result := r.db.Raw(query,
 		mahasiswa.NIM,
 		mahasiswa.Nama,
 		mahasiswa.Jurusan,
 		mahasiswa.Angkatan,
 		mahasiswa.Email,
 		mahasiswa.ID,
-	).Scan(mahasiswa).Error
+		mahasiswa.Version,
+	).Scan(mahasiswa)
+	return affectedOrStale(r.db, result, "mahasiswas", mahasiswa.ID, mahasiswaNotFound)
 }
 
-func (r *mahasiswaRepository) Delete(id uint) error {
-	query := `DELETE FROM mahasiswas WHERE id = ?`
-	result := r.db.Exec(query, id)
-	return result.Error
+func (r *mahasiswaRepository) Delete(id uint, version int) error {
+	query := `DELETE FROM mahasiswas WHERE id = ? AND (? = 0 OR version = ?)`
+	result := r.db.Exec(query, id, version, version)
+	return affectedOrStale(r.db, result, "mahasiswas", id, mahasiswaNotFound)
+}
+
+// Merge soft delete mahasiswa sumber dengan merged_into = targetID
+func (r *mahasiswaRepository) Merge(targetID uint, sourceIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE mahasiswas SET deleted_at = NOW(), merged_into = ?, updated_at = NOW(), version = version + 1
+			WHERE id IN ? AND deleted_at IS NULL
+		`, targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Exec(`UPDATE mahasiswas SET updated_at = NOW(), version = version + 1 WHERE id = ? AND deleted_at IS NULL`, targetID)
+		return affectedOrNotFound(result, mahasiswaNotFound)
+	})
 }
 
 func (r *mahasiswaRepository) Count() (int64, error) {
 	var count int64
-	query := `SELECT COUNT(*) FROM mahasiswas`
+	query := `SELECT COUNT(*) FROM mahasiswas WHERE deleted_at IS NULL`
 	err := r.db.Raw(query).Scan(&count).Error
 	return count, err
 }
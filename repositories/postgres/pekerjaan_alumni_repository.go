@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const pekerjaanNotFound = "Pekerjaan alumni tidak ditemukan"
+
 type pekerjaanAlumniRepository struct {
 	db *gorm.DB
 }
@@ -22,11 +25,12 @@ func (r *pekerjaanAlumniRepository) GetAll() ([]models.PekerjaanAlumni, error) {
 
 	query := `
 		SELECT 
-			pa.id, pa.alumni_id, pa.nama_perusahaan, pa.posisi_jabatan, 
-			pa.bidang_industri, pa.lokasi_kerja, pa.gaji_range, 
+			pa.id, pa.alumni_id, pa.perusahaan_id, pa.nama_perusahaan, pa.posisi_jabatan, 
+			pa.bidang_industri, pa.kode_industri, pa.lokasi_kerja, pa.kode_provinsi, pa.kode_kota, pa.gaji_range, 
+			pa.gaji_min, pa.gaji_max, pa.gaji_mata_uang, pa.gaji_periode, 
 			pa.tanggal_mulai_kerja, pa.tanggal_selesai_kerja, 
 			pa.status_pekerjaan, pa.deskripsi_pekerjaan, 
-			pa.created_at, pa.updated_at, pa.deleted_at,
+			pa.created_at, pa.updated_at, pa.deleted_at, pa.version,
 			a.id as "Alumni__id", a.user_id as "Alumni__user_id", 
 			a.nim as "Alumni__nim", a.nama as "Alumni__nama", 
 			a.jurusan as "Alumni__jurusan", a.angkatan as "Alumni__angkatan", 
@@ -53,8 +57,7 @@ func (r *pekerjaanAlumniRepository) GetWithPagination(pagination *models.Paginat
 	var total int64
 
 	// Set default values
-	pagination.SetDefaults()
-	pagination.ValidateSortOrder()
+	byRelevance := pagination.SetSearchDefaults()
 
 	// Count query
 	countQuery := `
@@ -65,19 +68,7 @@ func (r *pekerjaanAlumniRepository) GetWithPagination(pagination *models.Paginat
 	`
 
 	// Search filter
-	searchCondition := ""
-	searchArgs := []interface{}{}
-	if pagination.Search != "" {
-		searchPattern := "%" + pagination.Search + "%"
-		searchCondition = ` AND (
-			pa.posisi_jabatan ILIKE ? OR 
-			pa.nama_perusahaan ILIKE ? OR 
-			pa.bidang_industri ILIKE ? OR 
-			pa.lokasi_kerja ILIKE ? OR 
-			a.nama ILIKE ?
-		)`
-		searchArgs = []interface{}{searchPattern, searchPattern, searchPattern, searchPattern, searchPattern}
-	}
+	searchCondition, searchArgs := pekerjaanSearchCondition(pagination.Search)
 
 	// Execute count query
 	err := r.db.Raw(countQuery+searchCondition, searchArgs...).Scan(&total).Error
@@ -88,11 +79,12 @@ func (r *pekerjaanAlumniRepository) GetWithPagination(pagination *models.Paginat
 	// Data query
 	dataQuery := `
 		SELECT 
-			pa.id, pa.alumni_id, pa.nama_perusahaan, pa.posisi_jabatan, 
-			pa.bidang_industri, pa.lokasi_kerja, pa.gaji_range, 
+			pa.id, pa.alumni_id, pa.perusahaan_id, pa.nama_perusahaan, pa.posisi_jabatan, 
+			pa.bidang_industri, pa.kode_industri, pa.lokasi_kerja, pa.kode_provinsi, pa.kode_kota, pa.gaji_range, 
+			pa.gaji_min, pa.gaji_max, pa.gaji_mata_uang, pa.gaji_periode, 
 			pa.tanggal_mulai_kerja, pa.tanggal_selesai_kerja, 
 			pa.status_pekerjaan, pa.deskripsi_pekerjaan, 
-			pa.created_at, pa.updated_at, pa.deleted_at,
+			pa.created_at, pa.updated_at, pa.deleted_at, pa.version,
 			a.id as "Alumni__id", a.user_id as "Alumni__user_id", 
 			a.nim as "Alumni__nim", a.nama as "Alumni__nama", 
 			a.jurusan as "Alumni__jurusan", a.angkatan as "Alumni__angkatan", 
@@ -112,14 +104,42 @@ func (r *pekerjaanAlumniRepository) GetWithPagination(pagination *models.Paginat
 	// Add search condition to data query
 	dataQuery += searchCondition
 
+	dataArgs := append([]interface{}{}, searchArgs...)
+
 	// Add sorting and pagination
-	dataQuery += fmt.Sprintf(" ORDER BY pa.%s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	if byRelevance {
+		dataQuery += " ORDER BY ts_rank(pa.search_vector, to_tsquery('simple', ?)) DESC, pa.id ASC LIMIT ? OFFSET ?"
+		dataArgs = append(dataArgs, searchArgs...)
+	} else {
+		dataQuery += fmt.Sprintf(" ORDER BY pa.%s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	}
 
 	// Prepare arguments for data query
-	dataArgs := append(searchArgs, pagination.Limit, pagination.GetOffset())
+	dataArgs = append(dataArgs, pagination.Limit, pagination.GetOffset())
+
+	if err = r.db.Raw(dataQuery, dataArgs...).Scan(&pekerjaans).Error; err != nil {
+		return nil, 0, err
+	}
 
-	err = r.db.Raw(dataQuery, dataArgs...).Scan(&pekerjaans).Error
-	return pekerjaans, total, err
+	if len(searchArgs) > 0 && len(pekerjaans) > 0 {
+		ids := make([]uint, len(pekerjaans))
+		for i := range pekerjaans {
+			ids[i] = pekerjaans[i].ID
+		}
+		ranks, err := searchRanks(r.db, `
+			SELECT id, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank
+			FROM pekerjaan_alumnis WHERE id IN ?
+		`, append(searchArgs, ids)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		terms := models.SearchTerms(pagination.Search)
+		for i := range pekerjaans {
+			pekerjaans[i].Search = models.NewSearchMatch(terms, pekerjaans[i].SearchFields(), ranks[pekerjaans[i].ID])
+		}
+	}
+
+	return pekerjaans, total, nil
 }
 
 func (r *pekerjaanAlumniRepository) GetByID(id uint) (*models.PekerjaanAlumni, error) {
@@ -127,11 +147,12 @@ func (r *pekerjaanAlumniRepository) GetByID(id uint) (*models.PekerjaanAlumni, e
 
 	query := `
 		SELECT 
-			pa.id, pa.alumni_id, pa.nama_perusahaan, pa.posisi_jabatan, 
-			pa.bidang_industri, pa.lokasi_kerja, pa.gaji_range, 
+			pa.id, pa.alumni_id, pa.perusahaan_id, pa.nama_perusahaan, pa.posisi_jabatan, 
+			pa.bidang_industri, pa.kode_industri, pa.lokasi_kerja, pa.kode_provinsi, pa.kode_kota, pa.gaji_range, 
+			pa.gaji_min, pa.gaji_max, pa.gaji_mata_uang, pa.gaji_periode, 
 			pa.tanggal_mulai_kerja, pa.tanggal_selesai_kerja, 
 			pa.status_pekerjaan, pa.deskripsi_pekerjaan, 
-			pa.created_at, pa.updated_at, pa.deleted_at,
+			pa.created_at, pa.updated_at, pa.deleted_at, pa.version,
 			a.id as "Alumni__id", a.user_id as "Alumni__user_id", 
 			a.nim as "Alumni__nim", a.nama as "Alumni__nama", 
 			a.jurusan as "Alumni__jurusan", a.angkatan as "Alumni__angkatan", 
@@ -152,6 +173,9 @@ func (r *pekerjaanAlumniRepository) GetByID(id uint) (*models.PekerjaanAlumni, e
 	if err != nil {
 		return nil, err
 	}
+	if pekerjaan.ID == 0 {
+		return nil, utils.NotFound(pekerjaanNotFound)
+	}
 	return &pekerjaan, nil
 }
 
@@ -160,11 +184,12 @@ func (r *pekerjaanAlumniRepository) GetByAlumniID(alumniID uint) ([]models.Peker
 
 	query := `
 		SELECT 
-			pa.id, pa.alumni_id, pa.nama_perusahaan, pa.posisi_jabatan, 
-			pa.bidang_industri, pa.lokasi_kerja, pa.gaji_range, 
+			pa.id, pa.alumni_id, pa.perusahaan_id, pa.nama_perusahaan, pa.posisi_jabatan, 
+			pa.bidang_industri, pa.kode_industri, pa.lokasi_kerja, pa.kode_provinsi, pa.kode_kota, pa.gaji_range, 
+			pa.gaji_min, pa.gaji_max, pa.gaji_mata_uang, pa.gaji_periode, 
 			pa.tanggal_mulai_kerja, pa.tanggal_selesai_kerja, 
 			pa.status_pekerjaan, pa.deskripsi_pekerjaan, 
-			pa.created_at, pa.updated_at, pa.deleted_at,
+			pa.created_at, pa.updated_at, pa.deleted_at, pa.version,
 			a.id as "Alumni__id", a.user_id as "Alumni__user_id", 
 			a.nim as "Alumni__nim", a.nama as "Alumni__nama", 
 			a.jurusan as "Alumni__jurusan", a.angkatan as "Alumni__angkatan", 
@@ -186,16 +211,29 @@ func (r *pekerjaanAlumniRepository) GetByAlumniID(alumniID uint) ([]models.Peker
 	return pekerjaans, err
 }
 
+func (r *pekerjaanAlumniRepository) GetAllByAlumniIDs(alumniIDs []uint) ([]models.PekerjaanAlumni, error) {
+	var pekerjaans []models.PekerjaanAlumni
+	err := r.db.Raw(`SELECT * FROM pekerjaan_alumnis WHERE alumni_id IN ? ORDER BY id`, alumniIDs).Scan(&pekerjaans).Error
+	return pekerjaans, err
+}
+
+func (r *pekerjaanAlumniRepository) GetAllByPerusahaanIDs(perusahaanIDs []uint) ([]models.PekerjaanAlumni, error) {
+	var pekerjaans []models.PekerjaanAlumni
+	err := r.db.Raw(`SELECT * FROM pekerjaan_alumnis WHERE perusahaan_id IN ? ORDER BY id`, perusahaanIDs).Scan(&pekerjaans).Error
+	return pekerjaans, err
+}
+
 func (r *pekerjaanAlumniRepository) GetByUserID(userID int) ([]models.PekerjaanAlumni, error) {
 	var pekerjaans []models.PekerjaanAlumni
 
 	query := `
 		SELECT 
-			pa.id, pa.alumni_id, pa.nama_perusahaan, pa.posisi_jabatan, 
-			pa.bidang_industri, pa.lokasi_kerja, pa.gaji_range, 
+			pa.id, pa.alumni_id, pa.perusahaan_id, pa.nama_perusahaan, pa.posisi_jabatan, 
+			pa.bidang_industri, pa.kode_industri, pa.lokasi_kerja, pa.kode_provinsi, pa.kode_kota, pa.gaji_range, 
+			pa.gaji_min, pa.gaji_max, pa.gaji_mata_uang, pa.gaji_periode, 
 			pa.tanggal_mulai_kerja, pa.tanggal_selesai_kerja, 
 			pa.status_pekerjaan, pa.deskripsi_pekerjaan, 
-			pa.created_at, pa.updated_at, pa.deleted_at,
+			pa.created_at, pa.updated_at, pa.deleted_at, pa.version,
 			a.id as "Alumni__id", a.user_id as "Alumni__user_id", 
 			a.nim as "Alumni__nim", a.nama as "Alumni__nama", 
 			a.jurusan as "Alumni__jurusan", a.angkatan as "Alumni__angkatan", 
@@ -220,70 +258,89 @@ func (r *pekerjaanAlumniRepository) GetByUserID(userID int) ([]models.PekerjaanA
 func (r *pekerjaanAlumniRepository) Create(pekerjaan *models.PekerjaanAlumni) error {
 	query := `
 		INSERT INTO pekerjaan_alumnis 
-		(alumni_id, nama_perusahaan, posisi_jabatan, bidang_industri, lokasi_kerja, 
-		 gaji_range, tanggal_mulai_kerja, tanggal_selesai_kerja, status_pekerjaan, 
+		(alumni_id, perusahaan_id, nama_perusahaan, posisi_jabatan, bidang_industri, kode_industri, 
+		 lokasi_kerja, kode_provinsi, kode_kota, gaji_range, gaji_min, gaji_max, gaji_mata_uang, gaji_periode, 
+		 tanggal_mulai_kerja, tanggal_selesai_kerja, status_pekerjaan, 
 		 deskripsi_pekerjaan, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
-		RETURNING id, created_at, updated_at
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		RETURNING id, created_at, updated_at, version
 	`
 
-	return r.db.Raw(query,
+	err := r.db.Raw(query,
 		pekerjaan.AlumniID,
+		pekerjaan.PerusahaanID,
 		pekerjaan.NamaPerusahaan,
 		pekerjaan.PosisiJabatan,
 		pekerjaan.BidangIndustri,
+		pekerjaan.KodeIndustri,
 		pekerjaan.LokasiKerja,
+		pekerjaan.KodeProvinsi,
+		pekerjaan.KodeKota,
 		pekerjaan.GajiRange,
+		pekerjaan.GajiMin,
+		pekerjaan.GajiMax,
+		pekerjaan.GajiMataUang,
+		pekerjaan.GajiPeriode,
 		pekerjaan.TanggalMulaiKerja,
 		pekerjaan.TanggalSelesaiKerja,
 		pekerjaan.StatusPekerjaan,
 		pekerjaan.DeskripsiPekerjaan,
 	).Scan(pekerjaan).Error
+	return translateError(err, pekerjaanNotFound)
 }
 
 func (r *pekerjaanAlumniRepository) Update(pekerjaan *models.PekerjaanAlumni) error {
 	query := `
 		UPDATE pekerjaan_alumnis 
-		SET nama_perusahaan = ?, posisi_jabatan = ?, bidang_industri = ?, 
-		    lokasi_kerja = ?, gaji_range = ?, tanggal_mulai_kerja = ?, 
+		SET perusahaan_id = ?, nama_perusahaan = ?, posisi_jabatan = ?, bidang_industri = ?, 
+		    kode_industri = ?, lokasi_kerja = ?, kode_provinsi = ?, kode_kota = ?, gaji_range = ?, gaji_min = ?, gaji_max = ?, 
+		    gaji_mata_uang = ?, gaji_periode = ?, tanggal_mulai_kerja = ?, 
 		    tanggal_selesai_kerja = ?, status_pekerjaan = ?, 
-		    deskripsi_pekerjaan = ?, updated_at = NOW()
-		WHERE id = ? AND deleted_at IS NULL
-		RETURNING updated_at
+		    deskripsi_pekerjaan = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+		RETURNING updated_at, version
 	`
 
-	return r.db.Raw(query,
+	result := r.db.Raw(query,
+		pekerjaan.PerusahaanID,
 		pekerjaan.NamaPerusahaan,
 		pekerjaan.PosisiJabatan,
 		pekerjaan.BidangIndustri,
+		pekerjaan.KodeIndustri,
 		pekerjaan.LokasiKerja,
+		pekerjaan.KodeProvinsi,
+		pekerjaan.KodeKota,
 		pekerjaan.GajiRange,
+		pekerjaan.GajiMin,
+		pekerjaan.GajiMax,
+		pekerjaan.GajiMataUang,
+		pekerjaan.GajiPeriode,
 		pekerjaan.TanggalMulaiKerja,
 		pekerjaan.TanggalSelesaiKerja,
 		pekerjaan.StatusPekerjaan,
 		pekerjaan.DeskripsiPekerjaan,
 		pekerjaan.ID,
-	).Scan(pekerjaan).Error
+		pekerjaan.Version,
+	).Scan(pekerjaan)
+	return affectedOrStale(r.db, result, "pekerjaan_alumnis", pekerjaan.ID, pekerjaanNotFound)
 }
 
-func (r *pekerjaanAlumniRepository) Delete(id uint) error {
-	var deletedAt *time.Time
+func (r *pekerjaanAlumniRepository) Delete(id uint, version int) error {
+	var rows []struct{ DeletedAt *time.Time }
 	checkQuery := `SELECT deleted_at FROM pekerjaan_alumnis WHERE id = ?`
-	err := r.db.Raw(checkQuery, id).Scan(&deletedAt).Error
-	if err != nil {
-		return fmt.Errorf("data pekerjaan alumni tidak ditemukan")
+	if err := r.db.Raw(checkQuery, id).Scan(&rows).Error; err != nil {
+		return err
 	}
-
-	if deletedAt == nil {
-		return fmt.Errorf("tidak bisa hard delete: data belum di-soft delete terlebih dahulu")
+	if len(rows) == 0 {
+		return utils.NotFound(pekerjaanNotFound)
 	}
-
-	query := `DELETE FROM pekerjaan_alumnis WHERE id = ? AND deleted_at IS NOT NULL`
-	result := r.db.Exec(query, id)
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("tidak ada data yang dihapus - pastikan data sudah di-soft delete")
+	if rows[0].DeletedAt == nil {
+		return utils.Conflict("Tidak bisa hard delete: data belum di-soft delete terlebih dahulu")
 	}
-	return result.Error
+
+	query := `DELETE FROM pekerjaan_alumnis WHERE id = ? AND deleted_at IS NOT NULL AND (? = 0 OR version = ?)`
+	result := r.db.Exec(query, id, version, version)
+	return affectedOrStale(r.db, result, "pekerjaan_alumnis", id, pekerjaanNotFound)
 }
 
 func (r *pekerjaanAlumniRepository) Count() (int64, error) {
@@ -293,30 +350,26 @@ func (r *pekerjaanAlumniRepository) Count() (int64, error) {
 	return count, err
 }
 
-func (r *pekerjaanAlumniRepository) GetAlumniCountByCompany(namaPerusahaan string) (int64, error) {
-	var count int64
-	query := `SELECT COUNT(DISTINCT alumni_id) FROM pekerjaan_alumnis WHERE nama_perusahaan = ? AND deleted_at IS NULL`
-	err := r.db.Raw(query, namaPerusahaan).Scan(&count).Error
-	return count, err
-}
-
 // Soft Delete methods
-func (r *pekerjaanAlumniRepository) SoftDelete(id uint) error {
-	query := `UPDATE pekerjaan_alumnis SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
-	result := r.db.Exec(query, id)
-	return result.Error
+func (r *pekerjaanAlumniRepository) SoftDelete(id uint, version int) error {
+	query := `UPDATE pekerjaan_alumnis SET deleted_at = NOW(), version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)`
+	result := r.db.Exec(query, id, version, version)
+	if version == 0 {
+		return affectedOrNotFound(result, pekerjaanNotFound)
+	}
+	return affectedOrStale(r.db, result, "pekerjaan_alumnis", id, pekerjaanNotFound)
 }
 
 func (r *pekerjaanAlumniRepository) SoftDeleteByAlumniID(alumniID uint) error {
-	query := `UPDATE pekerjaan_alumnis SET deleted_at = NOW() WHERE alumni_id = ? AND deleted_at IS NULL`
+	query := `UPDATE pekerjaan_alumnis SET deleted_at = NOW(), version = version + 1 WHERE alumni_id = ? AND deleted_at IS NULL`
 	result := r.db.Exec(query, alumniID)
 	return result.Error
 }
 
 func (r *pekerjaanAlumniRepository) Restore(id uint) error {
-	query := `UPDATE pekerjaan_alumnis SET deleted_at = NULL WHERE id = ?`
+	query := `UPDATE pekerjaan_alumnis SET deleted_at = NULL, version = version + 1 WHERE id = ?`
 	result := r.db.Exec(query, id)
-	return result.Error
+	return affectedOrNotFound(result, pekerjaanNotFound)
 }
 
 func (r *pekerjaanAlumniRepository) GetDeleted() ([]models.PekerjaanAlumni, error) {
@@ -357,3 +410,74 @@ func (r *pekerjaanAlumniRepository) GetDeletedByUserID(userID int) ([]models.Pek
 	err := r.db.Raw(query, userID).Scan(&pekerjaans).Error
 	return pekerjaans, err
 }
+
+// GetSalarySamples mengambil data gaji terstruktur beserta kunci grup untuk statistik gaji
+func (r *pekerjaanAlumniRepository) GetSalarySamples(groupBy string, mataUang string) ([]models.SalarySample, error) {
+	groupColumns := map[string]string{
+		"jurusan":         "a.jurusan",
+		"tahun_lulus":     "CAST(a.tahun_lulus AS TEXT)",
+		"bidang_industri": "pa.bidang_industri",
+		"lokasi_kerja":    "pa.lokasi_kerja",
+		"":                "'semua'",
+	}
+	groupColumn, ok := groupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik gaji tidak valid: %s", groupBy)
+	}
+
+	var samples []models.SalarySample
+	query := fmt.Sprintf(`
+		SELECT %s AS group_key, pa.gaji_min, pa.gaji_max, pa.gaji_periode
+		FROM pekerjaan_alumnis pa
+		LEFT JOIN alumnis a ON pa.alumni_id = a.id
+		WHERE pa.deleted_at IS NULL
+		AND (pa.gaji_min IS NOT NULL OR pa.gaji_max IS NOT NULL)
+		AND pa.gaji_mata_uang = ?
+	`, groupColumn)
+
+	err := r.db.Raw(query, mataUang).Scan(&samples).Error
+	return samples, err
+}
+
+// CountByGroup menghitung pekerjaan aktif per kode industri, kota, atau provinsi dengan GROUP BY
+func (r *pekerjaanAlumniRepository) CountByGroup(groupBy string, search string) ([]models.StatGroup, error) {
+	groupColumns := map[string][2]string{
+		"kode_industri": {"COALESCE(pa.kode_industri, '')", "COALESCE(pa.bidang_industri, '')"},
+		"kode_kota":     {"COALESCE(NULLIF(pa.kode_kota, ''), pa.kode_provinsi, '')", "COALESCE(pa.lokasi_kerja, '')"},
+		"kode_provinsi": {"COALESCE(pa.kode_provinsi, '')", "COALESCE(pa.lokasi_kerja, '')"},
+	}
+	columns, ok := groupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("grup statistik pekerjaan tidak valid: %s", groupBy)
+	}
+
+	searchCondition, searchArgs := pekerjaanSearchCondition(search)
+
+	// Label hanya dipakai untuk data yang kodenya kosong
+	query := fmt.Sprintf(`
+		SELECT g.kode, CASE WHEN g.kode = '' THEN g.label ELSE '' END AS label, COUNT(*) AS count
+		FROM (
+			SELECT %s AS kode, %s AS label
+			FROM pekerjaan_alumnis pa
+			LEFT JOIN alumnis a ON pa.alumni_id = a.id
+			WHERE pa.deleted_at IS NULL
+			%s
+		) g
+		GROUP BY 1, 2
+		ORDER BY count DESC, kode ASC, label ASC
+	`, columns[0], columns[1], searchCondition)
+
+	var groups []models.StatGroup
+	err := r.db.Raw(query, searchArgs...).Scan(&groups).Error
+	return groups, err
+}
+
+// pekerjaanSearchCondition kondisi full-text pencarian pekerjaan (lanjutan dari
+// WHERE deleted_at), dipakai bersama oleh list dan statistik
+func pekerjaanSearchCondition(search string) (string, []interface{}) {
+	terms := models.SearchTerms(search)
+	if len(terms) == 0 {
+		return "", []interface{}{}
+	}
+	return ` AND pa.search_vector @@ to_tsquery('simple', ?)`, []interface{}{tsQuery(terms)}
+}
@@ -0,0 +1,300 @@
+package postgre
+
+import (
+	"fmt"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+
+	"gorm.io/gorm"
+)
+
+const perusahaanNotFound = "Perusahaan tidak ditemukan"
+
+type perusahaanRepository struct {
+	db *gorm.DB
+}
+
+func NewPerusahaanRepository(db *gorm.DB) repo.PerusahaanRepository {
+	return &perusahaanRepository{db: db}
+}
+
+func (r *perusahaanRepository) GetWithPagination(pagination *models.PaginationRequest) ([]models.Perusahaan, int64, error) {
+	var perusahaans []models.Perusahaan
+	var total int64
+
+	// Set default values
+	byRelevance := pagination.SetSearchDefaults()
+
+	// Search filter - full-text pada nama/industri/lokasi dan alias
+	terms := models.SearchTerms(pagination.Search)
+	searchCondition := ""
+	searchArgs := []interface{}{}
+	if len(terms) > 0 {
+		searchCondition = ` WHERE (
+			p.search_vector @@ to_tsquery('simple', ?) OR
+			EXISTS (SELECT 1 FROM perusahaan_aliases pa WHERE pa.perusahaan_id = p.id AND pa.search_vector @@ to_tsquery('simple', ?))
+		)`
+		searchArgs = []interface{}{tsQuery(terms), tsQuery(terms)}
+	}
+
+	// Execute count query
+	err := r.db.Raw(`SELECT COUNT(*) FROM perusahaans p`+searchCondition, searchArgs...).Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Data query
+	dataQuery := `
+		SELECT p.id, p.nama, p.nama_normal, p.bidang_industri, p.lokasi, p.website, p.created_at, p.updated_at, p.version
+		FROM perusahaans p
+	` + searchCondition
+	dataArgs := append([]interface{}{}, searchArgs...)
+	if byRelevance {
+		dataQuery += " ORDER BY " + perusahaanSearchRank + " DESC, p.id ASC LIMIT ? OFFSET ?"
+		dataArgs = append(dataArgs, tsQuery(terms), tsQuery(terms))
+	} else {
+		dataQuery += fmt.Sprintf(" ORDER BY p.%s %s LIMIT ? OFFSET ?", pagination.SortBy, pagination.SortOrder)
+	}
+	dataArgs = append(dataArgs, pagination.Limit, pagination.GetOffset())
+
+	if err = r.db.Raw(dataQuery, dataArgs...).Scan(&perusahaans).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err = r.loadAliases(perusahaans); err != nil {
+		return nil, 0, err
+	}
+
+	if len(terms) > 0 && len(perusahaans) > 0 {
+		ids := make([]uint, len(perusahaans))
+		for i := range perusahaans {
+			ids[i] = perusahaans[i].ID
+		}
+		ranks, err := searchRanks(r.db, `SELECT p.id, `+perusahaanSearchRank+` AS rank FROM perusahaans p WHERE p.id IN ?`,
+			tsQuery(terms), tsQuery(terms), ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range perusahaans {
+			perusahaans[i].Search = models.NewSearchMatch(terms, perusahaans[i].SearchFields(), ranks[perusahaans[i].ID])
+		}
+	}
+	return perusahaans, total, nil
+}
+
+// perusahaanSearchRank rank perusahaan: nilai tertinggi antara field perusahaan dan
+// alias-aliasnya, sehingga perusahaan yang hanya cocok lewat alias tetap terurut
+const perusahaanSearchRank = `GREATEST(
+	ts_rank(p.search_vector, to_tsquery('simple', ?)),
+	(SELECT MAX(ts_rank(pa.search_vector, to_tsquery('simple', ?))) FROM perusahaan_aliases pa WHERE pa.perusahaan_id = p.id)
+)`
+
+func (r *perusahaanRepository) GetByID(id uint) (*models.Perusahaan, error) {
+	var perusahaans []models.Perusahaan
+
+	query := `
+		SELECT id, nama, nama_normal, bidang_industri, lokasi, website, created_at, updated_at, version
+		FROM perusahaans
+		WHERE id = ?
+	`
+	if err := r.db.Raw(query, id).Scan(&perusahaans).Error; err != nil {
+		return nil, err
+	}
+	if len(perusahaans) == 0 {
+		return nil, utils.NotFound(perusahaanNotFound)
+	}
+
+	if err := r.loadAliases(perusahaans); err != nil {
+		return nil, err
+	}
+	return &perusahaans[0], nil
+}
+
+func (r *perusahaanRepository) FindByNamaNormal(namaNormal string) (*models.Perusahaan, error) {
+	var id uint
+
+	query := `
+		SELECT id FROM perusahaans WHERE nama_normal = ?
+		UNION
+		SELECT perusahaan_id FROM perusahaan_aliases WHERE alias_normal = ?
+		LIMIT 1
+	`
+	if err := r.db.Raw(query, namaNormal, namaNormal).Scan(&id).Error; err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		return nil, nil
+	}
+	return r.GetByID(id)
+}
+
+func (r *perusahaanRepository) Create(perusahaan *models.Perusahaan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := `
+			INSERT INTO perusahaans
+			(nama, nama_normal, bidang_industri, lokasi, website, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, NOW(), NOW())
+			RETURNING id, created_at, updated_at, version
+		`
+		err := tx.Raw(query,
+			perusahaan.Nama,
+			perusahaan.NamaNormal,
+			perusahaan.BidangIndustri,
+			perusahaan.Lokasi,
+			perusahaan.Website,
+		).Scan(perusahaan).Error
+		if err != nil {
+			return translateError(err, perusahaanNotFound)
+		}
+		return replaceAliases(tx, perusahaan.ID, perusahaan.Aliases)
+	})
+}
+
+func (r *perusahaanRepository) Update(perusahaan *models.Perusahaan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := `
+			UPDATE perusahaans
+			SET nama = ?, nama_normal = ?, bidang_industri = ?, lokasi = ?, website = ?, updated_at = NOW(), version = version + 1
+			WHERE id = ? AND version = ?
+			RETURNING updated_at, version
+		`
+		result := tx.Raw(query,
+			perusahaan.Nama,
+			perusahaan.NamaNormal,
+			perusahaan.BidangIndustri,
+			perusahaan.Lokasi,
+			perusahaan.Website,
+			perusahaan.ID,
+			perusahaan.Version,
+		).Scan(perusahaan)
+		if err := affectedOrStale(tx, result, "perusahaans", perusahaan.ID, perusahaanNotFound); err != nil {
+			return err
+		}
+
+		// Nama yang tampil pada pekerjaan ikut diperbarui (versi pekerjaan ikut naik)
+		err := tx.Exec(`UPDATE pekerjaan_alumnis SET nama_perusahaan = ?, version = version + 1 WHERE perusahaan_id = ? AND nama_perusahaan <> ?`,
+			perusahaan.Nama, perusahaan.ID, perusahaan.Nama).Error
+		if err != nil {
+			return err
+		}
+		return replaceAliases(tx, perusahaan.ID, perusahaan.Aliases)
+	})
+}
+
+func (r *perusahaanRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM perusahaan_aliases WHERE perusahaan_id = ?`, id).Error; err != nil {
+			return err
+		}
+		return affectedOrNotFound(tx.Exec(`DELETE FROM perusahaans WHERE id = ?`, id), perusahaanNotFound)
+	})
+}
+
+func (r *perusahaanRepository) Merge(targetID uint, sourceIDs []uint) error {
+	target, err := r.GetByID(targetID)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Nama perusahaan sumber menjadi alias perusahaan tujuan
+		var sourceNames []string
+		if err := tx.Raw(`SELECT nama FROM perusahaans WHERE id IN ?`, sourceIDs).Scan(&sourceNames).Error; err != nil {
+			return err
+		}
+
+		err := tx.Exec(`
+			UPDATE pekerjaan_alumnis SET perusahaan_id = ?, nama_perusahaan = ?, version = version + 1
+			WHERE perusahaan_id IN ?
+		`, targetID, target.Nama, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE perusahaan_aliases SET perusahaan_id = ? WHERE perusahaan_id IN ?`, targetID, sourceIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM perusahaans WHERE id IN ?`, sourceIDs).Error; err != nil {
+			return err
+		}
+
+		for _, nama := range sourceNames {
+			if err := insertAlias(tx, targetID, nama); err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`UPDATE perusahaans SET updated_at = NOW(), version = version + 1 WHERE id = ?`, targetID).Error
+	})
+}
+
+func (r *perusahaanRepository) CountAlumni(id uint) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(DISTINCT alumni_id) FROM pekerjaan_alumnis WHERE perusahaan_id = ? AND deleted_at IS NULL`
+	err := r.db.Raw(query, id).Scan(&count).Error
+	return count, err
+}
+
+func (r *perusahaanRepository) CountPekerjaan(id uint) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM pekerjaan_alumnis WHERE perusahaan_id = ?`
+	err := r.db.Raw(query, id).Scan(&count).Error
+	return count, err
+}
+
+// loadAliases mengisi field Aliases untuk setiap perusahaan
+func (r *perusahaanRepository) loadAliases(perusahaans []models.Perusahaan) error {
+	if len(perusahaans) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(perusahaans))
+	for i, p := range perusahaans {
+		ids[i] = p.ID
+	}
+
+	var aliases []models.PerusahaanAlias
+	query := `SELECT perusahaan_id, alias FROM perusahaan_aliases WHERE perusahaan_id IN ? ORDER BY alias`
+	if err := r.db.Raw(query, ids).Scan(&aliases).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint][]string)
+	for _, a := range aliases {
+		byID[a.PerusahaanID] = append(byID[a.PerusahaanID], a.Alias)
+	}
+	for i := range perusahaans {
+		perusahaans[i].Aliases = byID[perusahaans[i].ID]
+		if perusahaans[i].Aliases == nil {
+			perusahaans[i].Aliases = []string{}
+		}
+	}
+	return nil
+}
+
+// replaceAliases mengganti seluruh alias milik sebuah perusahaan
+func replaceAliases(tx *gorm.DB, perusahaanID uint, aliases []string) error {
+	if err := tx.Exec(`DELETE FROM perusahaan_aliases WHERE perusahaan_id = ?`, perusahaanID).Error; err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		if err := insertAlias(tx, perusahaanID, alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertAlias menambahkan alias; alias yang sudah terdaftar diabaikan
+func insertAlias(tx *gorm.DB, perusahaanID uint, alias string) error {
+	aliasNormal := utils.NormalizeNamaPerusahaan(alias)
+	if aliasNormal == "" {
+		return nil
+	}
+	return tx.Exec(`
+		INSERT INTO perusahaan_aliases (perusahaan_id, alias, alias_normal)
+		SELECT ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM perusahaans WHERE id = ? AND nama_normal = ?)
+		ON CONFLICT (alias_normal) DO NOTHING
+	`, perusahaanID, alias, aliasNormal, perusahaanID, aliasNormal).Error
+}
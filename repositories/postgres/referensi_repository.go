@@ -0,0 +1,112 @@
+package postgre
+
+import (
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+
+	"gorm.io/gorm"
+)
+
+// referensiUsageQueries query penghitung pemakaian kode referensi untuk setiap kategori.
+// Sengaja tanpa filter deleted_at: baris yang di-soft delete masih bisa di-restore.
+var referensiUsageQueries = map[string]string{
+	models.KategoriIndustri: "SELECT COUNT(*) FROM pekerjaan_alumnis WHERE kode_industri = ?",
+	models.KategoriProvinsi: "SELECT COUNT(*) FROM pekerjaan_alumnis WHERE kode_provinsi = ?",
+	models.KategoriKota:     "SELECT COUNT(*) FROM pekerjaan_alumnis WHERE kode_kota = ?",
+	models.KategoriProdi:    "SELECT COUNT(*) FROM alumnis WHERE kode_prodi = ?",
+}
+
+const referensiNotFound = "Referensi tidak ditemukan"
+
+type referensiRepository struct {
+	db *gorm.DB
+}
+
+func NewReferensiRepository(db *gorm.DB) repo.ReferensiRepository {
+	return &referensiRepository{db: db}
+}
+
+func (r *referensiRepository) GetByKategori(kategori string) ([]models.Referensi, error) {
+	var referensis []models.Referensi
+
+	query := `
+		SELECT id, kategori, kode, nama, kode_induk, aliases, kode_terkait, created_at, updated_at, version
+		FROM referensis
+		WHERE kategori = ?
+		ORDER BY kode
+	`
+
+	err := r.db.Raw(query, kategori).Scan(&referensis).Error
+	return referensis, err
+}
+
+func (r *referensiRepository) GetByKode(kategori string, kode string) (*models.Referensi, error) {
+	var referensis []models.Referensi
+
+	query := `
+		SELECT id, kategori, kode, nama, kode_induk, aliases, kode_terkait, created_at, updated_at, version
+		FROM referensis
+		WHERE kategori = ? AND kode = ?
+	`
+	if err := r.db.Raw(query, kategori, kode).Scan(&referensis).Error; err != nil {
+		return nil, err
+	}
+	if len(referensis) == 0 {
+		return nil, nil
+	}
+	return &referensis[0], nil
+}
+
+func (r *referensiRepository) Create(referensi *models.Referensi) error {
+	query := `
+		INSERT INTO referensis
+		(kategori, kode, nama, kode_induk, aliases, kode_terkait, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW())
+		RETURNING id, created_at, updated_at, version
+	`
+
+	err := r.db.Raw(query,
+		referensi.Kategori,
+		referensi.Kode,
+		referensi.Nama,
+		referensi.KodeInduk,
+		referensi.Aliases,
+		referensi.KodeTerkait,
+	).Scan(referensi).Error
+	return translateError(err, referensiNotFound)
+}
+
+func (r *referensiRepository) Update(referensi *models.Referensi) error {
+	query := `
+		UPDATE referensis
+		SET nama = ?, kode_induk = ?, aliases = ?, kode_terkait = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ?
+		RETURNING updated_at, version
+	`
+
+	result := r.db.Raw(query,
+		referensi.Nama,
+		referensi.KodeInduk,
+		referensi.Aliases,
+		referensi.KodeTerkait,
+		referensi.ID,
+		referensi.Version,
+	).Scan(referensi)
+	return affectedOrStale(r.db, result, "referensis", referensi.ID, referensiNotFound)
+}
+
+func (r *referensiRepository) Delete(id uint) error {
+	query := `DELETE FROM referensis WHERE id = ?`
+	return affectedOrNotFound(r.db.Exec(query, id), referensiNotFound)
+}
+
+func (r *referensiRepository) CountUsage(kategori string, kode string) (int64, error) {
+	query, ok := referensiUsageQueries[kategori]
+	if !ok {
+		return 0, nil
+	}
+
+	var count int64
+	err := r.db.Raw(query, kode).Scan(&count).Error
+	return count, err
+}
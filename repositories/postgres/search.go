@@ -0,0 +1,43 @@
+package postgre
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// tsQuery mengubah kata pencarian menjadi prefix tsquery ("budi:* & santoso:*"),
+// sehingga "bud" tetap menemukan "Budi" seperti pencarian ILIKE sebelumnya.
+// Kata dari models.SearchTerms hanya berisi huruf/angka, aman untuk to_tsquery.
+func tsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// likePattern membuat pola ILIKE "%...%" dengan karakter wildcard (%, _) dan
+// backslash di-escape, sehingga input dicocokkan apa adanya
+func likePattern(search string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(strings.TrimSpace(search)) + "%"
+}
+
+// searchRanks membaca nilai ts_rank per id untuk halaman hasil pencarian.
+// Query harus mengembalikan kolom id dan rank.
+func searchRanks(db *gorm.DB, query string, args ...interface{}) (map[uint]float64, error) {
+	var rows []struct {
+		ID   uint
+		Rank float64
+	}
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		ranks[row.ID] = row.Rank
+	}
+	return ranks, nil
+}
@@ -0,0 +1,163 @@
+package postgre
+
+import (
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+
+	"gorm.io/gorm"
+)
+
+const surveyNotFound = "Survey tidak ditemukan"
+
+type surveyRepository struct {
+	db *gorm.DB
+}
+
+func NewSurveyRepository(db *gorm.DB) repo.SurveyRepository {
+	return &surveyRepository{db: db}
+}
+
+const surveyColumns = `id, kode, versi, judul, deskripsi, status, target_tahun_lulus, target_jurusan,
+		pertanyaan, dibuka_at, ditutup_at, created_at, updated_at, version`
+
+func (r *surveyRepository) GetAll(status string) ([]models.Survey, error) {
+	var surveys []models.Survey
+
+	query := "SELECT " + surveyColumns + " FROM surveys"
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY kode, versi DESC"
+
+	err := r.db.Raw(query, args...).Scan(&surveys).Error
+	return surveys, err
+}
+
+func (r *surveyRepository) GetByID(id uint) (*models.Survey, error) {
+	var surveys []models.Survey
+
+	query := "SELECT " + surveyColumns + " FROM surveys WHERE id = ?"
+	if err := r.db.Raw(query, id).Scan(&surveys).Error; err != nil {
+		return nil, err
+	}
+	if len(surveys) == 0 {
+		return nil, utils.NotFound(surveyNotFound)
+	}
+	return &surveys[0], nil
+}
+
+func (r *surveyRepository) GetLatestVersi(kode string) (int, error) {
+	var versi int
+	query := `SELECT COALESCE(MAX(versi), 0) FROM surveys WHERE kode = ?`
+	err := r.db.Raw(query, kode).Scan(&versi).Error
+	return versi, err
+}
+
+func (r *surveyRepository) Create(survey *models.Survey) error {
+	query := `
+		INSERT INTO surveys
+		(kode, versi, judul, deskripsi, status, target_tahun_lulus, target_jurusan, pertanyaan,
+		 dibuka_at, ditutup_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		RETURNING id, created_at, updated_at, version
+	`
+
+	return r.db.Raw(query,
+		survey.Kode,
+		survey.Versi,
+		survey.Judul,
+		survey.Deskripsi,
+		survey.Status,
+		survey.TargetTahunLulus,
+		survey.TargetJurusan,
+		survey.Pertanyaan,
+		survey.DibukaAt,
+		survey.DitutupAt,
+	).Scan(survey).Error
+}
+
+func (r *surveyRepository) Update(survey *models.Survey) error {
+	query := `
+		UPDATE surveys
+		SET judul = ?, deskripsi = ?, status = ?, target_tahun_lulus = ?, target_jurusan = ?,
+		    pertanyaan = ?, dibuka_at = ?, ditutup_at = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ?
+		RETURNING updated_at, version
+	`
+
+	result := r.db.Raw(query,
+		survey.Judul,
+		survey.Deskripsi,
+		survey.Status,
+		survey.TargetTahunLulus,
+		survey.TargetJurusan,
+		survey.Pertanyaan,
+		survey.DibukaAt,
+		survey.DitutupAt,
+		survey.ID,
+		survey.Version,
+	).Scan(survey)
+	return affectedOrStale(r.db, result, "surveys", survey.ID, surveyNotFound)
+}
+
+func (r *surveyRepository) Delete(id uint) error {
+	query := `DELETE FROM surveys WHERE id = ?`
+	return affectedOrNotFound(r.db.Exec(query, id), surveyNotFound)
+}
+
+func (r *surveyRepository) GetResponses(surveyID uint) ([]models.SurveyResponse, error) {
+	var responses []models.SurveyResponse
+
+	query := `
+		SELECT id, survey_id, alumni_id, jawaban, created_at, updated_at
+		FROM survey_responses
+		WHERE survey_id = ?
+		ORDER BY id
+	`
+
+	err := r.db.Raw(query, surveyID).Scan(&responses).Error
+	return responses, err
+}
+
+func (r *surveyRepository) GetResponse(surveyID uint, alumniID uint) (*models.SurveyResponse, error) {
+	var responses []models.SurveyResponse
+
+	query := `
+		SELECT id, survey_id, alumni_id, jawaban, created_at, updated_at
+		FROM survey_responses
+		WHERE survey_id = ? AND alumni_id = ?
+	`
+	if err := r.db.Raw(query, surveyID, alumniID).Scan(&responses).Error; err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+	return &responses[0], nil
+}
+
+func (r *surveyRepository) SaveResponse(response *models.SurveyResponse) error {
+	query := `
+		INSERT INTO survey_responses (survey_id, alumni_id, jawaban, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+		ON CONFLICT (survey_id, alumni_id)
+		DO UPDATE SET jawaban = EXCLUDED.jawaban, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.Raw(query,
+		response.SurveyID,
+		response.AlumniID,
+		response.Jawaban,
+	).Scan(response).Error
+}
+
+func (r *surveyRepository) CountResponses(surveyID uint) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM survey_responses WHERE survey_id = ?`
+	err := r.db.Raw(query, surveyID).Scan(&count).Error
+	return count, err
+}
@@ -8,6 +8,8 @@ import (
 	"gorm.io/gorm"
 )
 
+const userNotFound = "User tidak ditemukan"
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -20,7 +22,7 @@ func (r *userRepository) GetAll() ([]models.User, error) {
 	var users []models.User
 	
 	query := `
-		SELECT id, username, email, role, is_active, created_at, updated_at
+		SELECT id, username, email, role, is_active, created_at, updated_at, version
 		FROM users
 		ORDER BY id DESC
 	`
@@ -61,7 +63,7 @@ func (r *userRepository) GetWithPagination(pagination *models.PaginationRequest)
 	
 	// Data query
 	dataQuery := `
-		SELECT id, username, email, role, is_active, created_at, updated_at
+		SELECT id, username, email, role, is_active, created_at, updated_at, version
 		FROM users
 	`
 	
@@ -83,10 +85,7 @@ func (r *userRepository) GetByID(id int) (*models.User, error) {
 	
 	err := r.db.Where("id = ?", id).First(&user).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil // Return nil when no record found
-		}
-		return nil, err
+		return nil, translateError(err, userNotFound)
 	}
 	return &user, nil
 }
@@ -96,10 +95,7 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	
 	err := r.db.Where("email = ?", email).First(&user).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil // Return nil when no record found
-		}
-		return nil, err
+		return nil, translateError(err, userNotFound)
 	}
 	return &user, nil
 }
@@ -109,10 +105,7 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	
 	err := r.db.Where("username = ?", username).First(&user).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil // Return nil when no record found
-		}
-		return nil, err
+		return nil, translateError(err, userNotFound)
 	}
 	return &user, nil
 }
@@ -122,40 +115,43 @@ func (r *userRepository) Create(user *models.User) error {
 		INSERT INTO users 
 		(username, email, password, role, is_active, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, NOW(), NOW())
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, version
 	`
 	
-	return r.db.Raw(query,
+	err := r.db.Raw(query,
 		user.Username,
 		user.Email,
 		user.Password,
 		user.Role,
 		user.IsActive,
 	).Scan(user).Error
+	return translateError(err, userNotFound)
 }
 
 func (r *userRepository) Update(user *models.User) error {
 	query := `
 		UPDATE users 
-		SET username = ?, email = ?, password = ?, role = ?, is_active = ?, updated_at = NOW()
-		WHERE id = ?
-		RETURNING updated_at
+		SET username = ?, email = ?, password = ?, role = ?, is_active = ?, updated_at = NOW(), version = version + 1
+		WHERE id = ? AND version = ?
+		RETURNING updated_at, version
 	`
 	
-	return r.db.Raw(query,
+	result := r.db.Raw(query,
 		user.Username,
 		user.Email,
 		user.Password,
 		user.Role,
 		user.IsActive,
 		user.ID,
-	).Scan(user).Error
+		user.Version,
+	).Scan(user)
+	return affectedOrStale(r.db, result, "users", user.ID, userNotFound)
 }
 
-func (r *userRepository) Delete(id int) error {
-	query := `DELETE FROM users WHERE id = ?`
-	result := r.db.Exec(query, id)
-	return result.Error
+func (r *userRepository) Delete(id int, version int) error {
+	query := `DELETE FROM users WHERE id = ? AND (? = 0 OR version = ?)`
+	result := r.db.Exec(query, id, version, version)
+	return affectedOrStale(r.db, result, "users", id, userNotFound)
 }
 
 func (r *userRepository) Count() (int64, error) {
@@ -20,11 +20,22 @@ func SetupAlumniRoutes(api fiber.Router, alumniService *services.AlumniService)
 	alumni.Get("/filter", alumniService.GetAlumnis)                          // Filter endpoint
 	alumni.Get("/stats/by-year", alumniService.GetAlumniStatsByYear)         // Statistics by graduation year
 	alumni.Get("/stats/by-jurusan", alumniService.GetAlumniStatsByJurusan)   // Statistics by department
+	alumni.Get("/duplicates", middleware.RequireAdmin(), alumniService.FindAlumniDuplicates) // Admin: duplicate candidates (?min_score=&limit=)
 	alumni.Get("/", alumniService.GetAlumnis)                                // Get all with pagination
 	alumni.Get("/:id", alumniService.GetAlumni)                              // Get by ID
+	alumni.Get("/:id/timeline", alumniService.GetAlumniTimeline)             // Career history with tenure & gaps
 
 	// Admin-only routes - requires admin role
 	alumni.Post("/", middleware.RequireAdmin(), alumniService.CreateAlumni)      // Create new
-	alumni.Put("/:id", middleware.RequireAdmin(), alumniService.UpdateAlumni)    // Update existing
+	alumni.Put("/:id", middleware.RequireAdmin(), alumniService.UpdateAlumni)    // Replace existing
+	alumni.Patch("/:id", middleware.RequireAdmin(), alumniService.PatchAlumni)   // Partial update (merge patch)
 	alumni.Delete("/:id", middleware.RequireAdmin(), alumniService.DeleteAlumni) // Delete
+
+	// Duplicate merge - admin only
+	alumni.Post("/:id/merge", middleware.RequireAdmin(), alumniService.MergeAlumni) // Merge source_ids into :id
+
+	// Version history - admin only
+	alumni.Get("/:id/history", middleware.RequireAdmin(), alumniService.GetAlumniHistory)                       // List versions with changes
+	alumni.Get("/:id/history/diff", middleware.RequireAdmin(), alumniService.DiffAlumniHistory)                 // Diff two versions (?from=&to=)
+	alumni.Post("/:id/history/:version/restore", middleware.RequireAdmin(), alumniService.RestoreAlumniVersion) // Revert to an older version
 }
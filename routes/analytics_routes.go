@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"modul4crud/middleware"
+	"modul4crud/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAnalyticsRoutes configures tracer study analytics routes (accreditation reporting)
+// Filter: ?tahun_lulus=, ?angkatan=, ?kode_prodi=
+// Admin only
+func SetupAnalyticsRoutes(api fiber.Router, analyticsService *services.AnalyticsService) {
+	analytics := api.Group("/analytics", middleware.RequireAdmin())
+
+	tracer := analytics.Group("/tracer")
+	tracer.Get("/", analyticsService.GetTracerSummary)                    // Summary for all filtered alumni
+	tracer.Get("/by-jurusan", analyticsService.GetTracerByJurusan)        // Per program studi
+	tracer.Get("/by-angkatan", analyticsService.GetTracerByAngkatan)      // Per angkatan
+	tracer.Get("/by-tahun-lulus", analyticsService.GetTracerByTahunLulus) // Per cohort
+	tracer.Get("/export", analyticsService.ExportTracer)                  // LKPS table (?tabel=waktu-tunggu|kesesuaian&format=csv|json)
+}
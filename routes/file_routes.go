@@ -1,6 +1,7 @@
 package routes
 
 import (
+    "modul4crud/middleware"
     "modul4crud/services"
     "github.com/gofiber/fiber/v2"
 )
@@ -9,7 +10,29 @@ func SetupFileRoutes(router fiber.Router, service services.FileService) {
     files := router.Group("/files")
 
     files.Post("/upload", service.UploadFile)
+
+    // Upload bertahap (resumable) untuk dokumen besar
+    files.Post("/uploads", service.CreateUploadSession)
+    files.Get("/uploads/:id", service.GetUploadSession) // juga melayani HEAD
+    files.Patch("/uploads/:id", service.UploadChunk)
+    files.Delete("/uploads/:id", service.CancelUploadSession)
+
     files.Get("/", service.GetAllFiles)
+    files.Get("/integrity", middleware.RequireAdmin(), service.ScanFileIntegrity) // ?verify=false tanpa hash isi
+    files.Get("/links", middleware.RequireAdmin(), service.GetFileLinks) // link unduhan yang masih berlaku
+    files.Delete("/links/:id", middleware.RequireAdmin(), service.RevokeFileLink)
     files.Get("/:id", service.GetFileByID)
-    files.Delete("/:id", service.DeleteFile)
+    files.Get("/:id/download", service.DownloadFile) // juga melayani HEAD
+    files.Delete("/:id", service.DeleteFile) // pengupload atau admin
+    files.Post("/:id/links", service.CreateFileLink) // link unduhan bertanda tangan untuk pihak luar
+
+    // Lampiran per entitas, hanya file yang boleh dibaca user
+    router.Get("/alumni/:id/files", service.GetAlumniFiles)
+    router.Get("/mahasiswa/:id/files", service.GetMahasiswaFiles)
+    router.Get("/pekerjaan/:id/files", service.GetPekerjaanFiles)
+
+    // Kuota penyimpanan: pemakaian sendiri, dan kuota khusus per user oleh admin
+    router.Get("/profile/storage", service.GetStorageUsage)
+    router.Put("/users/:id/storage-quota", middleware.RequireAdmin(), service.SetStorageQuota)
+    router.Delete("/users/:id/storage-quota", middleware.RequireAdmin(), service.DeleteStorageQuota)
 }
@@ -17,11 +17,21 @@ func SetupMahasiswaRoutes(api fiber.Router, mahasiswaService *services.Mahasiswa
 	mahasiswa.Get("/count", mahasiswaService.GetMahasiswaCount)   // Get total count
 	mahasiswa.Get("/search", mahasiswaService.GetMahasiswas)      // Search endpoint
 	mahasiswa.Get("/filter", mahasiswaService.GetMahasiswas)      // Filter endpoint
+	mahasiswa.Get("/duplicates", middleware.RequireAdmin(), mahasiswaService.FindMahasiswaDuplicates) // Admin: duplicate candidates (?min_score=&limit=)
 	mahasiswa.Get("/", mahasiswaService.GetMahasiswas)            // Get all with pagination
 	mahasiswa.Get("/:id", mahasiswaService.GetMahasiswa)          // Get by ID
 
 	// Admin-only routes - requires admin role
 	mahasiswa.Post("/", middleware.RequireAdmin(), mahasiswaService.CreateMahasiswa)      // Create new
-	mahasiswa.Put("/:id", middleware.RequireAdmin(), mahasiswaService.UpdateMahasiswa)    // Update existing
+	mahasiswa.Put("/:id", middleware.RequireAdmin(), mahasiswaService.UpdateMahasiswa)    // Replace existing
+	mahasiswa.Patch("/:id", middleware.RequireAdmin(), mahasiswaService.PatchMahasiswa)   // Partial update (merge patch)
 	mahasiswa.Delete("/:id", middleware.RequireAdmin(), mahasiswaService.DeleteMahasiswa) // Delete
+
+	// Duplicate merge - admin only
+	mahasiswa.Post("/:id/merge", middleware.RequireAdmin(), mahasiswaService.MergeMahasiswa) // Merge source_ids into :id
+
+	// Version history - admin only
+	mahasiswa.Get("/:id/history", middleware.RequireAdmin(), mahasiswaService.GetMahasiswaHistory)                       // List versions with changes
+	mahasiswa.Get("/:id/history/diff", middleware.RequireAdmin(), mahasiswaService.DiffMahasiswaHistory)                 // Diff two versions (?from=&to=)
+	mahasiswa.Post("/:id/history/:version/restore", middleware.RequireAdmin(), mahasiswaService.RestoreMahasiswaVersion) // Revert to an older version
 }
@@ -21,13 +21,24 @@ func SetupPekerjaanRoutes(api fiber.Router, pekerjaanService *services.Pekerjaan
 	pekerjaan.Get("/filter", pekerjaanService.GetPekerjaanAlumnis)                       // Filter endpoint
 	pekerjaan.Get("/stats/by-industry", pekerjaanService.GetPekerjaanStatsByIndustry)    // Statistics by industry
 	pekerjaan.Get("/stats/by-location", pekerjaanService.GetPekerjaanStatsByLocation)    // Statistics by location
+	pekerjaan.Get("/stats/salary", pekerjaanService.GetSalaryStats)                                  // Salary percentiles (all)
+	pekerjaan.Get("/stats/salary/by-jurusan", pekerjaanService.GetSalaryStatsByJurusan)              // Salary percentiles by department
+	pekerjaan.Get("/stats/salary/by-tahun-lulus", pekerjaanService.GetSalaryStatsByTahunLulus)       // Salary percentiles by graduation year
+	pekerjaan.Get("/stats/salary/by-industry", pekerjaanService.GetSalaryStatsByIndustry)            // Salary percentiles by industry
+	pekerjaan.Get("/stats/salary/by-location", pekerjaanService.GetSalaryStatsByLocation)            // Salary percentiles by location
 	pekerjaan.Get("/alumni/:alumni_id", pekerjaanService.GetPekerjaanByAlumni)           // Get jobs by alumni ID
 	pekerjaan.Get("/", pekerjaanService.GetPekerjaanAlumnis)                             // Get all with pagination
 	pekerjaan.Get("/:id", pekerjaanService.GetPekerjaanAlumni)                           // Get by ID
 
 	// Admin-only routes - requires admin role
 	pekerjaan.Post("/", middleware.RequireAdmin(), pekerjaanService.CreatePekerjaanAlumni)     // Create new
-	pekerjaan.Put("/:id", middleware.RequireAdmin(), pekerjaanService.UpdatePekerjaanAlumni)   // Update existing
+	pekerjaan.Put("/:id", middleware.RequireAdmin(), pekerjaanService.UpdatePekerjaanAlumni)   // Replace existing
+	pekerjaan.Patch("/:id", middleware.RequireAdmin(), pekerjaanService.PatchPekerjaanAlumni)  // Partial update (merge patch)
+
+	// Version history - admin only
+	pekerjaan.Get("/:id/history", middleware.RequireAdmin(), pekerjaanService.GetPekerjaanHistory)                       // List versions with changes
+	pekerjaan.Get("/:id/history/diff", middleware.RequireAdmin(), pekerjaanService.DiffPekerjaanHistory)                 // Diff two versions (?from=&to=)
+	pekerjaan.Post("/:id/history/:version/restore", middleware.RequireAdmin(), pekerjaanService.RestorePekerjaanVersion) // Revert to an older version
 	
 	// Soft delete operations - admin only
 	pekerjaan.Delete("/soft/alumni/:alumni_id", pekerjaanService.SoftDeletePekerjaanByAlumni)  // Soft delete by alumni
@@ -36,7 +47,4 @@ func SetupPekerjaanRoutes(api fiber.Router, pekerjaanService *services.Pekerjaan
 	
 	// Hard delete - admin only
 	pekerjaan.Delete("/:id", pekerjaanService.DeletePekerjaanAlumni)                           // Permanent delete
-
-	// Company statistics - accessible by all authenticated users
-	api.Get("/perusahaan/:nama_perusahaan", pekerjaanService.GetAlumniCountByCompany)          // Alumni count by company
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"modul4crud/middleware"
+	"modul4crud/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupPerusahaanRoutes configures all company related routes
+// User: Only GET operations
+// Admin: Full CRUD operations + merge duplicates + backfill
+func SetupPerusahaanRoutes(api fiber.Router, perusahaanService *services.PerusahaanService) {
+	perusahaan := api.Group("/perusahaan")
+
+	// Public GET routes - accessible by both User & Admin
+	perusahaan.Get("/search", perusahaanService.GetPerusahaans) // Search by nama, alias, industri, lokasi
+	perusahaan.Get("/", perusahaanService.GetPerusahaans)       // Get all with pagination
+	perusahaan.Get("/:id", perusahaanService.GetPerusahaan)     // Get by ID (with jumlah_alumni)
+
+	// Admin-only routes - requires admin role
+	perusahaan.Post("/backfill", middleware.RequireAdmin(), perusahaanService.BackfillPerusahaan) // Link existing pekerjaan
+	perusahaan.Post("/", middleware.RequireAdmin(), perusahaanService.CreatePerusahaan)           // Create new
+	perusahaan.Put("/:id", middleware.RequireAdmin(), perusahaanService.UpdatePerusahaan)         // Update existing
+	perusahaan.Post("/:id/merge", middleware.RequireAdmin(), perusahaanService.MergePerusahaan)   // Merge duplicates into :id
+	perusahaan.Delete("/:id", middleware.RequireAdmin(), perusahaanService.DeletePerusahaan)      // Delete (unused only)
+}
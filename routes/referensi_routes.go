@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"modul4crud/middleware"
+	"modul4crud/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupReferensiRoutes configures reference data (controlled vocabulary) routes
+// Kategori: industri, provinsi, kota, fakultas, prodi
+// User: Only GET operations
+// Admin: Full CRUD operations + backfill existing data
+func SetupReferensiRoutes(api fiber.Router, referensiService *services.ReferensiService) {
+	referensi := api.Group("/referensi")
+
+	// Admin-only backfill - must be registered before /:kategori
+	referensi.Post("/backfill", middleware.RequireAdmin(), referensiService.BackfillReferensi) // Map existing values to codes
+
+	// Public GET routes - accessible by both User & Admin
+	referensi.Get("/:kategori", referensiService.GetReferensis)      // List by kategori (?kode_induk=)
+	referensi.Get("/:kategori/:kode", referensiService.GetReferensi) // Get by kode
+
+	// Admin-only routes - requires admin role
+	referensi.Post("/:kategori", middleware.RequireAdmin(), referensiService.CreateReferensi)         // Create new
+	referensi.Put("/:kategori/:kode", middleware.RequireAdmin(), referensiService.UpdateReferensi)    // Update existing
+	referensi.Delete("/:kategori/:kode", middleware.RequireAdmin(), referensiService.DeleteReferensi) // Delete (unused only)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"modul4crud/middleware"
 	"modul4crud/services"
+	"modul4crud/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -12,15 +13,25 @@ import (
 // - mahasiswa_routes.go: Student management
 // - alumni_routes.go: Alumni management
 // - pekerjaan_routes.go: Job/employment management
+// - perusahaan_routes.go: Company management
+// - referensi_routes.go: Reference data (industri, provinsi, kota, fakultas, prodi)
+// - analytics_routes.go: Tracer study analytics & accreditation export
+// - survey_routes.go: Tracer study questionnaires & alumni responses
 // - trash_routes.go: Soft delete/recycle bin management
+// - search_routes.go: Cross-entity global search
 func SetupRoutes(
 	app *fiber.App,
 	mahasiswaService *services.MahasiswaService,
 	alumniService *services.AlumniService,
 	pekerjaanService *services.PekerjaanAlumniService,
+	perusahaanService *services.PerusahaanService,
+	referensiService *services.ReferensiService,
+	analyticsService *services.AnalyticsService,
+	surveyService *services.SurveyService,
 	authService *services.AuthService,
 	trashService *services.TrashService,
 	fileService services.FileService,
+	searchService *services.SearchService,
 ) {
 	// Global variable for API status
 	var isAPIActive = true
@@ -38,6 +49,9 @@ func SetupRoutes(
 	auth.Post("/register", authService.Register)
 	auth.Post("/login", authService.Login)
 
+	// Link unduhan bertanda tangan (HMAC) untuk verifikator eksternal tanpa akun
+	app.Get("/api/public/files/:id", fileService.DownloadSharedFile)
+
 	// ========================================
 	// PROTECTED API GROUP - JWT authentication required
 	// All routes under /api/* (except register/login above) need JWT
@@ -52,7 +66,7 @@ func SetupRoutes(
 		}
 		var req StatusRequest
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+			return utils.BadRequest("Invalid request")
 		}
 		isAPIActive = req.Active
 		return c.JSON(fiber.Map{"active": isAPIActive})
@@ -76,11 +90,17 @@ func SetupRoutes(
 	users.Get("/count", authService.GetUsersCount)
 	users.Get("/:id", authService.GetUser)
 	users.Put("/:id", authService.UpdateUser)
+	users.Patch("/:id", authService.PatchUser)
 	users.Delete("/:id", authService.DeleteUser)
 	
 	SetupMahasiswaRoutes(api, mahasiswaService)          // Student management
 	SetupAlumniRoutes(api, alumniService)                // Alumni management
 	SetupPekerjaanRoutes(api, pekerjaanService)          // Job/employment management
+	SetupPerusahaanRoutes(api, perusahaanService)        // Company management
+	SetupReferensiRoutes(api, referensiService)          // Reference data / controlled vocabulary
+	SetupAnalyticsRoutes(api, analyticsService)          // Tracer study analytics
+	SetupSurveyRoutes(api, surveyService)                // Tracer study questionnaires
 	SetupTrashRoutes(api, pekerjaanService, trashService) // Trash/recycle bin
 	SetupFileRoutes(api, fileService)                    // File management
+	SetupSearchRoutes(api, searchService)                // Global search
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"modul4crud/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSearchRoutes configures the cross-entity global search route
+// User: mahasiswa, alumni, pekerjaan, perusahaan
+// Admin: all of the above + user accounts
+func SetupSearchRoutes(api fiber.Router, searchService *services.SearchService) {
+	api.Get("/search", searchService.Search) // ?q=&type=alumni,pekerjaan&limit=5
+}
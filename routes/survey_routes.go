@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"modul4crud/middleware"
+	"modul4crud/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSurveyRoutes configures tracer study questionnaire routes
+// Admin manages versioned surveys under /surveys, alumni fill them in under /me/surveys
+func SetupSurveyRoutes(api fiber.Router, surveyService *services.SurveyService) {
+	// Admin - survey management & results
+	surveys := api.Group("/surveys", middleware.RequireAdmin())
+	surveys.Get("/", surveyService.GetSurveys)                             // List surveys (?status=draft|open|closed)
+	surveys.Get("/:id", surveyService.GetSurvey)                           // Survey detail
+	surveys.Post("/", surveyService.CreateSurvey)                          // Create survey (versi 1, draft)
+	surveys.Put("/:id", surveyService.UpdateSurvey)                        // Update draft survey
+	surveys.Delete("/:id", surveyService.DeleteSurvey)                     // Delete draft survey
+	surveys.Post("/:id/open", surveyService.OpenSurvey)                    // Open for responses
+	surveys.Post("/:id/close", surveyService.CloseSurvey)                  // Close survey
+	surveys.Post("/:id/versions", surveyService.CreateSurveyVersion)       // Copy to a new draft version
+	surveys.Get("/:id/response-rate", surveyService.GetSurveyResponseRate) // Response rate per cohort
+	surveys.Get("/:id/results", surveyService.GetSurveyResults)            // Aggregated results (?format=json|csv)
+
+	// Alumni - own surveys
+	mySurveys := api.Group("/me/surveys")
+	mySurveys.Get("/", surveyService.GetMySurveys)       // Open surveys targeted at me
+	mySurveys.Get("/:id", surveyService.GetMySurvey)     // Survey with my answers
+	mySurveys.Post("/:id", surveyService.SubmitMySurvey) // Submit or replace answers
+}
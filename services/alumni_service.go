@@ -1,20 +1,36 @@
 package services
 
 import (
+	"fmt"
+	"math"
+	"modul4crud/middleware"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"slices"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type AlumniService struct {
-	alumniRepo repo.AlumniRepository
+	alumniRepo    repo.AlumniRepository
+	pekerjaanRepo repo.PekerjaanAlumniRepository
+	referensiRepo repo.ReferensiRepository
+	history       *recordHistory
+	// pekerjaanHistory riwayat pekerjaan yang dipindahkan saat merge
+	pekerjaanHistory *recordHistory
 }
 
-func NewAlumniService(alumniRepo repo.AlumniRepository) *AlumniService {
+func NewAlumniService(alumniRepo repo.AlumniRepository, pekerjaanRepo repo.PekerjaanAlumniRepository, referensiRepo repo.ReferensiRepository, historyRepo repo.HistoryRepository) *AlumniService {
 	return &AlumniService{
-		alumniRepo: alumniRepo,
+		alumniRepo:       alumniRepo,
+		pekerjaanRepo:    pekerjaanRepo,
+		referensiRepo:    referensiRepo,
+		history:          newRecordHistory(historyRepo, models.HistoryEntityAlumni),
+		pekerjaanHistory: newRecordHistory(historyRepo, models.HistoryEntityPekerjaan),
 	}
 }
 
@@ -22,14 +38,12 @@ func (s *AlumniService) GetAlumnis(c *fiber.Ctx) error {
 	// Parse pagination parameters from query
 	var pagination models.PaginationRequest
 	if err := c.QueryParser(&pagination); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid pagination parameters",
-		})
+		return utils.BadRequest("Invalid pagination parameters")
 	}
 
 	alumnis, total, err := s.alumniRepo.GetWithPagination(&pagination)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	response := models.NewPaginationResponse(alumnis, &pagination, total)
@@ -40,7 +54,7 @@ func (s *AlumniService) GetAlumnis(c *fiber.Ctx) error {
 func (s *AlumniService) GetAlumnisLegacy(c *fiber.Ctx) error {
 	alumnis, err := s.alumniRepo.GetAll()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(alumnis)
 }
@@ -48,7 +62,10 @@ func (s *AlumniService) GetAlumnisLegacy(c *fiber.Ctx) error {
 func (s *AlumniService) CreateAlumni(c *fiber.Ctx) error {
 	var req models.CreateAlumniRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
 	}
 
 	alumni := models.Alumni{
@@ -56,71 +73,294 @@ func (s *AlumniService) CreateAlumni(c *fiber.Ctx) error {
 		NIM:        req.NIM,
 		Nama:       req.Nama,
 		Jurusan:    req.Jurusan,
+		KodeProdi:  req.KodeProdi,
 		Angkatan:   req.Angkatan,
 		TahunLulus: req.TahunLulus,
 		NoTelepon:  req.NoTelepon,
 		Alamat:     req.Alamat,
 	}
+	if err := newReferensiResolver(s.referensiRepo).resolveProdi(&alumni); err != nil {
+		return err
+	}
 
 	err := s.alumniRepo.Create(&alumni)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
+	middleware.SetETag(c, alumni.Version)
 	return c.Status(201).JSON(alumni)
 }
 
 func (s *AlumniService) GetAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 	alumni, err := s.alumniRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Alumni not found"})
+		return err
 	}
+	middleware.SetETag(c, alumni.Version)
 	return c.JSON(alumni)
 }
 
+// UpdateAlumni mengganti seluruh data alumni (PUT); field opsional yang tidak dikirim dikosongkan
 func (s *AlumniService) UpdateAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 	
 	var req models.UpdateAlumniRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return utils.BadRequest(err.Error())
 	}
 	
 	// Get existing alumni
 	alumni, err := s.alumniRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Alumni not found"})
+		return err
 	}
 	
+	return s.saveAlumni(c, alumni, &req)
+}
+
+// PatchAlumni update sebagian (JSON Merge Patch); field yang tidak dikirim tidak berubah
+func (s *AlumniService) PatchAlumni(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	patch, err := utils.ParseMergePatch(c.Body())
+	if err != nil {
+		return err
+	}
+
+	alumni, err := s.alumniRepo.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+
+	req := alumniRequest(alumni)
+	if err := patch.ApplyTo(&req); err != nil {
+		return err
+	}
+	// Jurusan baru tanpa kode_prodi: kode lama dibuang supaya jurusan dicocokkan ulang
+	if patch.Has("jurusan") && !patch.Has("kode_prodi") {
+		req.KodeProdi = ""
+	}
+
+	return s.saveAlumni(c, alumni, &req)
+}
+
+// saveAlumni memvalidasi request lengkap lalu menyimpannya ke alumni
+func (s *AlumniService) saveAlumni(c *fiber.Ctx, alumni *models.Alumni, req *models.UpdateAlumniRequest) error {
+	if err := middleware.CheckIfMatch(c, alumni.Version); err != nil {
+		return err
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+	previous, previousVersion := alumniRequest(alumni), alumni.Version
+
 	// Update fields (business logic from usecase)
 	alumni.Nama = req.Nama
 	alumni.Jurusan = req.Jurusan
+	alumni.KodeProdi = req.KodeProdi
 	alumni.Angkatan = req.Angkatan
 	alumni.TahunLulus = req.TahunLulus
 	alumni.NoTelepon = req.NoTelepon
 	alumni.Alamat = req.Alamat
-	
-	err = s.alumniRepo.Update(alumni)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := newReferensiResolver(s.referensiRepo).resolveProdi(alumni); err != nil {
+		return err
+	}
+
+	if err := s.alumniRepo.Update(alumni); err != nil {
+		return err
 	}
+	s.history.save(c, alumni.ID, previousVersion, previous)
+	middleware.SetETag(c, alumni.Version)
 	return c.JSON(alumni)
 }
 
+// alumniRequest isi alumni yang bisa diubah, dasar PATCH dan snapshot riwayat
+func alumniRequest(alumni *models.Alumni) models.UpdateAlumniRequest {
+	return models.UpdateAlumniRequest{
+		Nama:       alumni.Nama,
+		Jurusan:    alumni.Jurusan,
+		KodeProdi:  alumni.KodeProdi,
+		Angkatan:   alumni.Angkatan,
+		TahunLulus: alumni.TahunLulus,
+		NoTelepon:  alumni.NoTelepon,
+		Alamat:     alumni.Alamat,
+	}
+}
+
+// GetAlumniHistory daftar versi lama profil alumni beserta perubahannya (admin)
+func (s *AlumniService) GetAlumniHistory(c *fiber.Ctx) error {
+	alumni, err := s.alumniFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.list(c, alumni.ID, alumni.Version, alumniRequest(alumni))
+}
+
+// DiffAlumniHistory perbedaan dua versi profil alumni (?from=&to=)
+func (s *AlumniService) DiffAlumniHistory(c *fiber.Ctx) error {
+	alumni, err := s.alumniFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.diff(c, alumni.ID, alumni.Version, alumniRequest(alumni))
+}
+
+// RestoreAlumniVersion mengembalikan profil alumni ke versi lama; kode prodi
+// dari snapshot dicocokkan ulang dengan referensi yang berlaku sekarang
+func (s *AlumniService) RestoreAlumniVersion(c *fiber.Ctx) error {
+	alumni, err := s.alumniFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateAlumniRequest
+	if err := s.history.restore(c, alumni.ID, &req); err != nil {
+		return err
+	}
+
+	return s.saveAlumni(c, alumni, &req)
+}
+
+// alumniFromParam mengambil alumni berdasarkan parameter :id
+func (s *AlumniService) alumniFromParam(c *fiber.Ctx) (*models.Alumni, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid ID")
+	}
+	return s.alumniRepo.GetByID(uint(id))
+}
+
+// FindAlumniDuplicates daftar pasangan alumni yang diduga duplikat, urut skor
+// tertinggi (?min_score=&limit=)
+func (s *AlumniService) FindAlumniDuplicates(c *fiber.Ctx) error {
+	minScore, limit, err := duplicateQuery(c)
+	if err != nil {
+		return err
+	}
+
+	alumnis, err := s.alumniRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	pairs := utils.FindDuplicates(alumnis, alumniBlockKeys, alumniDuplicateSignals, minScore)
+	return duplicateResponse(c, pairs, minScore, limit)
+}
+
+// MergeAlumni menggabungkan alumni duplikat (source_ids) ke alumni :id. Pekerjaan
+// dipindahkan ke alumni tujuan dan alumni sumber di-soft delete; akun user yang
+// tidak dipilih tidak lagi tertaut ke alumni mana pun (unlinked_user_ids).
+func (s *AlumniService) MergeAlumni(c *fiber.Ctx) error {
+	target, err := s.alumniFromParam(c)
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, target.Version); err != nil {
+		return err
+	}
+
+	var req models.MergeAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	sourceIDs, err := mergeSourceIDs(target.ID, req.SourceIDs, "Alumni")
+	if err != nil {
+		return err
+	}
+
+	userIDs := []int{target.UserID}
+	sources := make([]*models.Alumni, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		source, err := s.alumniRepo.GetByID(sourceID)
+		if err != nil {
+			if utils.IsNotFound(err) {
+				return utils.NotFound(fmt.Sprintf("Alumni %d tidak ditemukan", sourceID))
+			}
+			return err
+		}
+		userIDs = append(userIDs, source.UserID)
+		sources = append(sources, source)
+	}
+
+	userID := target.UserID
+	if req.UserID != nil {
+		if !slices.Contains(userIDs, *req.UserID) {
+			return utils.BadRequest("user_id harus akun milik salah satu alumni yang digabung")
+		}
+		userID = *req.UserID
+	}
+
+	unlinked := []int{}
+	for _, id := range userIDs {
+		if id > 0 && id != userID && !slices.Contains(unlinked, id) {
+			unlinked = append(unlinked, id)
+		}
+	}
+
+	// Merge menaikkan versi alumni tujuan, alumni sumber dan pekerjaan yang
+	// dipindahkan, jadi semuanya dicatat di riwayat
+	pekerjaans, err := s.pekerjaanRepo.GetAllByAlumniIDs(sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	previous, previousVersion := alumniRequest(target), target.Version
+	if err := s.alumniRepo.Merge(target.ID, sourceIDs, userID); err != nil {
+		return err
+	}
+	s.history.save(c, target.ID, previousVersion, previous)
+	for _, source := range sources {
+		s.history.save(c, source.ID, source.Version, alumniRequest(source))
+	}
+	for i := range pekerjaans {
+		s.pekerjaanHistory.save(c, pekerjaans[i].ID, pekerjaans[i].Version, pekerjaanRequest(&pekerjaans[i]))
+	}
+
+	alumni, err := s.alumniRepo.GetByID(target.ID)
+	if err != nil {
+		return err
+	}
+	middleware.SetETag(c, alumni.Version)
+	return c.JSON(fiber.Map{
+		"message":           "Alumni berhasil digabungkan",
+		"alumni":            alumni,
+		"merged_ids":        sourceIDs,
+		"unlinked_user_ids": unlinked,
+	})
+}
+
 func (s *AlumniService) DeleteAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
-	err = s.alumniRepo.Delete(uint(id))
+	version, err := middleware.CheckIfMatchWith(c, func() (int, error) {
+		alumni, err := s.alumniRepo.GetByID(uint(id))
+		if err != nil {
+			return 0, err
+		}
+		return alumni.Version, nil
+	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+	err = s.alumniRepo.Delete(uint(id), version)
+	if err != nil {
+		return err
 	}
 	return c.SendStatus(204)
 }
@@ -128,7 +368,7 @@ func (s *AlumniService) DeleteAlumni(c *fiber.Ctx) error {
 func (s *AlumniService) CountAlumni(c *fiber.Ctx) error {
 	count, err := s.alumniRepo.Count()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(fiber.Map{
 		"count":   count,
@@ -139,7 +379,7 @@ func (s *AlumniService) CountAlumni(c *fiber.Ctx) error {
 func (s *AlumniService) GetAlumniCount(c *fiber.Ctx) error {
 	count, err := s.alumniRepo.Count()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(fiber.Map{
 		"total_alumni": count,
@@ -151,73 +391,217 @@ func (s *AlumniService) GetAlumniByUser(c *fiber.Ctx) error {
 	// Get user info from middleware locals
 	userID, ok := c.Locals("user_id").(int)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+		return utils.Unauthorized("User ID tidak ditemukan")
 	}
 
 	alumni, err := s.alumniRepo.GetByUserID(userID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Alumni profile not found"})
+		return err
 	}
 
 	return c.JSON(alumni)
 }
 
-// GetAlumniStatsByYear - Get alumni statistics grouped by graduation year
-func (s *AlumniService) GetAlumniStatsByYear(c *fiber.Ctx) error {
-	alumnis, err := s.alumniRepo.GetAll()
+// GetAlumniTimeline - Riwayat karier alumni urut tanggal mulai kerja, dengan
+// masa kerja tiap pekerjaan dan jeda antar pekerjaan
+func (s *AlumniService) GetAlumniTimeline(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	alumni, err := s.alumniRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	// Group by tahun_lulus
-	stats := make(map[int]int)
-	for _, alumni := range alumnis {
-		stats[alumni.TahunLulus]++
+	pekerjaans, err := s.pekerjaanRepo.GetByAlumniID(alumni.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(buildCareerTimeline(alumni, pekerjaans, time.Now()))
+}
+
+// GetAlumniStatsByYear - Get alumni statistics grouped by graduation year
+func (s *AlumniService) GetAlumniStatsByYear(c *fiber.Ctx) error {
+	groups, err := s.alumniRepo.CountByGroup("tahun_lulus", c.Query("search"))
+	if err != nil {
+		return err
 	}
 
 	// Convert to array for response
 	type YearStat struct {
-		Year  int `json:"year"`
-		Count int `json:"count"`
+		Year  int   `json:"year"`
+		Count int64 `json:"count"`
 	}
-	
+
 	result := []YearStat{}
-	for year, count := range stats {
-		result = append(result, YearStat{Year: year, Count: count})
+	var total int64
+	for _, group := range groups {
+		year, _ := strconv.Atoi(group.Kode)
+		result = append(result, YearStat{Year: year, Count: group.Count})
+		total += group.Count
 	}
 
 	return c.JSON(fiber.Map{
-		"data": result,
-		"total": len(alumnis),
+		"data":  result,
+		"total": total,
 	})
 }
 
-// GetAlumniStatsByJurusan - Get alumni statistics grouped by jurusan
+// GetAlumniStatsByJurusan - Get alumni statistics grouped by program studi (kode prodi).
+// ?level=fakultas mengelompokkan per fakultas.
 func (s *AlumniService) GetAlumniStatsByJurusan(c *fiber.Ctx) error {
-	alumnis, err := s.alumniRepo.GetAll()
+	level := c.Query("level", models.KategoriProdi)
+	if level != models.KategoriProdi && level != models.KategoriFakultas {
+		return utils.BadRequest("level harus prodi atau fakultas")
+	}
+
+	groups, err := s.alumniRepo.CountByGroup("kode_prodi", c.Query("search"))
+	if err != nil {
+		return err
+	}
+
+	resolver := newReferensiResolver(s.referensiRepo)
+	prodi, err := resolver.vocabulary(models.KategoriProdi)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+	fakultas, err := resolver.vocabulary(models.KategoriFakultas)
+	if err != nil {
+		return err
 	}
 
-	// Group by jurusan
-	stats := make(map[string]int)
-	for _, alumni := range alumnis {
-		stats[alumni.Jurusan]++
+	// Jumlah per kode prodi dihitung di database; level fakultas menggabungkan
+	// prodi ke fakultas induknya. Jurusan yang belum terpetakan memakai teks aslinya.
+	stats := kodeCounter{}
+	var total int64
+	for _, group := range groups {
+		total += group.Count
+		if level == models.KategoriFakultas {
+			kodeFakultas := ""
+			if ref := prodi.lookupKode(group.Kode); ref != nil {
+				kodeFakultas = ref.KodeInduk
+			}
+			stats.add(kodeFakultas, fakultas.nama(kodeFakultas, statLabel(group)), group.Count)
+			continue
+		}
+		stats.add(group.Kode, prodi.nama(group.Kode, statLabel(group)), group.Count)
 	}
 
 	// Convert to array for response
 	type JurusanStat struct {
+		Kode    string `json:"kode"`
 		Jurusan string `json:"jurusan"`
-		Count   int    `json:"count"`
+		Count   int64  `json:"count"`
 	}
-	
+
 	result := []JurusanStat{}
-	for jurusan, count := range stats {
-		result = append(result, JurusanStat{Jurusan: jurusan, Count: count})
+	for _, item := range stats.sorted() {
+		result = append(result, JurusanStat{Kode: item.Kode, Jurusan: item.Nama, Count: item.Count})
 	}
 
 	return c.JSON(fiber.Map{
-		"data": result,
-		"total": len(alumnis),
+		"data":  result,
+		"level": level,
+		"total": total,
 	})
 }
+
+// buildCareerTimeline menyusun timeline karier. Pekerjaan aktif tanpa tanggal
+// selesai dihitung sampai now. Jeda dihitung dari akhir pekerjaan-pekerjaan
+// sebelumnya (yang paling akhir selesai), sehingga pekerjaan yang berjalan
+// bersamaan tidak dihitung dua kali di total masa kerja.
+func buildCareerTimeline(alumni *models.Alumni, pekerjaans []models.PekerjaanAlumni, now time.Time) models.CareerTimeline {
+	sort.SliceStable(pekerjaans, func(i, j int) bool {
+		if !pekerjaans[i].TanggalMulaiKerja.Equal(pekerjaans[j].TanggalMulaiKerja) {
+			return pekerjaans[i].TanggalMulaiKerja.Before(pekerjaans[j].TanggalMulaiKerja)
+		}
+		return pekerjaans[i].ID < pekerjaans[j].ID
+	})
+
+	timeline := models.CareerTimeline{
+		AlumniID:        alumni.ID,
+		Nama:            alumni.Nama,
+		TahunLulus:      alumni.TahunLulus,
+		JumlahPekerjaan: len(pekerjaans),
+		Pekerjaan:       []models.CareerTimelineEntry{},
+	}
+	if len(pekerjaans) > 0 && alumni.TahunLulus > 0 {
+		masaTunggu := bulatkanBulan(masaTungguBulan(alumni.TahunLulus, pekerjaans[0].TanggalMulaiKerja))
+		timeline.MasaTungguBulan = &masaTunggu
+	}
+
+	var tercakupSampai time.Time
+	var totalKerja, totalJeda float64
+	for i, pekerjaan := range pekerjaans {
+		entry := models.CareerTimelineEntry{
+			PekerjaanID:         pekerjaan.ID,
+			NamaPerusahaan:      pekerjaan.NamaPerusahaan,
+			PosisiJabatan:       pekerjaan.PosisiJabatan,
+			BidangIndustri:      pekerjaan.BidangIndustri,
+			KodeIndustri:        pekerjaan.KodeIndustri,
+			StatusPekerjaan:     pekerjaan.StatusPekerjaan,
+			TanggalMulaiKerja:   pekerjaan.TanggalMulaiKerja,
+			TanggalSelesaiKerja: pekerjaan.TanggalSelesaiKerja,
+		}
+
+		mulai := pekerjaan.TanggalMulaiKerja
+		if i > 0 {
+			jeda := 0.0
+			if mulai.After(tercakupSampai) {
+				jeda = bulanAntara(tercakupSampai, mulai)
+			} else {
+				entry.TumpangTindih = true
+			}
+			jeda = bulatkanBulan(jeda)
+			entry.JedaSebelumnyaBulan = &jeda
+			totalJeda += jeda
+		}
+
+		// Akhir periode tidak diketahui untuk pekerjaan tidak aktif tanpa tanggal selesai
+		selesai := mulai
+		known := true
+		switch {
+		case pekerjaan.TanggalSelesaiKerja != nil:
+			selesai = *pekerjaan.TanggalSelesaiKerja
+		case pekerjaan.StatusPekerjaan != models.StatusPekerjaanTidakAktif:
+			selesai = now
+		default:
+			known = false
+		}
+		if known {
+			masaKerja := bulatkanBulan(bulanAntara(mulai, selesai))
+			entry.MasaKerjaBulan = &masaKerja
+		}
+
+		// Total masa kerja hanya menghitung bagian periode yang belum tercakup
+		awal := mulai
+		if i > 0 && tercakupSampai.After(awal) {
+			awal = tercakupSampai
+		}
+		if selesai.After(awal) {
+			totalKerja += bulanAntara(awal, selesai)
+		}
+		if i == 0 || selesai.After(tercakupSampai) {
+			tercakupSampai = selesai
+		}
+
+		timeline.Pekerjaan = append(timeline.Pekerjaan, entry)
+	}
+
+	timeline.TotalMasaKerjaBulan = bulatkanBulan(totalKerja)
+	timeline.TotalJedaBulan = bulatkanBulan(totalJeda)
+	return timeline
+}
+
+// bulanAntara selisih dua tanggal dalam bulan (rata-rata panjang bulan)
+func bulanAntara(dari, sampai time.Time) float64 {
+	return sampai.Sub(dari).Hours() / 24 / rataRataHariBulan
+}
+
+// bulatkanBulan membulatkan durasi bulan ke satu angka desimal
+func bulatkanBulan(bulan float64) float64 {
+	return math.Round(bulan*10) / 10
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas masa tunggu (bulan) untuk indikator tracer study
+const (
+	tracerBatas6Bulan  = 6.0
+	tracerBatas12Bulan = 12.0
+	tracerBatas18Bulan = 18.0
+	rataRataHariBulan  = 30.4375
+)
+
+type AnalyticsService struct {
+	alumniRepo    repo.AlumniRepository
+	referensiRepo repo.ReferensiRepository
+}
+
+func NewAnalyticsService(alumniRepo repo.AlumniRepository, referensiRepo repo.ReferensiRepository) *AnalyticsService {
+	return &AnalyticsService{
+		alumniRepo:    alumniRepo,
+		referensiRepo: referensiRepo,
+	}
+}
+
+// GetTracerSummary - Indikator tracer study untuk seluruh alumni sesuai filter
+// (?tahun_lulus, ?angkatan, ?kode_prodi)
+func (s *AnalyticsService) GetTracerSummary(c *fiber.Ctx) error {
+	records, filter, err := s.tracerRecords(c)
+	if err != nil {
+		return err
+	}
+
+	selaras, err := s.prodiSelaras()
+	if err != nil {
+		return err
+	}
+
+	metrics := hitungTracer(records, selaras)
+	metrics.Grup = "semua"
+
+	return c.JSON(fiber.Map{
+		"data":   metrics,
+		"filter": filter,
+	})
+}
+
+// GetTracerByJurusan - Indikator tracer study per program studi (kode prodi)
+func (s *AnalyticsService) GetTracerByJurusan(c *fiber.Ctx) error {
+	records, filter, err := s.tracerRecords(c)
+	if err != nil {
+		return err
+	}
+
+	selaras, err := s.prodiSelaras()
+	if err != nil {
+		return err
+	}
+	prodi, err := loadVocabulary(s.referensiRepo, models.KategoriProdi)
+	if err != nil {
+		return err
+	}
+
+	// Jurusan yang belum terpetakan dikelompokkan berdasarkan teks aslinya
+	groups := map[string][]models.TracerRecord{}
+	labels := map[string]models.StatGroup{}
+	for _, record := range records {
+		key := record.KodeProdi
+		if key == "" {
+			key = "\x00" + record.Jurusan
+		}
+		groups[key] = append(groups[key], record)
+		labels[key] = models.StatGroup{Kode: record.KodeProdi, Label: record.Jurusan}
+	}
+
+	result := []models.TracerMetrics{}
+	for key, items := range groups {
+		metrics := hitungTracer(items, selaras)
+		metrics.Kode = labels[key].Kode
+		metrics.Grup = prodi.nama(labels[key].Kode, statLabel(labels[key]))
+		result = append(result, metrics)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Grup != result[j].Grup {
+			return result[i].Grup < result[j].Grup
+		}
+		return result[i].Kode < result[j].Kode
+	})
+
+	return c.JSON(fiber.Map{
+		"data":   result,
+		"filter": filter,
+	})
+}
+
+// GetTracerByAngkatan - Indikator tracer study per angkatan
+func (s *AnalyticsService) GetTracerByAngkatan(c *fiber.Ctx) error {
+	return s.tracerByTahun(c, func(record models.TracerRecord) int { return record.Angkatan })
+}
+
+// GetTracerByTahunLulus - Indikator tracer study per tahun lulus (cohort)
+func (s *AnalyticsService) GetTracerByTahunLulus(c *fiber.Ctx) error {
+	return s.tracerByTahun(c, func(record models.TracerRecord) int { return record.TahunLulus })
+}
+
+// ExportTracer - Export tabel LKPS BAN-PT per tahun lulus.
+// ?tabel=waktu-tunggu (default) atau kesesuaian, ?format=csv (default) atau json
+func (s *AnalyticsService) ExportTracer(c *fiber.Ctx) error {
+	tabel := c.Query("tabel", "waktu-tunggu")
+	if tabel != "waktu-tunggu" && tabel != "kesesuaian" {
+		return utils.BadRequest("tabel harus waktu-tunggu atau kesesuaian")
+	}
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return utils.BadRequest("format harus csv atau json")
+	}
+
+	records, _, err := s.tracerRecords(c)
+	if err != nil {
+		return err
+	}
+
+	selaras, err := s.prodiSelaras()
+	if err != nil {
+		return err
+	}
+
+	cohorts := groupTracerByTahun(records, func(record models.TracerRecord) int { return record.TahunLulus }, selaras)
+
+	header := []string{"Tahun Lulus", "Jumlah Lulusan", "Jumlah Lulusan yang Terlacak",
+		"WT < 6 bulan", "6 ≤ WT ≤ 18 bulan", "WT > 18 bulan"}
+	if tabel == "kesesuaian" {
+		header = []string{"Tahun Lulus", "Jumlah Lulusan", "Jumlah Lulusan yang Terlacak",
+			"Kesesuaian Rendah", "Kesesuaian Sedang", "Kesesuaian Tinggi"}
+	}
+
+	rows := [][]string{}
+	for _, cohort := range cohorts {
+		row := []string{cohort.Kode, strconv.Itoa(cohort.JumlahLulusan), strconv.Itoa(cohort.JumlahTerlacak)}
+		if tabel == "kesesuaian" {
+			row = append(row, strconv.Itoa(cohort.TidakSelaras), strconv.Itoa(cohort.SelarasSedang), strconv.Itoa(cohort.Selaras))
+		} else {
+			row = append(row, strconv.Itoa(cohort.MasaTungguKurang6), strconv.Itoa(cohort.MasaTunggu6Sampai18), strconv.Itoa(cohort.MasaTungguLebih18))
+		}
+		rows = append(rows, row)
+	}
+
+	if format == "json" {
+		return c.JSON(fiber.Map{
+			"tabel":  tabel,
+			"header": header,
+			"rows":   rows,
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(header)
+	writer.WriteAll(rows)
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="tracer-%s.csv"`, tabel))
+	return c.Send(buf.Bytes())
+}
+
+// tracerRecords membaca filter dari query lalu mengambil data alumni dan pekerjaan pertamanya
+func (s *AnalyticsService) tracerRecords(c *fiber.Ctx) ([]models.TracerRecord, models.TracerFilter, error) {
+	filter := models.TracerFilter{
+		TahunLulus: c.QueryInt("tahun_lulus"),
+		Angkatan:   c.QueryInt("angkatan"),
+		KodeProdi:  utils.NormalizeKode(c.Query("kode_prodi")),
+	}
+	records, err := s.alumniRepo.GetTracerRecords(filter)
+	return records, filter, err
+}
+
+// Tingkat keselarasan pekerjaan pertama dengan prodi (kolom kesesuaian LKPS)
+const (
+	selarasRendah = iota
+	selarasSedang
+	selarasTinggi
+)
+
+// prodiSelaras memetakan kode prodi ke tingkat keselarasan tiap kode industri.
+// Tinggi jika industri ada di kode_terkait prodi itu sendiri, sedang jika hanya
+// ada di kode_terkait prodi lain dalam fakultas yang sama (bidang serumpun)
+func (s *AnalyticsService) prodiSelaras() (map[string]map[string]int, error) {
+	prodis, err := s.referensiRepo.GetByKategori(models.KategoriProdi)
+	if err != nil {
+		return nil, err
+	}
+
+	fakultas := map[string][]string{}
+	for _, prodi := range prodis {
+		if prodi.KodeInduk != "" {
+			fakultas[prodi.KodeInduk] = append(fakultas[prodi.KodeInduk], prodi.KodeTerkait...)
+		}
+	}
+
+	selaras := map[string]map[string]int{}
+	for _, prodi := range prodis {
+		if len(prodi.KodeTerkait) == 0 {
+			continue
+		}
+		selaras[prodi.Kode] = map[string]int{}
+		if prodi.KodeInduk != "" {
+			for _, kode := range fakultas[prodi.KodeInduk] {
+				selaras[prodi.Kode][kode] = selarasSedang
+			}
+		}
+		for _, kode := range prodi.KodeTerkait {
+			selaras[prodi.Kode][kode] = selarasTinggi
+		}
+	}
+	return selaras, nil
+}
+
+func (s *AnalyticsService) tracerByTahun(c *fiber.Ctx, tahun func(models.TracerRecord) int) error {
+	records, filter, err := s.tracerRecords(c)
+	if err != nil {
+		return err
+	}
+
+	selaras, err := s.prodiSelaras()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   groupTracerByTahun(records, tahun, selaras),
+		"filter": filter,
+	})
+}
+
+// groupTracerByTahun menghitung indikator per tahun (angkatan atau tahun lulus), urut tahun
+func groupTracerByTahun(records []models.TracerRecord, tahun func(models.TracerRecord) int, selaras map[string]map[string]int) []models.TracerMetrics {
+	groups := map[int][]models.TracerRecord{}
+	for _, record := range records {
+		groups[tahun(record)] = append(groups[tahun(record)], record)
+	}
+
+	years := make([]int, 0, len(groups))
+	for year := range groups {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+
+	result := make([]models.TracerMetrics, 0, len(years))
+	for _, year := range years {
+		metrics := hitungTracer(groups[year], selaras)
+		metrics.Kode = strconv.Itoa(year)
+		metrics.Grup = strconv.Itoa(year)
+		result = append(result, metrics)
+	}
+	return result
+}
+
+// hitungTracer menghitung indikator tracer study untuk sekumpulan alumni
+func hitungTracer(records []models.TracerRecord, selaras map[string]map[string]int) models.TracerMetrics {
+	metrics := models.TracerMetrics{JumlahLulusan: len(records)}
+
+	masaTunggu := []float64{}
+	for _, record := range records {
+		if record.TanggalMulaiKerja == nil {
+			continue
+		}
+		metrics.JumlahTerlacak++
+
+		bulan := masaTungguBulan(record.TahunLulus, *record.TanggalMulaiKerja)
+		masaTunggu = append(masaTunggu, bulan)
+		if bulan <= tracerBatas6Bulan {
+			metrics.Bekerja6Bulan++
+		}
+		if bulan <= tracerBatas12Bulan {
+			metrics.Bekerja12Bulan++
+		}
+		switch {
+		case bulan < tracerBatas6Bulan:
+			metrics.MasaTungguKurang6++
+		case bulan <= tracerBatas18Bulan:
+			metrics.MasaTunggu6Sampai18++
+		default:
+			metrics.MasaTungguLebih18++
+		}
+
+		industri, ok := selaras[record.KodeProdi]
+		if !ok || record.KodeIndustri == "" {
+			continue
+		}
+		metrics.JumlahKeselarasan++
+		switch industri[record.KodeIndustri] {
+		case selarasTinggi:
+			metrics.Selaras++
+		case selarasSedang:
+			metrics.SelarasSedang++
+		default:
+			metrics.TidakSelaras++
+		}
+	}
+
+	metrics.PersenBekerja6Bulan = persen(metrics.Bekerja6Bulan, metrics.JumlahLulusan)
+	metrics.PersenBekerja12Bulan = persen(metrics.Bekerja12Bulan, metrics.JumlahLulusan)
+	metrics.PersenSelaras = persen(metrics.Selaras, metrics.JumlahKeselarasan)
+
+	if len(masaTunggu) > 0 {
+		sort.Float64s(masaTunggu)
+		total := 0.0
+		for _, bulan := range masaTunggu {
+			total += bulan
+		}
+		metrics.MasaTungguRataRata = math.Round(total/float64(len(masaTunggu))*10) / 10
+		metrics.MasaTungguMedian = math.Round(percentile(masaTunggu, 50)*10) / 10
+	}
+
+	return metrics
+}
+
+// masaTungguBulan selisih bulan antara tanggal lulus dan mulai kerja. Data alumni
+// hanya menyimpan tahun lulus, jadi tanggal lulus dianggap pertengahan tahun
+// (1 Juli) agar selisihnya paling banyak 6 bulan dari tanggal sebenarnya.
+// Pekerjaan yang dimulai sebelum lulus dihitung masa tunggu 0.
+func masaTungguBulan(tahunLulus int, mulaiKerja time.Time) float64 {
+	lulus := time.Date(tahunLulus, time.July, 1, 0, 0, 0, 0, time.UTC)
+	hari := mulaiKerja.Sub(lulus).Hours() / 24
+	if hari <= 0 {
+		return 0
+	}
+	return hari / rataRataHariBulan
+}
+
+// persen menghitung persentase dengan dua angka desimal
+func persen(bagian, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(bagian)/float64(total)*10000) / 100
+}
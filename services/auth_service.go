@@ -26,32 +26,27 @@ func NewAuthService(userRepo repo.UserRepository) *AuthService {
 func (s *AuthService) Register(c *fiber.Ctx) error {
 	var req models.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Data request tidak valid",
-		})
+		return utils.BadRequest("Data request tidak valid")
 	}
 
 	// Business logic moved from usecase
 	// Validasi input
-	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Username, email, dan password wajib diisi",
-		})
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
 	}
 
 	// Cek apakah user sudah ada
-	existingUser, _ := s.userRepo.GetByUsername(req.Username)
-	if existingUser != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Username sudah digunakan",
-		})
+	if _, err := s.userRepo.GetByUsername(req.Username); !utils.IsNotFound(err) {
+		if err != nil {
+			return err
+		}
+		return utils.DuplicateError("username")
 	}
-
-	existingUser, _ = s.userRepo.GetByEmail(req.Email)
-	if existingUser != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Email sudah digunakan",
-		})
+	if _, err := s.userRepo.GetByEmail(req.Email); !utils.IsNotFound(err) {
+		if err != nil {
+			return err
+		}
+		return utils.DuplicateError("email")
 	}
 
 	// Hash password only for PostgreSQL/MongoDB (PocketBase hashes internally)
@@ -62,9 +57,7 @@ func (s *AuthService) Register(c *fiber.Ctx) error {
 	if dbType != "pocketbase" {
 		password, err = utils.HashPassword(req.Password)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Gagal mengenkripsi password",
-			})
+			return utils.Internal(err)
 		}
 	}
 
@@ -84,9 +77,7 @@ func (s *AuthService) Register(c *fiber.Ctx) error {
 
 	err = s.userRepo.Create(user)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Gagal mendaftarkan user",
-		})
+		return err
 	}
 
 	return c.Status(201).JSON(fiber.Map{
@@ -99,9 +90,7 @@ func (s *AuthService) Register(c *fiber.Ctx) error {
 func (s *AuthService) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Data request tidak valid",
-		})
+		return utils.BadRequest("Data request tidak valid")
 	}
 
 	// Debug logging
@@ -109,11 +98,9 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 
 	// Business logic moved from usecase
 	// Validasi input
-	if req.Email == "" || req.Password == "" {
+	if err := utils.ValidateStruct(&req); err != nil {
 		fmt.Println("LOGIN DEBUG - Email atau password kosong")
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Email dan password wajib diisi",
-		})
+		return err
 	}
 
 	// Check if using PocketBase - use auth API directly
@@ -127,9 +114,7 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		user, err = s.userRepo.AuthenticateWithPassword(req.Email, req.Password)
 		if err != nil {
 			fmt.Printf("LOGIN DEBUG - PocketBase auth failed: %v\n", err)
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Email atau password salah",
-			})
+			return utils.Unauthorized("Email atau password salah")
 		}
 	} else {
 		fmt.Println("LOGIN DEBUG - Using PostgreSQL/MongoDB authentication")
@@ -137,9 +122,7 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		user, err = s.userRepo.GetByEmail(req.Email)
 		if err != nil || user == nil {
 			fmt.Printf("LOGIN DEBUG - User tidak ditemukan untuk email: %s, Error: %v\n", req.Email, err)
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Email atau password salah",
-			})
+			return utils.Unauthorized("Email atau password salah")
 		}
 
 		// Verify password with bcrypt
@@ -148,9 +131,7 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		
 		if !passwordValid {
 			fmt.Println("LOGIN DEBUG - Password tidak cocok")
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Email atau password salah",
-			})
+			return utils.Unauthorized("Email atau password salah")
 		}
 	}
 
@@ -159,18 +140,14 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 	// Cek apakah user aktif (only for non-PocketBase since PocketBase auth already checks this)
 	if dbType != "pocketbase" && !user.IsActive {
 		fmt.Println("LOGIN DEBUG - User tidak aktif")
-		return c.Status(401).JSON(fiber.Map{
-			"error": "Akun tidak aktif",
-		})
+		return utils.Unauthorized("Akun tidak aktif")
 	}
 
 	// Generate JWT token
 	token, err := utils.GenerateJWT(user)
 	if err != nil {
 		fmt.Println("LOGIN DEBUG - Gagal membuat token")
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Gagal membuat token",
-		})
+		return utils.Internal(err)
 	}
 
 	response := &models.LoginResponse{
@@ -194,9 +171,7 @@ func (s *AuthService) GetProfile(c *fiber.Ctx) error {
 
 	user, err := s.userRepo.GetByID(userInfo.UserID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User tidak ditemukan",
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -209,16 +184,12 @@ func (s *AuthService) GetUsers(c *fiber.Ctx) error {
 	// Parse pagination parameters from query
 	var pagination models.PaginationRequest
 	if err := c.QueryParser(&pagination); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid pagination parameters",
-		})
+		return utils.BadRequest("Invalid pagination parameters")
 	}
 
 	users, total, err := s.userRepo.GetWithPagination(&pagination)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := models.NewPaginationResponse(users, &pagination, total)
@@ -229,9 +200,7 @@ func (s *AuthService) GetUsers(c *fiber.Ctx) error {
 func (s *AuthService) GetUsersLegacy(c *fiber.Ctx) error {
 	users, err := s.userRepo.GetAll()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -244,84 +213,101 @@ func (s *AuthService) GetUsersLegacy(c *fiber.Ctx) error {
 func (s *AuthService) GetUser(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ID tidak valid",
-		})
+		return utils.BadRequest("ID tidak valid")
 	}
 
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User tidak ditemukan",
-		})
+		return err
 	}
 
+	middleware.SetETag(c, user.Version)
 	return c.JSON(fiber.Map{
 		"user": user,
 	})
 }
 
-// UpdateUser endpoint untuk update user (admin only)
+// UpdateUser endpoint untuk mengganti data user (admin only). Semua field
+// kecuali password wajib dikirim.
 func (s *AuthService) UpdateUser(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ID tidak valid",
-		})
+		return utils.BadRequest("ID tidak valid")
 	}
 
-	var updatedUser models.User
-	if err := c.BodyParser(&updatedUser); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Data request tidak valid",
-		})
+	var req models.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest("Data request tidak valid")
 	}
 
 	// Business logic moved from usecase
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User tidak ditemukan",
-		})
+		return err
 	}
 
-	// Update fields yang diizinkan
-	if updatedUser.Username != "" {
-		user.Username = updatedUser.Username
+	return s.saveUser(c, user, &req)
+}
+
+// PatchUser endpoint untuk update sebagian data user (admin only, JSON Merge Patch)
+func (s *AuthService) PatchUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.BadRequest("ID tidak valid")
 	}
-	if updatedUser.Email != "" {
-		user.Email = updatedUser.Email
+
+	patch, err := utils.ParseMergePatch(c.Body())
+	if err != nil {
+		return err
 	}
-	if updatedUser.Role != "" {
-		// Validasi role - hanya admin dan user
-		if updatedUser.Role != models.RoleUser && updatedUser.Role != models.RoleAdmin {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Role tidak valid. Hanya 'admin' dan 'user' yang diizinkan",
-			})
-		}
-		user.Role = updatedUser.Role
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
 	}
 
-	user.IsActive = updatedUser.IsActive
+	isActive := user.IsActive
+	req := models.UpdateUserRequest{
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		IsActive: &isActive,
+	}
+	if err := patch.ApplyTo(&req); err != nil {
+		return err
+	}
+
+	return s.saveUser(c, user, &req)
+}
+
+// saveUser memvalidasi request lengkap lalu menyimpannya ke user
+func (s *AuthService) saveUser(c *fiber.Ctx, user *models.User, req *models.UpdateUserRequest) error {
+	if err := middleware.CheckIfMatch(c, user.Version); err != nil {
+		return err
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	user.Username = req.Username
+	user.Email = req.Email
+	user.Role = req.Role
+	user.IsActive = *req.IsActive
 
 	// Hash password baru jika ada
-	if updatedUser.Password != "" {
-		hashedPassword, err := utils.HashPassword(updatedUser.Password)
+	if req.Password != "" {
+		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Gagal mengenkripsi password",
-			})
+			return utils.Internal(err)
 		}
 		user.Password = hashedPassword
 	}
 
-	err = s.userRepo.Update(user)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := s.userRepo.Update(user); err != nil {
+		return err
 	}
 
+	middleware.SetETag(c, user.Version)
 	return c.JSON(fiber.Map{
 		"message": "User berhasil diupdate",
 		"user":    user,
@@ -332,16 +318,23 @@ func (s *AuthService) UpdateUser(c *fiber.Ctx) error {
 func (s *AuthService) DeleteUser(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ID tidak valid",
-		})
+		return utils.BadRequest("ID tidak valid")
+	}
+
+	version, err := middleware.CheckIfMatchWith(c, func() (int, error) {
+		user, err := s.userRepo.GetByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return user.Version, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	err = s.userRepo.Delete(id)
+	err = s.userRepo.Delete(id, version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -353,9 +346,7 @@ func (s *AuthService) DeleteUser(c *fiber.Ctx) error {
 func (s *AuthService) GetUsersCount(c *fiber.Ctx) error {
 	count, err := s.userRepo.Count()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
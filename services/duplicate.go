@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas pencarian duplikat: skor minimal default dan jumlah pasangan per response
+const (
+	defaultDuplicateMinScore = 0.6
+	defaultDuplicateLimit    = 50
+	maxDuplicateLimit        = 500
+)
+
+// Bobot sinyal duplikat. Nama yang mirip saja belum cukup (nama umum sering sama),
+// perlu minimal satu sinyal lain untuk melewati skor minimal default.
+const (
+	duplicateNamaWeight    = 0.5 // dikali kemiripan nama
+	duplicateNamaMin       = 0.8 // kemiripan nama di bawah ini diabaikan
+	duplicateTeleponWeight = 0.3
+	duplicateNIMWeight     = 0.3 // NIM berbeda satu karakter (salah ketik)
+	duplicateUserWeight    = 0.4
+	duplicateEmailWeight   = 0.3 // bagian sebelum @ sama
+)
+
+// minNamaBlockToken kata nama yang lebih pendek (misal "m" atau "al") tidak dipakai sebagai blok
+const minNamaBlockToken = 3
+
+// duplicateQuery membaca ?min_score= (0..1) dan ?limit= pada endpoint duplicates
+func duplicateQuery(c *fiber.Ctx) (float64, int, error) {
+	minScore := defaultDuplicateMinScore
+	if value := c.Query("min_score"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return 0, 0, utils.BadRequest("Parameter min_score harus angka antara 0 dan 1")
+		}
+		minScore = parsed
+	}
+
+	limit := c.QueryInt("limit", defaultDuplicateLimit)
+	if limit < 1 || limit > maxDuplicateLimit {
+		return 0, 0, utils.BadRequest(fmt.Sprintf("Parameter limit harus antara 1 dan %d", maxDuplicateLimit))
+	}
+	return minScore, limit, nil
+}
+
+// duplicateResponse memotong pasangan sesuai limit; total tetap jumlah semua pasangan
+func duplicateResponse[T any](c *fiber.Ctx, pairs []models.DuplicatePair[T], minScore float64, limit int) error {
+	total := len(pairs)
+	if total > limit {
+		pairs = pairs[:limit]
+	}
+	return c.JSON(fiber.Map{
+		"data":      pairs,
+		"total":     total,
+		"min_score": minScore,
+	})
+}
+
+// mergeSourceIDs membuang ID ganda dari source_ids dan menolak record tujuan ikut
+// digabung. entity dipakai di pesan error (Alumni, Mahasiswa, Perusahaan).
+func mergeSourceIDs(targetID uint, ids []uint, entity string) ([]uint, error) {
+	sourceIDs := []uint{}
+	seen := map[uint]bool{}
+	for _, sourceID := range ids {
+		if sourceID == targetID {
+			return nil, utils.BadRequest(entity + " tujuan tidak boleh ada di source_ids")
+		}
+		if !seen[sourceID] {
+			seen[sourceID] = true
+			sourceIDs = append(sourceIDs, sourceID)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		return nil, utils.BadRequest("source_ids tidak boleh kosong")
+	}
+	return sourceIDs, nil
+}
+
+// namaBlockKeys kunci blok dari kata-kata nama, supaya hanya orang yang berbagi
+// minimal satu kata nama yang dibandingkan
+func namaBlockKeys(nama string) []string {
+	keys := []string{}
+	for _, token := range strings.Fields(utils.NormalizeNama(nama)) {
+		if len(token) >= minNamaBlockToken {
+			keys = append(keys, "nama:"+token)
+		}
+	}
+	return keys
+}
+
+// nimBlockKeys kunci blok NIM; NIM yang berbeda satu karakter berbagi minimal satu kunci
+func nimBlockKeys(nim string) []string {
+	keys := []string{}
+	for _, variant := range utils.DeletionVariants(strings.TrimSpace(nim)) {
+		keys = append(keys, "nim:"+variant)
+	}
+	return keys
+}
+
+// addNamaNIMSignals sinyal yang dipakai alumni maupun mahasiswa: kemiripan nama dan NIM salah ketik
+func addNamaNIMSignals(signals map[string]float64, namaA, namaB, nimA, nimB string) {
+	if similarity := utils.NameSimilarity(namaA, namaB); similarity >= duplicateNamaMin {
+		signals["nama"] = math.Round(duplicateNamaWeight*similarity*100) / 100
+	}
+	if utils.EditDistance(strings.TrimSpace(nimA), strings.TrimSpace(nimB)) == 1 {
+		signals["nim"] = duplicateNIMWeight
+	}
+}
+
+func alumniBlockKeys(a *models.Alumni) []string {
+	keys := append(namaBlockKeys(a.Nama), nimBlockKeys(a.NIM)...)
+	if telepon := utils.NormalizeTelepon(a.NoTelepon); telepon != "" {
+		keys = append(keys, "telepon:"+telepon)
+	}
+	if a.UserID > 0 {
+		keys = append(keys, fmt.Sprintf("user:%d", a.UserID))
+	}
+	return keys
+}
+
+// alumniDuplicateSignals sinyal duplikat alumni: nama, telepon, NIM salah ketik, dan akun user yang sama
+func alumniDuplicateSignals(a, b *models.Alumni) map[string]float64 {
+	signals := map[string]float64{}
+	addNamaNIMSignals(signals, a.Nama, b.Nama, a.NIM, b.NIM)
+	if telepon := utils.NormalizeTelepon(a.NoTelepon); telepon != "" && telepon == utils.NormalizeTelepon(b.NoTelepon) {
+		signals["telepon"] = duplicateTeleponWeight
+	}
+	if a.UserID > 0 && a.UserID == b.UserID {
+		signals["user"] = duplicateUserWeight
+	}
+	return signals
+}
+
+func mahasiswaBlockKeys(m *models.Mahasiswa) []string {
+	keys := append(namaBlockKeys(m.Nama), nimBlockKeys(m.NIM)...)
+	if local := emailLocalPart(m.Email); local != "" {
+		keys = append(keys, "email:"+local)
+	}
+	return keys
+}
+
+// mahasiswaDuplicateSignals sinyal duplikat mahasiswa: nama, NIM salah ketik, dan
+// email dengan nama akun sama di domain berbeda
+func mahasiswaDuplicateSignals(a, b *models.Mahasiswa) map[string]float64 {
+	signals := map[string]float64{}
+	addNamaNIMSignals(signals, a.Nama, b.Nama, a.NIM, b.NIM)
+	if local := emailLocalPart(a.Email); local != "" && local == emailLocalPart(b.Email) {
+		signals["email"] = duplicateEmailWeight
+	}
+	return signals
+}
+
+// emailLocalPart bagian email sebelum @ dalam huruf kecil
+func emailLocalPart(email string) string {
+	local, _, found := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !found {
+		return ""
+	}
+	return local
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"modul4crud/models"
+	"modul4crud/utils"
+	"slices"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fileActor user yang sedang mengakses file
+type fileActor struct {
+	userID int
+	role   string
+	admin  bool
+}
+
+func fileActorFrom(c *fiber.Ctx) (fileActor, error) {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return fileActor{}, utils.Unauthorized("User ID tidak ditemukan")
+	}
+	role, _ := c.Locals("role").(string)
+	return fileActor{userID: userID, role: role, admin: role == "admin"}, nil
+}
+
+// canManage hapus file hanya untuk pengupload dan admin. File lama tanpa
+// owner_id hanya bisa dikelola admin.
+func (a fileActor) canManage(file *models.File) bool {
+	return a.admin || (file.OwnerID > 0 && file.OwnerID == a.userID)
+}
+
+// canRead selain pengelola: pemilik entitas tempat file dilampirkan, dan semua
+// user untuk foto yang dilampirkan ke entitas
+func (s *fileService) canRead(a fileActor, file *models.File) (bool, error) {
+	if a.canManage(file) {
+		return true, nil
+	}
+	if file.EntityType == "" {
+		return false, nil
+	}
+	if file.Purpose == models.FilePurposeFoto {
+		return true, nil
+	}
+	return s.ownsEntity(a, file.EntityType, file.EntityID)
+}
+
+// ownsEntity true jika entitas terhubung ke akun user: alumni lewat user_id,
+// pekerjaan lewat alumninya. Mahasiswa tidak terhubung ke akun mana pun.
+func (s *fileService) ownsEntity(a fileActor, entityType string, entityID uint) (bool, error) {
+	switch entityType {
+	case models.FileEntityAlumni:
+		alumni, err := s.alumniRepo.GetByID(entityID)
+		if utils.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return alumni.UserID == a.userID, nil
+	case models.FileEntityPekerjaan:
+		pekerjaan, err := s.pekerjaanRepo.GetByID(entityID)
+		if utils.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return pekerjaan.Alumni.UserID == a.userID, nil
+	}
+	return false, nil
+}
+
+// entityExists memastikan entitas tujuan lampiran ada
+func (s *fileService) entityExists(entityType string, entityID uint) error {
+	var err error
+	switch entityType {
+	case models.FileEntityAlumni:
+		_, err = s.alumniRepo.GetByID(entityID)
+	case models.FileEntityMahasiswa:
+		_, err = s.mahasiswaRepo.GetByID(entityID)
+	case models.FileEntityPekerjaan:
+		_, err = s.pekerjaanRepo.GetByID(entityID)
+	}
+	return err
+}
+
+// attachmentFromForm membaca entity_type, entity_id dan purpose dari form upload
+func (s *fileService) attachmentFromForm(c *fiber.Ctx, a fileActor) (string, uint, string, error) {
+	entityType := c.FormValue("entity_type")
+	var entityID uint
+	if entityType != "" {
+		id, err := strconv.ParseUint(c.FormValue("entity_id"), 10, 32)
+		if err != nil || id == 0 {
+			return "", 0, "", utils.BadRequest("entity_id tidak valid")
+		}
+		entityID = uint(id)
+	}
+	return s.checkAttachment(a, c.FormValue("purpose", models.FilePurposeDokumen), entityType, entityID)
+}
+
+// checkAttachment memvalidasi purpose dan entitas tujuan lampiran (opsional).
+// User biasa hanya boleh melampirkan ke alumni/pekerjaan miliknya sendiri.
+func (s *fileService) checkAttachment(a fileActor, purpose, entityType string, entityID uint) (string, uint, string, error) {
+	if purpose == "" {
+		purpose = models.FilePurposeDokumen
+	}
+	if !slices.Contains(models.FilePurposes, purpose) {
+		return "", 0, "", utils.BadRequest("purpose tidak valid")
+	}
+
+	if entityType == "" {
+		return "", 0, purpose, nil
+	}
+	if !slices.Contains(models.FileEntities, entityType) {
+		return "", 0, "", utils.BadRequest("entity_type harus alumni, mahasiswa, atau pekerjaan")
+	}
+	if entityID == 0 {
+		return "", 0, "", utils.BadRequest("entity_id tidak valid")
+	}
+
+	if err := s.entityExists(entityType, entityID); err != nil {
+		return "", 0, "", err
+	}
+	if !a.admin {
+		owns, err := s.ownsEntity(a, entityType, entityID)
+		if err != nil {
+			return "", 0, "", err
+		}
+		if !owns {
+			return "", 0, "", utils.Forbidden("Access denied. You can only attach files to your own data.")
+		}
+	}
+	return entityType, entityID, purpose, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"os"
+	"time"
+)
+
+// blobID id blob per backend; isi yang sama di backend lain adalah blob lain
+func blobID(backend, sum string) string {
+	return backend + ":" + sum
+}
+
+// blobKey key isi file berdasarkan hash, dibagi per 2 karakter awal supaya
+// satu folder tidak berisi terlalu banyak file
+func blobKey(sum, ext string) string {
+	return "blobs/" + sum[:2] + "/" + sum + ext
+}
+
+// hashUpload menghitung SHA-256 isi yang akan disimpan. Gambar sudah ada di memori;
+// file lain ditulis dulu ke file sementara karena key baru diketahui setelah hash selesai.
+func hashUpload(upload *checkedUpload) (string, func(), error) {
+	if upload.image != nil {
+		sum := sha256.Sum256(upload.image)
+		return hex.EncodeToString(sum[:]), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), upload.body)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	upload.body, upload.size = tmp, size
+	return hex.EncodeToString(hash.Sum(nil)), cleanup, nil
+}
+
+// storeBlob menyimpan isi upload sekali per hash. Jika blob sudah tersimpan, isi
+// dan variant-nya dipakai ulang tanpa menulis ke storage.
+func (s *fileService) storeBlob(ctx context.Context, upload *checkedUpload) (*models.FileBlob, error) {
+	sum, cleanup, err := hashUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	backend := s.storages.Default()
+	blob, err := acquireBlob(s.blobs, &models.FileBlob{
+		ID:         blobID(backend.Name(), sum),
+		Backend:    backend.Name(),
+		StorageKey: blobKey(sum, upload.ext),
+		SHA256:     sum,
+		FileSize:   upload.size,
+		FileType:   upload.contentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if blob.Stored {
+		return blob, nil
+	}
+
+	// Blob baru, atau upload lain dengan isi sama belum selesai menulis. Menulis
+	// ulang aman karena key ditentukan oleh isinya.
+	if err := backend.Put(ctx, blob.StorageKey, upload.body, upload.size, upload.contentType); err != nil {
+		s.releaseBlob(ctx, backend, blob.ID, blob.StorageKey)
+		return nil, err
+	}
+	blob.Variants = s.storeVariants(ctx, backend, blob.StorageKey, upload)
+	if err := s.blobs.MarkStored(blob.ID, blob.Variants); err != nil {
+		s.releaseBlob(ctx, backend, blob.ID, blob.StorageKey)
+		return nil, err
+	}
+	blob.Stored = true
+	return blob, nil
+}
+
+// releaseContent melepas isi file yang record-nya dihapus. Isi baru dihapus dari
+// storage jika tidak ada file lain yang menunjuk ke blob yang sama. File lama
+// tanpa hash tidak berbagi isi, jadi langsung dihapus.
+func (s *fileService) releaseContent(ctx context.Context, file *models.File) {
+	backendName, key := file.Location()
+	backend, err := s.storages.Backend(backendName)
+	if err != nil {
+		log.Printf("Warning: Failed to delete file %s: %v", file.ID.Hex(), err)
+		return
+	}
+
+	if file.SHA256 == "" {
+		if err := backend.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: Failed to delete file from storage: %v", err)
+		}
+		deleteVariants(ctx, backend, file.Variants)
+		return
+	}
+	s.releaseBlob(ctx, backend, blobID(backendName, file.SHA256), key)
+}
+
+// blobAcquireAttempts jumlah percobaan Acquire selama blob dengan isi yang sama
+// sedang dihapus oleh releaseBlob
+const blobAcquireAttempts = 5
+
+// acquireBlob Acquire yang diulang dengan jeda selama isi yang sama sedang
+// dihapus (CONFLICT), supaya upload menunggu penghapusan selesai lalu menulis ulang
+func acquireBlob(blobs repo.FileBlobRepository, blob *models.FileBlob) (*models.FileBlob, error) {
+	for attempt := 1; ; attempt++ {
+		acquired, err := blobs.Acquire(blob)
+		if !errors.Is(err, utils.ErrConflict) || attempt == blobAcquireAttempts {
+			return acquired, err
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+}
+
+func (s *fileService) releaseBlob(ctx context.Context, backend storage.Storage, id, key string) {
+	releaseBlob(ctx, s.blobs, backend, id, key)
+}
+
+// releaseBlob mengurangi RefCount blob dan menghapus isi serta variant-nya jika
+// sudah tidak dipakai. Record blob tetap ada (ditandai DeletingAt) selama isinya
+// dihapus, jadi upload isi yang sama menunggu dan tidak kehilangan isinya.
+func releaseBlob(ctx context.Context, blobs repo.FileBlobRepository, backend storage.Storage, id, key string) {
+	blob, removed, err := blobs.Release(id)
+	if err != nil {
+		// Tanpa blob tidak diketahui siapa lagi yang memakai isinya; biarkan, scan integritas akan melaporkannya
+		log.Printf("Warning: Failed to release blob %s: %v", id, err)
+		return
+	}
+	if !removed {
+		return
+	}
+	if err := backend.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		// Tanda hapus dibiarkan supaya isinya tetap tercatat; Acquire berikutnya mengambil alih
+		log.Printf("Warning: Failed to delete blob %s from storage: %v", id, err)
+		return
+	}
+	deleteVariants(ctx, backend, blob.Variants)
+	if err := blobs.Purge(id); err != nil {
+		log.Printf("Warning: Failed to purge blob %s: %v", id, err)
+	}
+}
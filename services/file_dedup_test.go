@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPDF = "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n"
+
+func pdfUpload(content string) *checkedUpload {
+	return &checkedUpload{
+		body:        strings.NewReader(content),
+		size:        int64(len(content)),
+		contentType: "application/pdf",
+		ext:         ".pdf",
+	}
+}
+
+func countObjects(t *testing.T, backend storage.Storage, prefix string) int {
+	t.Helper()
+	count := 0
+	if err := backend.List(context.Background(), prefix, func(storage.Object) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestBlobAcquireRelease(t *testing.T) {
+	tests := []struct {
+		name         string
+		stores       int
+		releases     int
+		wantRefCount int
+		wantBlob     bool
+	}{
+		{name: "satu upload", stores: 1, wantRefCount: 1, wantBlob: true},
+		{name: "isi sama disimpan sekali", stores: 3, wantRefCount: 3, wantBlob: true},
+		{name: "sebagian dihapus", stores: 3, releases: 2, wantRefCount: 1, wantBlob: true},
+		{name: "semua dihapus", stores: 2, releases: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestFileService(t, nil)
+
+			var blob *models.FileBlob
+			for i := 0; i < tt.stores; i++ {
+				stored, err := s.storeBlob(ctx, pdfUpload(testPDF))
+				if err != nil {
+					t.Fatalf("storeBlob: %v", err)
+				}
+				if blob != nil && stored.ID != blob.ID {
+					t.Fatalf("blob ID = %s, want %s", stored.ID, blob.ID)
+				}
+				blob = stored
+			}
+			if got := countObjects(t, s.backend, "blobs/"); got != 1 {
+				t.Errorf("object di storage = %d, want 1", got)
+			}
+
+			for i := 0; i < tt.releases; i++ {
+				s.releaseBlob(ctx, s.backend, blob.ID, blob.StorageKey)
+			}
+
+			got, ok := s.blobRepo.get(blob.ID)
+			if ok != tt.wantBlob {
+				t.Fatalf("blob ada = %v, want %v", ok, tt.wantBlob)
+			}
+			if ok && (got.RefCount != tt.wantRefCount || !got.Stored || got.DeletingAt != nil) {
+				t.Errorf("blob = RefCount %d Stored %v DeletingAt %v, want RefCount %d tersimpan tanpa tanda hapus",
+					got.RefCount, got.Stored, got.DeletingAt, tt.wantRefCount)
+			}
+			if exists := s.exists(t, blob.StorageKey); exists != tt.wantBlob {
+				t.Errorf("isi ada di storage = %v, want %v", exists, tt.wantBlob)
+			}
+		})
+	}
+}
+
+func TestBlobConcurrentStore(t *testing.T) {
+	ctx := context.Background()
+	s := newTestFileService(t, nil)
+
+	const uploads = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, uploads)
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.storeBlob(ctx, pdfUpload(testPDF))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("storeBlob: %v", err)
+		}
+	}
+
+	blob, err := s.blobRepo.FindByID(blobID(storage.BackendLocal, sha256Hex(testPDF)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.RefCount != uploads || !blob.Stored {
+		t.Errorf("blob = RefCount %d Stored %v, want RefCount %d tersimpan", blob.RefCount, blob.Stored, uploads)
+	}
+	if got := countObjects(t, s.backend, "blobs/"); got != 1 {
+		t.Errorf("object di storage = %d, want 1", got)
+	}
+}
+
+func TestReleaseBlobKeepsMarkerWhenDeleteFails(t *testing.T) {
+	ctx := context.Background()
+	s := newTestFileService(t, nil)
+
+	blob, err := s.storeBlob(ctx, pdfUpload(testPDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.backend.setDeleteErr(errors.New("storage mati"))
+	s.releaseBlob(ctx, s.backend, blob.ID, blob.StorageKey)
+
+	got, ok := s.blobRepo.get(blob.ID)
+	if !ok || got.DeletingAt == nil || got.RefCount != 0 {
+		t.Fatalf("blob = %+v (ada %v), want tetap tercatat dengan tanda hapus", got, ok)
+	}
+	if !s.exists(t, blob.StorageKey) {
+		t.Error("isi blob hilang padahal Delete gagal")
+	}
+	if _, err := s.blobRepo.Acquire(&models.FileBlob{ID: blob.ID}); !errors.Is(err, utils.ErrConflict) {
+		t.Errorf("Acquire selama dihapus = %v, want CONFLICT", err)
+	}
+}
+
+func TestAcquireBlobWaitsForDeletion(t *testing.T) {
+	tests := []struct {
+		name    string
+		purge   bool
+		wantErr error
+	}{
+		{name: "penghapusan selesai", purge: true},
+		{name: "penghapusan tidak selesai", wantErr: utils.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blobs := newFakeBlobRepo()
+			blob := &models.FileBlob{ID: "local:abc", Backend: storage.BackendLocal, StorageKey: "blobs/ab/abc.pdf"}
+			if _, err := blobs.Acquire(blob); err != nil {
+				t.Fatal(err)
+			}
+			if _, removed, err := blobs.Release(blob.ID); err != nil || !removed {
+				t.Fatalf("Release = %v, %v; want removed", removed, err)
+			}
+
+			if tt.purge {
+				go func() {
+					time.Sleep(150 * time.Millisecond)
+					blobs.Purge(blob.ID)
+				}()
+			}
+
+			acquired, err := acquireBlob(blobs, blob)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("acquireBlob = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("acquireBlob: %v", err)
+			}
+			if acquired.RefCount != 1 || acquired.Stored || acquired.DeletingAt != nil {
+				t.Errorf("blob = RefCount %d Stored %v DeletingAt %v, want blob baru", acquired.RefCount, acquired.Stored, acquired.DeletingAt)
+			}
+		})
+	}
+}
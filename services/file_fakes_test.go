@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"modul4crud/malware"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Repository palsu di memori untuk test fileService. Semantiknya mengikuti
+// repository MongoDB: operasi atomik dijalankan di bawah satu mutex.
+
+type fakeFileRepo struct {
+	mu    sync.Mutex
+	files map[string]models.File
+}
+
+func newFakeFileRepo() *fakeFileRepo {
+	return &fakeFileRepo{files: map[string]models.File{}}
+}
+
+func (r *fakeFileRepo) Create(file *models.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file.ID = primitive.NewObjectID()
+	file.UploadedAt = time.Now()
+	r.files[file.ID.Hex()] = *file
+	return nil
+}
+
+func (r *fakeFileRepo) FindAll() ([]models.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := []models.File{}
+	for _, file := range r.files {
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (r *fakeFileRepo) FindWithPagination(pagination *models.PaginationRequest, filter models.FileFilter) ([]models.File, int64, error) {
+	files, _ := r.FindAll()
+	return files, int64(len(files)), nil
+}
+
+func (r *fakeFileRepo) FindByID(id string) (*models.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, ok := r.files[id]
+	if !ok {
+		return nil, utils.NotFound("File not found")
+	}
+	return &file, nil
+}
+
+func (r *fakeFileRepo) FindByOwner(ownerID int) ([]models.File, error) {
+	return r.filter(func(file models.File) bool { return file.OwnerID == ownerID }), nil
+}
+
+func (r *fakeFileRepo) FindByEntity(entityType string, entityID uint) ([]models.File, error) {
+	return r.filter(func(file models.File) bool { return file.EntityType == entityType && file.EntityID == entityID }), nil
+}
+
+func (r *fakeFileRepo) Update(file *models.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.files[file.ID.Hex()]; !ok {
+		return utils.NotFound("File not found")
+	}
+	r.files[file.ID.Hex()] = *file
+	return nil
+}
+
+func (r *fakeFileRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.files[id]; !ok {
+		return utils.NotFound("File not found")
+	}
+	delete(r.files, id)
+	return nil
+}
+
+func (r *fakeFileRepo) FindByScanStatus(status string) ([]models.File, error) {
+	return r.filter(func(file models.File) bool { return file.ScanStatus == status }), nil
+}
+
+func (r *fakeFileRepo) UpdateScanResult(backend, sha256 string, result models.FileScanResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, file := range r.files {
+		if file.Backend != backend || file.SHA256 != sha256 {
+			continue
+		}
+		scannedAt := result.ScannedAt
+		file.ScanStatus, file.ScanSignature, file.ScannedAt = result.Status, result.Signature, &scannedAt
+		if result.Status == models.FileScanInfected {
+			file.StorageKey, file.Variants = result.StorageKey, nil
+		}
+		r.files[id] = file
+	}
+	return nil
+}
+
+func (r *fakeFileRepo) UsageByOwner(ownerID int) ([]models.StorageUsageGroup, error) {
+	group := models.StorageUsageGroup{Purpose: models.FilePurposeDokumen}
+	for _, file := range r.filter(func(file models.File) bool { return file.OwnerID == ownerID }) {
+		group.UsedBytes += file.FileSize
+		group.FileCount++
+	}
+	if group.FileCount == 0 {
+		return []models.StorageUsageGroup{}, nil
+	}
+	return []models.StorageUsageGroup{group}, nil
+}
+
+func (r *fakeFileRepo) filter(match func(models.File) bool) []models.File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := []models.File{}
+	for _, file := range r.files {
+		if match(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+type fakeBlobRepo struct {
+	mu    sync.Mutex
+	blobs map[string]models.FileBlob
+}
+
+func newFakeBlobRepo() *fakeBlobRepo {
+	return &fakeBlobRepo{blobs: map[string]models.FileBlob{}}
+}
+
+func (r *fakeBlobRepo) Acquire(blob *models.FileBlob) (*models.FileBlob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	acquired, ok := r.blobs[blob.ID]
+	if ok && acquired.DeletingAt != nil {
+		return nil, utils.Conflict("Isi file yang sama sedang dihapus, coba lagi")
+	}
+	if !ok {
+		acquired = *blob
+		acquired.Stored, acquired.RefCount, acquired.CreatedAt = false, 0, time.Now()
+	}
+	acquired.RefCount++
+	r.blobs[blob.ID] = acquired
+	return &acquired, nil
+}
+
+func (r *fakeBlobRepo) MarkStored(id string, variants map[string]models.FileVariant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob := r.blobs[id]
+	blob.Stored = true
+	if len(variants) > 0 {
+		blob.Variants = variants
+	}
+	r.blobs[id] = blob
+	return nil
+}
+
+func (r *fakeBlobRepo) Release(id string) (*models.FileBlob, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[id]
+	if !ok {
+		return nil, false, utils.NotFound("Blob file tidak ditemukan")
+	}
+	blob.RefCount--
+	removed := blob.RefCount <= 0 && blob.DeletingAt == nil
+	if removed {
+		now := time.Now()
+		blob.DeletingAt = &now
+	}
+	r.blobs[id] = blob
+	return &blob, removed, nil
+}
+
+func (r *fakeBlobRepo) Purge(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if blob, ok := r.blobs[id]; ok && blob.RefCount <= 0 && blob.DeletingAt != nil {
+		delete(r.blobs, id)
+	}
+	return nil
+}
+
+func (r *fakeBlobRepo) FindByID(id string) (*models.FileBlob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[id]
+	if !ok {
+		return nil, utils.NotFound("Blob file tidak ditemukan")
+	}
+	return &blob, nil
+}
+
+func (r *fakeBlobRepo) UpdateScanResult(id string, result models.FileScanResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[id]
+	if !ok {
+		return utils.NotFound("Blob file tidak ditemukan")
+	}
+	scannedAt := result.ScannedAt
+	blob.ScanStatus, blob.ScanSignature, blob.ScannedAt = result.Status, result.Signature, &scannedAt
+	if result.Status == models.FileScanInfected {
+		blob.StorageKey, blob.Variants = result.StorageKey, nil
+	}
+	r.blobs[id] = blob
+	return nil
+}
+
+// get salinan blob untuk assertion; ok false jika sudah di-Purge
+func (r *fakeBlobRepo) get(id string) (models.FileBlob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[id]
+	return blob, ok
+}
+
+type fakeSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]models.UploadSession
+	// beforeFinish jika diisi dipanggil sebelum BeginFinish dijalankan, untuk menahan request yang bersamaan
+	beforeFinish func()
+	// afterFinish jika diisi dipanggil setelah BeginFinish selesai, sebelum hasilnya dikembalikan
+	afterFinish func()
+}
+
+func newFakeSessionRepo() *fakeSessionRepo {
+	return &fakeSessionRepo{sessions: map[string]models.UploadSession{}}
+}
+
+func (r *fakeSessionRepo) Create(session *models.UploadSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = time.Now()
+	if session.Status == "" {
+		session.Status = models.UploadSessionUploading
+	}
+	r.sessions[session.ID.Hex()] = *session
+	return nil
+}
+
+func (r *fakeSessionRepo) FindByID(id string) (*models.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, utils.NotFound("Sesi upload tidak ditemukan")
+	}
+	return &session, nil
+}
+
+func (r *fakeSessionRepo) AppendChunk(id string, expectedOffset int64, chunk models.UploadChunk, expiresAt time.Time) (*models.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, utils.NotFound("Sesi upload tidak ditemukan")
+	}
+	if session.Offset != expectedOffset {
+		return nil, utils.Conflict("Upload-Offset tidak sesuai dengan offset sesi")
+	}
+	session.Chunks = append(session.Chunks, chunk)
+	session.Offset += chunk.Size
+	session.ExpiresAt = expiresAt
+	r.sessions[id] = session
+	return &session, nil
+}
+
+func (r *fakeSessionRepo) BeginFinish(id string, expiresAt time.Time) (*models.UploadSession, error) {
+	if r.beforeFinish != nil {
+		r.beforeFinish()
+	}
+	if r.afterFinish != nil {
+		defer r.afterFinish()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, utils.NotFound("Sesi upload tidak ditemukan")
+	}
+	if session.Status == models.UploadSessionFinishing || session.Offset != session.FileSize {
+		return nil, utils.Conflict("Upload sedang diselesaikan oleh request lain")
+	}
+	session.Status, session.ExpiresAt = models.UploadSessionFinishing, expiresAt
+	r.sessions[id] = session
+	return &session, nil
+}
+
+func (r *fakeSessionRepo) ResetFinish(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.Status != models.UploadSessionFinishing {
+		return utils.NotFound("Sesi upload tidak ditemukan")
+	}
+	session.Status = models.UploadSessionUploading
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *fakeSessionRepo) FindExpired(before time.Time) ([]models.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []models.UploadSession{}
+	for _, session := range r.sessions {
+		if session.ExpiresAt.Before(before) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions[id]; !ok {
+		return utils.NotFound("Sesi upload tidak ditemukan")
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+// flakyStorage storage lokal yang Delete-nya bisa dibuat gagal
+type flakyStorage struct {
+	*storage.Local
+	mu        sync.Mutex
+	deleteErr error
+}
+
+func (s *flakyStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	err := s.deleteErr
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.Local.Delete(ctx, key)
+}
+
+func (s *flakyStorage) setDeleteErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteErr = err
+}
+
+type testFileService struct {
+	*fileService
+	files    *fakeFileRepo
+	blobRepo *fakeBlobRepo
+	sessRepo *fakeSessionRepo
+	backend  *flakyStorage
+}
+
+// newTestFileService fileService dengan repository palsu dan storage lokal di
+// direktori sementara. scanner boleh nil (pemindaian tidak aktif).
+func newTestFileService(t *testing.T, scanner malware.Scanner) *testFileService {
+	t.Helper()
+	backend := &flakyStorage{Local: storage.NewLocal(t.TempDir())}
+	storages, err := storage.NewRegistry(storage.BackendLocal, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, blobs, sessions := newFakeFileRepo(), newFakeBlobRepo(), newFakeSessionRepo()
+	service := &fileService{
+		repo:     files,
+		blobs:    blobs,
+		sessions: sessions,
+		storages: storages,
+		config: FileConfig{
+			UploadRules: DefaultUploadRules(),
+			SessionTTL:  defaultUploadSessionTTL,
+			Quotas:      DefaultStorageQuotas(),
+		},
+	}
+	if scanner != nil {
+		service.scanner = scanner
+	}
+	return &testFileService{fileService: service, files: files, blobRepo: blobs, sessRepo: sessions, backend: backend}
+}
+
+// exists true jika key ada di storage
+func (s *testFileService) exists(t *testing.T, key string) bool {
+	t.Helper()
+	reader, err := s.backend.Get(context.Background(), key)
+	if err != nil {
+		return false
+	}
+	reader.Close()
+	return true
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"maps"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Alasan pada FileIntegrityIssue
+const (
+	integrityNotFound           = "not_found"
+	integrityUnreadable         = "unreadable"
+	integrityBackendUnavailable = "backend_unavailable"
+	integritySizeMismatch       = "size_mismatch"
+	integrityHashMismatch       = "sha256_mismatch"
+	integrityUnreferenced       = "unreferenced"
+)
+
+// chunkPrefix object upload bertahap yang belum selesai, dibersihkan oleh expiry sesi
+const chunkPrefix = "chunks/"
+
+// ScanFileIntegrity laporan record tanpa isi, isi yang tidak sesuai record, dan
+// object yang tidak dipakai record mana pun. Hanya melapor, tidak menghapus apa pun.
+// ?verify=false hanya memeriksa keberadaan dan ukuran tanpa membaca isi file.
+func (s *fileService) ScanFileIntegrity(c *fiber.Ctx) error {
+	report, err := s.scanIntegrity(c.UserContext(), c.QueryBool("verify", true))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// storedObjects isi setiap backend hasil List: backend -> key -> ukuran
+type storedObjects map[string]map[string]int64
+
+func (s *fileService) scanIntegrity(ctx context.Context, verify bool) (*models.FileIntegrityReport, error) {
+	report := &models.FileIntegrityReport{
+		Missing:    []models.FileIntegrityIssue{},
+		Mismatched: []models.FileIntegrityIssue{},
+		Orphaned:   []models.FileIntegrityIssue{},
+		StartedAt:  time.Now(),
+	}
+
+	// Object di-list sebelum record dibaca, supaya upload yang selesai di tengah
+	// scan tidak terlapor sebagai missing
+	objects := storedObjects{}
+	for _, backend := range s.storages.Backends() {
+		keys := map[string]int64{}
+		err := backend.List(ctx, "", func(object storage.Object) error {
+			if !strings.HasPrefix(object.Key, chunkPrefix) {
+				keys[object.Key] = object.Size
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		objects[backend.Name()] = keys
+		report.ScannedObjects += len(keys)
+	}
+
+	files, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	report.ScannedFiles = len(files)
+
+	// Blob dipakai beberapa record; hash cukup dihitung sekali per object
+	hashes := map[string]string{}
+	referenced := map[string]bool{}
+	for i := range files {
+		file := &files[i]
+		backendName, key := file.Location()
+		referenced[backendName+"/"+key] = true
+
+		issue := models.FileIntegrityIssue{FileID: file.ID.Hex(), Backend: backendName, StorageKey: key}
+		missing, mismatch := s.checkObject(ctx, objects, hashes, backendName, key, file.FileSize, file.SHA256, verify)
+		if missing != "" {
+			issue.Reason = missing
+			report.Missing = append(report.Missing, issue)
+		} else if mismatch != "" {
+			issue.Reason = mismatch
+			report.Mismatched = append(report.Mismatched, issue)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(file.Variants)) {
+			variant := file.Variants[name]
+			referenced[backendName+"/"+variant.StorageKey] = true
+
+			issue := models.FileIntegrityIssue{FileID: file.ID.Hex(), Backend: backendName, StorageKey: variant.StorageKey, Variant: name}
+			missing, mismatch := s.checkObject(ctx, objects, hashes, backendName, variant.StorageKey, variant.FileSize, "", false)
+			if missing != "" {
+				issue.Reason = missing
+				report.Missing = append(report.Missing, issue)
+			} else if mismatch != "" {
+				issue.Reason = mismatch
+				report.Mismatched = append(report.Mismatched, issue)
+			}
+		}
+	}
+
+	for _, backend := range s.storages.Backends() {
+		keys := objects[backend.Name()]
+		for _, key := range slices.Sorted(maps.Keys(keys)) {
+			if !referenced[backend.Name()+"/"+key] {
+				report.Orphaned = append(report.Orphaned, models.FileIntegrityIssue{
+					Backend:    backend.Name(),
+					StorageKey: key,
+					Reason:     integrityUnreferenced,
+					Size:       keys[key],
+				})
+			}
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// checkObject mengembalikan alasan missing atau mismatch; keduanya kosong jika object sehat
+func (s *fileService) checkObject(ctx context.Context, objects storedObjects, hashes map[string]string, backendName, key string, size int64, sum string, verify bool) (string, string) {
+	keys, ok := objects[backendName]
+	if !ok {
+		return integrityBackendUnavailable, ""
+	}
+	actualSize, ok := keys[key]
+	if !ok {
+		return integrityNotFound, ""
+	}
+	if actualSize != size {
+		return "", integritySizeMismatch
+	}
+	if !verify || sum == "" {
+		return "", ""
+	}
+
+	location := backendName + "/" + key
+	actual, ok := hashes[location]
+	if !ok {
+		backend, err := s.storages.Backend(backendName)
+		if err != nil {
+			return integrityBackendUnavailable, ""
+		}
+		actual, err = hashObject(ctx, backend, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			return integrityNotFound, ""
+		}
+		if err != nil {
+			return integrityUnreadable, ""
+		}
+		hashes[location] = actual
+	}
+	if actual != sum {
+		return "", integrityHashMismatch
+	}
+	return "", ""
+}
+
+func hashObject(ctx context.Context, backend storage.Storage, key string) (string, error) {
+	reader, err := backend.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultFileLinkTTL masa berlaku link jika expires_in_hours tidak dikirim
+const defaultFileLinkTTL = 72 * time.Hour
+
+// minFileLinkSecret panjang minimal FILE_LINK_SECRET
+const minFileLinkSecret = 32
+
+const fileLinkNotFound = "Link unduhan tidak ditemukan"
+
+// fileLinkSecretFromEnv kunci HMAC link unduhan; kosong berarti fitur link tidak aktif
+func fileLinkSecretFromEnv() ([]byte, error) {
+	secret := os.Getenv("FILE_LINK_SECRET")
+	if secret != "" && len(secret) < minFileLinkSecret {
+		return nil, fmt.Errorf("FILE_LINK_SECRET minimal %d karakter", minFileLinkSecret)
+	}
+	return []byte(secret), nil
+}
+
+// signFileLink HMAC-SHA256 atas ID link, ID file dan waktu kedaluwarsanya,
+// base64url tanpa padding
+func (s *fileService) signFileLink(id, fileID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.config.LinkSecret)
+	fmt.Fprintf(mac, "%s.%s.%d", id, fileID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *fileService) validFileLinkSignature(id, fileID string, expires int64, signature string) bool {
+	expected := s.signFileLink(id, fileID, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *fileService) fileLinkPath(link *models.FileLink) string {
+	expires := link.ExpiresAt.Unix()
+	query := url.Values{}
+	query.Set("file", link.FileID)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signFileLink(link.ID.Hex(), link.FileID, expires))
+	return "/api/public/files/" + link.ID.Hex() + "?" + query.Encode()
+}
+
+// canShare membuat link untuk pihak luar lebih ketat dari canRead: foto yang
+// boleh dilihat semua user tetap hanya bisa dibagikan pengelola dan pemilik entitas
+func (s *fileService) canShare(a fileActor, file *models.File) (bool, error) {
+	if a.canManage(file) {
+		return true, nil
+	}
+	if file.EntityType == "" {
+		return false, nil
+	}
+	return s.ownsEntity(a, file.EntityType, file.EntityID)
+}
+
+// CreateFileLink membuat link unduhan bertanda tangan untuk file :id. URL hanya
+// dikirim di response ini.
+func (s *fileService) CreateFileLink(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+	if len(s.config.LinkSecret) == 0 {
+		return utils.BadRequest("Link unduhan tidak aktif, FILE_LINK_SECRET belum diatur")
+	}
+
+	file, err := s.repo.FindByID(c.Params("id"))
+	if err != nil {
+		return err
+	}
+	allowed, err := s.canShare(actor, file)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return utils.Forbidden("Anda tidak berhak membagikan file ini")
+	}
+	if file.ScanStatus == models.FileScanInfected {
+		return utils.Forbidden("File terdeteksi malware dan tidak bisa dibagikan").
+			WithDetail("scan_status", file.ScanStatus)
+	}
+
+	var req models.CreateFileLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	ttl := defaultFileLinkTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	link := &models.FileLink{
+		FileID:    file.ID.Hex(),
+		Recipient: req.Recipient,
+		SingleUse: req.SingleUse,
+		CreatedBy: actor.userID,
+		// Detik saja, karena tanda tangan memakai Unix time
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+	}
+	if err := s.links.Create(link); err != nil {
+		return err
+	}
+
+	response := toFileLinkResponse(link, time.Now())
+	response.URL = c.BaseURL() + s.fileLinkPath(link)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Link unduhan dibuat",
+		"data":    response,
+	})
+}
+
+// GetFileLinks admin melihat link yang masih bisa dipakai
+func (s *fileService) GetFileLinks(c *fiber.Ctx) error {
+	now := time.Now()
+	links, err := s.links.FindOutstanding(now)
+	if err != nil {
+		return err
+	}
+
+	responses := []models.FileLinkResponse{}
+	for i := range links {
+		responses = append(responses, *toFileLinkResponse(&links[i], now))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    responses,
+	})
+}
+
+// RevokeFileLink admin mencabut link :id; URL-nya langsung ditolak walaupun belum kedaluwarsa
+func (s *fileService) RevokeFileLink(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+
+	link, err := s.links.Revoke(c.Params("id"), actor.userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Link unduhan dicabut",
+		"data":    toFileLinkResponse(link, time.Now()),
+	})
+}
+
+// DownloadSharedFile unduhan lewat link bertanda tangan, tanpa JWT. Tanda tangan
+// diperiksa sebelum database; link sekali pakai baru dianggap terpakai saat isi
+// file benar-benar dikirim (bukan HEAD atau 304), dan selalu utuh (200): Range
+// diabaikan supaya potongan pertama tidak menghabiskan link.
+func (s *fileService) DownloadSharedFile(c *fiber.Ctx) error {
+	if len(s.config.LinkSecret) == 0 {
+		return utils.NotFound(fileLinkNotFound)
+	}
+	id, fileID := c.Params("id"), c.Query("file")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !s.validFileLinkSignature(id, fileID, expires, c.Query("signature")) {
+		return utils.Forbidden("Link unduhan tidak valid")
+	}
+	now := time.Now()
+	if now.Unix() >= expires {
+		return utils.Gone("Link unduhan sudah kedaluwarsa")
+	}
+
+	link, err := s.links.FindByID(id)
+	if utils.IsNotFound(err) {
+		// Tanda tangan benar tapi record sudah dihapus bersama filenya
+		return utils.Gone("File sudah tidak tersedia")
+	}
+	if err != nil {
+		return err
+	}
+	if link.FileID != fileID || link.ExpiresAt.Unix() != expires {
+		return utils.Forbidden("Link unduhan tidak valid")
+	}
+	if status := link.Status(now); status != models.FileLinkActive {
+		return utils.Gone("Link unduhan sudah tidak berlaku").WithDetail("status", status)
+	}
+
+	file, err := s.repo.FindByID(link.FileID)
+	if utils.IsNotFound(err) {
+		return utils.Gone("File sudah tidak tersedia")
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+	if link.SingleUse {
+		c.Request().Header.Del(fiber.HeaderRange)
+	}
+	err = s.sendFile(c, file, func() error {
+		_, err := s.links.MarkUsed(id, time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Link bisa dicabut kapan saja, jadi isinya tidak boleh disimpan di cache
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if link.SingleUse {
+		c.Set(fiber.HeaderAcceptRanges, "none")
+	}
+	return nil
+}
+
+func toFileLinkResponse(link *models.FileLink, now time.Time) *models.FileLinkResponse {
+	return &models.FileLinkResponse{FileLink: *link, Status: link.Status(now)}
+}
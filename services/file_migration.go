@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/storage"
+)
+
+// FileMigrationOptions pemindahan isi file dari satu backend storage ke backend lain
+type FileMigrationOptions struct {
+	From         string
+	To           string
+	DeleteSource bool // hapus file tanpa hash di backend asal setelah record menunjuk ke backend tujuan; isi blob selalu dihapus setelah record terakhirnya pindah
+	DryRun       bool // hanya menampilkan file yang akan dipindah
+}
+
+type FileMigrationResult struct {
+	Moved   int
+	Skipped int // sudah berada di backend lain
+	Failed  int
+}
+
+// MigrateFiles menyalin setiap file di backend From ke backend To lalu memperbarui
+// record-nya. Aman dijalankan ulang: file yang sudah pindah dilewati, dan file yang
+// gagal tetap menunjuk ke backend asal. Isi yang dipakai bersama beberapa file
+// (blob) hanya disalin sekali dan baru dihapus dari asal setelah file terakhir pindah.
+func MigrateFiles(ctx context.Context, fileRepo repo.FileRepository, blobs repo.FileBlobRepository, storages *storage.Registry, opts FileMigrationOptions) (FileMigrationResult, error) {
+	var result FileMigrationResult
+	if opts.From == opts.To {
+		return result, fmt.Errorf("backend asal dan tujuan sama: %s", opts.From)
+	}
+	source, err := storages.Backend(opts.From)
+	if err != nil {
+		return result, err
+	}
+	target, err := storages.Backend(opts.To)
+	if err != nil {
+		return result, err
+	}
+
+	files, err := fileRepo.FindAll()
+	if err != nil {
+		return result, err
+	}
+
+	for i := range files {
+		file := &files[i]
+		backend, key := file.Location()
+		if backend != opts.From {
+			result.Skipped++
+			continue
+		}
+		if opts.DryRun {
+			log.Printf("[dry-run] %s %s -> %s", file.ID.Hex(), key, opts.To)
+			result.Moved++
+			continue
+		}
+
+		if file.SHA256 != "" {
+			err = migrateBlobFile(ctx, fileRepo, blobs, source, target, file, key)
+		} else {
+			err = migrateFile(ctx, fileRepo, source, target, file, key, opts.DeleteSource)
+		}
+		if err != nil {
+			log.Printf("Gagal memindah file %s (%s): %v", file.ID.Hex(), key, err)
+			result.Failed++
+			continue
+		}
+		result.Moved++
+	}
+	return result, nil
+}
+
+func migrateFile(ctx context.Context, fileRepo repo.FileRepository, source, target storage.Storage, file *models.File, key string, deleteSource bool) error {
+	if err := copyObject(ctx, source, target, key, file.FileSize, file.FileType); err != nil {
+		return err
+	}
+	for name, variant := range file.Variants {
+		if err := copyObject(ctx, source, target, variant.StorageKey, variant.FileSize, variant.FileType); err != nil {
+			return fmt.Errorf("variant %s: %v", name, err)
+		}
+	}
+
+	file.Backend = target.Name()
+	file.StorageKey = key
+	file.FilePath = ""
+	if err := fileRepo.Update(file); err != nil {
+		return err
+	}
+
+	if deleteSource {
+		if err := source.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: file %s sudah pindah tapi gagal dihapus dari %s: %v", file.ID.Hex(), source.Name(), err)
+		}
+		deleteVariants(ctx, source, file.Variants)
+	}
+	return nil
+}
+
+// migrateBlobFile memindah file yang isinya tercatat sebagai blob: blob di backend
+// tujuan dipakai ulang jika sudah ada, lalu referensi ke blob asal dilepas
+func migrateBlobFile(ctx context.Context, fileRepo repo.FileRepository, blobs repo.FileBlobRepository, source, target storage.Storage, file *models.File, key string) error {
+	targetBlob, err := acquireBlob(blobs, &models.FileBlob{
+		ID:         blobID(target.Name(), file.SHA256),
+		Backend:    target.Name(),
+		StorageKey: key,
+		SHA256:     file.SHA256,
+		FileSize:   file.FileSize,
+		FileType:   file.FileType,
+	})
+	if err != nil {
+		return err
+	}
+	if !targetBlob.Stored {
+		err := copyObject(ctx, source, target, key, file.FileSize, file.FileType)
+		for name, variant := range file.Variants {
+			if err != nil {
+				break
+			}
+			if err = copyObject(ctx, source, target, variant.StorageKey, variant.FileSize, variant.FileType); err != nil {
+				err = fmt.Errorf("variant %s: %v", name, err)
+			}
+		}
+		if err == nil {
+			err = blobs.MarkStored(targetBlob.ID, file.Variants)
+		}
+		// Hasil pindai ikut pindah supaya isi yang sama tidak dipindai ulang
+		if err == nil && (file.ScanStatus == models.FileScanClean || file.ScanStatus == models.FileScanInfected) {
+			result := models.FileScanResult{Status: file.ScanStatus, Signature: file.ScanSignature, StorageKey: key}
+			if file.ScannedAt != nil {
+				result.ScannedAt = *file.ScannedAt
+			}
+			err = blobs.UpdateScanResult(targetBlob.ID, result)
+		}
+		if err != nil {
+			releaseBlob(ctx, blobs, target, targetBlob.ID, key)
+			return err
+		}
+		targetBlob.StorageKey, targetBlob.Variants = key, file.Variants
+	}
+
+	file.Backend = target.Name()
+	file.StorageKey = targetBlob.StorageKey
+	file.Variants = targetBlob.Variants
+	file.FilePath = ""
+	if err := fileRepo.Update(file); err != nil {
+		releaseBlob(ctx, blobs, target, targetBlob.ID, targetBlob.StorageKey)
+		return err
+	}
+
+	// Isi asal ikut dihapus saat record terakhir yang memakainya pindah, juga tanpa
+	// deleteSource, karena blob tanpa record tidak lagi tercatat di mana pun
+	releaseBlob(ctx, blobs, source, blobID(source.Name(), file.SHA256), key)
+	return nil
+}
+
+func copyObject(ctx context.Context, source, target storage.Storage, key string, size int64, contentType string) error {
+	reader, err := source.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return target.Put(ctx, key, reader, size, contentType)
+}
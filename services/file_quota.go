@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Sumber kuota yang berlaku untuk user
+const (
+	quotaSourceRole = "role"
+	quotaSourceUser = "user"
+)
+
+// DefaultStorageQuotas kuota bawaan per role; admin tanpa batas
+func DefaultStorageQuotas() map[string]int64 {
+	return map[string]int64{
+		"admin": 0,
+		"user":  1 << 30,
+	}
+}
+
+func storageQuotasFromEnv() (map[string]int64, error) {
+	quotas := DefaultStorageQuotas()
+	for role := range quotas {
+		key := "STORAGE_QUOTA_" + strings.ToUpper(role) + "_MB"
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		mb, err := strconv.ParseFloat(value, 64)
+		if err != nil || mb < 0 {
+			return nil, fmt.Errorf("%s tidak valid: %q", key, value)
+		}
+		quotas[role] = int64(mb * (1 << 20))
+	}
+	return quotas, nil
+}
+
+// storageQuota kuota khusus user jika ada, selain itu kuota bawaan role-nya.
+// Role yang tidak dikenal memakai kuota role user.
+func (s *fileService) storageQuota(actor fileActor) (int64, string, error) {
+	if s.quotas != nil {
+		quota, err := s.quotas.FindByOwner(actor.userID)
+		if err == nil {
+			return quota.MaxBytes, quotaSourceUser, nil
+		}
+		if !utils.IsNotFound(err) {
+			return 0, "", err
+		}
+	}
+	quota, ok := s.config.Quotas[actor.role]
+	if !ok {
+		quota = s.config.Quotas["user"]
+	}
+	return quota, quotaSourceRole, nil
+}
+
+// storageUsage pemakaian user beserta kuota yang berlaku
+func (s *fileService) storageUsage(actor fileActor) (*models.StorageUsage, error) {
+	groups, err := s.repo.UsageByOwner(actor.userID)
+	if err != nil {
+		return nil, err
+	}
+	quota, source, err := s.storageQuota(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.StorageUsage{QuotaBytes: quota, QuotaSource: source, ByPurpose: groups}
+	for _, group := range groups {
+		usage.UsedBytes += group.UsedBytes
+		usage.FileCount += group.FileCount
+	}
+	if quota > 0 {
+		remaining := max(quota-usage.UsedBytes, 0)
+		usage.RemainingBytes = &remaining
+	}
+	return usage, nil
+}
+
+// checkQuota menolak upload berukuran size jika pemakaian user akan melewati
+// kuotanya, sebelum isinya diproses. Isi yang sama dengan file lain tetap
+// dihitung penuh. Setelah file tercatat, recheckQuota memastikannya lagi.
+func (s *fileService) checkQuota(actor fileActor, size int64) error {
+	usage, err := s.storageUsage(actor)
+	if err != nil {
+		return err
+	}
+	if usage.QuotaBytes > 0 && usage.UsedBytes+size > usage.QuotaBytes {
+		return utils.QuotaExceeded(usage.UsedBytes, usage.QuotaBytes).WithDetail("file_size", size)
+	}
+	return nil
+}
+
+// recheckQuota memeriksa ulang kuota setelah file tercatat, karena checkQuota
+// sebelum upload tidak atomik dengan insert: upload lain milik user yang sama
+// bisa selesai di antaranya. Pemakaian di sini sudah termasuk file itu.
+func (s *fileService) recheckQuota(actor fileActor, file *models.File) error {
+	usage, err := s.storageUsage(actor)
+	if err != nil {
+		return err
+	}
+	if usage.QuotaBytes > 0 && usage.UsedBytes > usage.QuotaBytes {
+		return utils.QuotaExceeded(usage.UsedBytes-file.FileSize, usage.QuotaBytes).WithDetail("file_size", file.FileSize)
+	}
+	return nil
+}
+
+// GetStorageUsage pemakaian penyimpanan user yang sedang login (GET /api/profile/storage)
+func (s *fileService) GetStorageUsage(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+
+	usage, err := s.storageUsage(actor)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    usage,
+	})
+}
+
+// SetStorageQuota admin mengatur kuota khusus user :id, menggantikan kuota role-nya
+func (s *fileService) SetStorageQuota(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+	ownerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ownerID <= 0 {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	var req models.SetStorageQuotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	quota := &models.StorageQuota{
+		OwnerID:   ownerID,
+		MaxBytes:  int64(*req.MaxMB * (1 << 20)),
+		UpdatedBy: actor.userID,
+	}
+	if err := s.quotas.Upsert(quota); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kuota penyimpanan diperbarui",
+		"data":    quota,
+	})
+}
+
+// DeleteStorageQuota menghapus kuota khusus; user kembali memakai kuota role-nya
+func (s *fileService) DeleteStorageQuota(c *fiber.Ctx) error {
+	ownerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ownerID <= 0 {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	if err := s.quotas.Delete(ownerID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kuota khusus dihapus, user kembali memakai kuota role",
+	})
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"path"
+	"time"
+)
+
+// quarantinePrefix lokasi isi file yang terdeteksi malware, terpisah dari blob biasa
+const quarantinePrefix = "quarantine/"
+
+// initialScanStatus status file baru: mengikuti blob yang sudah pernah dipindai,
+// atau pending jika scanner aktif
+func (s *fileService) initialScanStatus(blob *models.FileBlob) string {
+	if blob.ScanStatus == "" && s.scanner != nil {
+		return models.FileScanPending
+	}
+	return blob.ScanStatus
+}
+
+// scanInBackground memindai blob tanpa menahan response upload. Jika gagal (scanner
+// mati), file tetap pending dan dicoba lagi oleh RescanPendingFiles.
+func (s *fileService) scanInBackground(id string) {
+	go func() {
+		if err := s.scanBlob(context.Background(), id); err != nil {
+			log.Printf("Warning: pemindaian %s gagal, dicoba lagi nanti: %v", id, err)
+		}
+	}()
+}
+
+// RescanPendingFiles memindai ulang semua file yang masih pending, misal setelah
+// clamd sempat mati atau server restart di tengah pemindaian
+func (s *fileService) RescanPendingFiles(ctx context.Context) (int, error) {
+	if s.scanner == nil {
+		return 0, nil
+	}
+	files, err := s.repo.FindByScanStatus(models.FileScanPending)
+	if err != nil {
+		return 0, err
+	}
+
+	scanned := 0
+	seen := map[string]bool{}
+	for _, file := range files {
+		if file.SHA256 == "" {
+			continue
+		}
+		id := blobID(file.Backend, file.SHA256)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := s.scanBlob(ctx, id); err != nil {
+			log.Printf("Warning: pemindaian %s gagal: %v", id, err)
+			continue
+		}
+		scanned++
+	}
+	return scanned, nil
+}
+
+// scanBlob memindai isi blob sekali lalu menyalin hasilnya ke semua file yang
+// memakainya. Blob yang sudah punya hasil tidak dipindai ulang.
+func (s *fileService) scanBlob(ctx context.Context, id string) error {
+	blob, err := s.blobs.FindByID(id)
+	if utils.IsNotFound(err) {
+		return nil // semua file dengan isi ini sudah dihapus
+	}
+	if err != nil {
+		return err
+	}
+	backend, err := s.storages.Backend(blob.Backend)
+	if err != nil {
+		return err
+	}
+
+	if blob.ScanStatus != "" {
+		result := models.FileScanResult{Status: blob.ScanStatus, Signature: blob.ScanSignature, StorageKey: blob.StorageKey}
+		if blob.ScannedAt != nil {
+			result.ScannedAt = *blob.ScannedAt
+		}
+		return s.repo.UpdateScanResult(blob.Backend, blob.SHA256, result)
+	}
+
+	reader, err := backend.Get(ctx, blob.StorageKey)
+	if err != nil {
+		return err
+	}
+	verdict, err := s.scanner.Scan(ctx, reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	result := models.FileScanResult{Status: models.FileScanClean, ScannedAt: time.Now()}
+	if verdict.Infected {
+		result.Status, result.Signature = models.FileScanInfected, verdict.Signature
+		result.StorageKey = quarantinePrefix + path.Base(blob.StorageKey)
+		if err := moveObject(ctx, backend, blob.StorageKey, result.StorageKey, blob.FileSize, blob.FileType); err != nil {
+			return err
+		}
+	}
+
+	if err := s.blobs.UpdateScanResult(id, result); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateScanResult(blob.Backend, blob.SHA256, result); err != nil {
+		return err
+	}
+
+	if verdict.Infected {
+		// Record sudah menunjuk ke karantina; isi lama dan variant-nya tidak boleh tersisa
+		log.Printf("Malware %s terdeteksi pada %s, dipindah ke %s", verdict.Signature, id, result.StorageKey)
+		if err := backend.Delete(ctx, blob.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: Failed to delete infected content %s: %v", blob.StorageKey, err)
+		}
+		deleteVariants(ctx, backend, blob.Variants)
+	}
+	return nil
+}
+
+// downloadable menolak unduhan file yang belum selesai dipindai atau terinfeksi
+func downloadable(file *models.File) error {
+	switch file.ScanStatus {
+	case models.FileScanPending:
+		return utils.Conflict("File masih dipindai antivirus, coba lagi beberapa saat lagi").
+			WithDetail("scan_status", file.ScanStatus)
+	case models.FileScanInfected:
+		return utils.Forbidden("File terdeteksi malware dan sudah dikarantina").
+			WithDetail("scan_status", file.ScanStatus)
+	}
+	return nil
+}
+
+func moveObject(ctx context.Context, backend storage.Storage, from, to string, size int64, contentType string) error {
+	reader, err := backend.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return backend.Put(ctx, to, reader, size, contentType)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"modul4crud/malware"
+	"modul4crud/malware/malwaretest"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeTestFile menyimpan isi sebagai blob dan mencatat File yang memakainya,
+// tanpa pemindaian di background supaya test bisa memanggil scanBlob sendiri
+func storeTestFile(t *testing.T, s *testFileService, content string) (*models.File, *models.FileBlob) {
+	t.Helper()
+	blob, err := s.storeBlob(context.Background(), pdfUpload(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &models.File{
+		FileName:   "test.pdf",
+		Backend:    blob.Backend,
+		StorageKey: blob.StorageKey,
+		FileSize:   blob.FileSize,
+		FileType:   blob.FileType,
+		SHA256:     blob.SHA256,
+		OwnerID:    testUploadOwner,
+		Purpose:    models.FilePurposeDokumen,
+		ScanStatus: s.initialScanStatus(blob),
+	}
+	if err := s.files.Create(file); err != nil {
+		t.Fatal(err)
+	}
+	return file, blob
+}
+
+func TestScanBlobStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		signatures    map[string]string
+		scanErr       error
+		wantStatus    string
+		wantSignature string
+		wantDownload  error
+	}{
+		{name: "clean", content: testPDF, wantStatus: models.FileScanClean},
+		{
+			name:          "infected eicar",
+			content:       testPDF + malwaretest.EICAR,
+			wantStatus:    models.FileScanInfected,
+			wantSignature: "Eicar-Signature",
+			wantDownload:  utils.ErrForbidden,
+		},
+		{
+			name:          "infected signature lain",
+			content:       testPDF + "TROJAN-UJI",
+			signatures:    map[string]string{"TROJAN-UJI": "Test.Trojan"},
+			wantStatus:    models.FileScanInfected,
+			wantSignature: "Test.Trojan",
+			wantDownload:  utils.ErrForbidden,
+		},
+		{
+			name:         "scanner error tetap pending",
+			content:      testPDF,
+			scanErr:      errors.New("clamd tidak bisa dihubungi"),
+			wantStatus:   models.FileScanPending,
+			wantDownload: utils.ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := malwaretest.New()
+			for pattern, name := range tt.signatures {
+				scanner.Signatures[pattern] = name
+			}
+			scanner.Err = tt.scanErr
+			s := newTestFileService(t, scanner)
+
+			file, blob := storeTestFile(t, s, tt.content)
+			if file.ScanStatus != models.FileScanPending {
+				t.Fatalf("status awal = %q, want pending", file.ScanStatus)
+			}
+
+			err := s.scanBlob(context.Background(), blob.ID)
+			if tt.scanErr != nil {
+				if !errors.Is(err, tt.scanErr) {
+					t.Errorf("scanBlob = %v, want %v", err, tt.scanErr)
+				}
+			} else if err != nil {
+				t.Fatalf("scanBlob: %v", err)
+			}
+
+			got, err := s.files.FindByID(file.ID.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ScanStatus != tt.wantStatus || got.ScanSignature != tt.wantSignature {
+				t.Errorf("file = %q/%q, want %q/%q", got.ScanStatus, got.ScanSignature, tt.wantStatus, tt.wantSignature)
+			}
+			if err := downloadable(got); !errors.Is(err, tt.wantDownload) {
+				t.Errorf("downloadable = %v, want %v", err, tt.wantDownload)
+			}
+		})
+	}
+}
+
+func TestScanBlobWithoutScanner(t *testing.T) {
+	s := newTestFileService(t, nil)
+	file, _ := storeTestFile(t, s, testPDF)
+	if file.ScanStatus != "" {
+		t.Errorf("status = %q, want kosong tanpa scanner", file.ScanStatus)
+	}
+	if err := downloadable(file); err != nil {
+		t.Errorf("downloadable = %v, want nil", err)
+	}
+	if n, err := s.RescanPendingFiles(context.Background()); n != 0 || err != nil {
+		t.Errorf("RescanPendingFiles = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestRescanPendingFiles(t *testing.T) {
+	ctx := context.Background()
+	scanner := malwaretest.New()
+	scanner.Err = errors.New("clamd mati")
+	s := newTestFileService(t, scanner)
+
+	// Dua file dengan isi sama berbagi satu blob, jadi hanya dipindai sekali
+	first, blob := storeTestFile(t, s, testPDF)
+	second, _ := storeTestFile(t, s, testPDF)
+	if err := s.scanBlob(ctx, blob.ID); err == nil {
+		t.Fatal("scanBlob berhasil padahal scanner mati")
+	}
+
+	scanner.SetErr(nil)
+	scanned, err := s.RescanPendingFiles(ctx)
+	if err != nil || scanned != 1 {
+		t.Fatalf("RescanPendingFiles = %d, %v; want 1, nil", scanned, err)
+	}
+	if scanner.Scanned() != 1 {
+		t.Errorf("Scanned = %d, want 1", scanner.Scanned())
+	}
+	for _, file := range []*models.File{first, second} {
+		got, _ := s.files.FindByID(file.ID.Hex())
+		if got.ScanStatus != models.FileScanClean {
+			t.Errorf("file %s status = %q, want clean", file.ID.Hex(), got.ScanStatus)
+		}
+	}
+
+	// Upload berikutnya dengan isi yang sama memakai hasil blob tanpa dipindai ulang
+	third, _ := storeTestFile(t, s, testPDF)
+	if third.ScanStatus != models.FileScanClean || scanner.Scanned() != 1 {
+		t.Errorf("file baru = %q setelah %d pemindaian, want clean tanpa pemindaian baru", third.ScanStatus, scanner.Scanned())
+	}
+}
+
+// File terinfeksi dipindah ke karantina lewat client clamd sungguhan (INSTREAM)
+// ke server clamd palsu: isi asli dan variant-nya hilang, semua file dengan isi
+// yang sama menunjuk ke karantina, dan upload berikutnya langsung ditolak
+func TestScanBlobQuarantine(t *testing.T) {
+	ctx := context.Background()
+	fake := malwaretest.New()
+	listener, err := malwaretest.NewClamdServer(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	s := newTestFileService(t, malware.NewClamd(listener.Addr().String(), 5*time.Second))
+
+	content := testPDF + malwaretest.EICAR
+	first, blob := storeTestFile(t, s, content)
+	second, _ := storeTestFile(t, s, content)
+
+	thumbKey := variantKey(blob.StorageKey, "thumb", ".pdf")
+	if err := s.backend.Put(ctx, thumbKey, strings.NewReader("thumb"), 5, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	s.blobRepo.MarkStored(blob.ID, map[string]models.FileVariant{"thumb": {StorageKey: thumbKey}})
+
+	if err := s.scanBlob(ctx, blob.ID); err != nil {
+		t.Fatalf("scanBlob: %v", err)
+	}
+
+	quarantineKey := quarantinePrefix + path.Base(blob.StorageKey)
+	if s.exists(t, blob.StorageKey) || s.exists(t, thumbKey) {
+		t.Error("isi asli atau variant masih ada setelah dikarantina")
+	}
+	reader, err := s.backend.Get(ctx, quarantineKey)
+	if err != nil {
+		t.Fatalf("isi karantina: %v", err)
+	}
+	quarantined, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(quarantined) != content {
+		t.Error("isi karantina berbeda dengan isi asli")
+	}
+
+	got, _ := s.blobRepo.get(blob.ID)
+	if got.ScanStatus != models.FileScanInfected || got.StorageKey != quarantineKey || got.Variants != nil {
+		t.Errorf("blob = %q %q variants %v, want infected di %q tanpa variant", got.ScanStatus, got.StorageKey, got.Variants, quarantineKey)
+	}
+	for _, file := range []*models.File{first, second} {
+		got, _ := s.files.FindByID(file.ID.Hex())
+		if got.ScanStatus != models.FileScanInfected || got.ScanSignature != "Eicar-Signature" || got.StorageKey != quarantineKey {
+			t.Errorf("file %s = %q/%q di %q, want infected di %q", got.ID.Hex(), got.ScanStatus, got.ScanSignature, got.StorageKey, quarantineKey)
+		}
+		if err := downloadable(got); !errors.Is(err, utils.ErrForbidden) {
+			t.Errorf("downloadable = %v, want FORBIDDEN", err)
+		}
+	}
+
+	// Pindai ulang memakai hasil blob; isi asli sudah tidak ada dan tidak dipindai lagi
+	if err := s.scanBlob(ctx, blob.ID); err != nil || fake.Scanned() != 1 {
+		t.Errorf("scan ulang = %v setelah %d pemindaian, want nil tanpa pemindaian baru", err, fake.Scanned())
+	}
+
+	third, _ := storeTestFile(t, s, content)
+	if third.ScanStatus != models.FileScanInfected || third.StorageKey != quarantineKey {
+		t.Errorf("upload ulang = %q di %q, want langsung infected di karantina", third.ScanStatus, third.StorageKey)
+	}
+	if s.exists(t, blob.StorageKey) {
+		t.Error("upload ulang menulis isi terinfeksi di luar karantina")
+	}
+}
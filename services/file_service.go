@@ -1,11 +1,23 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
+	"modul4crud/malware"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
-	"os"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"net/http"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,157 +27,329 @@ type FileService interface {
 	UploadFile(c *fiber.Ctx) error
 	GetAllFiles(c *fiber.Ctx) error
 	GetFileByID(c *fiber.Ctx) error
+	DownloadFile(c *fiber.Ctx) error
 	DeleteFile(c *fiber.Ctx) error
+	GetAlumniFiles(c *fiber.Ctx) error
+	GetMahasiswaFiles(c *fiber.Ctx) error
+	GetPekerjaanFiles(c *fiber.Ctx) error
+	CreateUploadSession(c *fiber.Ctx) error
+	GetUploadSession(c *fiber.Ctx) error
+	UploadChunk(c *fiber.Ctx) error
+	CancelUploadSession(c *fiber.Ctx) error
+	CleanupExpiredUploads(ctx context.Context) (int, error)
+	ScanFileIntegrity(c *fiber.Ctx) error
+	RescanPendingFiles(ctx context.Context) (int, error)
+	GetStorageUsage(c *fiber.Ctx) error
+	SetStorageQuota(c *fiber.Ctx) error
+	DeleteStorageQuota(c *fiber.Ctx) error
+	CreateFileLink(c *fiber.Ctx) error
+	GetFileLinks(c *fiber.Ctx) error
+	RevokeFileLink(c *fiber.Ctx) error
+	DownloadSharedFile(c *fiber.Ctx) error
 }
 
 type fileService struct {
-	repo       repo.FileRepository
-	uploadPath string
+	repo          repo.FileRepository
+	blobs         repo.FileBlobRepository
+	sessions      repo.UploadSessionRepository
+	quotas        repo.StorageQuotaRepository
+	links         repo.FileLinkRepository
+	storages      *storage.Registry
+	scanner       malware.Scanner
+	alumniRepo    repo.AlumniRepository
+	mahasiswaRepo repo.MahasiswaRepository
+	pekerjaanRepo repo.PekerjaanAlumniRepository
+	config        FileConfig
 }
 
-func NewFileService(repo repo.FileRepository, uploadPath string) FileService {
+// NewFileService file baru disimpan di backend default registry, file lama
+// dibaca/dihapus dari backend yang tercatat di record-nya. Repository entitas
+// dipakai untuk memeriksa lampiran dan hak akses, blobs untuk deduplikasi isi,
+// sessions untuk upload bertahap, quotas untuk kuota khusus per user, links untuk
+// link unduhan bertanda tangan. scanner boleh nil jika pemindaian malware tidak aktif.
+func NewFileService(repo repo.FileRepository, blobs repo.FileBlobRepository, sessions repo.UploadSessionRepository, quotas repo.StorageQuotaRepository, links repo.FileLinkRepository, storages *storage.Registry, scanner malware.Scanner, alumniRepo repo.AlumniRepository, mahasiswaRepo repo.MahasiswaRepository, pekerjaanRepo repo.PekerjaanAlumniRepository, config FileConfig) FileService {
 	return &fileService{
-		repo:       repo,
-		uploadPath: uploadPath,
+		repo:          repo,
+		blobs:         blobs,
+		sessions:      sessions,
+		quotas:        quotas,
+		links:         links,
+		storages:      storages,
+		scanner:       scanner,
+		alumniRepo:    alumniRepo,
+		mahasiswaRepo: mahasiswaRepo,
+		pekerjaanRepo: pekerjaanRepo,
+		config:        config,
 	}
 }
 
 func (s *fileService) UploadFile(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+
 	// Get file from form
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "No file uploaded",
-			"error":   err.Error(),
-		})
+		return utils.BadRequest("No file uploaded")
 	}
 
-	// Validasi ukuran file (max 10MB)
-	if fileHeader.Size > 10*1024*1024 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "File size exceeds 10MB",
-		})
+	// Lampiran opsional ke alumni, mahasiswa atau pekerjaan
+	entityType, entityID, purpose, err := s.attachmentFromForm(c, actor)
+	if err != nil {
+		return err
+	}
+	if err := s.checkQuota(actor, fileHeader.Size); err != nil {
+		return err
 	}
 
-	// Validasi tipe file
-	allowedTypes := map[string]bool{
-		"image/jpeg":      true,
-		"image/png":       true,
-		"image/jpg":       true,
-		"application/pdf": true,
+	// Tipe asli dari isi file, batas per purpose, dan metadata gambar dibuang
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
 	}
+	defer file.Close()
+
+	upload, err := s.checkUpload(file, fileHeader.Size, fileHeader.Header.Get("Content-Type"), purpose)
+	if err != nil {
+		return err
+	}
+
+	fileModel, err := s.storeUpload(c.UserContext(), actor, upload, fileHeader.Filename, entityType, entityID, purpose)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "File uploaded successfully",
+		"data":    s.toFileResponse(fileModel),
+	})
+}
 
-	contentType := fileHeader.Header.Get("Content-Type")
-	if !allowedTypes[contentType] {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "File type not allowed",
-		})
+// storeUpload menyimpan upload yang sudah diperiksa lalu mencatat metadatanya.
+// Isi yang sama hanya disimpan sekali; record baru menunjuk ke blob yang sudah ada.
+// Dipakai upload biasa dan upload bertahap yang sudah selesai.
+func (s *fileService) storeUpload(ctx context.Context, actor fileActor, upload *checkedUpload, originalName, entityType string, entityID uint, purpose string) (*models.File, error) {
+	blob, err := s.storeBlob(ctx, upload)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	newFileName := uuid.New().String() + ext
-	filePath := filepath.Join(s.uploadPath, newFileName)
+	fileModel := &models.File{
+		FileName:      uuid.New().String() + upload.ext,
+		OriginalName:  safeOriginalName(originalName, upload.contentType, upload.ext),
+		Backend:       blob.Backend,
+		StorageKey:    blob.StorageKey,
+		FileSize:      blob.FileSize,
+		FileType:      blob.FileType,
+		SHA256:        blob.SHA256,
+		OwnerID:       actor.userID,
+		EntityType:    entityType,
+		EntityID:      entityID,
+		Purpose:       purpose,
+		Variants:      blob.Variants,
+		ScanStatus:    s.initialScanStatus(blob),
+		ScanSignature: blob.ScanSignature,
+		ScannedAt:     blob.ScannedAt,
+	}
 
-	// Buat folder jika belum ada
-	if err := os.MkdirAll(s.uploadPath, os.ModePerm); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to create upload directory",
-			"error":   err.Error(),
-		})
+	if err := s.repo.Create(fileModel); err != nil {
+		// Lepas blob; isinya ikut dihapus jika tidak dipakai file lain
+		s.releaseContent(ctx, fileModel)
+		return nil, err
+	}
+	// Upload bersamaan yang bersama-sama melewati kuota dibatalkan; keduanya bisa
+	// ditolak, tetapi pemakaian tidak pernah tetap di atas kuota
+	if err := s.recheckQuota(actor, fileModel); err != nil {
+		if delErr := s.repo.Delete(fileModel.ID.Hex()); delErr != nil {
+			log.Printf("Warning: Failed to delete file %s over quota: %v", fileModel.ID.Hex(), delErr)
+			return nil, err
+		}
+		s.releaseContent(ctx, fileModel)
+		return nil, err
 	}
+	if fileModel.ScanStatus == models.FileScanPending {
+		s.scanInBackground(blob.ID)
+	}
+	return fileModel, nil
+}
 
-	// Simpan file
-	file, err := fileHeader.Open()
+// GetAllFiles daftar file dengan pagination dan filter (?owner_id, file_type,
+// purpose, entity_type, from, to). Admin melihat semua file, user lain hanya
+// file yang diuploadnya.
+func (s *fileService) GetAllFiles(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to open file",
-			"error":   err.Error(),
-		})
+		return err
 	}
-	defer file.Close()
 
-	out, err := os.Create(filePath)
+	var pagination models.PaginationRequest
+	if err := c.QueryParser(&pagination); err != nil {
+		return utils.BadRequest("Invalid pagination parameters")
+	}
+	if pagination.SortBy != "" && !slices.Contains(models.FileSortFields, pagination.SortBy) {
+		return utils.BadRequest("sort_by tidak valid").WithDetail("allowed", models.FileSortFields)
+	}
+	filter, err := fileFilterFrom(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to save file",
-			"error":   err.Error(),
-		})
+		return err
+	}
+	if !actor.admin {
+		filter.OwnerID = actor.userID
 	}
-	defer out.Close()
 
-	if _, err := out.ReadFrom(file); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to write file",
-			"error":   err.Error(),
-		})
+	files, total, err := s.repo.FindWithPagination(&pagination, filter)
+	if err != nil {
+		return err
 	}
 
-	// Simpan metadata ke database
-	fileModel := &models.File{
-		FileName:     newFileName,
-		OriginalName: fileHeader.Filename,
-		FilePath:     filePath,
-		FileSize:     fileHeader.Size,
-		FileType:     contentType,
+	return c.JSON(models.NewPaginationResponse(s.toFileResponses(files), &pagination, total))
+}
+
+// fileFilterFrom membaca filter daftar file dari query; from/to berformat
+// YYYY-MM-DD dan keduanya inklusif
+func fileFilterFrom(c *fiber.Ctx) (models.FileFilter, error) {
+	filter := models.FileFilter{
+		FileType:   strings.ToLower(strings.TrimSpace(c.Query("file_type"))),
+		Purpose:    c.Query("purpose"),
+		EntityType: c.Query("entity_type"),
+	}
+	if value := c.Query("owner_id"); value != "" {
+		ownerID, err := strconv.Atoi(value)
+		if err != nil || ownerID <= 0 {
+			return filter, utils.BadRequest("owner_id tidak valid")
+		}
+		filter.OwnerID = ownerID
+	}
+	if filter.Purpose != "" && !slices.Contains(models.FilePurposes, filter.Purpose) {
+		return filter, utils.BadRequest("purpose tidak valid").WithDetail("allowed", models.FilePurposes)
+	}
+	if filter.EntityType != "" && !slices.Contains(models.FileEntities, filter.EntityType) {
+		return filter, utils.BadRequest("entity_type tidak valid").WithDetail("allowed", models.FileEntities)
+	}
+	from, err := queryDate(c, "from")
+	if err != nil {
+		return filter, err
 	}
+	to, err := queryDate(c, "to")
+	if err != nil {
+		return filter, err
+	}
+	filter.UploadedFrom = from
+	if to != nil {
+		// Batas atas eksklusif: awal hari setelah tanggal to
+		end := to.AddDate(0, 0, 1)
+		filter.UploadedTo = &end
+	}
+	if filter.UploadedFrom != nil && filter.UploadedTo != nil && !filter.UploadedFrom.Before(*filter.UploadedTo) {
+		return filter, utils.BadRequest("from tidak boleh setelah to")
+	}
+	return filter, nil
+}
 
-	if err := s.repo.Create(fileModel); err != nil {
-		// Hapus file jika gagal simpan ke database
-		os.Remove(filePath)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to save file metadata",
-			"error":   err.Error(),
-		})
+// queryDate tanggal YYYY-MM-DD (zona waktu server), nil jika parameter kosong
+func queryDate(c *fiber.Ctx, param string) (*time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, utils.BadRequest(param + " harus berformat YYYY-MM-DD")
 	}
+	return &day, nil
+}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "File uploaded successfully",
-		"data":    s.toFileResponse(fileModel),
-	})
+func (s *fileService) GetAlumniFiles(c *fiber.Ctx) error {
+	return s.entityFiles(c, models.FileEntityAlumni)
 }
 
-func (s *fileService) GetAllFiles(c *fiber.Ctx) error {
-	files, err := s.repo.FindAll()
+func (s *fileService) GetMahasiswaFiles(c *fiber.Ctx) error {
+	return s.entityFiles(c, models.FileEntityMahasiswa)
+}
+
+func (s *fileService) GetPekerjaanFiles(c *fiber.Ctx) error {
+	return s.entityFiles(c, models.FileEntityPekerjaan)
+}
+
+// entityFiles lampiran satu entitas (:id) yang boleh dibaca user
+func (s *fileService) entityFiles(c *fiber.Ctx, entityType string) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+	if err := s.entityExists(entityType, uint(id)); err != nil {
+		return err
+	}
+
+	files, err := s.repo.FindByEntity(entityType, uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to get files",
-			"error":   err.Error(),
-		})
+		return err
 	}
 
-	var responses []models.FileResponse
+	// Hak akses sama untuk semua lampiran entitas ini, cukup diperiksa sekali
+	ownsEntity := actor.admin
+	if !ownsEntity {
+		if ownsEntity, err = s.ownsEntity(actor, entityType, uint(id)); err != nil {
+			return err
+		}
+	}
+	readable := []models.File{}
 	for _, file := range files {
-		responses = append(responses, *s.toFileResponse(&file))
+		if ownsEntity || actor.canManage(&file) || file.Purpose == models.FilePurposeFoto {
+			readable = append(readable, file)
+		}
 	}
 
+	return s.fileListResponse(c, readable)
+}
+
+func (s *fileService) fileListResponse(c *fiber.Ctx, files []models.File) error {
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Files retrieved successfully",
-		"data":    responses,
+		"data":    s.toFileResponses(files),
 	})
 }
 
-func (s *fileService) GetFileByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (s *fileService) toFileResponses(files []models.File) []models.FileResponse {
+	responses := []models.FileResponse{}
+	for _, file := range files {
+		responses = append(responses, *s.toFileResponse(&file))
+	}
+	return responses
+}
 
-	file, err := s.repo.FindByID(id)
+// readableFile mengambil file :id dan memastikan user boleh membacanya
+func (s *fileService) readableFile(c *fiber.Ctx) (*models.File, error) {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.repo.FindByID(c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := s.canRead(actor, file)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, utils.Forbidden("Access denied. You do not have access to this file.")
+	}
+	return file, nil
+}
+
+func (s *fileService) GetFileByID(c *fiber.Ctx) error {
+	file, err := s.readableFile(c)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "File not found",
-			"error":   err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -175,30 +359,183 @@ func (s *fileService) GetFileByID(c *fiber.Ctx) error {
 	})
 }
 
+// DownloadFile mengirim isi file secara streaming. Mendukung satu rentang Range
+// (206), If-Range, dan conditional GET lewat If-None-Match / If-Modified-Since (304).
+// ?inline=true mengirim Content-Disposition inline untuk preview di browser,
+// ?variant=<nama> mengirim variant gambar (thumb, medium).
+func (s *fileService) DownloadFile(c *fiber.Ctx) error {
+	file, err := s.readableFile(c)
+	if err != nil {
+		return err
+	}
+	return s.sendFile(c, file, nil)
+}
+
+// sendFile dipakai download biasa dan link bertanda tangan. beforeBody (boleh nil)
+// dipanggil setelah isi file berhasil dibuka, tepat sebelum body dikirim; tidak
+// dipanggil untuk HEAD dan 304.
+func (s *fileService) sendFile(c *fiber.Ctx, file *models.File, beforeBody func() error) error {
+	if err := downloadable(file); err != nil {
+		return err
+	}
+	backendName, key := file.Location()
+	backend, err := s.storages.Backend(backendName)
+	if err != nil {
+		return err
+	}
+
+	// ?variant=thumb|medium mengirim versi gambar yang diperkecil
+	size, contentType, name := file.FileSize, file.FileType, file.OriginalName
+	etag := fmt.Sprintf(`"%s"`, file.ID.Hex())
+	if variantName := c.Query("variant"); variantName != "" {
+		if _, ok := s.config.Variants[variantName]; !ok {
+			return utils.BadRequest("variant tidak dikenal").WithDetail("variants", variantNames(s.config.Variants))
+		}
+		variant, ok := file.Variants[variantName]
+		if !ok {
+			return utils.NotFound("Variant " + variantName + " tidak tersedia untuk file ini")
+		}
+		key, size, contentType = variant.StorageKey, variant.FileSize, variant.FileType
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + "_" + variantName + filepath.Ext(name)
+		etag = fmt.Sprintf(`"%s-%s"`, file.ID.Hex(), variantName)
+	}
+
+	// Isi file tidak pernah berubah setelah diupload, jadi ID (dan nama variant) cukup sebagai ETag
+	lastModified := file.UploadedAt.UTC().Truncate(time.Second)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+
+	if notModified(c, etag, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	byteRange := utils.ByteRange{Start: 0, Length: size}
+	partial := false
+	if header := c.Get(fiber.HeaderRange); header != "" && rangeStillValid(c, etag, lastModified) {
+		byteRange, partial, err = utils.ParseRange(header, size)
+		if err != nil {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+			return err
+		}
+		if !partial {
+			byteRange = utils.ByteRange{Start: 0, Length: size}
+		}
+	}
+
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	// Content-Disposition baru dipasang saat body jadi dikirim, supaya response
+	// error dari beforeBody tidak diunduh browser sebagai file
+	disposition := contentDisposition(c.QueryBool("inline"), name)
+	if partial {
+		c.Status(fiber.StatusPartialContent)
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", byteRange.Start, byteRange.Start+byteRange.Length-1, size))
+	}
+
+	if c.Method() == fiber.MethodHead {
+		c.Set(fiber.HeaderContentDisposition, disposition)
+		c.Response().Header.SetContentLength(int(byteRange.Length))
+		c.Response().SkipBody = true
+		return nil
+	}
+
+	var reader io.ReadCloser
+	if partial {
+		reader, err = backend.GetRange(c.UserContext(), key, byteRange.Start, byteRange.Length)
+	} else {
+		reader, err = backend.Get(c.UserContext(), key)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return utils.NotFound("Isi file tidak ditemukan di storage")
+	}
+	if err != nil {
+		return err
+	}
+	if beforeBody != nil {
+		if err := beforeBody(); err != nil {
+			reader.Close()
+			return err
+		}
+	}
+	c.Set(fiber.HeaderContentDisposition, disposition)
+
+	// Fasthttp menutup reader setelah body selesai dikirim
+	c.Context().SetBodyStream(reader, int(byteRange.Length))
+	return nil
+}
+
+// notModified true jika versi file di cache client masih sama. If-None-Match
+// diutamakan; If-Modified-Since hanya dipakai jika If-None-Match tidak dikirim.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !lastModified.After(since)
+}
+
+// rangeStillValid memeriksa If-Range: Range hanya dipakai jika ETag atau tanggal
+// yang dikirim masih cocok, selain itu seluruh file dikirim ulang
+func rangeStillValid(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	header := c.Get(fiber.HeaderIfRange)
+	if header == "" {
+		return true
+	}
+	if strings.HasPrefix(header, `"`) {
+		return header == etag
+	}
+	date, err := http.ParseTime(header)
+	return err == nil && date.Equal(lastModified)
+}
+
+// contentDisposition memakai nama asli file; nama non-ASCII dikirim sebagai filename*
+func contentDisposition(inline bool, name string) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": name}); value != "" {
+		return value
+	}
+	return disposition
+}
+
+// DeleteFile hanya untuk pengupload dan admin
 func (s *fileService) DeleteFile(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
 	id := c.Params("id")
 
 	file, err := s.repo.FindByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "File not found",
-			"error":   err.Error(),
-		})
+		return err
 	}
-
-	// Hapus file dari storage
-	if err := os.Remove(file.FilePath); err != nil {
-		fmt.Println("Warning: Failed to delete file from storage:", err)
+	if !actor.canManage(file) {
+		return utils.Forbidden("Access denied. You can only delete your own files.")
 	}
 
-	// Hapus dari database
+	// Record dihapus dulu supaya isi yang masih tercatat tidak pernah hilang
 	if err := s.repo.Delete(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to delete file",
-			"error":   err.Error(),
-		})
+		return err
+	}
+	s.releaseContent(c.UserContext(), file)
+	if s.links != nil {
+		if err := s.links.DeleteByFile(id); err != nil {
+			log.Printf("Warning: Failed to delete links of file %s: %v", id, err)
+		}
 	}
 
 	return c.JSON(fiber.Map{
@@ -208,13 +545,39 @@ func (s *fileService) DeleteFile(c *fiber.Ctx) error {
 }
 
 func (s *fileService) toFileResponse(file *models.File) *models.FileResponse {
+	backend, _ := file.Location()
+	downloadURL := "/api/files/" + file.ID.Hex() + "/download"
+
+	var variants map[string]models.FileVariantResponse
+	if len(file.Variants) > 0 {
+		variants = map[string]models.FileVariantResponse{}
+		for name, variant := range file.Variants {
+			variants[name] = models.FileVariantResponse{
+				FileSize:    variant.FileSize,
+				Width:       variant.Width,
+				Height:      variant.Height,
+				DownloadURL: downloadURL + "?variant=" + name,
+			}
+		}
+	}
+
 	return &models.FileResponse{
-		ID:           file.ID.Hex(),
-		FileName:     file.FileName,
-		OriginalName: file.OriginalName,
-		FilePath:     file.FilePath,
-		FileSize:     file.FileSize,
-		FileType:     file.FileType,
-		UploadedAt:   file.UploadedAt,
+		ID:            file.ID.Hex(),
+		FileName:      file.FileName,
+		OriginalName:  file.OriginalName,
+		Backend:       backend,
+		FileSize:      file.FileSize,
+		FileType:      file.FileType,
+		SHA256:        file.SHA256,
+		OwnerID:       file.OwnerID,
+		EntityType:    file.EntityType,
+		EntityID:      file.EntityID,
+		Purpose:       file.Purpose,
+		ScanStatus:    file.ScanStatus,
+		ScanSignature: file.ScanSignature,
+		ScannedAt:     file.ScannedAt,
+		UploadedAt:    file.UploadedAt,
+		DownloadURL:   downloadURL,
+		Variants:      variants,
 	}
 }
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"modul4crud/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxUploadChunk batas satu chunk PATCH, di bawah batas body request server
+const maxUploadChunk = 16 << 20
+
+// Header upload bertahap, mengikuti penamaan protokol tus
+const (
+	headerUploadOffset   = "Upload-Offset"
+	headerUploadLength   = "Upload-Length"
+	headerUploadChecksum = "Upload-Checksum"
+)
+
+// CreateUploadSession membuka sesi upload bertahap. Purpose, lampiran dan ukuran
+// diperiksa di awal supaya client tidak mengirim chunk untuk upload yang pasti ditolak.
+func (s *fileService) CreateUploadSession(c *fiber.Ctx) error {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateUploadSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	entityType, entityID, purpose, err := s.checkAttachment(actor, req.Purpose, req.EntityType, req.EntityID)
+	if err != nil {
+		return err
+	}
+	rule, err := s.uploadRule(purpose, req.FileSize)
+	if err != nil {
+		return err
+	}
+	if err := s.checkQuota(actor, req.FileSize); err != nil {
+		return err
+	}
+	contentType := normalizeContentType(req.ContentType)
+	if contentType != "" && contentType != "application/octet-stream" && !slices.Contains(rule.Types, contentType) {
+		return utils.BadRequest(fmt.Sprintf("Tipe file %s tidak diizinkan untuk %s", contentType, purpose)).
+			WithDetail("allowed_types", rule.Types)
+	}
+	checksum := strings.ToLower(strings.TrimSpace(req.Checksum))
+	if decoded, err := hex.DecodeString(checksum); checksum != "" && (err != nil || len(decoded) != sha256.Size) {
+		return utils.BadRequest("checksum harus SHA-256 dalam format hex")
+	}
+
+	session := &models.UploadSession{
+		OwnerID:     actor.userID,
+		FileName:    req.FileName,
+		FileSize:    req.FileSize,
+		ContentType: contentType,
+		Checksum:    checksum,
+		Purpose:     purpose,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Backend:     s.storages.Default().Name(),
+		ExpiresAt:   time.Now().Add(s.config.SessionTTL),
+	}
+	if err := s.sessions.Create(session); err != nil {
+		return err
+	}
+
+	response := s.toUploadSessionResponse(session)
+	c.Set(fiber.HeaderLocation, response.UploadURL)
+	c.Set(headerUploadOffset, "0")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Sesi upload dibuat",
+		"data":    response,
+	})
+}
+
+// GetUploadSession offset yang sudah diterima server; client melanjutkan dari sini
+// setelah koneksi terputus. Juga melayani HEAD dengan header Upload-Offset.
+func (s *fileService) GetUploadSession(c *fiber.Ctx) error {
+	session, _, err := s.ownUploadSession(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set(headerUploadOffset, strconv.FormatInt(session.Offset, 10))
+	c.Set(headerUploadLength, strconv.FormatInt(session.FileSize, 10))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    s.toUploadSessionResponse(session),
+	})
+}
+
+// UploadChunk menerima satu chunk di posisi Upload-Offset. Checksum opsional
+// dikirim lewat header "Upload-Checksum: sha256 <base64>". Chunk terakhir
+// langsung menyelesaikan upload menjadi File biasa.
+func (s *fileService) UploadChunk(c *fiber.Ctx) error {
+	session, actor, err := s.ownUploadSession(c)
+	if err != nil {
+		return err
+	}
+
+	offset, err := strconv.ParseInt(c.Get(headerUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		return utils.BadRequest("Header Upload-Offset tidak valid")
+	}
+	if offset != session.Offset {
+		return utils.Conflict("Upload-Offset tidak sesuai dengan offset sesi").
+			WithDetail("offset", session.Offset)
+	}
+	if contentType := c.Get(fiber.HeaderContentType); contentType != "application/offset+octet-stream" && contentType != fiber.MIMEOctetStream {
+		return utils.BadRequest("Content-Type chunk harus application/offset+octet-stream")
+	}
+
+	if session.Status == models.UploadSessionFinishing {
+		return utils.Conflict("Upload sedang diselesaikan oleh request lain")
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		// Semua chunk sudah diterima tapi penyelesaian sebelumnya gagal: coba lagi
+		if session.Offset == session.FileSize {
+			return s.finishUploadSession(c, actor, session)
+		}
+		return utils.BadRequest("Chunk kosong")
+	}
+	if len(body) > maxUploadChunk {
+		return utils.BadRequest(fmt.Sprintf("Ukuran chunk melebihi batas %s", formatBytes(maxUploadChunk)))
+	}
+	if offset+int64(len(body)) > session.FileSize {
+		return utils.BadRequest("Chunk melebihi ukuran file yang didaftarkan")
+	}
+
+	sum := sha256.Sum256(body)
+	if err := verifyChunkChecksum(c.Get(headerUploadChecksum), sum[:]); err != nil {
+		return err
+	}
+
+	backend, err := s.storages.Backend(session.Backend)
+	if err != nil {
+		return err
+	}
+	chunk := models.UploadChunk{
+		Offset:     offset,
+		Size:       int64(len(body)),
+		StorageKey: fmt.Sprintf("chunks/%s/%012d-%s", session.ID.Hex(), offset, uuid.New().String()),
+		SHA256:     hex.EncodeToString(sum[:]),
+	}
+	if err := backend.Put(c.UserContext(), chunk.StorageKey, bytes.NewReader(body), chunk.Size, fiber.MIMEOctetStream); err != nil {
+		return err
+	}
+
+	session, err = s.sessions.AppendChunk(session.ID.Hex(), offset, chunk, time.Now().Add(s.config.SessionTTL))
+	if err != nil {
+		// Chunk lain di offset yang sama lebih dulu tercatat
+		backend.Delete(c.UserContext(), chunk.StorageKey)
+		return err
+	}
+
+	if session.Offset == session.FileSize {
+		return s.finishUploadSession(c, actor, session)
+	}
+
+	c.Set(headerUploadOffset, strconv.FormatInt(session.Offset, 10))
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Chunk diterima",
+		"data":    s.toUploadSessionResponse(session),
+	})
+}
+
+// CancelUploadSession membatalkan upload dan menghapus chunk yang sudah terkirim
+func (s *fileService) CancelUploadSession(c *fiber.Ctx) error {
+	session, _, err := s.ownUploadSession(c)
+	if err != nil {
+		return err
+	}
+	// Chunk sedang dibaca untuk digabung, jadi belum boleh dihapus
+	if session.Status == models.UploadSessionFinishing {
+		return utils.Conflict("Upload sedang diselesaikan dan tidak bisa dibatalkan")
+	}
+
+	s.removeUploadSession(c.UserContext(), session)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Sesi upload dibatalkan",
+	})
+}
+
+// CleanupExpiredUploads menghapus sesi yang ditinggalkan beserta chunk-nya
+func (s *fileService) CleanupExpiredUploads(ctx context.Context) (int, error) {
+	sessions, err := s.sessions.FindExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for i := range sessions {
+		s.removeUploadSession(ctx, &sessions[i])
+	}
+	return len(sessions), nil
+}
+
+// finishUploadSession menggabungkan chunk lalu memprosesnya seperti upload biasa:
+// deteksi tipe, metadata gambar, variant dan record File. Isi yang ditolak
+// tidak bisa diperbaiki dengan chunk baru, jadi sesinya ikut dihapus.
+//
+// Sesi ditandai finishing lebih dulu secara atomik, sehingga dari beberapa PATCH
+// terakhir yang datang bersamaan hanya satu yang menyimpan blob dan membuat File.
+// Kegagalan yang bisa diulang (kuota, storage) mengembalikan sesi ke uploading.
+func (s *fileService) finishUploadSession(c *fiber.Ctx, actor fileActor, session *models.UploadSession) error {
+	ctx := c.UserContext()
+	session, err := s.sessions.BeginFinish(session.ID.Hex(), time.Now().Add(s.config.SessionTTL))
+	if err != nil {
+		return err
+	}
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		// Sesi yang sudah dihapus (isi ditolak) tidak perlu dikembalikan
+		if err := s.sessions.ResetFinish(session.ID.Hex()); err != nil && !utils.IsNotFound(err) {
+			log.Printf("Warning: Failed to reset upload session %s: %v", session.ID.Hex(), err)
+		}
+	}()
+
+	backend, err := s.storages.Backend(session.Backend)
+	if err != nil {
+		return err
+	}
+
+	if session.Checksum != "" {
+		sum, err := hashChunks(ctx, backend, session.Chunks)
+		if err != nil {
+			return err
+		}
+		if sum != session.Checksum {
+			s.removeUploadSession(ctx, session)
+			return utils.BadRequest("Checksum file tidak sesuai, upload dibatalkan")
+		}
+	}
+
+	// Entitas bisa saja dihapus selama upload berlangsung
+	entityType, entityID, purpose, err := s.checkAttachment(actor, session.Purpose, session.EntityType, session.EntityID)
+	if err != nil {
+		s.removeUploadSession(ctx, session)
+		return err
+	}
+	// Kuota bisa terpakai upload lain selama sesi berjalan. Sesi tidak dihapus:
+	// setelah user mengosongkan ruang, PATCH kosong mengulang penyelesaian.
+	if err := s.checkQuota(actor, session.FileSize); err != nil {
+		return err
+	}
+
+	reader := newChunkReader(ctx, backend, session.Chunks)
+	defer reader.Close()
+
+	upload, err := s.checkUpload(reader, session.FileSize, session.ContentType, purpose)
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		s.removeUploadSession(ctx, session)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fileModel, err := s.storeUpload(ctx, actor, upload, session.FileName, entityType, entityID, purpose)
+	if err != nil {
+		return err
+	}
+	finished = true
+	s.removeUploadSession(ctx, session)
+
+	c.Set(headerUploadOffset, strconv.FormatInt(session.FileSize, 10))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "File uploaded successfully",
+		"data":    s.toFileResponse(fileModel),
+	})
+}
+
+// ownUploadSession sesi :id milik user yang sedang login dan belum kedaluwarsa
+func (s *fileService) ownUploadSession(c *fiber.Ctx) (*models.UploadSession, fileActor, error) {
+	actor, err := fileActorFrom(c)
+	if err != nil {
+		return nil, actor, err
+	}
+	session, err := s.sessions.FindByID(c.Params("id"))
+	if err != nil {
+		return nil, actor, err
+	}
+	if session.OwnerID != actor.userID {
+		return nil, actor, utils.Forbidden("Access denied. This upload session belongs to another user.")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		s.removeUploadSession(c.UserContext(), session)
+		return nil, actor, utils.NotFound("Sesi upload sudah kedaluwarsa")
+	}
+	return session, actor, nil
+}
+
+func (s *fileService) removeUploadSession(ctx context.Context, session *models.UploadSession) {
+	if backend, err := s.storages.Backend(session.Backend); err == nil {
+		for _, chunk := range session.Chunks {
+			if err := backend.Delete(ctx, chunk.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("Warning: Failed to delete chunk %s: %v", chunk.StorageKey, err)
+			}
+		}
+	}
+	if err := s.sessions.Delete(session.ID.Hex()); err != nil && !utils.IsNotFound(err) {
+		log.Printf("Warning: Failed to delete upload session %s: %v", session.ID.Hex(), err)
+	}
+}
+
+func (s *fileService) toUploadSessionResponse(session *models.UploadSession) *models.UploadSessionResponse {
+	return &models.UploadSessionResponse{
+		ID:           session.ID.Hex(),
+		FileName:     session.FileName,
+		FileSize:     session.FileSize,
+		Offset:       session.Offset,
+		Status:       session.Status,
+		Purpose:      session.Purpose,
+		EntityType:   session.EntityType,
+		EntityID:     session.EntityID,
+		MaxChunkSize: maxUploadChunk,
+		UploadURL:    "/api/files/uploads/" + session.ID.Hex(),
+		CreatedAt:    session.CreatedAt,
+		ExpiresAt:    session.ExpiresAt,
+	}
+}
+
+// verifyChunkChecksum memeriksa header "sha256 <base64>"; tanpa header chunk diterima apa adanya
+func verifyChunkChecksum(header string, sum []byte) error {
+	if header == "" {
+		return nil
+	}
+	algorithm, value, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if algorithm != "sha256" {
+		return utils.BadRequest("Upload-Checksum hanya mendukung sha256")
+	}
+	expected, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return utils.BadRequest("Upload-Checksum tidak valid")
+	}
+	if !bytes.Equal(expected, sum) {
+		return utils.BadRequest("Checksum chunk tidak sesuai, kirim ulang chunk ini")
+	}
+	return nil
+}
+
+func hashChunks(ctx context.Context, backend storage.Storage, chunks []models.UploadChunk) (string, error) {
+	reader := newChunkReader(ctx, backend, chunks)
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// chunkReader membaca chunk satu per satu sesuai urutan offset, sehingga file
+// besar tidak perlu dimuat ke memori saat digabung
+type chunkReader struct {
+	ctx     context.Context
+	backend storage.Storage
+	chunks  []models.UploadChunk
+	current io.ReadCloser
+}
+
+func newChunkReader(ctx context.Context, backend storage.Storage, chunks []models.UploadChunk) *chunkReader {
+	sorted := slices.Clone(chunks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+	return &chunkReader{ctx: ctx, backend: backend, chunks: sorted}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := r.backend.Get(r.ctx, r.chunks[0].StorageKey)
+			if err != nil {
+				return 0, fmt.Errorf("chunk offset %d: %w", r.chunks[0].Offset, err)
+			}
+			r.current, r.chunks = reader, r.chunks[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"modul4crud/middleware"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testUploadOwner = 7
+
+// newCompleteSession sesi yang semua chunk-nya sudah diterima, seperti setelah
+// penyelesaian sebelumnya gagal; PATCH kosong mengulang penyelesaiannya
+func newCompleteSession(t *testing.T, s *testFileService, content string, status string) *models.UploadSession {
+	t.Helper()
+	ctx := context.Background()
+	session := &models.UploadSession{
+		OwnerID:   testUploadOwner,
+		FileName:  "ijazah.pdf",
+		FileSize:  int64(len(content)),
+		Purpose:   models.FilePurposeDokumen,
+		Backend:   storage.BackendLocal,
+		Status:    status,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := s.sessRepo.Create(session); err != nil {
+		t.Fatal(err)
+	}
+
+	half := len(content) / 2
+	for _, part := range []struct {
+		offset int
+		data   string
+	}{{0, content[:half]}, {half, content[half:]}} {
+		chunk := models.UploadChunk{
+			Offset:     int64(part.offset),
+			Size:       int64(len(part.data)),
+			StorageKey: fmt.Sprintf("chunks/%s/%012d", session.ID.Hex(), part.offset),
+			SHA256:     sha256Hex(part.data),
+		}
+		if err := s.backend.Put(ctx, chunk.StorageKey, strings.NewReader(part.data), chunk.Size, fiber.MIMEOctetStream); err != nil {
+			t.Fatal(err)
+		}
+		updated, err := s.sessRepo.AppendChunk(session.ID.Hex(), chunk.Offset, chunk, session.ExpiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session = updated
+	}
+	return session
+}
+
+func newUploadApp(s *testFileService, role string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", testUploadOwner)
+		c.Locals("role", role)
+		return c.Next()
+	})
+	app.Patch("/uploads/:id", s.UploadChunk)
+	return app
+}
+
+// patchEmpty PATCH tanpa isi di akhir sesi, yang menyelesaikan upload
+func patchEmpty(t *testing.T, app *fiber.App, session *models.UploadSession) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/uploads/"+session.ID.Hex(), nil)
+	req.Header.Set(fiber.HeaderContentType, "application/offset+octet-stream")
+	req.Header.Set(headerUploadOffset, strconv.FormatInt(session.FileSize, 10))
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestUploadSessionFinish(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		checksum    string
+		quota       int64
+		wantStatus  int
+		wantFiles   int
+		wantSession string // status sesi setelahnya, kosong = sesi dihapus
+	}{
+		{name: "selesai", status: models.UploadSessionUploading, wantStatus: http.StatusCreated, wantFiles: 1},
+		{name: "checksum cocok", status: models.UploadSessionUploading, checksum: sha256Hex(testPDF), wantStatus: http.StatusCreated, wantFiles: 1},
+		{
+			name:        "sedang diselesaikan request lain",
+			status:      models.UploadSessionFinishing,
+			wantStatus:  http.StatusConflict,
+			wantSession: models.UploadSessionFinishing,
+		},
+		{
+			name:        "kuota habis, sesi bisa diulang",
+			status:      models.UploadSessionUploading,
+			quota:       10,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantSession: models.UploadSessionUploading,
+		},
+		{name: "checksum salah, sesi dibatalkan", status: models.UploadSessionUploading, checksum: sha256Hex("lain"), wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFileService(t, nil)
+			if tt.quota > 0 {
+				s.config.Quotas["user"] = tt.quota
+			}
+			session := newCompleteSession(t, s, testPDF, tt.status)
+			if tt.checksum != "" {
+				stored := s.sessRepo.sessions[session.ID.Hex()]
+				stored.Checksum = tt.checksum
+				s.sessRepo.sessions[session.ID.Hex()] = stored
+			}
+
+			if got := patchEmpty(t, newUploadApp(s, "user"), session); got != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", got, tt.wantStatus)
+			}
+
+			if files, _ := s.files.FindAll(); len(files) != tt.wantFiles {
+				t.Errorf("file tercatat = %d, want %d", len(files), tt.wantFiles)
+			}
+			got, err := s.sessRepo.FindByID(session.ID.Hex())
+			switch {
+			case tt.wantSession == "" && err == nil:
+				t.Errorf("sesi masih ada dengan status %s, want dihapus", got.Status)
+			case tt.wantSession != "" && err != nil:
+				t.Errorf("sesi: %v, want status %s", err, tt.wantSession)
+			case tt.wantSession != "" && got.Status != tt.wantSession:
+				t.Errorf("status sesi = %s, want %s", got.Status, tt.wantSession)
+			}
+			if chunks := countObjects(t, s.backend, "chunks/"); (tt.wantSession == "") != (chunks == 0) {
+				t.Errorf("chunk tersisa = %d", chunks)
+			}
+		})
+	}
+}
+
+// Beberapa PATCH terakhir yang datang bersamaan: semuanya sudah melihat sesi
+// lengkap sebelum BeginFinish, tetapi hanya satu yang boleh membuat File
+func TestUploadSessionBeginFinishRace(t *testing.T) {
+	const requests = 8
+	s := newTestFileService(t, nil)
+	session := newCompleteSession(t, s, testPDF, models.UploadSessionUploading)
+
+	var arrived, finished sync.WaitGroup
+	arrived.Add(requests)
+	finished.Add(requests)
+	s.sessRepo.beforeFinish = func() {
+		arrived.Done()
+		arrived.Wait()
+	}
+	s.sessRepo.afterFinish = func() {
+		finished.Done()
+		finished.Wait()
+	}
+
+	app := newUploadApp(s, "user")
+	statuses := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- patchEmpty(t, app, session)
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != requests-1 {
+		t.Errorf("status = %v, want 1x201 dan %dx409", counts, requests-1)
+	}
+
+	files, _ := s.files.FindAll()
+	if len(files) != 1 {
+		t.Fatalf("file tercatat = %d, want 1", len(files))
+	}
+	blob, err := s.blobRepo.FindByID(blobID(files[0].Backend, files[0].SHA256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.RefCount != 1 {
+		t.Errorf("RefCount = %d, want 1", blob.RefCount)
+	}
+	if _, err := s.sessRepo.FindByID(session.ID.Hex()); err == nil {
+		t.Error("sesi masih ada setelah upload selesai")
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"modul4crud/models"
+	"modul4crud/utils"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniffLength jumlah byte awal yang dibaca untuk mendeteksi tipe file (batas bawaan mimetype)
+const sniffLength = 3072
+
+// maxImageMetadataStrip gambar lebih besar dari ini tidak dimuat ke memori untuk dibuang metadatanya
+const maxImageMetadataStrip = 50 << 20
+
+// UploadRule batas upload untuk satu purpose lampiran
+type UploadRule struct {
+	MaxSize int64    // byte
+	Types   []string // MIME type hasil deteksi isi file
+}
+
+// FileConfig konfigurasi file service yang bisa diubah lewat environment
+type FileConfig struct {
+	UploadRules map[string]UploadRule   // per purpose (models.FilePurposes)
+	Variants    map[string]ImageVariant // variant gambar per nama (?variant=)
+	SessionTTL  time.Duration           // sesi upload bertahap kedaluwarsa setelah tidak aktif selama ini
+	Quotas      map[string]int64        // kuota penyimpanan bawaan per role (byte), 0 = tanpa batas
+	LinkSecret  []byte                  // kunci HMAC link unduhan bertanda tangan, kosong = tidak aktif
+}
+
+// defaultUploadSessionTTL masa berlaku sesi upload bertahap sejak chunk terakhir
+const defaultUploadSessionTTL = 24 * time.Hour
+
+// DefaultUploadRules batas bawaan per purpose
+func DefaultUploadRules() map[string]UploadRule {
+	images := []string{"image/jpeg", "image/png"}
+	documents := []string{"application/pdf", "image/jpeg", "image/png"}
+	return map[string]UploadRule{
+		models.FilePurposeFoto:    {MaxSize: 5 << 20, Types: images},
+		models.FilePurposeCV:      {MaxSize: 5 << 20, Types: []string{"application/pdf"}},
+		models.FilePurposeKontrak: {MaxSize: 10 << 20, Types: documents},
+		models.FilePurposeDokumen: {MaxSize: 10 << 20, Types: documents},
+	}
+}
+
+// FileConfigFromEnv membaca override batas upload per purpose dan variant gambar:
+//
+//	UPLOAD_<PURPOSE>_MAX_MB   misal UPLOAD_CV_MAX_MB=2
+//	UPLOAD_<PURPOSE>_TYPES    misal UPLOAD_FOTO_TYPES=image/jpeg,image/png
+//	IMAGE_VARIANTS            misal thumb:200x200:crop,medium:800x800 ("none" = tanpa variant)
+//	UPLOAD_SESSION_TTL        misal 12h
+//	STORAGE_QUOTA_<ROLE>_MB   misal STORAGE_QUOTA_USER_MB=500 (0 = tanpa batas)
+//	FILE_LINK_SECRET          kunci HMAC link unduhan, minimal 32 karakter
+func FileConfigFromEnv() (FileConfig, error) {
+	rules := DefaultUploadRules()
+	for purpose, rule := range rules {
+		prefix := "UPLOAD_" + strings.ToUpper(purpose) + "_"
+		if value := os.Getenv(prefix + "MAX_MB"); value != "" {
+			mb, err := strconv.ParseFloat(value, 64)
+			if err != nil || mb <= 0 {
+				return FileConfig{}, fmt.Errorf("%sMAX_MB tidak valid: %q", prefix, value)
+			}
+			rule.MaxSize = int64(mb * (1 << 20))
+		}
+		if value := os.Getenv(prefix + "TYPES"); value != "" {
+			rule.Types = nil
+			for _, contentType := range strings.Split(value, ",") {
+				if contentType = normalizeContentType(contentType); contentType != "" {
+					rule.Types = append(rule.Types, contentType)
+				}
+			}
+		}
+		rules[purpose] = rule
+	}
+
+	variants, err := imageVariantsFromEnv()
+	if err != nil {
+		return FileConfig{}, err
+	}
+
+	ttl := defaultUploadSessionTTL
+	if value := os.Getenv("UPLOAD_SESSION_TTL"); value != "" {
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return FileConfig{}, fmt.Errorf("UPLOAD_SESSION_TTL tidak valid: %q", value)
+		}
+	}
+
+	quotas, err := storageQuotasFromEnv()
+	if err != nil {
+		return FileConfig{}, err
+	}
+
+	linkSecret, err := fileLinkSecretFromEnv()
+	if err != nil {
+		return FileConfig{}, err
+	}
+	return FileConfig{UploadRules: rules, Variants: variants, SessionTTL: ttl, Quotas: quotas, LinkSecret: linkSecret}, nil
+}
+
+// checkedUpload isi upload yang sudah diperiksa dan siap disimpan
+type checkedUpload struct {
+	body        io.Reader
+	size        int64
+	contentType string
+	ext         string
+	image       []byte // isi gambar JPEG/PNG setelah metadata dibuang, untuk membuat variant
+}
+
+// checkUpload mendeteksi tipe asli dari magic bytes, menolak tipe yang tidak
+// diizinkan untuk purpose atau berbeda dari Content-Type yang dikirim client,
+// lalu membuang metadata gambar. Ekstensi diambil dari tipe asli, bukan nama file client.
+func (s *fileService) checkUpload(r io.Reader, size int64, declaredType, purpose string) (*checkedUpload, error) {
+	rule, err := s.uploadRule(purpose, size)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	detected := mimetype.Detect(head)
+	contentType := normalizeContentType(detected.String())
+	if !slices.Contains(rule.Types, contentType) {
+		return nil, utils.BadRequest(fmt.Sprintf("Tipe file %s tidak diizinkan untuk %s", contentType, purpose)).
+			WithDetail("allowed_types", rule.Types)
+	}
+	if declared := normalizeContentType(declaredType); declared != "" && declared != "application/octet-stream" && declared != contentType {
+		return nil, utils.BadRequest(fmt.Sprintf("Content-Type %s tidak sesuai dengan isi file (%s)", declared, contentType))
+	}
+
+	upload := &checkedUpload{body: io.MultiReader(bytes.NewReader(head), r), size: size, contentType: contentType, ext: detected.Extension()}
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return upload, nil
+	}
+
+	// Gambar dibaca utuh supaya EXIF (lokasi GPS, info kamera) bisa dibuang
+	data, err := io.ReadAll(io.LimitReader(upload.body, maxImageMetadataStrip+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageMetadataStrip {
+		return nil, utils.BadRequest("Ukuran gambar terlalu besar")
+	}
+	data, err = utils.StripImageMetadata(data, contentType)
+	if err != nil {
+		return nil, utils.BadRequest("File gambar rusak atau tidak valid")
+	}
+	upload.body, upload.size, upload.image = bytes.NewReader(data), int64(len(data)), data
+	return upload, nil
+}
+
+// uploadRule aturan purpose, sekaligus menolak ukuran di atas batasnya
+func (s *fileService) uploadRule(purpose string, size int64) (UploadRule, error) {
+	rule, ok := s.config.UploadRules[purpose]
+	if !ok {
+		return UploadRule{}, utils.BadRequest("purpose tidak valid")
+	}
+	if size > rule.MaxSize {
+		return UploadRule{}, utils.BadRequest(fmt.Sprintf("Ukuran file melebihi batas %s untuk %s", formatBytes(rule.MaxSize), purpose))
+	}
+	return rule, nil
+}
+
+// normalizeContentType tanpa parameter (charset) dan dengan alias image/jpg -> image/jpeg
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(contentType))
+	if err != nil {
+		return ""
+	}
+	if mediaType == "image/jpg" || mediaType == "image/pjpeg" {
+		return "image/jpeg"
+	}
+	return mediaType
+}
+
+// safeOriginalName nama file client dengan ekstensi disesuaikan tipe aslinya,
+// supaya file yang diunduh tidak tersimpan dengan ekstensi menyesatkan (.exe, .html)
+func safeOriginalName(name, contentType, ext string) string {
+	base := filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if base == "." || base == "/" {
+		base = "file"
+	}
+	current := strings.ToLower(filepath.Ext(base))
+	if current == ext || (contentType == "image/jpeg" && current == ".jpeg") {
+		return base
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ext
+}
+
+func formatBytes(size int64) string {
+	if size%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", size>>20)
+	}
+	return fmt.Sprintf("%.1fMB", float64(size)/(1<<20))
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"modul4crud/models"
+	"modul4crud/storage"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// maxVariantPixels gambar dengan piksel lebih banyak tidak dibuatkan variant (mencegah decompression bomb)
+const maxVariantPixels = 40_000_000
+
+// ImageVariant ukuran maksimal satu variant gambar. Crop memotong gambar supaya
+// tepat Width x Height (thumbnail persegi); tanpa Crop gambar diperkecil
+// proporsional dan tidak pernah diperbesar.
+type ImageVariant struct {
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// DefaultImageVariants variant bawaan untuk foto JPEG/PNG
+func DefaultImageVariants() map[string]ImageVariant {
+	return map[string]ImageVariant{
+		"thumb":  {Width: 200, Height: 200, Crop: true},
+		"medium": {Width: 800, Height: 800},
+	}
+}
+
+// parseImageVariants membaca IMAGE_VARIANTS, misal "thumb:200x200:crop,medium:800x800"
+func parseImageVariants(value string) (map[string]ImageVariant, error) {
+	variants := map[string]ImageVariant{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("IMAGE_VARIANTS tidak valid: %q", item)
+		}
+		width, height, found := strings.Cut(parts[1], "x")
+		w, errW := strconv.Atoi(width)
+		h, errH := strconv.Atoi(height)
+		if !found || errW != nil || errH != nil || w <= 0 || h <= 0 {
+			return nil, fmt.Errorf("ukuran variant %s tidak valid: %q", parts[0], parts[1])
+		}
+		if len(parts) == 3 && parts[2] != "crop" {
+			return nil, fmt.Errorf("opsi variant %s tidak dikenal: %q", parts[0], parts[2])
+		}
+		variants[parts[0]] = ImageVariant{Width: w, Height: h, Crop: len(parts) == 3}
+	}
+	return variants, nil
+}
+
+func imageVariantsFromEnv() (map[string]ImageVariant, error) {
+	value := strings.TrimSpace(os.Getenv("IMAGE_VARIANTS"))
+	if value == "" {
+		return DefaultImageVariants(), nil
+	}
+	if value == "none" {
+		return map[string]ImageVariant{}, nil
+	}
+	return parseImageVariants(value)
+}
+
+// storeVariants membuat semua variant dari gambar yang sudah diperiksa lalu
+// menyimpannya di backend yang sama dengan key <key asli tanpa ekstensi>_<nama><ext>.
+// Gagal membuat variant tidak membatalkan upload; file asli tetap tersimpan.
+func (s *fileService) storeVariants(ctx context.Context, backend storage.Storage, key string, upload *checkedUpload) map[string]models.FileVariant {
+	if upload.image == nil || len(s.config.Variants) == 0 {
+		return nil
+	}
+	format := imaging.JPEG
+	if upload.contentType == "image/png" {
+		format = imaging.PNG
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(upload.image))
+	if err != nil || cfg.Width*cfg.Height > maxVariantPixels {
+		log.Printf("Warning: variant untuk %s dilewati: gambar tidak bisa dibaca atau terlalu besar", key)
+		return nil
+	}
+	img, err := imaging.Decode(bytes.NewReader(upload.image))
+	if err != nil {
+		log.Printf("Warning: variant untuk %s dilewati: %v", key, err)
+		return nil
+	}
+
+	variants := map[string]models.FileVariant{}
+	for _, name := range variantNames(s.config.Variants) {
+		size := s.config.Variants[name]
+		var resized image.Image
+		if size.Crop {
+			resized = imaging.Fill(img, size.Width, size.Height, imaging.Center, imaging.Lanczos)
+		} else {
+			resized = imaging.Fit(img, size.Width, size.Height, imaging.Lanczos)
+		}
+
+		var out bytes.Buffer
+		if err := imaging.Encode(&out, resized, format, imaging.JPEGQuality(85)); err != nil {
+			log.Printf("Warning: variant %s untuk %s gagal: %v", name, key, err)
+			continue
+		}
+		objectKey, objectSize := variantKey(key, name, upload.ext), int64(out.Len())
+		if err := backend.Put(ctx, objectKey, &out, objectSize, upload.contentType); err != nil {
+			log.Printf("Warning: variant %s untuk %s gagal disimpan: %v", name, key, err)
+			continue
+		}
+		bounds := resized.Bounds()
+		variants[name] = models.FileVariant{
+			StorageKey: objectKey,
+			FileSize:   objectSize,
+			FileType:   upload.contentType,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		}
+	}
+	return variants
+}
+
+// deleteVariants menghapus variant dari backend tempat file aslinya disimpan
+func deleteVariants(ctx context.Context, backend storage.Storage, variants map[string]models.FileVariant) {
+	for name, variant := range variants {
+		if err := backend.Delete(ctx, variant.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: Failed to delete variant %s (%s): %v", name, variant.StorageKey, err)
+		}
+	}
+}
+
+func variantKey(key, name, ext string) string {
+	return strings.TrimSuffix(key, ext) + "_" + name + ext
+}
+
+// variantNames urut nama supaya hasil upload deterministik
+func variantNames(variants map[string]ImageVariant) []string {
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
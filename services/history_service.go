@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recordHistory mencatat dan membaca riwayat versi satu jenis entitas. Isi record
+// disimpan dalam bentuk request update-nya, sehingga restore cukup mengirim ulang
+// snapshot lewat alur PUT yang sama (validasi, If-Match, referensi).
+type recordHistory struct {
+	historyRepo repo.HistoryRepository
+	entity      string
+}
+
+func newRecordHistory(historyRepo repo.HistoryRepository, entity string) *recordHistory {
+	return &recordHistory{historyRepo: historyRepo, entity: entity}
+}
+
+// save menyimpan isi record versi `version` (sebelum diubah). Riwayat bersifat
+// best-effort: perubahan record sudah tersimpan saat fungsi ini dipanggil, dan
+// mengembalikan error di titik ini membuat klien mengulang perubahan yang sudah
+// berhasil, jadi kegagalan hanya dicatat di log.
+func (h *recordHistory) save(c *fiber.Ctx, recordID uint, version int, previous interface{}) {
+	snapshot, err := models.NewRecordSnapshot(previous)
+	if err == nil {
+		changedBy, _ := c.Locals("user_id").(int)
+		err = h.historyRepo.Create(&models.RecordHistory{
+			Entity:    h.entity,
+			RecordID:  recordID,
+			Version:   version,
+			Snapshot:  snapshot,
+			ChangedBy: changedBy,
+		})
+	}
+	if err != nil {
+		log.Printf("⚠️  Riwayat %s #%d versi %d gagal disimpan: %v", h.entity, recordID, version, err)
+	}
+}
+
+// list handler GET /:id/history. Setiap versi disertai perubahan menuju versi
+// sesudahnya; versi terbaru dibandingkan dengan isi record saat ini.
+func (h *recordHistory) list(c *fiber.Ctx, recordID uint, currentVersion int, current interface{}) error {
+	next, err := models.NewRecordSnapshot(current)
+	if err != nil {
+		return utils.Internal(err)
+	}
+
+	histories, err := h.historyRepo.GetByRecord(h.entity, recordID)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]models.RecordHistoryEntry, 0, len(histories))
+	for _, history := range histories {
+		entries = append(entries, models.RecordHistoryEntry{
+			RecordHistory: history,
+			Changes:       history.Snapshot.Diff(next),
+		})
+		next = history.Snapshot
+	}
+
+	return c.JSON(fiber.Map{
+		"current_version": currentVersion,
+		"data":            entries,
+		"total":           len(entries),
+	})
+}
+
+// diff handler GET /:id/history/diff?from=&to=; to default ke versi saat ini
+func (h *recordHistory) diff(c *fiber.Ctx, recordID uint, currentVersion int, current interface{}) error {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		return utils.BadRequest("Parameter from wajib berisi nomor versi")
+	}
+	to := c.QueryInt("to", currentVersion)
+
+	fromSnapshot, err := h.snapshot(recordID, from, currentVersion, current)
+	if err != nil {
+		return err
+	}
+	toSnapshot, err := h.snapshot(recordID, to, currentVersion, current)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"from":    from,
+		"to":      to,
+		"changes": fromSnapshot.Diff(toSnapshot),
+	})
+}
+
+// restore mengisi target (request update) dengan isi record pada versi tertentu
+func (h *recordHistory) restore(c *fiber.Ctx, recordID uint, target interface{}) error {
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return utils.BadRequest("Versi tidak valid")
+	}
+
+	history, err := h.historyRepo.GetVersion(h.entity, recordID, version)
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		return utils.NotFound(fmt.Sprintf("Versi %d tidak ada di riwayat", version))
+	}
+	return history.Snapshot.Decode(target)
+}
+
+// snapshot isi record pada versi tertentu, dari riwayat atau dari record saat ini
+func (h *recordHistory) snapshot(recordID uint, version int, currentVersion int, current interface{}) (models.RecordSnapshot, error) {
+	if version == currentVersion {
+		snapshot, err := models.NewRecordSnapshot(current)
+		if err != nil {
+			return nil, utils.Internal(err)
+		}
+		return snapshot, nil
+	}
+
+	history, err := h.historyRepo.GetVersion(h.entity, recordID, version)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return nil, utils.NotFound(fmt.Sprintf("Versi %d tidak ada di riwayat", version))
+	}
+	return history.Snapshot, nil
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"fmt"
+	"modul4crud/middleware"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,11 +13,13 @@ import (
 
 type MahasiswaService struct {
 	mahasiswaRepo repo.MahasiswaRepository
+	history       *recordHistory
 }
 
-func NewMahasiswaService(mahasiswaRepo repo.MahasiswaRepository) *MahasiswaService {
+func NewMahasiswaService(mahasiswaRepo repo.MahasiswaRepository, historyRepo repo.HistoryRepository) *MahasiswaService {
 	return &MahasiswaService{
 		mahasiswaRepo: mahasiswaRepo,
+		history:       newRecordHistory(historyRepo, models.HistoryEntityMahasiswa),
 	}
 }
 
@@ -22,14 +27,12 @@ func (s *MahasiswaService) GetMahasiswas(c *fiber.Ctx) error {
 	// Parse pagination parameters from query
 	var pagination models.PaginationRequest
 	if err := c.QueryParser(&pagination); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid pagination parameters",
-		})
+		return utils.BadRequest("Invalid pagination parameters")
 	}
 
 	mahasiswas, total, err := s.mahasiswaRepo.GetWithPagination(&pagination)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	response := models.NewPaginationResponse(mahasiswas, &pagination, total)
@@ -40,7 +43,7 @@ func (s *MahasiswaService) GetMahasiswas(c *fiber.Ctx) error {
 func (s *MahasiswaService) GetMahasiswasLegacy(c *fiber.Ctx) error {
 	mahasiswas, err := s.mahasiswaRepo.GetAll()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.JSON(mahasiswas)
 }
@@ -48,7 +51,10 @@ func (s *MahasiswaService) GetMahasiswasLegacy(c *fiber.Ctx) error {
 func (s *MahasiswaService) CreateMahasiswa(c *fiber.Ctx) error {
 	var req models.CreateMahasiswaRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
 	}
 
 	// Convert request to model (business logic from usecase)
@@ -62,66 +68,246 @@ func (s *MahasiswaService) CreateMahasiswa(c *fiber.Ctx) error {
 
 	err := s.mahasiswaRepo.Create(mahasiswa)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
+	middleware.SetETag(c, mahasiswa.Version)
 	return c.JSON(mahasiswa)
 }
 
 func (s *MahasiswaService) GetMahasiswa(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	mahasiswa, err := s.mahasiswaRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Mahasiswa not found"})
+		return err
 	}
 
+	middleware.SetETag(c, mahasiswa.Version)
 	return c.JSON(mahasiswa)
 }
 
+// UpdateMahasiswa mengganti seluruh data mahasiswa (PUT); semua field wajib dikirim
 func (s *MahasiswaService) UpdateMahasiswa(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	var req models.UpdateMahasiswaRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return utils.BadRequest(err.Error())
 	}
 
 	// Get existing mahasiswa
 	mahasiswa, err := s.mahasiswaRepo.GetByID(uint(id))
-	if err != nil || mahasiswa == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Mahasiswa not found"})
+	if err != nil {
+		return err
+	}
+
+	return s.saveMahasiswa(c, mahasiswa, &req)
+}
+
+// PatchMahasiswa update sebagian (JSON Merge Patch); field yang tidak dikirim tidak berubah
+func (s *MahasiswaService) PatchMahasiswa(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	patch, err := utils.ParseMergePatch(c.Body())
+	if err != nil {
+		return err
 	}
 
+	mahasiswa, err := s.mahasiswaRepo.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+
+	req := mahasiswaRequest(mahasiswa)
+	if err := patch.ApplyTo(&req); err != nil {
+		return err
+	}
+
+	return s.saveMahasiswa(c, mahasiswa, &req)
+}
+
+// saveMahasiswa memvalidasi request lengkap lalu menyimpannya ke mahasiswa.
+// If-Match dicek di sini agar PUT dan PATCH berperilaku sama.
+func (s *MahasiswaService) saveMahasiswa(c *fiber.Ctx, mahasiswa *models.Mahasiswa, req *models.UpdateMahasiswaRequest) error {
+	if err := middleware.CheckIfMatch(c, mahasiswa.Version); err != nil {
+		return err
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+	previous, previousVersion := mahasiswaRequest(mahasiswa), mahasiswa.Version
+
 	// Update fields (business logic from usecase)
 	mahasiswa.Nama = req.Nama
 	mahasiswa.Email = req.Email
 	mahasiswa.Jurusan = req.Jurusan
 	mahasiswa.Angkatan = req.Angkatan
 
-	err = s.mahasiswaRepo.Update(mahasiswa)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := s.mahasiswaRepo.Update(mahasiswa); err != nil {
+		return err
 	}
+	s.history.save(c, mahasiswa.ID, previousVersion, previous)
 
+	middleware.SetETag(c, mahasiswa.Version)
 	return c.JSON(mahasiswa)
 }
 
+// mahasiswaRequest isi mahasiswa yang bisa diubah, dasar PATCH dan snapshot riwayat
+func mahasiswaRequest(mahasiswa *models.Mahasiswa) models.UpdateMahasiswaRequest {
+	return models.UpdateMahasiswaRequest{
+		Nama:     mahasiswa.Nama,
+		Jurusan:  mahasiswa.Jurusan,
+		Angkatan: mahasiswa.Angkatan,
+		Email:    mahasiswa.Email,
+	}
+}
+
+// GetMahasiswaHistory daftar versi lama mahasiswa beserta perubahannya (admin)
+func (s *MahasiswaService) GetMahasiswaHistory(c *fiber.Ctx) error {
+	mahasiswa, err := s.mahasiswaFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.list(c, mahasiswa.ID, mahasiswa.Version, mahasiswaRequest(mahasiswa))
+}
+
+// DiffMahasiswaHistory perbedaan dua versi mahasiswa (?from=&to=)
+func (s *MahasiswaService) DiffMahasiswaHistory(c *fiber.Ctx) error {
+	mahasiswa, err := s.mahasiswaFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.diff(c, mahasiswa.ID, mahasiswa.Version, mahasiswaRequest(mahasiswa))
+}
+
+// RestoreMahasiswaVersion mengembalikan isi mahasiswa ke versi lama. Restore
+// dicatat sebagai perubahan baru sehingga bisa dibatalkan lagi.
+func (s *MahasiswaService) RestoreMahasiswaVersion(c *fiber.Ctx) error {
+	mahasiswa, err := s.mahasiswaFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateMahasiswaRequest
+	if err := s.history.restore(c, mahasiswa.ID, &req); err != nil {
+		return err
+	}
+
+	return s.saveMahasiswa(c, mahasiswa, &req)
+}
+
+// mahasiswaFromParam mengambil mahasiswa berdasarkan parameter :id
+func (s *MahasiswaService) mahasiswaFromParam(c *fiber.Ctx) (*models.Mahasiswa, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid ID")
+	}
+	return s.mahasiswaRepo.GetByID(uint(id))
+}
+
+// FindMahasiswaDuplicates daftar pasangan mahasiswa yang diduga duplikat, urut skor
+// tertinggi (?min_score=&limit=)
+func (s *MahasiswaService) FindMahasiswaDuplicates(c *fiber.Ctx) error {
+	minScore, limit, err := duplicateQuery(c)
+	if err != nil {
+		return err
+	}
+
+	mahasiswas, err := s.mahasiswaRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	pairs := utils.FindDuplicates(mahasiswas, mahasiswaBlockKeys, mahasiswaDuplicateSignals, minScore)
+	return duplicateResponse(c, pairs, minScore, limit)
+}
+
+// MergeMahasiswa menggabungkan mahasiswa duplikat (source_ids) ke mahasiswa :id;
+// mahasiswa sumber di-soft delete dengan merged_into menunjuk mahasiswa tujuan
+func (s *MahasiswaService) MergeMahasiswa(c *fiber.Ctx) error {
+	target, err := s.mahasiswaFromParam(c)
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, target.Version); err != nil {
+		return err
+	}
+
+	var req models.MergeMahasiswaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	sourceIDs, err := mergeSourceIDs(target.ID, req.SourceIDs, "Mahasiswa")
+	if err != nil {
+		return err
+	}
+	sources := make([]*models.Mahasiswa, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		source, err := s.mahasiswaRepo.GetByID(sourceID)
+		if err != nil {
+			if utils.IsNotFound(err) {
+				return utils.NotFound(fmt.Sprintf("Mahasiswa %d tidak ditemukan", sourceID))
+			}
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	previous, previousVersion := mahasiswaRequest(target), target.Version
+	if err := s.mahasiswaRepo.Merge(target.ID, sourceIDs); err != nil {
+		return err
+	}
+	s.history.save(c, target.ID, previousVersion, previous)
+	for _, source := range sources {
+		s.history.save(c, source.ID, source.Version, mahasiswaRequest(source))
+	}
+
+	mahasiswa, err := s.mahasiswaRepo.GetByID(target.ID)
+	if err != nil {
+		return err
+	}
+	middleware.SetETag(c, mahasiswa.Version)
+	return c.JSON(fiber.Map{
+		"message":    "Mahasiswa berhasil digabungkan",
+		"mahasiswa":  mahasiswa,
+		"merged_ids": sourceIDs,
+	})
+}
+
 func (s *MahasiswaService) DeleteMahasiswa(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
+	}
+
+	version, err := middleware.CheckIfMatchWith(c, func() (int, error) {
+		mahasiswa, err := s.mahasiswaRepo.GetByID(uint(id))
+		if err != nil {
+			return 0, err
+		}
+		return mahasiswa.Version, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	err = s.mahasiswaRepo.Delete(uint(id))
+	err = s.mahasiswaRepo.Delete(uint(id), version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.SendStatus(204)
@@ -130,7 +316,7 @@ func (s *MahasiswaService) DeleteMahasiswa(c *fiber.Ctx) error {
 func (s *MahasiswaService) GetMahasiswaCount(c *fiber.Ctx) error {
 	count, err := s.mahasiswaRepo.Count()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
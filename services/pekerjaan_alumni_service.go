@@ -1,20 +1,93 @@
 package services
 
 import (
+	"fmt"
+	"math"
+	"modul4crud/middleware"
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// minSalaryGroupSize jumlah minimal data dalam satu grup agar statistik gaji
+// ditampilkan, sehingga gaji individu tidak bisa ditebak dari grup kecil
+const minSalaryGroupSize = 5
+
+// mataUangPattern kode mata uang ISO 4217 (IDR, USD, SGD)
+var mataUangPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// salaryViewer menentukan gaji persis siapa yang boleh dilihat pemanggil: admin
+// melihat semua, user lain hanya gaji pekerjaannya sendiri. Selebihnya gaji hanya
+// tersedia lewat statistik yang menyembunyikan grup kecil.
+type salaryViewer struct {
+	admin    bool
+	alumniID uint // 0 jika user tidak terhubung ke alumni
+}
+
+func newSalaryViewer(c *fiber.Ctx, alumniRepo repo.AlumniRepository) (salaryViewer, error) {
+	role, _ := c.Locals("role").(string)
+	if role == "admin" {
+		return salaryViewer{admin: true}, nil
+	}
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return salaryViewer{}, utils.Unauthorized("User ID tidak ditemukan")
+	}
+	alumni, err := alumniRepo.GetByUserID(userID)
+	if utils.IsNotFound(err) {
+		return salaryViewer{}, nil
+	}
+	if err != nil {
+		return salaryViewer{}, err
+	}
+	return salaryViewer{alumniID: alumni.ID}, nil
+}
+
+func (v salaryViewer) mask(pekerjaans []models.PekerjaanAlumni) {
+	for i := range pekerjaans {
+		v.maskOne(&pekerjaans[i])
+	}
+}
+
+func (v salaryViewer) maskOne(pekerjaan *models.PekerjaanAlumni) {
+	if v.admin || (v.alumniID != 0 && pekerjaan.AlumniID == v.alumniID) {
+		return
+	}
+	pekerjaan.HideGaji()
+}
+
+// maskSalaries menyembunyikan gaji milik alumni lain sebelum pekerjaans dikirim
+func (s *PekerjaanAlumniService) maskSalaries(c *fiber.Ctx, pekerjaans []models.PekerjaanAlumni) error {
+	viewer, err := newSalaryViewer(c, s.alumniRepo)
+	if err != nil {
+		return err
+	}
+	viewer.mask(pekerjaans)
+	return nil
+}
+
 type PekerjaanAlumniService struct {
-	pekerjaanRepo repo.PekerjaanAlumniRepository
+	pekerjaanRepo  repo.PekerjaanAlumniRepository
+	alumniRepo     repo.AlumniRepository
+	perusahaanRepo repo.PerusahaanRepository
+	referensiRepo  repo.ReferensiRepository
+	history        *recordHistory
 }
 
-func NewPekerjaanAlumniService(pekerjaanRepo repo.PekerjaanAlumniRepository) *PekerjaanAlumniService {
+func NewPekerjaanAlumniService(pekerjaanRepo repo.PekerjaanAlumniRepository, alumniRepo repo.AlumniRepository, perusahaanRepo repo.PerusahaanRepository, referensiRepo repo.ReferensiRepository, historyRepo repo.HistoryRepository) *PekerjaanAlumniService {
 	return &PekerjaanAlumniService{
-		pekerjaanRepo: pekerjaanRepo,
+		pekerjaanRepo:  pekerjaanRepo,
+		alumniRepo:     alumniRepo,
+		perusahaanRepo: perusahaanRepo,
+		referensiRepo:  referensiRepo,
+		history:        newRecordHistory(historyRepo, models.HistoryEntityPekerjaan),
 	}
 }
 
@@ -22,14 +95,15 @@ func (s *PekerjaanAlumniService) GetPekerjaanAlumnis(c *fiber.Ctx) error {
 	// Parse pagination parameters from query
 	var pagination models.PaginationRequest
 	if err := c.QueryParser(&pagination); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid pagination parameters",
-		})
+		return utils.BadRequest("Invalid pagination parameters")
 	}
 
 	pekerjaans, total, err := s.pekerjaanRepo.GetWithPagination(&pagination)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+	if err := s.maskSalaries(c, pekerjaans); err != nil {
+		return err
 	}
 
 	response := models.NewPaginationResponse(pekerjaans, &pagination, total)
@@ -40,184 +114,387 @@ func (s *PekerjaanAlumniService) GetPekerjaanAlumnis(c *fiber.Ctx) error {
 func (s *PekerjaanAlumniService) GetPekerjaanAlumnisLegacy(c *fiber.Ctx) error {
 	pekerjaans, err := s.pekerjaanRepo.GetAll()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+	if err := s.maskSalaries(c, pekerjaans); err != nil {
+		return err
 	}
 	return c.JSON(pekerjaans)
 }
 
 func (s *PekerjaanAlumniService) CreatePekerjaanAlumni(c *fiber.Ctx) error {
-	var pekerjaan models.PekerjaanAlumni
-	if err := c.BodyParser(&pekerjaan); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	var req models.CreatePekerjaanAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	pekerjaan := models.PekerjaanAlumni{
+		AlumniID:            req.AlumniID,
+		PerusahaanID:        req.PerusahaanID,
+		NamaPerusahaan:      req.NamaPerusahaan,
+		PosisiJabatan:       req.PosisiJabatan,
+		BidangIndustri:      req.BidangIndustri,
+		KodeIndustri:        req.KodeIndustri,
+		LokasiKerja:         req.LokasiKerja,
+		KodeProvinsi:        req.KodeProvinsi,
+		KodeKota:            req.KodeKota,
+		GajiRange:           req.GajiRange,
+		GajiMin:             req.GajiMin,
+		GajiMax:             req.GajiMax,
+		GajiMataUang:        req.GajiMataUang,
+		GajiPeriode:         req.GajiPeriode,
+		TanggalMulaiKerja:   req.TanggalMulaiKerja,
+		TanggalSelesaiKerja: req.TanggalSelesaiKerja,
+		StatusPekerjaan:     req.StatusPekerjaan,
+		DeskripsiPekerjaan:  req.DeskripsiPekerjaan,
+	}
+	if err := s.validatePekerjaan(&pekerjaan); err != nil {
+		return err
+	}
+	normalizeGaji(&pekerjaan)
+	if err := s.resolveReferensi(&pekerjaan); err != nil {
+		return err
+	}
+	if err := resolvePerusahaan(s.perusahaanRepo, &pekerjaan); err != nil {
+		return err
 	}
 
 	err := s.pekerjaanRepo.Create(&pekerjaan)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
+	middleware.SetETag(c, pekerjaan.Version)
 	return c.JSON(pekerjaan)
 }
 
 func (s *PekerjaanAlumniService) GetPekerjaanAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan not found"})
+		return err
+	}
+	viewer, err := newSalaryViewer(c, s.alumniRepo)
+	if err != nil {
+		return err
 	}
+	viewer.maskOne(pekerjaan)
 
+	middleware.SetETag(c, pekerjaan.Version)
 	return c.JSON(pekerjaan)
 }
 
 func (s *PekerjaanAlumniService) GetPekerjaanByAlumni(c *fiber.Ctx) error {
 	alumniID, err := strconv.ParseUint(c.Params("alumni_id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid Alumni ID"})
+		return utils.BadRequest("Invalid Alumni ID")
 	}
 
 	pekerjaans, err := s.pekerjaanRepo.GetByAlumniID(uint(alumniID))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+	if err := s.maskSalaries(c, pekerjaans); err != nil {
+		return err
 	}
 
 	return c.JSON(pekerjaans)
 }
 
+// UpdatePekerjaanAlumni mengganti seluruh data pekerjaan (PUT); field opsional yang tidak dikirim dikosongkan
 func (s *PekerjaanAlumniService) UpdatePekerjaanAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
-	var updatedPekerjaan models.PekerjaanAlumni
-	if err := c.BodyParser(&updatedPekerjaan); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	var req models.UpdatePekerjaanAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
 	}
 
 	// Get existing pekerjaan
 	pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan not found"})
+		return err
 	}
 
-	// Update fields (business logic from usecase)
-	pekerjaan.AlumniID = updatedPekerjaan.AlumniID
-	pekerjaan.NamaPerusahaan = updatedPekerjaan.NamaPerusahaan
-	pekerjaan.PosisiJabatan = updatedPekerjaan.PosisiJabatan
-	pekerjaan.BidangIndustri = updatedPekerjaan.BidangIndustri
-	pekerjaan.LokasiKerja = updatedPekerjaan.LokasiKerja
-	pekerjaan.GajiRange = updatedPekerjaan.GajiRange
-	pekerjaan.TanggalMulaiKerja = updatedPekerjaan.TanggalMulaiKerja
-	pekerjaan.TanggalSelesaiKerja = updatedPekerjaan.TanggalSelesaiKerja
-	pekerjaan.StatusPekerjaan = updatedPekerjaan.StatusPekerjaan
-	pekerjaan.DeskripsiPekerjaan = updatedPekerjaan.DeskripsiPekerjaan
+	return s.savePekerjaan(c, pekerjaan, &req)
+}
+
+// PatchPekerjaanAlumni update sebagian (JSON Merge Patch); field yang tidak dikirim tidak berubah
+func (s *PekerjaanAlumniService) PatchPekerjaanAlumni(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	patch, err := utils.ParseMergePatch(c.Body())
+	if err != nil {
+		return err
+	}
 
-	err = s.pekerjaanRepo.Update(pekerjaan)
+	pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+
+	req := pekerjaanRequest(pekerjaan)
+	if err := patch.ApplyTo(&req); err != nil {
+		return err
+	}
+	resetDerivedPekerjaan(&req, patch)
+
+	return s.savePekerjaan(c, pekerjaan, &req)
+}
+
+// resetDerivedPekerjaan membuang nilai turunan lama yang tidak ikut dikirim di
+// patch. Tanpa ini, mis. bidang_industri baru akan kalah oleh kode_industri
+// lama saat resolveReferensi, dan gaji_range lama tidak dihitung ulang.
+func resetDerivedPekerjaan(req *models.UpdatePekerjaanAlumniRequest, patch utils.MergePatch) {
+	if patch.Has("nama_perusahaan") && !patch.Has("perusahaan_id") {
+		req.PerusahaanID = nil
+	}
+	if patch.Has("bidang_industri") && !patch.Has("kode_industri") {
+		req.KodeIndustri = ""
+	}
+	if patch.Has("lokasi_kerja") && !patch.Has("kode_kota") && !patch.Has("kode_provinsi") {
+		req.KodeKota, req.KodeProvinsi = "", ""
+	}
+	if patch.Has("kode_provinsi") && !patch.Has("kode_kota") {
+		req.KodeKota = ""
+	}
+	if patch.Has("gaji_range") && !patch.Has("gaji_min") && !patch.Has("gaji_max") {
+		req.GajiMin, req.GajiMax = nil, nil
+	}
+	if (patch.Has("gaji_min") || patch.Has("gaji_max") || patch.Has("gaji_periode") || patch.Has("gaji_mata_uang")) && !patch.Has("gaji_range") {
+		req.GajiRange = ""
+	}
+}
+
+// savePekerjaan memvalidasi request lengkap, menerapkannya ke pekerjaan, lalu menyimpan
+func (s *PekerjaanAlumniService) savePekerjaan(c *fiber.Ctx, pekerjaan *models.PekerjaanAlumni, req *models.UpdatePekerjaanAlumniRequest) error {
+	if err := middleware.CheckIfMatch(c, pekerjaan.Version); err != nil {
+		return err
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		return err
+	}
+	if req.AlumniID != nil && *req.AlumniID != pekerjaan.AlumniID {
+		return &utils.FieldError{Field: "alumni_id", Message: "Pekerjaan tidak bisa dipindah ke alumni lain"}
 	}
+	previous, previousVersion := pekerjaanRequest(pekerjaan), pekerjaan.Version
 
+	// Update fields (business logic from usecase)
+	pekerjaan.PerusahaanID = req.PerusahaanID
+	pekerjaan.NamaPerusahaan = req.NamaPerusahaan
+	pekerjaan.PosisiJabatan = req.PosisiJabatan
+	pekerjaan.BidangIndustri = req.BidangIndustri
+	pekerjaan.KodeIndustri = req.KodeIndustri
+	pekerjaan.LokasiKerja = req.LokasiKerja
+	pekerjaan.KodeProvinsi = req.KodeProvinsi
+	pekerjaan.KodeKota = req.KodeKota
+	pekerjaan.GajiRange = req.GajiRange
+	pekerjaan.GajiMin = req.GajiMin
+	pekerjaan.GajiMax = req.GajiMax
+	pekerjaan.GajiMataUang = req.GajiMataUang
+	pekerjaan.GajiPeriode = req.GajiPeriode
+	pekerjaan.TanggalMulaiKerja = req.TanggalMulaiKerja
+	pekerjaan.TanggalSelesaiKerja = req.TanggalSelesaiKerja
+	pekerjaan.StatusPekerjaan = req.StatusPekerjaan
+	pekerjaan.DeskripsiPekerjaan = req.DeskripsiPekerjaan
+	if err := s.validatePekerjaan(pekerjaan); err != nil {
+		return err
+	}
+	normalizeGaji(pekerjaan)
+	if err := s.resolveReferensi(pekerjaan); err != nil {
+		return err
+	}
+	if err := resolvePerusahaan(s.perusahaanRepo, pekerjaan); err != nil {
+		return err
+	}
+
+	if err := s.pekerjaanRepo.Update(pekerjaan); err != nil {
+		return err
+	}
+	s.history.save(c, pekerjaan.ID, previousVersion, previous)
+
+	middleware.SetETag(c, pekerjaan.Version)
 	return c.JSON(pekerjaan)
 }
 
+// pekerjaanRequest isi pekerjaan yang bisa diubah, dasar PATCH dan snapshot riwayat
+func pekerjaanRequest(pekerjaan *models.PekerjaanAlumni) models.UpdatePekerjaanAlumniRequest {
+	alumniID := pekerjaan.AlumniID
+	return models.UpdatePekerjaanAlumniRequest{
+		AlumniID:            &alumniID,
+		PerusahaanID:        pekerjaan.PerusahaanID,
+		NamaPerusahaan:      pekerjaan.NamaPerusahaan,
+		PosisiJabatan:       pekerjaan.PosisiJabatan,
+		BidangIndustri:      pekerjaan.BidangIndustri,
+		KodeIndustri:        pekerjaan.KodeIndustri,
+		LokasiKerja:         pekerjaan.LokasiKerja,
+		KodeProvinsi:        pekerjaan.KodeProvinsi,
+		KodeKota:            pekerjaan.KodeKota,
+		GajiRange:           pekerjaan.GajiRange,
+		GajiMin:             pekerjaan.GajiMin,
+		GajiMax:             pekerjaan.GajiMax,
+		GajiMataUang:        pekerjaan.GajiMataUang,
+		GajiPeriode:         pekerjaan.GajiPeriode,
+		TanggalMulaiKerja:   pekerjaan.TanggalMulaiKerja,
+		TanggalSelesaiKerja: pekerjaan.TanggalSelesaiKerja,
+		StatusPekerjaan:     pekerjaan.StatusPekerjaan,
+		DeskripsiPekerjaan:  pekerjaan.DeskripsiPekerjaan,
+	}
+}
+
+// GetPekerjaanHistory daftar versi lama pekerjaan beserta perubahannya (admin)
+func (s *PekerjaanAlumniService) GetPekerjaanHistory(c *fiber.Ctx) error {
+	pekerjaan, err := s.pekerjaanFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.list(c, pekerjaan.ID, pekerjaan.Version, pekerjaanRequest(pekerjaan))
+}
+
+// DiffPekerjaanHistory perbedaan dua versi pekerjaan (?from=&to=)
+func (s *PekerjaanAlumniService) DiffPekerjaanHistory(c *fiber.Ctx) error {
+	pekerjaan, err := s.pekerjaanFromParam(c)
+	if err != nil {
+		return err
+	}
+	return s.history.diff(c, pekerjaan.ID, pekerjaan.Version, pekerjaanRequest(pekerjaan))
+}
+
+// RestorePekerjaanVersion mengembalikan pekerjaan ke versi lama; aturan riwayat
+// kerja (tanggal tumpang tindih dll.) tetap dicek terhadap data sekarang
+func (s *PekerjaanAlumniService) RestorePekerjaanVersion(c *fiber.Ctx) error {
+	pekerjaan, err := s.pekerjaanFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdatePekerjaanAlumniRequest
+	if err := s.history.restore(c, pekerjaan.ID, &req); err != nil {
+		return err
+	}
+
+	return s.savePekerjaan(c, pekerjaan, &req)
+}
+
+// pekerjaanFromParam mengambil pekerjaan berdasarkan parameter :id
+func (s *PekerjaanAlumniService) pekerjaanFromParam(c *fiber.Ctx) (*models.PekerjaanAlumni, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid ID")
+	}
+	return s.pekerjaanRepo.GetByID(uint(id))
+}
+
 func (s *PekerjaanAlumniService) DeletePekerjaanAlumni(c *fiber.Ctx) error {
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 
 	userID, ok := c.Locals("user_id").(int)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+		return utils.Unauthorized("User ID tidak ditemukan")
 	}
 
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	if userRole != "admin" {
 		pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan not found"})
+			return err
 		}
 
 		if pekerjaan.Alumni.UserID != userID {
-			return c.Status(403).JSON(fiber.Map{"error": "Access denied. You can only delete your own job records."})
+			return utils.Forbidden("Access denied. You can only delete your own job records.")
 		}
 	}
 
-	err = s.pekerjaanRepo.Delete(uint(id))
+	version, err := middleware.CheckIfMatchWith(c, s.pekerjaanVersion(uint(id)))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.SendStatus(204)
-}
-
-func (s *PekerjaanAlumniService) GetPekerjaanAlumniCount(c *fiber.Ctx) error {
-	count, err := s.pekerjaanRepo.Count()
+	err = s.pekerjaanRepo.Delete(uint(id), version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"total_pekerjaan_alumni": count,
-	})
+	return c.SendStatus(204)
 }
 
-func (s *PekerjaanAlumniService) GetAlumniCountByCompany(c *fiber.Ctx) error {
-	namaPerusahaan := c.Params("nama_perusahaan")
-	if namaPerusahaan == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Nama perusahaan tidak boleh kosong"})
+// pekerjaanVersion membaca versi pekerjaan saat ini untuk pengecekan If-Match
+func (s *PekerjaanAlumniService) pekerjaanVersion(id uint) func() (int, error) {
+	return func() (int, error) {
+		pekerjaan, err := s.pekerjaanRepo.GetByID(id)
+		if err != nil {
+			return 0, err
+		}
+		return pekerjaan.Version, nil
 	}
+}
 
-	count, err := s.pekerjaanRepo.GetAlumniCountByCompany(namaPerusahaan)
+func (s *PekerjaanAlumniService) GetPekerjaanAlumniCount(c *fiber.Ctx) error {
+	count, err := s.pekerjaanRepo.Count()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
-		"nama_perusahaan": namaPerusahaan,
-		"jumlah_alumni":   count,
-		"message":         "Data jumlah alumni di perusahaan berhasil diambil",
+		"total_pekerjaan_alumni": count,
 	})
 }
 
 func (s *PekerjaanAlumniService) SoftDeletePekerjaanAlumni(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 
 	userID, ok := c.Locals("user_id").(int)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+		return utils.Unauthorized("User ID tidak ditemukan")
 	}
 
 	if userRole != "admin" {
 		pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan not found"})
+			return err
 		}
 
 		if pekerjaan.Alumni.UserID != userID {
-			return c.Status(403).JSON(fiber.Map{"error": "Access denied. You can only delete your own job records."})
+			return utils.Forbidden("Access denied. You can only delete your own job records.")
 		}
 	}
 
-	err = s.pekerjaanRepo.SoftDelete(uint(id))
+	version, err := middleware.CheckIfMatchWith(c, s.pekerjaanVersion(uint(id)))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
+	}
+
+	err = s.pekerjaanRepo.SoftDelete(uint(id), version)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(fiber.Map{"message": "Pekerjaan berhasil dihapus sementara"})
@@ -226,19 +503,19 @@ func (s *PekerjaanAlumniService) SoftDeletePekerjaanAlumni(c *fiber.Ctx) error {
 func (s *PekerjaanAlumniService) SoftDeletePekerjaanByAlumni(c *fiber.Ctx) error {
 	alumniID, err := strconv.ParseUint(c.Params("alumni_id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid Alumni ID"})
+		return utils.BadRequest("Invalid Alumni ID")
 	}
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 	if userRole != "admin" {
-		return c.Status(403).JSON(fiber.Map{"error": "Access denied. Only admin can perform bulk operations."})
+		return utils.Forbidden("Access denied. Only admin can perform bulk operations.")
 	}
 
 	err = s.pekerjaanRepo.SoftDeleteByAlumniID(uint(alumniID))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(fiber.Map{"message": "Semua pekerjaan alumni berhasil dihapus sementara"})
@@ -247,33 +524,33 @@ func (s *PekerjaanAlumniService) SoftDeletePekerjaanByAlumni(c *fiber.Ctx) error
 func (s *PekerjaanAlumniService) RestorePekerjaanAlumni(c *fiber.Ctx) error {
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 
 	userID, ok := c.Locals("user_id").(int)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+		return utils.Unauthorized("User ID tidak ditemukan")
 	}
 
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return utils.BadRequest("Invalid ID")
 	}
 
 	if userRole != "admin" {
 		pekerjaan, err := s.pekerjaanRepo.GetByID(uint(id))
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan not found"})
+			return err
 		}
 
 		if pekerjaan.Alumni.UserID != userID {
-			return c.Status(403).JSON(fiber.Map{"error": "Access denied. You can only restore your own job records."})
+			return utils.Forbidden("Access denied. You can only restore your own job records.")
 		}
 	}
 
 	err = s.pekerjaanRepo.Restore(uint(id))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(fiber.Map{"message": "Pekerjaan berhasil dikembalikan"})
@@ -282,16 +559,16 @@ func (s *PekerjaanAlumniService) RestorePekerjaanAlumni(c *fiber.Ctx) error {
 func (s *PekerjaanAlumniService) GetDeletedPekerjaan(c *fiber.Ctx) error {
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 
 	if userRole != "admin" {
-		return c.Status(403).JSON(fiber.Map{"error": "Access denied. Only admin can view deleted data."})
+		return utils.Forbidden("Access denied. Only admin can view deleted data.")
 	}
 
 	pekerjaans, err := s.pekerjaanRepo.GetDeleted()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(pekerjaans)
@@ -300,73 +577,298 @@ func (s *PekerjaanAlumniService) GetDeletedPekerjaan(c *fiber.Ctx) error {
 func (s *PekerjaanAlumniService) GetPekerjaanByUser(c *fiber.Ctx) error {
 	userID, ok := c.Locals("user_id").(int)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+		return utils.Unauthorized("User ID tidak ditemukan")
 	}
 
 	pekerjaans, err := s.pekerjaanRepo.GetByUserID(userID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(pekerjaans)
 }
 
-// GetPekerjaanStatsByIndustry - Get pekerjaan statistics grouped by industry
+// GetPekerjaanStatsByIndustry - Get pekerjaan statistics grouped by kode industri
 func (s *PekerjaanAlumniService) GetPekerjaanStatsByIndustry(c *fiber.Ctx) error {
-	pekerjaans, err := s.pekerjaanRepo.GetAll()
+	groups, err := s.pekerjaanRepo.CountByGroup("kode_industri", c.Query("search"))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	// Group by bidang_industri
-	stats := make(map[string]int)
-	for _, pekerjaan := range pekerjaans {
-		stats[pekerjaan.BidangIndustri]++
+	industri, err := loadVocabulary(s.referensiRepo, models.KategoriIndustri)
+	if err != nil {
+		return err
 	}
 
-	// Convert to array for response
+	// Convert to array for response; bidang yang belum terpetakan memakai teks aslinya
 	type IndustryStat struct {
+		Kode     string `json:"kode"`
 		Industry string `json:"industry"`
-		Count    int    `json:"count"`
+		Count    int64  `json:"count"`
+	}
+
+	stats := kodeCounter{}
+	var total int64
+	for _, group := range groups {
+		stats.add(group.Kode, industri.nama(group.Kode, statLabel(group)), group.Count)
+		total += group.Count
 	}
-	
+
 	result := []IndustryStat{}
-	for industry, count := range stats {
-		result = append(result, IndustryStat{Industry: industry, Count: count})
+	for _, item := range stats.sorted() {
+		result = append(result, IndustryStat{Kode: item.Kode, Industry: item.Nama, Count: item.Count})
 	}
 
 	return c.JSON(fiber.Map{
-		"data": result,
-		"total": len(pekerjaans),
+		"data":  result,
+		"total": total,
 	})
 }
 
-// GetPekerjaanStatsByLocation - Get pekerjaan statistics grouped by location
+// GetPekerjaanStatsByLocation - Get pekerjaan statistics grouped by kode kota.
+// ?level=provinsi mengelompokkan per provinsi.
 func (s *PekerjaanAlumniService) GetPekerjaanStatsByLocation(c *fiber.Ctx) error {
-	pekerjaans, err := s.pekerjaanRepo.GetAll()
+	level := c.Query("level", models.KategoriKota)
+	if level != models.KategoriKota && level != models.KategoriProvinsi {
+		return utils.BadRequest("level harus kota atau provinsi")
+	}
+
+	groupBy := "kode_kota"
+	if level == models.KategoriProvinsi {
+		groupBy = "kode_provinsi"
+	}
+	groups, err := s.pekerjaanRepo.CountByGroup(groupBy, c.Query("search"))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	// Group by lokasi_kerja
-	stats := make(map[string]int)
-	for _, pekerjaan := range pekerjaans {
-		stats[pekerjaan.LokasiKerja]++
+	resolver := newReferensiResolver(s.referensiRepo)
+	kota, err := resolver.vocabulary(models.KategoriKota)
+	if err != nil {
+		return err
+	}
+	provinsi, err := resolver.vocabulary(models.KategoriProvinsi)
+	if err != nil {
+		return err
+	}
+
+	// Pada level kota, grup berisi kode provinsi jika kota tidak diketahui;
+	// lokasi yang belum terpetakan memakai teks aslinya
+	stats := kodeCounter{}
+	var total int64
+	for _, group := range groups {
+		total += group.Count
+		if level == models.KategoriKota && kota.lookupKode(group.Kode) != nil {
+			stats.add(group.Kode, kota.nama(group.Kode, ""), group.Count)
+			continue
+		}
+		stats.add(group.Kode, provinsi.nama(group.Kode, statLabel(group)), group.Count)
 	}
 
 	// Convert to array for response
 	type LocationStat struct {
+		Kode     string `json:"kode"`
 		Location string `json:"location"`
-		Count    int    `json:"count"`
+		Count    int64  `json:"count"`
 	}
-	
+
 	result := []LocationStat{}
-	for location, count := range stats {
-		result = append(result, LocationStat{Location: location, Count: count})
+	for _, item := range stats.sorted() {
+		result = append(result, LocationStat{Kode: item.Kode, Location: item.Nama, Count: item.Count})
 	}
 
 	return c.JSON(fiber.Map{
-		"data": result,
-		"total": len(pekerjaans),
+		"data":  result,
+		"level": level,
+		"total": total,
 	})
 }
+
+// GetSalaryStats - Statistik gaji (persentil) untuk seluruh pekerjaan
+func (s *PekerjaanAlumniService) GetSalaryStats(c *fiber.Ctx) error {
+	return s.salaryStats(c, "")
+}
+
+// GetSalaryStatsByJurusan - Statistik gaji dikelompokkan per jurusan
+func (s *PekerjaanAlumniService) GetSalaryStatsByJurusan(c *fiber.Ctx) error {
+	return s.salaryStats(c, "jurusan")
+}
+
+// GetSalaryStatsByTahunLulus - Statistik gaji dikelompokkan per tahun lulus
+func (s *PekerjaanAlumniService) GetSalaryStatsByTahunLulus(c *fiber.Ctx) error {
+	return s.salaryStats(c, "tahun_lulus")
+}
+
+// GetSalaryStatsByIndustry - Statistik gaji dikelompokkan per bidang industri
+func (s *PekerjaanAlumniService) GetSalaryStatsByIndustry(c *fiber.Ctx) error {
+	return s.salaryStats(c, "bidang_industri")
+}
+
+// GetSalaryStatsByLocation - Statistik gaji dikelompokkan per lokasi kerja
+func (s *PekerjaanAlumniService) GetSalaryStatsByLocation(c *fiber.Ctx) error {
+	return s.salaryStats(c, "lokasi_kerja")
+}
+
+// salaryStats menghitung persentil gaji per bulan untuk setiap grup. Grup dengan
+// data kurang dari min_group_size (minimal minSalaryGroupSize) tidak ditampilkan.
+func (s *PekerjaanAlumniService) salaryStats(c *fiber.Ctx, groupBy string) error {
+	mataUang := strings.ToUpper(c.Query("mata_uang", models.DefaultGajiMataUang))
+	if !mataUangPattern.MatchString(mataUang) {
+		return utils.BadRequest("mata_uang harus kode ISO 4217 tiga huruf, misal IDR")
+	}
+	minGroupSize := c.QueryInt("min_group_size", minSalaryGroupSize)
+	if minGroupSize < minSalaryGroupSize {
+		minGroupSize = minSalaryGroupSize
+	}
+
+	samples, err := s.pekerjaanRepo.GetSalarySamples(groupBy, mataUang)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]float64)
+	for _, sample := range samples {
+		value, ok := utils.MonthlyGaji(sample.GajiMin, sample.GajiMax, sample.GajiPeriode)
+		if !ok {
+			continue
+		}
+		groups[sample.GroupKey] = append(groups[sample.GroupKey], value)
+	}
+
+	result := []models.SalaryStat{}
+	suppressed := 0
+	for group, values := range groups {
+		if len(values) < minGroupSize {
+			suppressed++
+			continue
+		}
+		sort.Float64s(values)
+		result = append(result, models.SalaryStat{
+			Group:  group,
+			Count:  len(values),
+			P10:    percentile(values, 10),
+			P25:    percentile(values, 25),
+			Median: percentile(values, 50),
+			P75:    percentile(values, 75),
+			P90:    percentile(values, 90),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+
+	return c.JSON(fiber.Map{
+		"data":              result,
+		"group_by":          groupBy,
+		"mata_uang":         mataUang,
+		"periode":           models.GajiPeriodeBulanan,
+		"min_group_size":    minGroupSize,
+		"suppressed_groups": suppressed,
+	})
+}
+
+// validatePekerjaan memeriksa aturan riwayat pekerjaan: alumni harus terdaftar,
+// tanggal selesai tidak sebelum tanggal mulai, dan periode
+// pekerjaan aktif tidak boleh tumpang tindih dengan pekerjaan aktif lain.
+func (s *PekerjaanAlumniService) validatePekerjaan(pekerjaan *models.PekerjaanAlumni) error {
+	// Format field (status, tanggal wajib) sudah dicek lewat tag validate di request
+	if pekerjaan.StatusPekerjaan == "" {
+		pekerjaan.StatusPekerjaan = models.StatusPekerjaanAktif
+	}
+
+	if pekerjaan.AlumniID == 0 {
+		return &utils.FieldError{Field: "alumni_id", Message: "alumni_id wajib diisi"}
+	}
+	if _, err := s.alumniRepo.GetByID(pekerjaan.AlumniID); err != nil {
+		if utils.IsNotFound(err) {
+			return &utils.FieldError{Field: "alumni_id", Message: fmt.Sprintf("Alumni dengan ID %d tidak ditemukan", pekerjaan.AlumniID)}
+		}
+		return err
+	}
+
+	if pekerjaan.TanggalSelesaiKerja != nil && pekerjaan.TanggalSelesaiKerja.Before(pekerjaan.TanggalMulaiKerja) {
+		return &utils.FieldError{Field: "tanggal_selesai_kerja", Message: "Tanggal selesai kerja tidak boleh sebelum tanggal mulai kerja"}
+	}
+
+	if pekerjaan.StatusPekerjaan != models.StatusPekerjaanAktif {
+		return nil
+	}
+	existing, err := s.pekerjaanRepo.GetByAlumniID(pekerjaan.AlumniID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID == pekerjaan.ID || other.StatusPekerjaan != models.StatusPekerjaanAktif {
+			continue
+		}
+		if periodeTumpangTindih(pekerjaan.TanggalMulaiKerja, pekerjaan.TanggalSelesaiKerja, other.TanggalMulaiKerja, other.TanggalSelesaiKerja) {
+			return &utils.FieldError{
+				Field:   "tanggal_mulai_kerja",
+				Message: fmt.Sprintf("Periode kerja tumpang tindih dengan pekerjaan aktif lain (ID %d, %s)", other.ID, other.NamaPerusahaan),
+			}
+		}
+	}
+	return nil
+}
+
+// periodeTumpangTindih memeriksa apakah dua periode kerja beririsan.
+// Tanggal selesai kosong berarti pekerjaan masih berjalan.
+func periodeTumpangTindih(mulaiA time.Time, selesaiA *time.Time, mulaiB time.Time, selesaiB *time.Time) bool {
+	if selesaiA != nil && selesaiA.Before(mulaiB) {
+		return false
+	}
+	if selesaiB != nil && selesaiB.Before(mulaiA) {
+		return false
+	}
+	return true
+}
+
+// resolveReferensi memvalidasi bidang industri dan lokasi kerja terhadap data referensi
+func (s *PekerjaanAlumniService) resolveReferensi(pekerjaan *models.PekerjaanAlumni) error {
+	resolver := newReferensiResolver(s.referensiRepo)
+	if err := resolver.resolveIndustri(pekerjaan); err != nil {
+		return err
+	}
+	return resolver.resolveLokasi(pekerjaan)
+}
+
+// normalizeGaji mengisi gaji terstruktur dari GajiRange (atau sebaliknya) dan
+// memberi nilai default mata uang serta periode
+func normalizeGaji(pekerjaan *models.PekerjaanAlumni) {
+	if pekerjaan.GajiMin == nil && pekerjaan.GajiMax == nil && pekerjaan.GajiRange != "" {
+		if parsed, ok := utils.ParseGajiRange(pekerjaan.GajiRange); ok {
+			pekerjaan.GajiMin = parsed.Min
+			pekerjaan.GajiMax = parsed.Max
+			if pekerjaan.GajiMataUang == "" {
+				pekerjaan.GajiMataUang = parsed.MataUang
+			}
+			if pekerjaan.GajiPeriode == "" {
+				pekerjaan.GajiPeriode = parsed.Periode
+			}
+		}
+	}
+
+	if pekerjaan.GajiMataUang == "" {
+		pekerjaan.GajiMataUang = models.DefaultGajiMataUang
+	}
+	pekerjaan.GajiMataUang = strings.ToUpper(pekerjaan.GajiMataUang)
+	if pekerjaan.GajiPeriode != models.GajiPeriodeTahunan {
+		pekerjaan.GajiPeriode = models.GajiPeriodeBulanan
+	}
+
+	if pekerjaan.GajiRange == "" {
+		pekerjaan.GajiRange = utils.FormatGajiRange(pekerjaan.GajiMin, pekerjaan.GajiMax, pekerjaan.GajiMataUang, pekerjaan.GajiPeriode)
+	}
+}
+
+// percentile menghitung persentil dengan interpolasi linear dari data yang sudah terurut
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
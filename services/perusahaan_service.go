@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"modul4crud/middleware"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// perusahaanSortFields kolom yang boleh dipakai untuk sort_by
+var perusahaanSortFields = map[string]bool{
+	"id": true, "nama": true, "bidang_industri": true, "lokasi": true, "created_at": true,
+	models.SortByRelevance: true,
+}
+
+type PerusahaanService struct {
+	perusahaanRepo repo.PerusahaanRepository
+	pekerjaanRepo  repo.PekerjaanAlumniRepository
+	// pekerjaanHistory riwayat pekerjaan yang dipindahkan saat merge
+	pekerjaanHistory *recordHistory
+}
+
+func NewPerusahaanService(perusahaanRepo repo.PerusahaanRepository, pekerjaanRepo repo.PekerjaanAlumniRepository, historyRepo repo.HistoryRepository) *PerusahaanService {
+	return &PerusahaanService{
+		perusahaanRepo:   perusahaanRepo,
+		pekerjaanRepo:    pekerjaanRepo,
+		pekerjaanHistory: newRecordHistory(historyRepo, models.HistoryEntityPekerjaan),
+	}
+}
+
+func (s *PerusahaanService) GetPerusahaans(c *fiber.Ctx) error {
+	var pagination models.PaginationRequest
+	if err := c.QueryParser(&pagination); err != nil {
+		return utils.BadRequest("Invalid pagination parameters")
+	}
+	if pagination.SortBy != "" && !perusahaanSortFields[pagination.SortBy] {
+		return utils.BadRequest("Invalid sort_by field")
+	}
+
+	perusahaans, total, err := s.perusahaanRepo.GetWithPagination(&pagination)
+	if err != nil {
+		return err
+	}
+
+	response := models.NewPaginationResponse(perusahaans, &pagination, total)
+	return c.JSON(response)
+}
+
+func (s *PerusahaanService) GetPerusahaan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	perusahaan, err := s.perusahaanRepo.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+
+	jumlahAlumni, err := s.perusahaanRepo.CountAlumni(perusahaan.ID)
+	if err != nil {
+		return err
+	}
+	perusahaan.JumlahAlumni = &jumlahAlumni
+
+	middleware.SetETag(c, perusahaan.Version)
+	return c.JSON(perusahaan)
+}
+
+func (s *PerusahaanService) CreatePerusahaan(c *fiber.Ctx) error {
+	var req models.PerusahaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	perusahaan := models.Perusahaan{}
+	if err := s.applyPerusahaanRequest(&perusahaan, req); err != nil {
+		return err
+	}
+
+	if err := s.perusahaanRepo.Create(&perusahaan); err != nil {
+		return err
+	}
+	middleware.SetETag(c, perusahaan.Version)
+	return c.Status(201).JSON(perusahaan)
+}
+
+func (s *PerusahaanService) UpdatePerusahaan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	var req models.PerusahaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	perusahaan, err := s.perusahaanRepo.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, perusahaan.Version); err != nil {
+		return err
+	}
+
+	if err := s.applyPerusahaanRequest(perusahaan, req); err != nil {
+		return err
+	}
+
+	if err := s.perusahaanRepo.Update(perusahaan); err != nil {
+		return err
+	}
+	middleware.SetETag(c, perusahaan.Version)
+	return c.JSON(perusahaan)
+}
+
+func (s *PerusahaanService) DeletePerusahaan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	perusahaan, err := s.perusahaanRepo.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, perusahaan.Version); err != nil {
+		return err
+	}
+
+	// Perusahaan yang masih dipakai pekerjaan tidak boleh dihapus, gunakan merge
+	count, err := s.perusahaanRepo.CountPekerjaan(uint(id))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utils.Conflict("Perusahaan masih digunakan oleh data pekerjaan, gabungkan ke perusahaan lain terlebih dahulu").
+			WithDetail("jumlah_pekerjaan", count)
+	}
+
+	if err := s.perusahaanRepo.Delete(uint(id)); err != nil {
+		return err
+	}
+	return c.SendStatus(204)
+}
+
+// MergePerusahaan menggabungkan perusahaan duplikat (source_ids) ke perusahaan :id.
+// Pekerjaan dipindahkan ke perusahaan tujuan dan nama sumber disimpan sebagai alias.
+func (s *PerusahaanService) MergePerusahaan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequest("Invalid ID")
+	}
+
+	var req models.MergePerusahaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	targetID := uint(id)
+	sourceIDs, err := mergeSourceIDs(targetID, req.SourceIDs, "Perusahaan")
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.perusahaanRepo.GetByID(targetID); err != nil {
+		return err
+	}
+	for _, sourceID := range sourceIDs {
+		if _, err := s.perusahaanRepo.GetByID(sourceID); err != nil {
+			if utils.IsNotFound(err) {
+				return utils.NotFound(fmt.Sprintf("Perusahaan %d tidak ditemukan", sourceID))
+			}
+			return err
+		}
+	}
+
+	// Pekerjaan yang dipindahkan naik versinya, jadi dicatat di riwayat
+	pekerjaans, err := s.pekerjaanRepo.GetAllByPerusahaanIDs(sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := s.perusahaanRepo.Merge(targetID, sourceIDs); err != nil {
+		return err
+	}
+	for i := range pekerjaans {
+		s.pekerjaanHistory.save(c, pekerjaans[i].ID, pekerjaans[i].Version, pekerjaanRequest(&pekerjaans[i]))
+	}
+
+	perusahaan, err := s.perusahaanRepo.GetByID(targetID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message":    "Perusahaan berhasil digabungkan",
+		"perusahaan": perusahaan,
+		"merged_ids": sourceIDs,
+	})
+}
+
+// BackfillPerusahaan menautkan pekerjaan lama (yang hanya punya nama_perusahaan)
+// ke entitas perusahaan, membuat perusahaan baru bila belum ada
+func (s *PerusahaanService) BackfillPerusahaan(c *fiber.Ctx) error {
+	pekerjaans, err := s.pekerjaanRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	linked := 0
+	failed := []fiber.Map{}
+	for i := range pekerjaans {
+		pekerjaan := &pekerjaans[i]
+		if pekerjaan.PerusahaanID != nil || strings.TrimSpace(pekerjaan.NamaPerusahaan) == "" {
+			continue
+		}
+
+		err := resolvePerusahaan(s.perusahaanRepo, pekerjaan)
+		if err == nil {
+			err = s.pekerjaanRepo.Update(pekerjaan)
+		}
+		if err != nil {
+			failed = append(failed, fiber.Map{"id": pekerjaan.ID, "error": err.Error()})
+			continue
+		}
+		linked++
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Backfill perusahaan selesai",
+		"linked":  linked,
+		"failed":  failed,
+	})
+}
+
+// applyPerusahaanRequest menyalin request ke model dan memastikan nama
+// tidak bentrok dengan perusahaan lain (nama utama maupun alias)
+func (s *PerusahaanService) applyPerusahaanRequest(perusahaan *models.Perusahaan, req models.PerusahaanRequest) error {
+	nama := strings.TrimSpace(req.Nama)
+	namaNormal := utils.NormalizeNamaPerusahaan(nama)
+	if namaNormal == "" {
+		return &utils.FieldError{Field: "nama", Message: "Nama perusahaan tidak boleh kosong"}
+	}
+
+	aliases := utils.UniqueAliases(req.Aliases, namaNormal)
+	for _, key := range append([]string{namaNormal}, utils.NormalizeAliases(aliases)...) {
+		existing, err := s.perusahaanRepo.FindByNamaNormal(key)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != perusahaan.ID {
+			return utils.Conflict(fmt.Sprintf("Nama '%s' sudah terdaftar untuk perusahaan %s (id %d)", key, existing.Nama, existing.ID))
+		}
+	}
+
+	perusahaan.Nama = nama
+	perusahaan.NamaNormal = namaNormal
+	perusahaan.BidangIndustri = req.BidangIndustri
+	perusahaan.Lokasi = req.Lokasi
+	perusahaan.Website = req.Website
+	perusahaan.Aliases = aliases
+	return nil
+}
+
+// resolvePerusahaan menautkan pekerjaan ke entitas perusahaan. Jika perusahaan_id
+// diisi, perusahaan itu yang dipakai; jika tidak, nama_perusahaan dicocokkan ke
+// nama atau alias yang sudah ada, dan perusahaan baru dibuat bila belum ada.
+// NamaPerusahaan selalu diisi dengan nama resmi perusahaan.
+func resolvePerusahaan(perusahaanRepo repo.PerusahaanRepository, pekerjaan *models.PekerjaanAlumni) error {
+	if pekerjaan.PerusahaanID != nil {
+		perusahaan, err := perusahaanRepo.GetByID(*pekerjaan.PerusahaanID)
+		if err != nil {
+			if utils.IsNotFound(err) {
+				return &utils.FieldError{Field: "perusahaan_id", Message: fmt.Sprintf("Perusahaan dengan ID %d tidak ditemukan", *pekerjaan.PerusahaanID)}
+			}
+			return err
+		}
+		pekerjaan.NamaPerusahaan = perusahaan.Nama
+		return nil
+	}
+
+	nama := strings.TrimSpace(pekerjaan.NamaPerusahaan)
+	namaNormal := utils.NormalizeNamaPerusahaan(nama)
+	if namaNormal == "" {
+		return &utils.FieldError{Field: "nama_perusahaan", Message: "Nama perusahaan tidak boleh kosong"}
+	}
+
+	perusahaan, err := perusahaanRepo.FindByNamaNormal(namaNormal)
+	if err != nil {
+		return err
+	}
+	if perusahaan == nil {
+		perusahaan = &models.Perusahaan{
+			Nama:           nama,
+			NamaNormal:     namaNormal,
+			BidangIndustri: pekerjaan.BidangIndustri,
+			Lokasi:         pekerjaan.LokasiKerja,
+			Aliases:        []string{},
+		}
+		err := perusahaanRepo.Create(perusahaan)
+		if errors.Is(err, utils.ErrConflict) {
+			// Pekerjaan lain dengan perusahaan baru yang sama dibuat bersamaan:
+			// unique nama_normal menolak create ini, jadi pakai record yang menang
+			perusahaan, err = perusahaanRepo.FindByNamaNormal(namaNormal)
+			if err == nil && perusahaan == nil {
+				err = utils.Conflict("Perusahaan " + nama + " sedang dibuat, coba lagi")
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	pekerjaan.PerusahaanID = &perusahaan.ID
+	pekerjaan.NamaPerusahaan = perusahaan.Nama
+	return nil
+}
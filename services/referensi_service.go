@@ -0,0 +1,622 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"modul4crud/middleware"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReferensiService struct {
+	referensiRepo repo.ReferensiRepository
+	alumniRepo    repo.AlumniRepository
+	pekerjaanRepo repo.PekerjaanAlumniRepository
+}
+
+func NewReferensiService(referensiRepo repo.ReferensiRepository, alumniRepo repo.AlumniRepository, pekerjaanRepo repo.PekerjaanAlumniRepository) *ReferensiService {
+	return &ReferensiService{
+		referensiRepo: referensiRepo,
+		alumniRepo:    alumniRepo,
+		pekerjaanRepo: pekerjaanRepo,
+	}
+}
+
+func (s *ReferensiService) GetReferensis(c *fiber.Ctx) error {
+	kategori := c.Params("kategori")
+	if !isKategoriReferensi(kategori) {
+		return utils.NotFound("Kategori referensi tidak dikenal")
+	}
+
+	referensis, err := s.referensiRepo.GetByKategori(kategori)
+	if err != nil {
+		return err
+	}
+
+	// Filter opsional berdasarkan induk, misalnya kota dalam satu provinsi
+	if induk := utils.NormalizeKode(c.Query("kode_induk")); induk != "" {
+		filtered := []models.Referensi{}
+		for _, ref := range referensis {
+			if ref.KodeInduk == induk {
+				filtered = append(filtered, ref)
+			}
+		}
+		referensis = filtered
+	}
+
+	return c.JSON(fiber.Map{
+		"kategori": kategori,
+		"data":     referensis,
+		"total":    len(referensis),
+	})
+}
+
+func (s *ReferensiService) GetReferensi(c *fiber.Ctx) error {
+	kategori := c.Params("kategori")
+	if !isKategoriReferensi(kategori) {
+		return utils.NotFound("Kategori referensi tidak dikenal")
+	}
+
+	referensi, err := s.referensiRepo.GetByKode(kategori, utils.NormalizeKode(c.Params("kode")))
+	if err != nil {
+		return err
+	}
+	if referensi == nil {
+		return utils.NotFound("Referensi not found")
+	}
+
+	middleware.SetETag(c, referensi.Version)
+	return c.JSON(referensi)
+}
+
+func (s *ReferensiService) CreateReferensi(c *fiber.Ctx) error {
+	kategori := c.Params("kategori")
+	if !isKategoriReferensi(kategori) {
+		return utils.NotFound("Kategori referensi tidak dikenal")
+	}
+
+	var req models.ReferensiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	kode := utils.NormalizeKode(req.Kode)
+	if kode == "" {
+		return &utils.FieldError{Field: "kode", Message: "Wajib diisi"}
+	}
+	existing, err := s.referensiRepo.GetByKode(kategori, kode)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return utils.Conflict(fmt.Sprintf("Kode '%s' sudah terdaftar", kode))
+	}
+
+	referensi := models.Referensi{Kategori: kategori, Kode: kode}
+	if err := s.applyReferensiRequest(&referensi, req); err != nil {
+		return err
+	}
+
+	if err := s.referensiRepo.Create(&referensi); err != nil {
+		return err
+	}
+	middleware.SetETag(c, referensi.Version)
+	return c.Status(201).JSON(referensi)
+}
+
+func (s *ReferensiService) UpdateReferensi(c *fiber.Ctx) error {
+	kategori := c.Params("kategori")
+	if !isKategoriReferensi(kategori) {
+		return utils.NotFound("Kategori referensi tidak dikenal")
+	}
+
+	var req models.ReferensiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(err.Error())
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	referensi, err := s.referensiRepo.GetByKode(kategori, utils.NormalizeKode(c.Params("kode")))
+	if err != nil {
+		return err
+	}
+	if referensi == nil {
+		return utils.NotFound("Referensi not found")
+	}
+	if err := middleware.CheckIfMatch(c, referensi.Version); err != nil {
+		return err
+	}
+
+	// Kode dipakai sebagai kunci di data alumni/pekerjaan, jadi tidak bisa diubah
+	if kode := utils.NormalizeKode(req.Kode); kode != "" && kode != referensi.Kode {
+		return utils.BadRequest("Kode referensi tidak dapat diubah")
+	}
+
+	if err := s.applyReferensiRequest(referensi, req); err != nil {
+		return err
+	}
+
+	if err := s.referensiRepo.Update(referensi); err != nil {
+		return err
+	}
+	middleware.SetETag(c, referensi.Version)
+	return c.JSON(referensi)
+}
+
+func (s *ReferensiService) DeleteReferensi(c *fiber.Ctx) error {
+	kategori := c.Params("kategori")
+	if !isKategoriReferensi(kategori) {
+		return utils.NotFound("Kategori referensi tidak dikenal")
+	}
+
+	referensi, err := s.referensiRepo.GetByKode(kategori, utils.NormalizeKode(c.Params("kode")))
+	if err != nil {
+		return err
+	}
+	if referensi == nil {
+		return utils.NotFound("Referensi not found")
+	}
+	if err := middleware.CheckIfMatch(c, referensi.Version); err != nil {
+		return err
+	}
+
+	count, err := s.referensiRepo.CountUsage(kategori, referensi.Kode)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utils.Conflict("Referensi masih digunakan oleh data alumni/pekerjaan (termasuk yang ada di trash)").
+			WithDetail("jumlah_dipakai", count)
+	}
+
+	// Entri induk (provinsi/fakultas) tidak boleh dihapus selama masih punya anak
+	for child, parent := range models.KategoriIndukReferensi {
+		if parent != kategori {
+			continue
+		}
+		children, err := s.referensiRepo.GetByKategori(child)
+		if err != nil {
+			return err
+		}
+		for _, ref := range children {
+			if ref.KodeInduk == referensi.Kode {
+				return utils.Conflict(fmt.Sprintf("Referensi masih menjadi induk %s '%s'", child, ref.Nama))
+			}
+		}
+	}
+
+	if err := s.referensiRepo.Delete(referensi.ID); err != nil {
+		return err
+	}
+	return c.SendStatus(204)
+}
+
+// BackfillReferensi memetakan jurusan, bidang industri, dan lokasi kerja yang
+// sudah ada ke kode referensi. Dengan ?dry_run=true tidak ada data yang diubah.
+// Nilai yang tidak bisa dipetakan dilaporkan agar admin bisa menambah alias.
+func (s *ReferensiService) BackfillReferensi(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dry_run", false)
+	resolver := newReferensiResolver(s.referensiRepo)
+	unmapped := map[string]map[string]int{}
+
+	collect := func(err error, raw string) error {
+		var fieldErr *utils.FieldError
+		if errors.As(err, &fieldErr) {
+			if unmapped[fieldErr.Field] == nil {
+				unmapped[fieldErr.Field] = map[string]int{}
+			}
+			unmapped[fieldErr.Field][raw]++
+			return nil
+		}
+		return err
+	}
+
+	alumnis, err := s.alumniRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	alumniUpdated := 0
+	for i := range alumnis {
+		alumni := &alumnis[i]
+		before := alumni.KodeProdi + "|" + alumni.Jurusan
+		raw := alumni.Jurusan
+		if err := collect(resolver.resolveProdi(alumni), raw); err != nil {
+			return err
+		}
+		if alumni.KodeProdi+"|"+alumni.Jurusan == before {
+			continue
+		}
+		if !dryRun {
+			if err := s.alumniRepo.Update(alumni); err != nil {
+				return err
+			}
+		}
+		alumniUpdated++
+	}
+
+	pekerjaans, err := s.pekerjaanRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	pekerjaanUpdated := 0
+	for i := range pekerjaans {
+		pekerjaan := &pekerjaans[i]
+		before := referensiSnapshot(pekerjaan)
+		rawIndustri, rawLokasi := pekerjaan.BidangIndustri, pekerjaan.LokasiKerja
+		if err := collect(resolver.resolveIndustri(pekerjaan), rawIndustri); err != nil {
+			return err
+		}
+		if err := collect(resolver.resolveLokasi(pekerjaan), rawLokasi); err != nil {
+			return err
+		}
+		if referensiSnapshot(pekerjaan) == before {
+			continue
+		}
+		if !dryRun {
+			if err := s.pekerjaanRepo.Update(pekerjaan); err != nil {
+				return err
+			}
+		}
+		pekerjaanUpdated++
+	}
+
+	type UnmappedValue struct {
+		Value string `json:"value"`
+		Count int    `json:"count"`
+	}
+	unmappedResult := map[string][]UnmappedValue{}
+	for field, values := range unmapped {
+		list := []UnmappedValue{}
+		for value, count := range values {
+			list = append(list, UnmappedValue{Value: value, Count: count})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Value < list[j].Value
+		})
+		unmappedResult[field] = list
+	}
+
+	return c.JSON(fiber.Map{
+		"message":           "Backfill referensi selesai",
+		"dry_run":           dryRun,
+		"alumni_checked":    len(alumnis),
+		"alumni_updated":    alumniUpdated,
+		"pekerjaan_checked": len(pekerjaans),
+		"pekerjaan_updated": pekerjaanUpdated,
+		"unmapped":          unmappedResult,
+	})
+}
+
+// applyReferensiRequest menyalin request ke model, memeriksa induk untuk
+// kategori berjenjang, dan memastikan nama/alias tidak bentrok dengan entri lain
+func (s *ReferensiService) applyReferensiRequest(referensi *models.Referensi, req models.ReferensiRequest) error {
+	nama := strings.TrimSpace(req.Nama)
+	if nama == "" {
+		return utils.BadRequest("Nama tidak boleh kosong")
+	}
+
+	kodeInduk := utils.NormalizeKode(req.KodeInduk)
+	if parent, ok := models.KategoriIndukReferensi[referensi.Kategori]; ok {
+		if kodeInduk == "" {
+			return utils.BadRequest(fmt.Sprintf("kode_induk (%s) wajib diisi", parent))
+		}
+		induk, err := s.referensiRepo.GetByKode(parent, kodeInduk)
+		if err != nil {
+			return err
+		}
+		if induk == nil {
+			return utils.BadRequest(fmt.Sprintf("Kode %s '%s' tidak terdaftar", parent, kodeInduk))
+		}
+	} else {
+		kodeInduk = ""
+	}
+
+	aliases := models.StringList{}
+	seen := map[string]bool{utils.NormalizeIstilah(nama): true}
+	for _, alias := range req.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := utils.NormalizeIstilah(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+
+	// Satu istilah hanya boleh menunjuk ke satu entri dalam kategori yang sama
+	vocab, err := loadVocabulary(s.referensiRepo, referensi.Kategori)
+	if err != nil {
+		return err
+	}
+	for key := range seen {
+		if other, ok := vocab.byIstilah[key]; ok && other.Kode != referensi.Kode {
+			return utils.Conflict(fmt.Sprintf("Istilah '%s' sudah dipakai oleh %s '%s'", key, referensi.Kategori, other.Kode))
+		}
+	}
+
+	// Kode industri yang selaras hanya berlaku untuk prodi (keselarasan bidang kerja)
+	kodeTerkait := models.StringList{}
+	if referensi.Kategori == models.KategoriProdi && len(req.KodeTerkait) > 0 {
+		industri, err := loadVocabulary(s.referensiRepo, models.KategoriIndustri)
+		if err != nil {
+			return err
+		}
+		seenKode := map[string]bool{}
+		for _, kode := range req.KodeTerkait {
+			kode = utils.NormalizeKode(kode)
+			if kode == "" || seenKode[kode] {
+				continue
+			}
+			if industri.lookupKode(kode) == nil {
+				return utils.BadRequest(fmt.Sprintf("Kode industri '%s' tidak terdaftar", kode))
+			}
+			seenKode[kode] = true
+			kodeTerkait = append(kodeTerkait, kode)
+		}
+	}
+
+	referensi.Nama = nama
+	referensi.KodeInduk = kodeInduk
+	referensi.Aliases = aliases
+	referensi.KodeTerkait = kodeTerkait
+	return nil
+}
+
+func isKategoriReferensi(kategori string) bool {
+	for _, k := range models.KategoriReferensi {
+		if k == kategori {
+			return true
+		}
+	}
+	return false
+}
+
+func referensiSnapshot(p *models.PekerjaanAlumni) string {
+	return strings.Join([]string{p.KodeIndustri, p.BidangIndustri, p.KodeProvinsi, p.KodeKota, p.LokasiKerja}, "|")
+}
+
+// vocabulary satu kategori referensi yang siap dicocokkan
+type vocabulary struct {
+	byKode    map[string]*models.Referensi
+	byIstilah map[string]*models.Referensi
+}
+
+func loadVocabulary(referensiRepo repo.ReferensiRepository, kategori string) (*vocabulary, error) {
+	referensis, err := referensiRepo.GetByKategori(kategori)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vocabulary{
+		byKode:    make(map[string]*models.Referensi, len(referensis)),
+		byIstilah: make(map[string]*models.Referensi),
+	}
+	for i := range referensis {
+		ref := &referensis[i]
+		v.byKode[ref.Kode] = ref
+		v.byIstilah[utils.NormalizeIstilah(ref.Nama)] = ref
+		for _, alias := range ref.Aliases {
+			v.byIstilah[utils.NormalizeIstilah(alias)] = ref
+		}
+	}
+	return v, nil
+}
+
+// empty true jika kategori belum diisi admin; validasi dilewati
+func (v *vocabulary) empty() bool {
+	return len(v.byKode) == 0
+}
+
+func (v *vocabulary) lookupKode(kode string) *models.Referensi {
+	return v.byKode[utils.NormalizeKode(kode)]
+}
+
+// match mencocokkan teks bebas ke entri berdasarkan kode, nama, atau alias
+func (v *vocabulary) match(raw string) *models.Referensi {
+	if ref := v.lookupKode(raw); ref != nil {
+		return ref
+	}
+	return v.byIstilah[utils.NormalizeIstilah(raw)]
+}
+
+// nama mengembalikan nama resmi sebuah kode, atau fallback jika kode tidak dikenal
+func (v *vocabulary) nama(kode string, fallback string) string {
+	if ref := v.lookupKode(kode); ref != nil {
+		return ref.Nama
+	}
+	return fallback
+}
+
+// referensiResolver memuat vocabulary per kategori sekali per request
+type referensiResolver struct {
+	referensiRepo repo.ReferensiRepository
+	cache         map[string]*vocabulary
+}
+
+func newReferensiResolver(referensiRepo repo.ReferensiRepository) *referensiResolver {
+	return &referensiResolver{
+		referensiRepo: referensiRepo,
+		cache:         make(map[string]*vocabulary),
+	}
+}
+
+func (r *referensiResolver) vocabulary(kategori string) (*vocabulary, error) {
+	if v, ok := r.cache[kategori]; ok {
+		return v, nil
+	}
+	v, err := loadVocabulary(r.referensiRepo, kategori)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[kategori] = v
+	return v, nil
+}
+
+// resolveProdi mengisi KodeProdi dan nama jurusan resmi. kode_prodi diutamakan,
+// jika kosong jurusan dicocokkan ke nama atau alias prodi.
+func (r *referensiResolver) resolveProdi(alumni *models.Alumni) error {
+	v, err := r.vocabulary(models.KategoriProdi)
+	if err != nil {
+		return err
+	}
+
+	if alumni.KodeProdi != "" {
+		ref := v.lookupKode(alumni.KodeProdi)
+		if ref == nil {
+			return &utils.FieldError{Field: "kode_prodi", Message: fmt.Sprintf("Kode prodi '%s' tidak terdaftar", alumni.KodeProdi)}
+		}
+		alumni.KodeProdi, alumni.Jurusan = ref.Kode, ref.Nama
+		return nil
+	}
+	if v.empty() {
+		return nil
+	}
+
+	ref := v.match(alumni.Jurusan)
+	if ref == nil {
+		return &utils.FieldError{Field: "jurusan", Message: fmt.Sprintf("Jurusan '%s' tidak terdaftar di referensi prodi", alumni.Jurusan)}
+	}
+	alumni.KodeProdi, alumni.Jurusan = ref.Kode, ref.Nama
+	return nil
+}
+
+// resolveIndustri mengisi KodeIndustri dan nama bidang industri resmi
+func (r *referensiResolver) resolveIndustri(pekerjaan *models.PekerjaanAlumni) error {
+	v, err := r.vocabulary(models.KategoriIndustri)
+	if err != nil {
+		return err
+	}
+
+	if pekerjaan.KodeIndustri != "" {
+		ref := v.lookupKode(pekerjaan.KodeIndustri)
+		if ref == nil {
+			return &utils.FieldError{Field: "kode_industri", Message: fmt.Sprintf("Kode industri '%s' tidak terdaftar", pekerjaan.KodeIndustri)}
+		}
+		pekerjaan.KodeIndustri, pekerjaan.BidangIndustri = ref.Kode, ref.Nama
+		return nil
+	}
+	if v.empty() {
+		return nil
+	}
+
+	ref := v.match(pekerjaan.BidangIndustri)
+	if ref == nil {
+		return &utils.FieldError{Field: "bidang_industri", Message: fmt.Sprintf("Bidang industri '%s' tidak terdaftar", pekerjaan.BidangIndustri)}
+	}
+	pekerjaan.KodeIndustri, pekerjaan.BidangIndustri = ref.Kode, ref.Nama
+	return nil
+}
+
+// resolveLokasi mengisi KodeKota/KodeProvinsi. Lokasi bebas seperti
+// "Jakarta Selatan, DKI Jakarta" dicocokkan per bagian, kota lebih dulu.
+func (r *referensiResolver) resolveLokasi(pekerjaan *models.PekerjaanAlumni) error {
+	kota, err := r.vocabulary(models.KategoriKota)
+	if err != nil {
+		return err
+	}
+	provinsi, err := r.vocabulary(models.KategoriProvinsi)
+	if err != nil {
+		return err
+	}
+
+	if pekerjaan.KodeKota != "" {
+		ref := kota.lookupKode(pekerjaan.KodeKota)
+		if ref == nil {
+			return &utils.FieldError{Field: "kode_kota", Message: fmt.Sprintf("Kode kota '%s' tidak terdaftar", pekerjaan.KodeKota)}
+		}
+		pekerjaan.KodeKota, pekerjaan.KodeProvinsi, pekerjaan.LokasiKerja = ref.Kode, ref.KodeInduk, ref.Nama
+		return nil
+	}
+	if pekerjaan.KodeProvinsi != "" {
+		ref := provinsi.lookupKode(pekerjaan.KodeProvinsi)
+		if ref == nil {
+			return &utils.FieldError{Field: "kode_provinsi", Message: fmt.Sprintf("Kode provinsi '%s' tidak terdaftar", pekerjaan.KodeProvinsi)}
+		}
+		pekerjaan.KodeProvinsi, pekerjaan.LokasiKerja = ref.Kode, ref.Nama
+		return nil
+	}
+	if kota.empty() && provinsi.empty() {
+		return nil
+	}
+
+	parts := []string{pekerjaan.LokasiKerja}
+	parts = append(parts, strings.FieldsFunc(pekerjaan.LokasiKerja, func(r rune) bool {
+		return r == ',' || r == '/' || r == '-'
+	})...)
+	for _, part := range parts {
+		if ref := kota.match(part); ref != nil {
+			pekerjaan.KodeKota, pekerjaan.KodeProvinsi, pekerjaan.LokasiKerja = ref.Kode, ref.KodeInduk, ref.Nama
+			return nil
+		}
+	}
+	for _, part := range parts {
+		if ref := provinsi.match(part); ref != nil {
+			pekerjaan.KodeKota, pekerjaan.KodeProvinsi, pekerjaan.LokasiKerja = "", ref.Kode, ref.Nama
+			return nil
+		}
+	}
+
+	return &utils.FieldError{Field: "lokasi_kerja", Message: fmt.Sprintf("Lokasi kerja '%s' tidak terdaftar di referensi kota/provinsi", pekerjaan.LokasiKerja)}
+}
+
+// statLabel teks fallback untuk grup statistik yang kodenya tidak ada di vocabulary
+func statLabel(group models.StatGroup) string {
+	if group.Label != "" {
+		return group.Label
+	}
+	return group.Kode
+}
+
+// kodeCount jumlah data untuk satu kode referensi
+type kodeCount struct {
+	Kode  string
+	Nama  string
+	Count int64
+}
+
+// kodeCounter mengelompokkan data berdasarkan kode referensi. Data yang belum
+// terpetakan (kode kosong) dikelompokkan berdasarkan teks aslinya.
+type kodeCounter map[string]*kodeCount
+
+func (k kodeCounter) add(kode string, nama string, count int64) {
+	key := kode
+	if key == "" {
+		key = "\x00" + nama
+	}
+	if item, ok := k[key]; ok {
+		item.Count += count
+		return
+	}
+	k[key] = &kodeCount{Kode: kode, Nama: nama, Count: count}
+}
+
+// sorted mengurutkan hasil berdasarkan jumlah terbanyak, lalu nama dan kode
+func (k kodeCounter) sorted() []kodeCount {
+	result := make([]kodeCount, 0, len(k))
+	for _, item := range k {
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		if result[i].Nama != result[j].Nama {
+			return result[i].Nama < result[j].Nama
+		}
+		return result[i].Kode < result[j].Kode
+	})
+	return result
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas jumlah hasil per tipe pada pencarian global
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 20
+)
+
+// searchTypes tipe entitas pencarian global, urutan ini juga urutan hasil.
+// adminOnly mengikuti hak akses endpoint list masing-masing.
+var searchTypes = []struct {
+	name      string
+	adminOnly bool
+}{
+	{"mahasiswa", false},
+	{"alumni", false},
+	{"pekerjaan", false},
+	{"perusahaan", false},
+	{"user", true},
+}
+
+// SearchResult satu hasil pencarian global; data berisi record lengkapnya
+type SearchResult struct {
+	Type       string            `json:"type"`
+	ID         uint              `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+	Data       interface{}       `json:"data"`
+}
+
+type SearchService struct {
+	mahasiswaRepo  repo.MahasiswaRepository
+	alumniRepo     repo.AlumniRepository
+	pekerjaanRepo  repo.PekerjaanAlumniRepository
+	perusahaanRepo repo.PerusahaanRepository
+	userRepo       repo.UserRepository
+}
+
+func NewSearchService(mahasiswaRepo repo.MahasiswaRepository, alumniRepo repo.AlumniRepository, pekerjaanRepo repo.PekerjaanAlumniRepository, perusahaanRepo repo.PerusahaanRepository, userRepo repo.UserRepository) *SearchService {
+	return &SearchService{
+		mahasiswaRepo:  mahasiswaRepo,
+		alumniRepo:     alumniRepo,
+		pekerjaanRepo:  pekerjaanRepo,
+		perusahaanRepo: perusahaanRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// Search handler GET /api/search?q=&type=alumni,pekerjaan&limit=5. Setiap tipe dicari
+// paralel lewat GetWithPagination (urut relevansi), lalu digabung per tipe sesuai
+// urutan searchTypes. Skor antar tipe tidak sebanding (lihat collect), jadi hasil
+// tidak diurutkan ulang berdasarkan skor lintas tipe.
+func (s *SearchService) Search(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if len(models.SearchTerms(q)) == 0 {
+		return utils.BadRequest("Parameter q wajib berisi kata yang dicari")
+	}
+
+	limit := c.QueryInt("limit", defaultSearchLimit)
+	if limit < 1 || limit > maxSearchLimit {
+		return utils.BadRequest(fmt.Sprintf("Parameter limit harus antara 1 dan %d", maxSearchLimit))
+	}
+
+	role, _ := c.Locals("role").(string)
+	types, err := allowedSearchTypes(c.Query("type"), role == "admin")
+	if err != nil {
+		return err
+	}
+	viewer, err := newSalaryViewer(c, s.alumniRepo)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []SearchResult
+		totals  = map[string]int64{}
+		errs    []error
+	)
+	for _, t := range types {
+		wg.Add(1)
+		go func(t string) {
+			defer wg.Done()
+			items, total, err := s.searchType(t, q, limit, viewer)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			results = append(results, items...)
+			totals[t] = total
+		}(t)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	order := map[string]int{}
+	for i, t := range searchTypes {
+		order[t.name] = i
+	}
+	// Stable: di dalam satu tipe urutan relevansi dari repository dipertahankan
+	sort.SliceStable(results, func(i, j int) bool {
+		return order[results[i].Type] < order[results[j].Type]
+	})
+	if results == nil {
+		results = []SearchResult{}
+	}
+
+	return c.JSON(fiber.Map{
+		"query":  q,
+		"types":  types,
+		"data":   results,
+		"totals": totals,
+	})
+}
+
+// allowedSearchTypes membaca ?type= (dipisah koma); kosong berarti semua tipe yang
+// boleh dilihat role pemanggil
+func allowedSearchTypes(param string, isAdmin bool) ([]string, error) {
+	requested := map[string]bool{}
+	for _, t := range strings.Split(param, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			requested[t] = true
+		}
+	}
+
+	filtered := len(requested) > 0
+	types := []string{}
+	for _, t := range searchTypes {
+		wanted := requested[t.name]
+		delete(requested, t.name)
+		if filtered && !wanted {
+			continue
+		}
+		if t.adminOnly && !isAdmin {
+			if wanted {
+				return nil, utils.Forbidden(fmt.Sprintf("Pencarian tipe %s hanya untuk admin", t.name))
+			}
+			continue
+		}
+		types = append(types, t.name)
+	}
+
+	for t := range requested {
+		return nil, utils.BadRequest(fmt.Sprintf("Tipe pencarian tidak dikenal: %s", t))
+	}
+	return types, nil
+}
+
+// searchType mencari satu tipe entitas, mengembalikan hasil halaman pertama dan total kecocokan
+func (s *SearchService) searchType(t, q string, limit int, viewer salaryViewer) ([]SearchResult, int64, error) {
+	pagination := models.PaginationRequest{Page: 1, Limit: limit, Search: q}
+	terms := models.SearchTerms(q)
+
+	switch t {
+	case "mahasiswa":
+		items, total, err := s.mahasiswaRepo.GetWithPagination(&pagination)
+		return collect(t, items, func(m models.Mahasiswa) uint { return m.ID }, func(m *models.Mahasiswa) *models.SearchMatch { return takeMatch(&m.Search) }), total, err
+	case "alumni":
+		items, total, err := s.alumniRepo.GetWithPagination(&pagination)
+		return collect(t, items, func(a models.Alumni) uint { return a.ID }, func(a *models.Alumni) *models.SearchMatch { return takeMatch(&a.Search) }), total, err
+	case "pekerjaan":
+		items, total, err := s.pekerjaanRepo.GetWithPagination(&pagination)
+		viewer.mask(items)
+		return collect(t, items, func(p models.PekerjaanAlumni) uint { return p.ID }, func(p *models.PekerjaanAlumni) *models.SearchMatch { return takeMatch(&p.Search) }), total, err
+	case "perusahaan":
+		items, total, err := s.perusahaanRepo.GetWithPagination(&pagination)
+		return collect(t, items, func(p models.Perusahaan) uint { return p.ID }, func(p *models.Perusahaan) *models.SearchMatch { return takeMatch(&p.Search) }), total, err
+	case "user":
+		// Pencarian user belum full-text (ILIKE), skor dihitung di sisi aplikasi
+		items, total, err := s.userRepo.GetWithPagination(&pagination)
+		return collect(t, items, func(u models.User) uint { return uint(u.ID) }, func(u *models.User) *models.SearchMatch {
+			if match := models.MatchSearch(terms, u.SearchFields()); match != nil {
+				return match
+			}
+			return models.NewSearchMatch(terms, u.SearchFields(), 0)
+		}), total, err
+	}
+	return nil, 0, fmt.Errorf("tipe pencarian tidak dikenal: %s", t)
+}
+
+// takeMatch mengambil skor & highlights dari field Search record lalu
+// mengosongkannya, karena keduanya sudah ada di level hasil
+func takeMatch(search **models.SearchMatch) *models.SearchMatch {
+	match := *search
+	*search = nil
+	return match
+}
+
+// collect mengubah hasil satu tipe menjadi SearchResult. Skor dinormalisasi ke 0..1
+// terhadap skor tertinggi tipe itu (hasil teratas bernilai 1), sehingga hanya
+// bermakna di dalam tipe yang sama: ts_rank, textScore dan skor aplikasi tidak
+// sebanding antar tipe.
+func collect[T any](t string, items []T, id func(T) uint, match func(*T) *models.SearchMatch) []SearchResult {
+	results := make([]SearchResult, 0, len(items))
+	maxScore := 0.0
+	for i := range items {
+		m := match(&items[i])
+		result := SearchResult{Type: t, ID: id(items[i]), Highlights: map[string]string{}, Data: items[i]}
+		if m != nil {
+			result.Score = m.Score
+			result.Highlights = m.Highlights
+		}
+		maxScore = math.Max(maxScore, result.Score)
+		results = append(results, result)
+	}
+
+	for i := range results {
+		if maxScore > 0 {
+			results[i].Score /= maxScore
+		}
+	}
+	return results
+}
@@ -0,0 +1,838 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"modul4crud/middleware"
+	"modul4crud/models"
+	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Skala default untuk pertanyaan skala yang tidak menyebut batasnya (Likert 1-5)
+const (
+	defaultSkalaMin = 1
+	defaultSkalaMax = 5
+)
+
+type SurveyService struct {
+	surveyRepo    repo.SurveyRepository
+	alumniRepo    repo.AlumniRepository
+	referensiRepo repo.ReferensiRepository
+}
+
+func NewSurveyService(surveyRepo repo.SurveyRepository, alumniRepo repo.AlumniRepository, referensiRepo repo.ReferensiRepository) *SurveyService {
+	return &SurveyService{
+		surveyRepo:    surveyRepo,
+		alumniRepo:    alumniRepo,
+		referensiRepo: referensiRepo,
+	}
+}
+
+// ========================================
+// ADMIN - kelola survey
+// ========================================
+
+// GetSurveys - List survey (?status=draft|open|closed)
+func (s *SurveyService) GetSurveys(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && status != models.SurveyStatusDraft && status != models.SurveyStatusOpen && status != models.SurveyStatusClosed {
+		return utils.BadRequest("status harus draft, open, atau closed")
+	}
+
+	surveys, err := s.surveyRepo.GetAll(status)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  surveys,
+		"total": len(surveys),
+	})
+}
+
+func (s *SurveyService) GetSurvey(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	jumlahJawaban, err := s.surveyRepo.CountResponses(survey.ID)
+	if err != nil {
+		return err
+	}
+
+	middleware.SetETag(c, survey.Version)
+	return c.JSON(fiber.Map{
+		"data":           survey,
+		"jumlah_jawaban": jumlahJawaban,
+	})
+}
+
+// CreateSurvey - Buat survey baru (versi 1, status draft)
+func (s *SurveyService) CreateSurvey(c *fiber.Ctx) error {
+	var req models.SurveyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest("Invalid request body")
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	kode := utils.NormalizeKode(req.Kode)
+	if kode == "" {
+		return &utils.FieldError{Field: "kode", Message: "Wajib diisi"}
+	}
+
+	latest, err := s.surveyRepo.GetLatestVersi(kode)
+	if err != nil {
+		return err
+	}
+	if latest > 0 {
+		return utils.Conflict(fmt.Sprintf("Kode survey '%s' sudah dipakai, buat versi baru lewat POST /api/surveys/{id}/versions", kode))
+	}
+
+	survey := models.Survey{Kode: kode, Versi: 1, Status: models.SurveyStatusDraft}
+	if err := s.applySurveyRequest(&survey, req); err != nil {
+		return err
+	}
+
+	if err := s.surveyRepo.Create(&survey); err != nil {
+		return err
+	}
+
+	middleware.SetETag(c, survey.Version)
+	return c.Status(201).JSON(survey)
+}
+
+// UpdateSurvey - Ubah survey yang masih draft
+func (s *SurveyService) UpdateSurvey(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, survey.Version); err != nil {
+		return err
+	}
+
+	if survey.Status != models.SurveyStatusDraft {
+		return utils.Conflict("Survey yang sudah dibuka tidak bisa diubah, buat versi baru")
+	}
+
+	var req models.SurveyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest("Invalid request body")
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	if err := s.applySurveyRequest(survey, req); err != nil {
+		return err
+	}
+
+	if err := s.surveyRepo.Update(survey); err != nil {
+		return err
+	}
+
+	middleware.SetETag(c, survey.Version)
+	return c.JSON(survey)
+}
+
+// DeleteSurvey - Hapus survey yang masih draft
+func (s *SurveyService) DeleteSurvey(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+	if err := middleware.CheckIfMatch(c, survey.Version); err != nil {
+		return err
+	}
+
+	if survey.Status != models.SurveyStatusDraft {
+		return utils.Conflict("Hanya survey draft yang bisa dihapus")
+	}
+
+	if err := s.surveyRepo.Delete(survey.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"message": "Survey deleted successfully"})
+}
+
+// CreateSurveyVersion - Salin survey menjadi versi baru berstatus draft
+func (s *SurveyService) CreateSurveyVersion(c *fiber.Ctx) error {
+	source, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.surveyRepo.GetLatestVersi(source.Kode)
+	if err != nil {
+		return err
+	}
+
+	survey := models.Survey{
+		Kode:             source.Kode,
+		Versi:            latest + 1,
+		Judul:            source.Judul,
+		Deskripsi:        source.Deskripsi,
+		Status:           models.SurveyStatusDraft,
+		TargetTahunLulus: append(models.IntList{}, source.TargetTahunLulus...),
+		TargetJurusan:    append(models.StringList{}, source.TargetJurusan...),
+		Pertanyaan:       append(models.SurveyQuestions{}, source.Pertanyaan...),
+	}
+
+	if err := s.surveyRepo.Create(&survey); err != nil {
+		return err
+	}
+
+	return c.Status(201).JSON(survey)
+}
+
+// OpenSurvey - Buka survey untuk diisi alumni. Versi lain dengan kode yang
+// sama yang masih terbuka otomatis ditutup.
+func (s *SurveyService) OpenSurvey(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if survey.Status == models.SurveyStatusOpen {
+		return utils.Conflict("Survey sudah dibuka")
+	}
+
+	openSurveys, err := s.surveyRepo.GetAll(models.SurveyStatusOpen)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range openSurveys {
+		other := &openSurveys[i]
+		if other.Kode != survey.Kode {
+			continue
+		}
+		other.Status = models.SurveyStatusClosed
+		other.DitutupAt = &now
+		if err := s.surveyRepo.Update(other); err != nil {
+			return err
+		}
+	}
+
+	survey.Status = models.SurveyStatusOpen
+	survey.DibukaAt = &now
+	survey.DitutupAt = nil
+	if err := s.surveyRepo.Update(survey); err != nil {
+		return err
+	}
+
+	return c.JSON(survey)
+}
+
+// CloseSurvey - Tutup survey; jawaban yang sudah masuk tetap tersimpan
+func (s *SurveyService) CloseSurvey(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if survey.Status != models.SurveyStatusOpen {
+		return utils.Conflict("Survey tidak sedang dibuka")
+	}
+
+	now := time.Now()
+	survey.Status = models.SurveyStatusClosed
+	survey.DitutupAt = &now
+	if err := s.surveyRepo.Update(survey); err != nil {
+		return err
+	}
+
+	return c.JSON(survey)
+}
+
+// GetSurveyResponseRate - Tingkat respons survey terhadap alumni target,
+// keseluruhan dan per tahun lulus/jurusan
+func (s *SurveyService) GetSurveyResponseRate(c *fiber.Ctx) error {
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	// Alumni dihitung per cohort di database; filter jurusan (yang dinormalisasi)
+	// diterapkan pada hasil grup yang jumlahnya kecil
+	cohorts, err := s.alumniRepo.CountSurveyCohorts(survey.ID, survey.TargetTahunLulus)
+	if err != nil {
+		return err
+	}
+	jumlahJawaban, err := s.surveyRepo.CountResponses(survey.ID)
+	if err != nil {
+		return err
+	}
+	prodi, err := loadVocabulary(s.referensiRepo, models.KategoriProdi)
+	if err != nil {
+		return err
+	}
+
+	total := &models.SurveyResponseRate{Grup: "semua"}
+	perTahun := map[string]*models.SurveyResponseRate{}
+	perJurusan := map[string]*models.SurveyResponseRate{}
+	for _, cohort := range cohorts {
+		alumni := &models.Alumni{TahunLulus: cohort.TahunLulus, KodeProdi: cohort.KodeProdi, Jurusan: cohort.Jurusan}
+		if !surveyTarget(survey, alumni) {
+			continue
+		}
+
+		tahun := strconv.Itoa(alumni.TahunLulus)
+		jurusan := prodi.nama(alumni.KodeProdi, alumni.Jurusan)
+		if perTahun[tahun] == nil {
+			perTahun[tahun] = &models.SurveyResponseRate{Grup: tahun}
+		}
+		if perJurusan[jurusan] == nil {
+			perJurusan[jurusan] = &models.SurveyResponseRate{Grup: jurusan}
+		}
+
+		for _, rate := range []*models.SurveyResponseRate{total, perTahun[tahun], perJurusan[jurusan]} {
+			rate.Target += cohort.Jumlah
+			rate.Responden += cohort.Responden
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"survey_id":       survey.ID,
+		"total":           responseRateResult(map[string]*models.SurveyResponseRate{"": total})[0],
+		"per_tahun_lulus": responseRateResult(perTahun),
+		"per_jurusan":     responseRateResult(perJurusan),
+		"jumlah_jawaban":  jumlahJawaban,
+	})
+}
+
+// GetSurveyResults - Hasil agregat per pertanyaan (?format=json|csv)
+func (s *SurveyService) GetSurveyResults(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "csv" && format != "json" {
+		return utils.BadRequest("format harus csv atau json")
+	}
+
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	responses, err := s.surveyRepo.GetResponses(survey.ID)
+	if err != nil {
+		return err
+	}
+
+	results := aggregateSurveyResults(survey, responses)
+
+	if format == "json" {
+		return c.JSON(fiber.Map{
+			"survey_id":      survey.ID,
+			"kode":           survey.Kode,
+			"versi":          survey.Versi,
+			"jumlah_jawaban": len(responses),
+			"data":           results,
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Kode Pertanyaan", "Pertanyaan", "Tipe", "Jawaban", "Jumlah", "Persen", "Rata-rata", "Median"})
+	for _, result := range results {
+		// Baris ringkasan per pertanyaan, lalu satu baris per pilihan/nilai/jawaban teks
+		kode, teks := csvCell(result.Kode), csvCell(result.Teks)
+		writer.Write([]string{kode, teks, result.Tipe, "(semua)", strconv.Itoa(result.JumlahJawaban), "",
+			formatOptionalFloat(result.RataRata), formatOptionalFloat(result.Median)})
+		for _, option := range result.Distribusi {
+			writer.Write([]string{kode, teks, result.Tipe, csvCell(option.Jawaban), strconv.Itoa(option.Jumlah),
+				strconv.FormatFloat(option.Persen, 'f', 2, 64), "", ""})
+		}
+		for _, jawaban := range result.JawabanTeks {
+			writer.Write([]string{kode, teks, result.Tipe, csvCell(jawaban), "1", "", "", ""})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="survey-%s-v%d.csv"`, strings.ToLower(survey.Kode), survey.Versi))
+	return c.Send(buf.Bytes())
+}
+
+// ========================================
+// ALUMNI - /api/me/surveys
+// ========================================
+
+// mySurvey survey yang ditujukan ke alumni beserta status pengisiannya
+type mySurvey struct {
+	models.Survey
+	SudahDiisi bool       `json:"sudah_diisi"`
+	DiisiAt    *time.Time `json:"diisi_at"`
+}
+
+// GetMySurveys - Survey terbuka yang ditujukan ke alumni yang sedang login
+func (s *SurveyService) GetMySurveys(c *fiber.Ctx) error {
+	alumni, err := s.currentAlumni(c)
+	if err != nil {
+		return err
+	}
+
+	surveys, err := s.surveyRepo.GetAll(models.SurveyStatusOpen)
+	if err != nil {
+		return err
+	}
+
+	result := []mySurvey{}
+	for i := range surveys {
+		if !surveyTarget(&surveys[i], alumni) {
+			continue
+		}
+		item := mySurvey{Survey: surveys[i]}
+		response, err := s.surveyRepo.GetResponse(surveys[i].ID, alumni.ID)
+		if err != nil {
+			return err
+		}
+		if response != nil {
+			item.SudahDiisi = true
+			item.DiisiAt = &response.UpdatedAt
+		}
+		result = append(result, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  result,
+		"total": len(result),
+	})
+}
+
+// GetMySurvey - Detail survey beserta jawaban alumni (jika sudah mengisi)
+func (s *SurveyService) GetMySurvey(c *fiber.Ctx) error {
+	alumni, err := s.currentAlumni(c)
+	if err != nil {
+		return err
+	}
+
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if survey.Status == models.SurveyStatusDraft || !surveyTarget(survey, alumni) {
+		return utils.NotFound("Survey tidak ditemukan")
+	}
+
+	response, err := s.surveyRepo.GetResponse(survey.ID, alumni.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"survey":  survey,
+		"jawaban": response,
+	})
+}
+
+// SubmitMySurvey - Kirim atau ganti jawaban survey selama survey masih dibuka
+func (s *SurveyService) SubmitMySurvey(c *fiber.Ctx) error {
+	alumni, err := s.currentAlumni(c)
+	if err != nil {
+		return err
+	}
+
+	survey, err := s.surveyFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if survey.Status == models.SurveyStatusDraft || !surveyTarget(survey, alumni) {
+		return utils.NotFound("Survey tidak ditemukan")
+	}
+	if survey.Status != models.SurveyStatusOpen {
+		return utils.Conflict("Survey sudah ditutup")
+	}
+
+	var req models.SurveyResponseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest("Invalid request body")
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	jawaban, err := validateSurveyAnswers(survey.Pertanyaan, req.Jawaban)
+	if err != nil {
+		return err
+	}
+
+	response := models.SurveyResponse{
+		SurveyID: survey.ID,
+		AlumniID: alumni.ID,
+		Jawaban:  jawaban,
+	}
+	if err := s.surveyRepo.SaveResponse(&response); err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// ========================================
+// HELPERS
+// ========================================
+
+// surveyFromParam mengambil survey berdasarkan parameter :id. Error yang
+// dikembalikan berupa utils.AppError dan dikirim lewat middleware.ErrorHandler.
+func (s *SurveyService) surveyFromParam(c *fiber.Ctx) (*models.Survey, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid ID")
+	}
+	return s.surveyRepo.GetByID(uint(id))
+}
+
+// currentAlumni mengambil profil alumni milik user yang sedang login
+func (s *SurveyService) currentAlumni(c *fiber.Ctx) (*models.Alumni, error) {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return nil, utils.Unauthorized("User ID tidak ditemukan")
+	}
+
+	alumni, err := s.alumniRepo.GetByUserID(userID)
+	if err != nil {
+		if utils.IsNotFound(err) {
+			return nil, utils.NotFound("Profil alumni tidak ditemukan")
+		}
+		return nil, err
+	}
+	return alumni, nil
+}
+
+// applySurveyRequest memvalidasi request lalu menyalinnya ke model survey
+func (s *SurveyService) applySurveyRequest(survey *models.Survey, req models.SurveyRequest) error {
+	pertanyaan, err := normalizeSurveyQuestions(req.Pertanyaan)
+	if err != nil {
+		return err
+	}
+
+	tahunLulus := models.IntList{}
+	seenTahun := map[int]bool{}
+	for _, tahun := range req.TargetTahunLulus {
+		if tahun <= 0 {
+			return &utils.FieldError{Field: "target_tahun_lulus", Message: fmt.Sprintf("Tahun lulus '%d' tidak valid", tahun)}
+		}
+		if !seenTahun[tahun] {
+			seenTahun[tahun] = true
+			tahunLulus = append(tahunLulus, tahun)
+		}
+	}
+	sort.Ints(tahunLulus)
+
+	// Jurusan target disimpan sebagai kode prodi jika data referensi prodi sudah diisi
+	prodi, err := loadVocabulary(s.referensiRepo, models.KategoriProdi)
+	if err != nil {
+		return err
+	}
+	jurusan := models.StringList{}
+	seenJurusan := map[string]bool{}
+	for _, raw := range req.TargetJurusan {
+		value := strings.TrimSpace(raw)
+		if value == "" {
+			continue
+		}
+		if !prodi.empty() {
+			ref := prodi.match(value)
+			if ref == nil {
+				return &utils.FieldError{Field: "target_jurusan", Message: fmt.Sprintf("Jurusan '%s' tidak terdaftar di referensi prodi", value)}
+			}
+			value = ref.Kode
+		}
+		if !seenJurusan[value] {
+			seenJurusan[value] = true
+			jurusan = append(jurusan, value)
+		}
+	}
+
+	survey.Judul = strings.TrimSpace(req.Judul)
+	survey.Deskripsi = strings.TrimSpace(req.Deskripsi)
+	survey.TargetTahunLulus = tahunLulus
+	survey.TargetJurusan = jurusan
+	survey.Pertanyaan = pertanyaan
+	return nil
+}
+
+// normalizeSurveyQuestions memvalidasi daftar pertanyaan dan merapikan field sesuai tipe
+func normalizeSurveyQuestions(questions []models.SurveyQuestion) (models.SurveyQuestions, error) {
+	if len(questions) == 0 {
+		return nil, &utils.FieldError{Field: "pertanyaan", Message: "Survey harus punya minimal satu pertanyaan"}
+	}
+
+	result := models.SurveyQuestions{}
+	seenKode := map[string]bool{}
+	for i, q := range questions {
+		field := fmt.Sprintf("pertanyaan[%d]", i)
+
+		q.Kode = strings.TrimSpace(q.Kode)
+		q.Teks = strings.TrimSpace(q.Teks)
+		if seenKode[q.Kode] {
+			return nil, &utils.FieldError{Field: field + ".kode", Message: fmt.Sprintf("Kode pertanyaan '%s' dipakai lebih dari sekali", q.Kode)}
+		}
+		seenKode[q.Kode] = true
+
+		switch q.Tipe {
+		case models.TipePertanyaanPilihan, models.TipePertanyaanPilihanGanda:
+			pilihan := []string{}
+			seenPilihan := map[string]bool{}
+			for _, p := range q.Pilihan {
+				p = strings.TrimSpace(p)
+				if p != "" && !seenPilihan[p] {
+					seenPilihan[p] = true
+					pilihan = append(pilihan, p)
+				}
+			}
+			if len(pilihan) < 2 {
+				return nil, &utils.FieldError{Field: field + ".pilihan", Message: "Pertanyaan pilihan harus punya minimal dua pilihan"}
+			}
+			q.Pilihan, q.SkalaMin, q.SkalaMax = pilihan, 0, 0
+		case models.TipePertanyaanSkala:
+			if q.SkalaMin == 0 && q.SkalaMax == 0 {
+				q.SkalaMin, q.SkalaMax = defaultSkalaMin, defaultSkalaMax
+			}
+			if q.SkalaMin >= q.SkalaMax {
+				return nil, &utils.FieldError{Field: field + ".skala_max", Message: "skala_max harus lebih besar dari skala_min"}
+			}
+			q.Pilihan = nil
+		case models.TipePertanyaanTeks, models.TipePertanyaanAngka:
+			q.Pilihan, q.SkalaMin, q.SkalaMax = nil, 0, 0
+		default:
+			return nil, &utils.FieldError{Field: field + ".tipe", Message: fmt.Sprintf("Tipe pertanyaan harus salah satu dari: %s", strings.Join(models.TipePertanyaan, ", "))}
+		}
+
+		result = append(result, q)
+	}
+	return result, nil
+}
+
+// validateSurveyAnswers mencocokkan jawaban dengan pertanyaan survey dan
+// mengembalikannya dalam urutan pertanyaan, hanya dengan field yang sesuai tipe
+func validateSurveyAnswers(questions models.SurveyQuestions, answers []models.SurveyAnswer) (models.SurveyAnswers, error) {
+	byKode := map[string]models.SurveyAnswer{}
+	for _, answer := range answers {
+		kode := strings.TrimSpace(answer.Kode)
+		if _, ok := byKode[kode]; ok {
+			return nil, &utils.FieldError{Field: "jawaban." + kode, Message: fmt.Sprintf("Jawaban untuk '%s' dikirim lebih dari sekali", kode)}
+		}
+		byKode[kode] = answer
+	}
+	for kode := range byKode {
+		if !hasSurveyQuestion(questions, kode) {
+			return nil, &utils.FieldError{Field: "jawaban." + kode, Message: fmt.Sprintf("Pertanyaan '%s' tidak ada di survey ini", kode)}
+		}
+	}
+
+	result := models.SurveyAnswers{}
+	for _, q := range questions {
+		field := "jawaban." + q.Kode
+		answer := byKode[q.Kode]
+		clean := models.SurveyAnswer{Kode: q.Kode}
+
+		switch q.Tipe {
+		case models.TipePertanyaanTeks:
+			clean.Teks = strings.TrimSpace(answer.Teks)
+			if clean.Teks == "" {
+				if q.Wajib {
+					return nil, &utils.FieldError{Field: field, Message: fmt.Sprintf("Pertanyaan '%s' wajib diisi", q.Kode)}
+				}
+				continue
+			}
+		case models.TipePertanyaanPilihan, models.TipePertanyaanPilihanGanda:
+			pilihan := []string{}
+			seen := map[string]bool{}
+			for _, p := range answer.Pilihan {
+				p = strings.TrimSpace(p)
+				if p == "" || seen[p] {
+					continue
+				}
+				if !containsString(q.Pilihan, p) {
+					return nil, &utils.FieldError{Field: field, Message: fmt.Sprintf("Pilihan '%s' tidak tersedia", p)}
+				}
+				seen[p] = true
+				pilihan = append(pilihan, p)
+			}
+			if len(pilihan) == 0 {
+				if q.Wajib {
+					return nil, &utils.FieldError{Field: field, Message: fmt.Sprintf("Pertanyaan '%s' wajib diisi", q.Kode)}
+				}
+				continue
+			}
+			if q.Tipe == models.TipePertanyaanPilihan && len(pilihan) > 1 {
+				return nil, &utils.FieldError{Field: field, Message: "Pertanyaan ini hanya boleh satu pilihan"}
+			}
+			clean.Pilihan = pilihan
+		case models.TipePertanyaanSkala, models.TipePertanyaanAngka:
+			if answer.Nilai == nil {
+				if q.Wajib {
+					return nil, &utils.FieldError{Field: field, Message: fmt.Sprintf("Pertanyaan '%s' wajib diisi", q.Kode)}
+				}
+				continue
+			}
+			nilai := *answer.Nilai
+			if math.IsNaN(nilai) || math.IsInf(nilai, 0) {
+				return nil, &utils.FieldError{Field: field, Message: "Nilai tidak valid"}
+			}
+			if q.Tipe == models.TipePertanyaanSkala &&
+				(nilai != math.Trunc(nilai) || nilai < float64(q.SkalaMin) || nilai > float64(q.SkalaMax)) {
+				return nil, &utils.FieldError{Field: field, Message: fmt.Sprintf("Nilai harus bilangan bulat %d-%d", q.SkalaMin, q.SkalaMax)}
+			}
+			clean.Nilai = &nilai
+		}
+
+		result = append(result, clean)
+	}
+	return result, nil
+}
+
+// aggregateSurveyResults merangkum jawaban per pertanyaan tanpa identitas responden
+func aggregateSurveyResults(survey *models.Survey, responses []models.SurveyResponse) []models.SurveyQuestionResult {
+	results := make([]models.SurveyQuestionResult, 0, len(survey.Pertanyaan))
+	for _, q := range survey.Pertanyaan {
+		result := models.SurveyQuestionResult{Kode: q.Kode, Teks: q.Teks, Tipe: q.Tipe}
+
+		counts := map[string]int{}
+		values := []float64{}
+		for _, response := range responses {
+			for _, answer := range response.Jawaban {
+				if answer.Kode != q.Kode {
+					continue
+				}
+				result.JumlahJawaban++
+				switch q.Tipe {
+				case models.TipePertanyaanTeks:
+					result.JawabanTeks = append(result.JawabanTeks, answer.Teks)
+				case models.TipePertanyaanPilihan, models.TipePertanyaanPilihanGanda:
+					for _, p := range answer.Pilihan {
+						counts[p]++
+					}
+				case models.TipePertanyaanSkala, models.TipePertanyaanAngka:
+					if answer.Nilai != nil {
+						values = append(values, *answer.Nilai)
+						counts[strconv.FormatFloat(*answer.Nilai, 'f', -1, 64)]++
+					}
+				}
+			}
+		}
+
+		// Distribusi mengikuti urutan pilihan/skala, termasuk yang belum pernah dipilih
+		options := q.Pilihan
+		if q.Tipe == models.TipePertanyaanSkala {
+			options = []string{}
+			for v := q.SkalaMin; v <= q.SkalaMax; v++ {
+				options = append(options, strconv.Itoa(v))
+			}
+		}
+		for _, option := range options {
+			result.Distribusi = append(result.Distribusi, models.SurveyOptionCount{
+				Jawaban: option,
+				Jumlah:  counts[option],
+				Persen:  persen(counts[option], result.JumlahJawaban),
+			})
+		}
+
+		if len(values) > 0 {
+			sort.Float64s(values)
+			total := 0.0
+			for _, v := range values {
+				total += v
+			}
+			rataRata := math.Round(total/float64(len(values))*100) / 100
+			median := math.Round(percentile(values, 50)*100) / 100
+			result.RataRata, result.Median = &rataRata, &median
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// surveyTarget memeriksa apakah alumni termasuk cohort target survey.
+// Target kosong berarti semua alumni.
+func surveyTarget(survey *models.Survey, alumni *models.Alumni) bool {
+	if len(survey.TargetTahunLulus) > 0 {
+		found := false
+		for _, tahun := range survey.TargetTahunLulus {
+			if tahun == alumni.TahunLulus {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(survey.TargetJurusan) == 0 {
+		return true
+	}
+	jurusan := utils.NormalizeIstilah(alumni.Jurusan)
+	for _, target := range survey.TargetJurusan {
+		if (alumni.KodeProdi != "" && target == alumni.KodeProdi) || utils.NormalizeIstilah(target) == jurusan {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRateResult menghitung persentase lalu mengurutkan grup berdasarkan nama
+func responseRateResult(groups map[string]*models.SurveyResponseRate) []models.SurveyResponseRate {
+	result := make([]models.SurveyResponseRate, 0, len(groups))
+	for _, rate := range groups {
+		rate.Persen = persen(rate.Responden, rate.Target)
+		result = append(result, *rate)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Grup < result[j].Grup })
+	return result
+}
+
+func hasSurveyQuestion(questions models.SurveyQuestions, kode string) bool {
+	for _, q := range questions {
+		if q.Kode == kode {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// csvCell mencegah formula injection saat CSV dibuka di spreadsheet: sel teks
+// yang diawali =, +, -, @ (atau tab/CR) diberi awalan tanda kutip tunggal
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 2, 64)
+}
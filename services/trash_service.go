@@ -3,6 +3,7 @@ package services
 import (
 	"modul4crud/models"
 	repo "modul4crud/repositories/interface"
+	"modul4crud/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -20,7 +21,7 @@ func NewTrashService(pekerjaanRepo repo.PekerjaanAlumniRepository) *TrashService
 func (s *TrashService) GetAllTrash(c *fiber.Ctx) error {
 	userRole, ok := c.Locals("role").(string)
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+		return utils.Unauthorized("Role tidak ditemukan")
 	}
 
 	var pekerjaanAlumnis []models.PekerjaanAlumni
@@ -31,15 +32,13 @@ func (s *TrashService) GetAllTrash(c *fiber.Ctx) error {
 	} else {
 		userID, ok := c.Locals("user_id").(int)
 		if !ok {
-			return c.Status(401).JSON(fiber.Map{"error": "User ID tidak ditemukan"})
+			return utils.Unauthorized("User ID tidak ditemukan")
 		}
 		pekerjaanAlumnis, err = s.pekerjaanRepo.GetDeletedByUserID(userID)
 	}
 
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to fetch deleted pekerjaan alumni data",
-		})
+		return err
 	}
 
 	message := "Data trash berhasil diambil"
@@ -0,0 +1,162 @@
+// Package s3test server S3 palsu di memori untuk mencoba driver storage.S3 tanpa MinIO.
+// Hanya mendukung PUT/GET/HEAD/DELETE object dengan URL path-style (/bucket/key),
+// GET dengan satu Range "bytes=awal-akhir", dan ListObjectsV2 (GET /bucket?list-type=2).
+package s3test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Fake http.Handler yang menyimpan object per bucket di memori
+type Fake struct {
+	// AccessKeyID jika diisi, request dengan credential lain ditolak 403
+	AccessKeyID string
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func New() *Fake {
+	return &Fake{objects: map[string]object{}}
+}
+
+// NewServer menjalankan Fake di httptest.Server; URL-nya dipakai sebagai S3_ENDPOINT
+func NewServer(accessKeyID string) (*Fake, *httptest.Server) {
+	fake := New()
+	fake.AccessKeyID = accessKeyID
+	return fake, httptest.NewServer(fake)
+}
+
+// Keys daftar "bucket/key" yang tersimpan
+func (f *Fake) Keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+f.AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if bucket := strings.TrimSuffix(name, "/"); bucket != "" && !strings.Contains(bucket, "/") && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.list(w, bucket, r.URL.Query())
+		return
+	}
+	if bucket, key, ok := strings.Cut(name, "/"); !ok || bucket == "" || key == "" {
+		writeError(w, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[name] = object{data: data, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		data, status := obj.data, http.StatusOK
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil && r.Method == http.MethodGet {
+			if start >= len(data) || end < start {
+				writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			end = min(end, len(data)-1)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+			data, status = data[start:end+1], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// list satu halaman ListObjectsV2; continuation-token adalah key terakhir halaman sebelumnya
+func (f *Fake) list(w http.ResponseWriter, bucket string, query url.Values) {
+	maxKeys := 1000
+	if value, err := strconv.Atoi(query.Get("max-keys")); err == nil && value > 0 {
+		maxKeys = min(value, 1000)
+	}
+	prefix := bucket + "/" + query.Get("prefix")
+	after := query.Get("continuation-token")
+
+	f.mu.Lock()
+	keys := []string{}
+	for name := range f.objects {
+		if key := strings.TrimPrefix(name, bucket+"/"); strings.HasPrefix(name, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	truncated := len(keys) > maxKeys
+	if truncated {
+		keys = keys[:maxKeys]
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult>`)
+	for _, key := range keys {
+		b.WriteString("<Contents><Key>")
+		xml.EscapeText(&b, []byte(key))
+		fmt.Fprintf(&b, "</Key><Size>%d</Size></Contents>", len(f.objects[bucket+"/"+key].data))
+	}
+	f.mu.Unlock()
+
+	fmt.Fprintf(&b, "<IsTruncated>%t</IsTruncated>", truncated)
+	if truncated {
+		b.WriteString("<NextContinuationToken>")
+		xml.EscapeText(&b, []byte(keys[len(keys)-1]))
+		b.WriteString("</NextContinuationToken>")
+	}
+	b.WriteString("</ListBucketResult>")
+
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, b.String())
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><Error><Code>"+code+"</Code></Error>")
+}
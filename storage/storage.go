@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Nama backend storage, disimpan di models.File.Backend
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound dikembalikan Get/Delete jika key tidak ada di backend
+var ErrNotFound = errors.New("storage: object tidak ditemukan")
+
+// Storage tempat penyimpanan isi file yang diupload. Key adalah nama relatif
+// (misal "3f2c...e1.pdf"); setiap backend menentukan sendiri lokasi fisiknya.
+type Storage interface {
+	// Name nama backend (BackendLocal, BackendS3)
+	Name() string
+	// Put menyimpan size byte dari r ke key, menimpa object lama dengan key yang sama
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get membuka isi object; pemanggil wajib menutup reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// GetRange seperti Get tetapi hanya length byte mulai dari offset
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List memanggil fn untuk setiap object dengan awalan prefix ("" = semua)
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// Object ringkasan satu object hasil List
+type Object struct {
+	Key  string
+	Size int64
+}
+
+// Registry kumpulan backend yang aktif. File baru disimpan di backend default,
+// file lama dibaca dari backend yang tercatat di record-nya.
+type Registry struct {
+	defaultName string
+	backends    map[string]Storage
+}
+
+// NewRegistry membuat registry; defaultName harus salah satu backend
+func NewRegistry(defaultName string, backends ...Storage) (*Registry, error) {
+	registry := &Registry{defaultName: defaultName, backends: map[string]Storage{}}
+	for _, backend := range backends {
+		registry.backends[backend.Name()] = backend
+	}
+	if _, ok := registry.backends[defaultName]; !ok {
+		return nil, fmt.Errorf("storage backend %q belum dikonfigurasi", defaultName)
+	}
+	return registry, nil
+}
+
+// Default backend untuk file baru
+func (r *Registry) Default() Storage {
+	return r.backends[r.defaultName]
+}
+
+// Backend mencari backend berdasarkan nama
+func (r *Registry) Backend(name string) (Storage, error) {
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("storage backend %q belum dikonfigurasi", name)
+	}
+	return backend, nil
+}
+
+// Backends semua backend yang aktif, urut nama
+func (r *Registry) Backends() []Storage {
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	backends := make([]Storage, 0, len(names))
+	for _, name := range names {
+		backends = append(backends, r.backends[name])
+	}
+	return backends
+}
+
+// NewRegistryFromEnv membaca konfigurasi storage dari environment:
+//
+//	STORAGE_DRIVER       local (default) atau s3, backend untuk file baru
+//	STORAGE_LOCAL_PATH   folder driver local (default ./uploads)
+//	S3_BUCKET            mengaktifkan driver s3 jika diisi
+//	S3_ENDPOINT          misal http://localhost:9000 untuk MinIO (default AWS sesuai region)
+//	S3_REGION            default us-east-1
+//	S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY
+//	S3_PATH_STYLE        true (default) untuk URL endpoint/bucket/key, false untuk bucket.endpoint/key
+//
+// Driver local selalu aktif supaya file lama tetap bisa dibaca setelah pindah ke s3.
+func NewRegistryFromEnv() (*Registry, error) {
+	localPath := os.Getenv("STORAGE_LOCAL_PATH")
+	if localPath == "" {
+		localPath = "./uploads"
+	}
+	backends := []Storage{NewLocal(localPath)}
+
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		pathStyle := true
+		if value := os.Getenv("S3_PATH_STYLE"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("S3_PATH_STYLE tidak valid: %v", err)
+			}
+			pathStyle = parsed
+		}
+		s3, err := NewS3(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       pathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, s3)
+	}
+
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if driver == "" {
+		driver = BackendLocal
+	}
+	return NewRegistry(driver, backends...)
+}
+
+// validKey menolak key kosong, absolut, atau yang keluar dari root (..)
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("storage: key tidak valid: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("storage: key tidak valid: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"math"
+	"modul4crud/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	nonDigitPattern = regexp.MustCompile(`[^0-9]+`)
+	// Gelar yang diabaikan saat membandingkan nama orang
+	namaTitleTokens = map[string]bool{
+		"dr": true, "ir": true, "drs": true, "dra": true, "h": true, "hj": true,
+		"st": true, "se": true, "sh": true, "mt": true, "mm": true, "kom": true, "skom": true,
+	}
+)
+
+// maxDuplicateBlock blok kandidat yang lebih besar dari ini (misal kata nama yang
+// sangat umum seperti "muhammad") dilewati supaya jumlah pasangan tidak meledak
+const maxDuplicateBlock = 500
+
+// NormalizeNama membuat kunci pembanding nama orang: huruf kecil, tanpa tanda baca
+// dan tanpa gelar. "Ir. Budi Santoso, S.T." menjadi "budi santoso".
+func NormalizeNama(nama string) string {
+	// Titik dibuang lebih dulu supaya gelar seperti "S.T." menjadi satu kata "st"
+	cleaned := strings.ReplaceAll(strings.ToLower(nama), ".", "")
+	cleaned = nonAlnumPattern.ReplaceAllString(cleaned, " ")
+
+	tokens := []string{}
+	for _, token := range strings.Fields(cleaned) {
+		if !namaTitleTokens[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// NormalizeTelepon membuat kunci pembanding nomor telepon: digit saja dengan awalan
+// 0, sehingga "+62 812-3456-789" sama dengan "0812 3456 789". Nomor yang terlalu
+// pendek untuk dibandingkan menghasilkan "".
+func NormalizeTelepon(telepon string) string {
+	digits := nonDigitPattern.ReplaceAllString(telepon, "")
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	if len(digits) < 8 {
+		return ""
+	}
+	return digits
+}
+
+// EditDistance jarak edit antar dua string (penyisipan, penghapusan, penggantian,
+// dan pertukaran dua karakter bersebelahan masing-masing bernilai 1)
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// NameSimilarity kemiripan dua nama (0..1) dari jarak edit nama yang sudah
+// dinormalisasi. Urutan kata diabaikan: "Santoso Budi" sama dengan "Budi Santoso".
+func NameSimilarity(a, b string) float64 {
+	a, b = NormalizeNama(a), NormalizeNama(b)
+	if a == "" || b == "" {
+		return 0
+	}
+
+	similarity := func(x, y string) float64 {
+		longest := max(len([]rune(x)), len([]rune(y)))
+		return 1 - float64(EditDistance(x, y))/float64(longest)
+	}
+	return math.Max(similarity(a, b), similarity(sortedTokens(a), sortedTokens(b)))
+}
+
+func sortedTokens(s string) string {
+	tokens := strings.Fields(s)
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// DeletionVariants string itu sendiri beserta semua hasil penghapusan satu karakternya.
+// Dua string dengan jarak edit 1 selalu berbagi minimal satu varian, sehingga cocok
+// untuk mengelompokkan NIM yang salah ketik.
+func DeletionVariants(s string) []string {
+	runes := []rune(s)
+	variants := []string{s}
+	for i := range runes {
+		variants = append(variants, string(runes[:i])+string(runes[i+1:]))
+	}
+	return variants
+}
+
+// FindDuplicates mencari pasangan record yang diduga duplikat. Hanya record yang
+// berbagi minimal satu kunci blok yang dibandingkan; signals mengembalikan
+// kontribusi tiap sinyal, dan skor (jumlah sinyal, maksimal 1) minimal minScore
+// yang dilaporkan. Hasil urut skor tertinggi.
+func FindDuplicates[T any](records []T, blockKeys func(*T) []string, signals func(a, b *T) map[string]float64, minScore float64) []models.DuplicatePair[T] {
+	blocks := map[string][]int{}
+	for i := range records {
+		seenKeys := map[string]bool{}
+		for _, key := range blockKeys(&records[i]) {
+			if !seenKeys[key] {
+				seenKeys[key] = true
+				blocks[key] = append(blocks[key], i)
+			}
+		}
+	}
+
+	type pairKey struct{ a, b int }
+	seen := map[pairKey]bool{}
+	pairs := []models.DuplicatePair[T]{}
+	for _, members := range blocks {
+		if len(members) < 2 || len(members) > maxDuplicateBlock {
+			continue
+		}
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				key := pairKey{min(members[x], members[y]), max(members[x], members[y])}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				found := signals(&records[key.a], &records[key.b])
+				score := 0.0
+				for _, value := range found {
+					score += value
+				}
+				score = math.Min(1, math.Round(score*100)/100)
+				if score >= minScore {
+					pairs = append(pairs, models.DuplicatePair[T]{
+						Score:   score,
+						Signals: found,
+						Records: [2]T{records[key.a], records[key.b]},
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Score > pairs[j].Score })
+	return pairs
+}
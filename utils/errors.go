@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kode error yang stabil untuk client; pesan boleh berubah, kode tidak
+const (
+	ErrCodeBadRequest   = "BAD_REQUEST"
+	ErrCodeUnauthorized = "UNAUTHORIZED"
+	ErrCodeForbidden    = "FORBIDDEN"
+	ErrCodeNotFound     = "NOT_FOUND"
+	ErrCodeConflict     = "CONFLICT"
+	ErrCodeGone         = "GONE"
+	ErrCodePrecondition = "PRECONDITION_FAILED"
+	ErrCodeValidation   = "VALIDATION_FAILED"
+	ErrCodeRange        = "RANGE_NOT_SATISFIABLE"
+	ErrCodeQuota        = "QUOTA_EXCEEDED"
+	ErrCodeInternal     = "INTERNAL_ERROR"
+)
+
+// AppError error domain yang dikembalikan repository dan service. ErrorHandler
+// mengubahnya menjadi response JSON dengan Status, Code, Message, Fields dan Details.
+type AppError struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  ValidationErrors       // field yang gagal validasi (VALIDATION_FAILED)
+	Details map[string]interface{} // data tambahan yang ikut dikirim di response
+	Err     error                  // penyebab asli, hanya untuk log
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is membuat errors.Is(err, utils.ErrNotFound) cocok dengan semua AppError berkode sama
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail menambahkan data ke response error
+func (e *AppError) WithDetail(key string, value interface{}) *AppError {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Sentinel untuk errors.Is
+var (
+	ErrNotFound  = &AppError{Code: ErrCodeNotFound, Status: http.StatusNotFound, Message: "Data tidak ditemukan"}
+	ErrConflict  = &AppError{Code: ErrCodeConflict, Status: http.StatusConflict, Message: "Data sudah ada"}
+	ErrForbidden = &AppError{Code: ErrCodeForbidden, Status: http.StatusForbidden, Message: "Akses ditolak"}
+)
+
+func BadRequest(message string) *AppError {
+	return &AppError{Code: ErrCodeBadRequest, Status: http.StatusBadRequest, Message: message}
+}
+
+func Unauthorized(message string) *AppError {
+	return &AppError{Code: ErrCodeUnauthorized, Status: http.StatusUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *AppError {
+	return &AppError{Code: ErrCodeForbidden, Status: http.StatusForbidden, Message: message}
+}
+
+func NotFound(message string) *AppError {
+	return &AppError{Code: ErrCodeNotFound, Status: http.StatusNotFound, Message: message}
+}
+
+func Conflict(message string) *AppError {
+	return &AppError{Code: ErrCodeConflict, Status: http.StatusConflict, Message: message}
+}
+
+// Gone resource pernah ada tetapi sudah tidak berlaku lagi (kedaluwarsa, dicabut, sudah dipakai)
+func Gone(message string) *AppError {
+	return &AppError{Code: ErrCodeGone, Status: http.StatusGone, Message: message}
+}
+
+// VersionMismatch error PRECONDITION_FAILED: record sudah diubah orang lain
+// sejak dibaca (If-Match tidak cocok atau update bersyarat versi gagal)
+func VersionMismatch() *AppError {
+	return &AppError{
+		Code:    ErrCodePrecondition,
+		Status:  http.StatusPreconditionFailed,
+		Message: "Data sudah diubah oleh pengguna lain, muat ulang data lalu coba lagi",
+	}
+}
+
+// RangeNotSatisfiable header Range berada di luar ukuran file; size ikut dikirim
+// supaya client bisa mengulang request dengan range yang benar
+func RangeNotSatisfiable(size int64) *AppError {
+	appErr := &AppError{Code: ErrCodeRange, Status: http.StatusRequestedRangeNotSatisfiable, Message: "Range di luar ukuran file"}
+	return appErr.WithDetail("size", size)
+}
+
+// QuotaExceeded upload melebihi kuota penyimpanan user; pemakaian dan
+// kuotanya (byte) ikut dikirim supaya client bisa menampilkan sisa ruang
+func QuotaExceeded(used, quota int64) *AppError {
+	appErr := &AppError{Code: ErrCodeQuota, Status: http.StatusRequestEntityTooLarge, Message: "Kuota penyimpanan tidak mencukupi"}
+	return appErr.WithDetail("used_bytes", used).WithDetail("quota_bytes", quota)
+}
+
+// DuplicateError error CONFLICT untuk pelanggaran unique constraint pada field
+// tertentu (nim, email, username, ...). Nama field ikut dikirim di response.
+func DuplicateError(field string) *AppError {
+	message := "Data sudah ada"
+	switch field {
+	case "nim":
+		message = "NIM sudah terdaftar"
+	case "email":
+		message = "Email sudah terdaftar"
+	case "username":
+		message = "Username sudah digunakan"
+	case "nama_normal", "alias_normal":
+		message = "Nama perusahaan sudah terdaftar"
+	}
+	appErr := Conflict(message)
+	if field != "" {
+		appErr.WithDetail("field", field)
+	}
+	return appErr
+}
+
+// Validation error validasi untuk satu atau lebih field
+func Validation(fields ValidationErrors) *AppError {
+	return &AppError{Code: ErrCodeValidation, Status: http.StatusUnprocessableEntity, Message: "Validasi gagal", Fields: fields}
+}
+
+// Internal membungkus error tak terduga; detailnya tidak dikirim ke client
+func Internal(err error) *AppError {
+	return &AppError{Code: ErrCodeInternal, Status: http.StatusInternalServerError, Message: "Terjadi kesalahan pada server", Err: err}
+}
+
+// ToAppError mengubah error apa pun menjadi AppError. Error yang bukan error
+// domain dianggap kesalahan internal.
+func ToAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return Validation(ValidationErrors{*fieldErr})
+	}
+	var fieldErrs ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return Validation(fieldErrs)
+	}
+	return Internal(err)
+}
+
+// IsNotFound true jika err adalah error NOT_FOUND
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
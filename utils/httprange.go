@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ByteRange satu rentang byte dari header Range
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// ParseRange membaca header Range "bytes=awal-akhir", "bytes=awal-" atau
+// "bytes=-n" (n byte terakhir) untuk file berukuran size. ok=false berarti
+// header diabaikan dan seluruh file dikirim: header kosong, formatnya tidak
+// dikenal, atau berisi lebih dari satu rentang. Rentang yang dimulai di luar
+// file menghasilkan error RANGE_NOT_SATISFIABLE.
+func ParseRange(header string, size int64) (ByteRange, bool, error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return ByteRange{}, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return ByteRange{}, false, nil
+	}
+
+	// bytes=-n: n byte terakhir
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return ByteRange{}, false, nil
+		}
+		if n == 0 || size == 0 {
+			return ByteRange{}, false, RangeNotSatisfiable(size)
+		}
+		n = min(n, size)
+		return ByteRange{Start: size - n, Length: n}, true, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return ByteRange{}, false, nil
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return ByteRange{}, false, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return ByteRange{}, false, RangeNotSatisfiable(size)
+	}
+	return ByteRange{Start: start, Length: end - start + 1}, true, nil
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/disintegration/imaging"
+)
+
+// ErrInvalidImage struktur JPEG/PNG rusak sehingga metadata tidak bisa dibuang
+var ErrInvalidImage = errors.New("format gambar tidak valid")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// StripImageMetadata membuang metadata (EXIF termasuk lokasi GPS, XMP, IPTC,
+// komentar) dari JPEG dan PNG tanpa meng-encode ulang piksel. JPEG dengan
+// orientasi EXIF selain normal diputar lalu di-encode ulang supaya tetap tampil
+// tegak setelah EXIF-nya dibuang. Tipe lain dikembalikan apa adanya.
+func StripImageMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEGMetadata(data)
+	case "image/png":
+		return stripPNGMetadata(data)
+	}
+	return data, nil
+}
+
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	orientation := 1
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, ErrInvalidImage
+		}
+		start := i
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			return nil, ErrInvalidImage
+		}
+		marker := data[i]
+		i++
+
+		switch {
+		case marker == 0xD9: // EOI
+			out.Write(data[start:i])
+			return reorient(out.Bytes(), orientation)
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // tanpa panjang
+			out.Write(data[start:i])
+			continue
+		}
+
+		if i+2 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		end := i + int(binary.BigEndian.Uint16(data[i:]))
+		if end > len(data) || end < i+2 {
+			return nil, ErrInvalidImage
+		}
+
+		switch marker {
+		case 0xDA: // SOS: sisanya data gambar terkompresi
+			out.Write(data[start:])
+			return reorient(out.Bytes(), orientation)
+		case 0xE1: // APP1: EXIF / XMP
+			if o := exifOrientation(data[i+2 : end]); o > 0 {
+				orientation = o
+			}
+		case 0xED, 0xFE: // APP13 (IPTC/Photoshop), COM
+		default:
+			out.Write(data[start:end])
+		}
+		i = end
+	}
+	return nil, ErrInvalidImage
+}
+
+// reorient memutar gambar sesuai orientasi EXIF yang sudah dibuang
+func reorient(data []byte, orientation int) ([]byte, error) {
+	if orientation <= 1 || orientation > 8 {
+		return data, nil
+	}
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	switch orientation {
+	case 2:
+		img = imaging.FlipH(img)
+	case 3:
+		img = imaging.Rotate180(img)
+	case 4:
+		img = imaging.FlipV(img)
+	case 5:
+		img = imaging.Transpose(img)
+	case 6:
+		img = imaging.Rotate270(img)
+	case 7:
+		img = imaging.Transverse(img)
+	case 8:
+		img = imaging.Rotate90(img)
+	}
+
+	var out bytes.Buffer
+	if err := imaging.Encode(&out, img, imaging.JPEG, imaging.JPEGQuality(92)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// exifOrientation membaca tag Orientation (0x0112) dari IFD0; 0 jika tidak ada
+func exifOrientation(app1 []byte) int {
+	tiff, found := bytes.CutPrefix(app1, []byte("Exif\x00\x00"))
+	if !found || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// stripPNGMetadata membuang chunk teks, eXIf dan tIME; chunk lain (termasuk
+// profil warna) tetap disimpan
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		case "IEND":
+			out.Write(data[i:end])
+			return out.Bytes(), nil
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, ErrInvalidImage
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MergePatch body request PATCH dengan semantik JSON Merge Patch (RFC 7396):
+// field yang tidak dikirim tidak berubah, field bernilai null dikosongkan,
+// dan objek bersarang digabung secara rekursif.
+type MergePatch map[string]interface{}
+
+// ParseMergePatch membaca body PATCH. Body harus berupa objek JSON.
+func ParseMergePatch(body []byte) (MergePatch, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, BadRequest("Body PATCH bukan JSON yang valid")
+	}
+	patch, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, BadRequest("Body PATCH harus berupa objek JSON")
+	}
+	return MergePatch(patch), nil
+}
+
+// Has true jika field dikirim di patch, termasuk yang bernilai null
+func (p MergePatch) Has(field string) bool {
+	_, ok := p[field]
+	return ok
+}
+
+// ApplyTo menerapkan patch ke target (pointer ke struct request). Target diisi
+// lebih dulu dengan nilai lama, lalu hasil gabungan di-decode ulang ke target,
+// sehingga field yang tidak dikenal struct diabaikan seperti pada BodyParser.
+func (p MergePatch) ApplyTo(target interface{}) error {
+	current, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(current))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergeValue(doc, map[string]interface{}(p)))
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	if err := json.Unmarshal(merged, target); err != nil {
+		return BadRequest(err.Error())
+	}
+	return nil
+}
+
+// mergeValue algoritma MergePatch dari RFC 7396 bagian 2
+func mergeValue(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type patchAlamat struct {
+	Kota    string `json:"kota"`
+	KodePos string `json:"kode_pos"`
+}
+
+type patchRequest struct {
+	Nama    string       `json:"nama"`
+	Email   string       `json:"email"`
+	Gaji    *int         `json:"gaji"`
+	Tags    []string     `json:"tags"`
+	Alamat  patchAlamat  `json:"alamat"`
+	Kontak  *patchAlamat `json:"kontak"`
+	Catatan string       `json:"-"`
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestParseMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+		has     []string
+	}{
+		{name: "objek", body: `{"nama":"Budi","gaji":null}`, has: []string{"nama", "gaji"}},
+		{name: "objek kosong", body: `{}`},
+		{name: "array", body: `[1,2]`, wantErr: true},
+		{name: "null", body: `null`, wantErr: true},
+		{name: "bukan json", body: `nama=Budi`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseMergePatch([]byte(tt.body))
+			if tt.wantErr {
+				var appErr *AppError
+				if !errors.As(err, &appErr) || appErr.Code != ErrCodeBadRequest {
+					t.Fatalf("err = %v, want BadRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range tt.has {
+				if !patch.Has(field) {
+					t.Errorf("Has(%q) = false", field)
+				}
+			}
+			if patch.Has("tidak_ada") {
+				t.Error(`Has("tidak_ada") = true`)
+			}
+		})
+	}
+}
+
+func TestMergePatchApplyTo(t *testing.T) {
+	base := func() patchRequest {
+		return patchRequest{
+			Nama:    "Budi",
+			Email:   "budi@example.com",
+			Gaji:    intPtr(5000000),
+			Tags:    []string{"go", "sql"},
+			Alamat:  patchAlamat{Kota: "Malang", KodePos: "65145"},
+			Kontak:  &patchAlamat{Kota: "Surabaya", KodePos: "60111"},
+			Catatan: "internal",
+		}
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  func(r *patchRequest)
+	}{
+		{
+			name:  "field yang tidak dikirim tidak berubah",
+			patch: `{"nama":"Budi Santoso"}`,
+			want:  func(r *patchRequest) { r.Nama = "Budi Santoso" },
+		},
+		{
+			name:  "null mengosongkan field",
+			patch: `{"gaji":null,"email":null}`,
+			want:  func(r *patchRequest) { r.Gaji, r.Email = nil, "" },
+		},
+		{
+			name:  "objek bersarang digabung",
+			patch: `{"alamat":{"kota":"Batu"}}`,
+			want:  func(r *patchRequest) { r.Alamat.Kota = "Batu" },
+		},
+		{
+			name:  "null di objek bersarang",
+			patch: `{"kontak":{"kode_pos":null}}`,
+			want:  func(r *patchRequest) { r.Kontak.KodePos = "" },
+		},
+		{
+			name:  "objek bersarang null",
+			patch: `{"kontak":null}`,
+			want:  func(r *patchRequest) { r.Kontak = nil },
+		},
+		{
+			name:  "array diganti utuh",
+			patch: `{"tags":["rust"]}`,
+			want:  func(r *patchRequest) { r.Tags = []string{"rust"} },
+		},
+		{
+			name:  "field tidak dikenal diabaikan",
+			patch: `{"role":"admin"}`,
+			want:  func(r *patchRequest) {},
+		},
+		{
+			name:  "field json:\"-\" ikut dikosongkan",
+			patch: `{"catatan":"x"}`,
+			want:  func(r *patchRequest) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseMergePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := base()
+			if err := patch.ApplyTo(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			want := base()
+			want.Catatan = "" // tidak ikut di-marshal, jadi tidak bisa dipertahankan
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ApplyTo =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestMergePatchApplyToTypeMismatch(t *testing.T) {
+	patch, err := ParseMergePatch([]byte(`{"gaji":"banyak"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := patchRequest{Nama: "Budi"}
+	err = patch.ApplyTo(&req)
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr.Code != ErrCodeBadRequest {
+		t.Fatalf("err = %v, want BadRequest", err)
+	}
+}
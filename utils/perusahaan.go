@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	nonAlnumPattern       = regexp.MustCompile(`[^a-z0-9]+`)
+	perusahaanLegalTokens = map[string]bool{
+		"pt": true, "tbk": true, "cv": true, "persero": true, "ltd": true,
+		"inc": true, "corp": true, "co": true, "llc": true, "plc": true,
+	}
+)
+
+// NormalizeNamaPerusahaan membuat kunci pembanding nama perusahaan: huruf kecil,
+// tanpa tanda baca, dan tanpa bentuk badan usaha seperti "PT", "Tbk" atau "(Persero)".
+// "PT. Telkom Tbk" dan "telkom" menghasilkan kunci yang sama.
+func NormalizeNamaPerusahaan(nama string) string {
+	cleaned := nonAlnumPattern.ReplaceAllString(strings.ToLower(nama), " ")
+
+	tokens := []string{}
+	for _, token := range strings.Fields(cleaned) {
+		if !perusahaanLegalTokens[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return strings.Join(strings.Fields(cleaned), " ")
+	}
+	return strings.Join(tokens, " ")
+}
+
+// NormalizeAliases membuat daftar alias ternormalisasi untuk pencarian
+func NormalizeAliases(aliases []string) []string {
+	normals := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if normal := NormalizeNamaPerusahaan(alias); normal != "" {
+			normals = append(normals, normal)
+		}
+	}
+	return normals
+}
+
+// UniqueAliases membuang alias kosong, alias duplikat, dan alias yang sama
+// dengan nama utama perusahaan (dibandingkan setelah normalisasi)
+func UniqueAliases(aliases []string, namaNormal string) []string {
+	seen := map[string]bool{namaNormal: true}
+	result := []string{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		normal := NormalizeNamaPerusahaan(alias)
+		if normal == "" || seen[normal] {
+			continue
+		}
+		seen[normal] = true
+		result = append(result, alias)
+	}
+	return result
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "strings"
+
+// NormalizeIstilah membuat kunci pembanding untuk istilah data referensi
+// (industri, lokasi, prodi): huruf kecil dan tanpa tanda baca.
+// "D.I. Yogyakarta" dan "di yogyakarta" menghasilkan kunci yang sama.
+func NormalizeIstilah(istilah string) string {
+	cleaned := nonAlnumPattern.ReplaceAllString(strings.ToLower(istilah), " ")
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// NormalizeKode merapikan kode referensi (tanpa spasi di tepi, huruf besar)
+func NormalizeKode(kode string) string {
+	return strings.ToUpper(strings.TrimSpace(kode))
+}
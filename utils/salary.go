@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"fmt"
+	"modul4crud/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParsedGaji hasil parsing string GajiRange menjadi data terstruktur
+type ParsedGaji struct {
+	Min      *int64
+	Max      *int64
+	MataUang string
+	Periode  string
+}
+
+var (
+	gajiNumberPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)*)\s*(juta|jt|ribu|rb|k)?`)
+	gajiSeparator     = regexp.MustCompile(`[.,]`)
+	gajiUpperBound    = []string{"<", "kurang dari", "dibawah", "di bawah", "maks", "max", "under", "below", "up to", "hingga"}
+	gajiLowerBound    = []string{">", "lebih dari", "diatas", "di atas", "min", "above", "over", "mulai"}
+	gajiYearly        = []string{"tahun", "/thn", "per year", "/year", "/yr", "annual", "p.a"}
+)
+
+// ParseGajiRange mengubah teks bebas seperti "5-10 juta", "Rp 7.500.000",
+// "> 15jt" atau "USD 2,000 - 3,000/year" menjadi gaji minimum, maksimum,
+// mata uang dan periode. Mengembalikan false jika tidak ada angka yang dikenali.
+func ParseGajiRange(raw string) (ParsedGaji, bool) {
+	text := strings.ToLower(strings.TrimSpace(raw))
+	parsed := ParsedGaji{
+		MataUang: detectMataUang(text),
+		Periode:  models.GajiPeriodeBulanan,
+	}
+	if containsAny(text, gajiYearly) {
+		parsed.Periode = models.GajiPeriodeTahunan
+	}
+
+	matches := gajiNumberPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return parsed, false
+	}
+
+	values := make([]float64, 0, len(matches))
+	units := make([]string, 0, len(matches))
+	for _, m := range matches {
+		value, err := parseGajiNumber(m[1])
+		if err != nil {
+			continue
+		}
+		values = append(values, value)
+		units = append(units, m[2])
+	}
+	if len(values) == 0 {
+		return parsed, false
+	}
+
+	// "5-10 juta": satuan yang hanya ditulis sekali berlaku untuk semua angka
+	for i := range units {
+		if units[i] != "" {
+			continue
+		}
+		for j := i + 1; j < len(units); j++ {
+			if units[j] != "" {
+				units[i] = units[j]
+				break
+			}
+		}
+		if units[i] == "" && i > 0 {
+			units[i] = units[i-1]
+		}
+	}
+
+	amounts := make([]int64, len(values))
+	for i, v := range values {
+		amounts[i] = int64(v * gajiMultiplier(units[i]))
+	}
+
+	if len(amounts) == 1 {
+		amount := amounts[0]
+		switch {
+		case containsAny(text, gajiUpperBound):
+			parsed.Max = &amount
+		case containsAny(text, gajiLowerBound) || strings.HasSuffix(text, "+"):
+			parsed.Min = &amount
+		default:
+			parsed.Min = &amount
+			parsed.Max = &amount
+		}
+		return parsed, true
+	}
+
+	lo, hi := amounts[0], amounts[1]
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	parsed.Min = &lo
+	parsed.Max = &hi
+	return parsed, true
+}
+
+// FormatGajiRange membuat teks GajiRange dari data terstruktur agar field lama tetap terisi
+func FormatGajiRange(min, max *int64, mataUang, periode string) string {
+	if mataUang == "" {
+		mataUang = models.DefaultGajiMataUang
+	}
+	suffix := ""
+	if periode == models.GajiPeriodeTahunan {
+		suffix = "/tahun"
+	}
+
+	switch {
+	case min != nil && max != nil && *min == *max:
+		return fmt.Sprintf("%s %d%s", mataUang, *min, suffix)
+	case min != nil && max != nil:
+		return fmt.Sprintf("%s %d - %d%s", mataUang, *min, *max, suffix)
+	case min != nil:
+		return fmt.Sprintf("> %s %d%s", mataUang, *min, suffix)
+	case max != nil:
+		return fmt.Sprintf("< %s %d%s", mataUang, *max, suffix)
+	}
+	return ""
+}
+
+// MonthlyGaji menghitung nilai tengah gaji per bulan dari data terstruktur.
+// Batas <= 0 dianggap tidak diisi: PocketBase menyimpan field number kosong
+// sebagai 0, dan range satu sisi tidak boleh dirata-rata dengan 0.
+func MonthlyGaji(min, max *int64, periode string) (float64, bool) {
+	if min != nil && *min <= 0 {
+		min = nil
+	}
+	if max != nil && *max <= 0 {
+		max = nil
+	}
+
+	var value float64
+	switch {
+	case min != nil && max != nil:
+		value = float64(*min+*max) / 2
+	case min != nil:
+		value = float64(*min)
+	case max != nil:
+		value = float64(*max)
+	default:
+		return 0, false
+	}
+	if periode == models.GajiPeriodeTahunan {
+		value = value / 12
+	}
+	return value, true
+}
+
+func detectMataUang(text string) string {
+	switch {
+	case strings.Contains(text, "usd") || strings.Contains(text, "$"):
+		return "USD"
+	case strings.Contains(text, "sgd"):
+		return "SGD"
+	case strings.Contains(text, "eur") || strings.Contains(text, "€"):
+		return "EUR"
+	}
+	return models.DefaultGajiMataUang
+}
+
+// parseGajiNumber membaca angka dengan pemisah ribuan gaya Indonesia (titik)
+// maupun gaya internasional (koma). Pemisah diikuti tepat tiga digit dianggap
+// pemisah ribuan, selain itu dianggap desimal.
+func parseGajiNumber(token string) (float64, error) {
+	groups := gajiSeparator.Split(token, -1)
+	thousands := len(groups) > 1
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			thousands = false
+			break
+		}
+	}
+	if thousands {
+		return strconv.ParseFloat(strings.Join(groups, ""), 64)
+	}
+	normalized := strings.ReplaceAll(token, ",", ".")
+	if strings.Count(normalized, ".") > 1 {
+		last := strings.LastIndex(normalized, ".")
+		normalized = strings.ReplaceAll(normalized[:last], ".", "") + normalized[last:]
+	}
+	return strconv.ParseFloat(normalized, 64)
+}
+
+func gajiMultiplier(unit string) float64 {
+	switch unit {
+	case "juta", "jt":
+		return 1000000
+	case "ribu", "rb", "k":
+		return 1000
+	}
+	return 1
+}
+
+func containsAny(text string, needles []string) bool {
+	for _, n := range needles {
+		if strings.Contains(text, n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,378 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Validasi request berbasis tag `validate:"..."`, mis. `validate:"required,email,max=100"`.
+// Nama field di pesan error mengikuti tag json. Struct dan slice of struct di
+// dalam request ikut divalidasi, dengan nama field seperti `pertanyaan[0].kode`.
+//
+// Rule bawaan:
+//   required            tidak boleh kosong (string berisi spasi dianggap kosong)
+//   omitempty           lewati rule lain jika kosong
+//   required_without=F  wajib diisi jika field F kosong
+//   min=N, max=N, len=N panjang string/slice atau nilai angka
+//   oneof=a b c         salah satu dari nilai yang disebut
+//   email, url          format email / URL http(s)
+//   gtefield=F          angka tidak boleh lebih kecil dari field F (jika F diisi)
+//   nim, phone, tahun   format NIM, nomor telepon Indonesia, tahun 1950..tahun depan
+// Rule lain bisa ditambahkan dengan RegisterValidation. Rule yang tidak dikenal
+// membuat ValidateStruct mengembalikan error biasa (bukan ValidationErrors),
+// sehingga tag yang salah ketik menjadi 500, bukan panic.
+
+// FieldError kesalahan validasi untuk satu field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors semua field yang gagal validasi
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidationRule rule validasi kustom. Check menerima nilai field (bukan pointer)
+// dan parameter setelah tanda "=" di tag.
+type ValidationRule struct {
+	Check   func(value reflect.Value, param string) bool
+	Message func(param string) string
+}
+
+var (
+	validationRulesMu sync.RWMutex
+	validationRules   = map[string]ValidationRule{}
+)
+
+// RegisterValidation menambahkan atau mengganti rule validasi kustom
+func RegisterValidation(name string, rule ValidationRule) {
+	validationRulesMu.Lock()
+	defer validationRulesMu.Unlock()
+	validationRules[name] = rule
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+	nimPattern   = regexp.MustCompile(`^[A-Za-z0-9]{5,20}$`)
+	phonePattern = regexp.MustCompile(`^(\+62|62|0)[0-9]{8,13}$`)
+)
+
+// Rentang tahun yang diterima untuk angkatan dan tahun lulus
+const minTahun = 1950
+
+func init() {
+	RegisterValidation("nim", ValidationRule{
+		Check: func(value reflect.Value, _ string) bool {
+			return value.Kind() == reflect.String && nimPattern.MatchString(value.String())
+		},
+		Message: func(string) string { return "NIM harus 5-20 karakter huruf atau angka" },
+	})
+	RegisterValidation("phone", ValidationRule{
+		Check: func(value reflect.Value, _ string) bool {
+			if value.Kind() != reflect.String {
+				return false
+			}
+			phone := strings.NewReplacer(" ", "", "-", "").Replace(value.String())
+			return phonePattern.MatchString(phone)
+		},
+		Message: func(string) string { return "Nomor telepon harus diawali 0, 62, atau +62 dan berisi 9-14 digit" },
+	})
+	RegisterValidation("tahun", ValidationRule{
+		Check: func(value reflect.Value, _ string) bool {
+			if !isIntKind(value.Kind()) {
+				return false
+			}
+			tahun := value.Int()
+			return tahun >= minTahun && tahun <= int64(time.Now().Year()+1)
+		},
+		Message: func(string) string {
+			return fmt.Sprintf("Tahun harus antara %d dan %d", minTahun, time.Now().Year()+1)
+		},
+	})
+}
+
+// ValidateStruct menjalankan rule dari tag validate pada struct (atau pointer ke struct).
+// Mengembalikan ValidationErrors jika ada field yang gagal, nil jika valid.
+func ValidateStruct(s interface{}) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := validateStructValue(value, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStructValue(value reflect.Value, prefix string, errs *ValidationErrors) error {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fieldValue := value.Field(i)
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			fieldErr, err := validateField(value, fieldValue, tag)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", structType.Name(), field.Name, err)
+			}
+			if fieldErr != "" {
+				*errs = append(*errs, FieldError{Field: path, Message: fieldErr})
+				continue
+			}
+		}
+		if err := validateNested(fieldValue, path, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested turun ke struct dan slice of struct di dalam field
+func validateNested(value reflect.Value, path string, errs *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		return validateStructValue(value, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := validateNested(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField menjalankan rule satu field secara berurutan dan mengembalikan
+// pesan error pertama, atau string kosong jika valid. error hanya untuk tag yang
+// memakai rule tidak dikenal, juga saat field-nya kosong.
+func validateField(parent reflect.Value, value reflect.Value, tag string) (string, error) {
+	rules := strings.Split(tag, ",")
+	// Nama rule dicek dulu semuanya: omitempty bisa berhenti sebelum rule yang salah ketik
+	for _, rule := range rules {
+		name, _, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "", "omitempty", "required", "required_without":
+		default:
+			if !knownRule(name) {
+				return "", fmt.Errorf("validator: rule %q tidak dikenal", name)
+			}
+		}
+	}
+
+	isPtr := value.Kind() == reflect.Ptr
+	empty := isEmptyValue(value)
+	if isPtr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			if empty {
+				return "", nil
+			}
+		case "required":
+			if empty {
+				return "Wajib diisi", nil
+			}
+		case "required_without":
+			other := parent.FieldByName(param)
+			if empty && other.IsValid() && isEmptyValue(other) {
+				return fmt.Sprintf("Wajib diisi jika %s kosong", fieldJSONName(parent, param)), nil
+			}
+		default:
+			if empty {
+				// Field kosong yang tidak wajib tidak diperiksa rule lain
+				continue
+			}
+			if msg := checkRule(parent, value, name, param); msg != "" {
+				return msg, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// knownRule true untuk rule bawaan checkRule dan rule dari RegisterValidation
+func knownRule(name string) bool {
+	switch name {
+	case "min", "max", "len", "oneof", "email", "url", "gtefield":
+		return true
+	}
+	validationRulesMu.RLock()
+	defer validationRulesMu.RUnlock()
+	_, ok := validationRules[name]
+	return ok
+}
+
+// checkRule menjalankan satu rule; nama rule sudah dicek knownRule
+func checkRule(parent reflect.Value, value reflect.Value, name, param string) string {
+	switch name {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return ""
+		}
+		size, isLength := valueSize(value)
+		unit := ""
+		if isLength {
+			unit = " item"
+			if value.Kind() == reflect.String {
+				unit = " karakter"
+			}
+		}
+		switch {
+		case name == "min" && size < limit:
+			return fmt.Sprintf("Minimal %s%s", param, unit)
+		case name == "max" && size > limit:
+			return fmt.Sprintf("Maksimal %s%s", param, unit)
+		case name == "len" && size != limit:
+			return fmt.Sprintf("Harus tepat %s%s", param, unit)
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if actual == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("Harus salah satu dari: %s", strings.Join(options, ", "))
+	case "email":
+		if value.Kind() != reflect.String || !emailPattern.MatchString(value.String()) {
+			return "Format email tidak valid"
+		}
+	case "url":
+		if value.Kind() != reflect.String {
+			return "Format URL tidak valid"
+		}
+		parsed, err := url.Parse(value.String())
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "Format URL tidak valid (harus diawali http:// atau https://)"
+		}
+	case "gtefield":
+		other := parent.FieldByName(param)
+		if !other.IsValid() || isEmptyValue(other) {
+			return ""
+		}
+		if other.Kind() == reflect.Ptr {
+			other = other.Elem()
+		}
+		actual, _ := valueSize(value)
+		limit, _ := valueSize(other)
+		if actual < limit {
+			return fmt.Sprintf("Tidak boleh lebih kecil dari %s", fieldJSONName(parent, param))
+		}
+	default:
+		validationRulesMu.RLock()
+		custom, ok := validationRules[name]
+		validationRulesMu.RUnlock()
+		if ok && !custom.Check(value, param) {
+			return custom.Message(param)
+		}
+	}
+	return ""
+}
+
+// valueSize panjang string (dalam karakter) atau slice, atau nilai angka.
+// isLength bernilai true jika yang dihitung adalah panjang.
+func valueSize(value reflect.Value) (size float64, isLength bool) {
+	switch {
+	case value.Kind() == reflect.String:
+		return float64(len([]rune(value.String()))), true
+	case value.Kind() == reflect.Slice, value.Kind() == reflect.Array, value.Kind() == reflect.Map:
+		return float64(value.Len()), true
+	case isIntKind(value.Kind()):
+		return float64(value.Int()), false
+	case value.Kind() >= reflect.Uint && value.Kind() <= reflect.Uint64:
+		return float64(value.Uint()), false
+	case value.Kind() == reflect.Float32, value.Kind() == reflect.Float64:
+		return value.Float(), false
+	}
+	return 0, false
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// fieldJSONName nama json untuk field lain di struct yang sama (untuk pesan error)
+func fieldJSONName(parent reflect.Value, goName string) string {
+	if field, ok := parent.Type().FieldByName(goName); ok {
+		return jsonFieldName(field)
+	}
+	return goName
+}
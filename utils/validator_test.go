@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validatorPertanyaan struct {
+	Kode string `json:"kode" validate:"required,max=5"`
+}
+
+type validatorRequest struct {
+	Nama          string                `json:"nama" validate:"required,min=3,max=10"`
+	Email         string                `json:"email" validate:"omitempty,email"`
+	Website       string                `json:"website" validate:"omitempty,url"`
+	NIM           string                `json:"nim" validate:"omitempty,nim"`
+	NoTelepon     string                `json:"no_telepon" validate:"required_without=Email,omitempty,phone"`
+	Status        string                `json:"status" validate:"omitempty,oneof=aktif lulus"`
+	Angkatan      int                   `json:"angkatan" validate:"omitempty,tahun"`
+	GajiMin       *int                  `json:"gaji_min" validate:"omitempty,min=0"`
+	GajiMax       *int                  `json:"gaji_max" validate:"omitempty,gtefield=GajiMin"`
+	Kode          string                `json:"kode" validate:"omitempty,len=4"`
+	Pertanyaan    []validatorPertanyaan `json:"pertanyaan" validate:"omitempty,max=2"`
+	Dibuat        time.Time             `json:"dibuat"`
+	Diabaikan     string                `json:"-" validate:"required"`
+	tidakDiekspor string
+}
+
+func validRequest() validatorRequest {
+	return validatorRequest{Nama: "Budi", Email: "budi@example.com"}
+}
+
+func TestValidateStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *validatorRequest)
+		want   map[string]string // field -> awal pesan; kosong = valid
+	}{
+		{name: "valid", modify: func(r *validatorRequest) {}},
+		{name: "required", modify: func(r *validatorRequest) { r.Nama = "   " }, want: map[string]string{"nama": "Wajib diisi"}},
+		{name: "min karakter", modify: func(r *validatorRequest) { r.Nama = "Bu" }, want: map[string]string{"nama": "Minimal 3 karakter"}},
+		{name: "max karakter unicode", modify: func(r *validatorRequest) { r.Nama = "ÀÀÀÀÀÀÀÀÀÀ" }},
+		{name: "max karakter", modify: func(r *validatorRequest) { r.Nama = "Budi Santoso" }, want: map[string]string{"nama": "Maksimal 10 karakter"}},
+		{name: "email", modify: func(r *validatorRequest) { r.Email = "budi@" }, want: map[string]string{"email": "Format email tidak valid"}},
+		{name: "url tanpa http", modify: func(r *validatorRequest) { r.Website = "ftp://example.com" }, want: map[string]string{"website": "Format URL tidak valid"}},
+		{name: "url valid", modify: func(r *validatorRequest) { r.Website = "https://example.com" }},
+		{name: "nim", modify: func(r *validatorRequest) { r.NIM = "12-34" }, want: map[string]string{"nim": "NIM harus"}},
+		{name: "required_without", modify: func(r *validatorRequest) { r.Email = "" }, want: map[string]string{"no_telepon": "Wajib diisi jika email kosong"}},
+		{name: "phone", modify: func(r *validatorRequest) { r.NoTelepon = "12345" }, want: map[string]string{"no_telepon": "Nomor telepon"}},
+		{name: "phone dengan pemisah", modify: func(r *validatorRequest) { r.NoTelepon = "+62 812-3456-7890" }},
+		{name: "oneof", modify: func(r *validatorRequest) { r.Status = "cuti" }, want: map[string]string{"status": "Harus salah satu dari: aktif, lulus"}},
+		{name: "tahun", modify: func(r *validatorRequest) { r.Angkatan = 1900 }, want: map[string]string{"angkatan": "Tahun harus antara"}},
+		{name: "tahun depan", modify: func(r *validatorRequest) { r.Angkatan = time.Now().Year() + 1 }},
+		{name: "min angka", modify: func(r *validatorRequest) { r.GajiMin = intPtr(-1) }, want: map[string]string{"gaji_min": "Minimal 0"}},
+		{name: "pointer nol tidak kosong", modify: func(r *validatorRequest) { r.GajiMin = intPtr(0) }},
+		{
+			name:   "gtefield",
+			modify: func(r *validatorRequest) { r.GajiMin, r.GajiMax = intPtr(5), intPtr(4) },
+			want:   map[string]string{"gaji_max": "Tidak boleh lebih kecil dari gaji_min"},
+		},
+		{name: "gtefield tanpa pembanding", modify: func(r *validatorRequest) { r.GajiMax = intPtr(4) }},
+		{name: "len", modify: func(r *validatorRequest) { r.Kode = "ABC" }, want: map[string]string{"kode": "Harus tepat 4 karakter"}},
+		{
+			name:   "max item",
+			modify: func(r *validatorRequest) { r.Pertanyaan = make([]validatorPertanyaan, 3) },
+			want:   map[string]string{"pertanyaan": "Maksimal 2 item"},
+		},
+		{
+			name:   "slice of struct",
+			modify: func(r *validatorRequest) { r.Pertanyaan = []validatorPertanyaan{{Kode: "Q1"}, {Kode: "TERLALU"}} },
+			want:   map[string]string{"pertanyaan[1].kode": "Maksimal 5 karakter"},
+		},
+		{
+			name:   "beberapa field sekaligus",
+			modify: func(r *validatorRequest) { r.Nama, r.Status = "", "x" },
+			want:   map[string]string{"nama": "Wajib diisi", "status": "Harus salah satu dari"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(&req)
+			err := ValidateStruct(&req)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("err = %v, want ValidationErrors", err)
+			}
+			got := map[string]string{}
+			for _, fieldErr := range errs {
+				got[fieldErr.Field] = fieldErr.Message
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("errors = %v, want field %v", got, tt.want)
+			}
+			for field, prefix := range tt.want {
+				if !strings.HasPrefix(got[field], prefix) {
+					t.Errorf("%s = %q, want awalan %q", field, got[field], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateStructNonStruct(t *testing.T) {
+	var nilReq *validatorRequest
+	for _, value := range []interface{}{nil, nilReq, "teks", 42} {
+		if err := ValidateStruct(value); err != nil {
+			t.Errorf("ValidateStruct(%#v) = %v, want nil", value, err)
+		}
+	}
+}
+
+func TestValidateStructUnknownRule(t *testing.T) {
+	type salahKetik struct {
+		Nama string `json:"nama" validate:"omitempty,emial"`
+	}
+	type bersarang struct {
+		Isi []salahKetik `json:"isi"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"field terisi", &salahKetik{Nama: "Budi"}},
+		{"field kosong", &salahKetik{}},
+		{"di dalam slice", &bersarang{Isi: []salahKetik{{Nama: "Budi"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(tt.value)
+			var errs ValidationErrors
+			if err == nil || errors.As(err, &errs) {
+				t.Fatalf("err = %v, want error biasa", err)
+			}
+			if !strings.Contains(err.Error(), `salahKetik.Nama`) || !strings.Contains(err.Error(), `"emial"`) {
+				t.Errorf("err = %q, want menyebut field dan rule", err)
+			}
+		})
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	RegisterValidation("test_prefix", ValidationRule{
+		Check: func(value reflect.Value, param string) bool {
+			return strings.HasPrefix(value.String(), param)
+		},
+		Message: func(param string) string { return fmt.Sprintf("Harus diawali %s", param) },
+	})
+	type request struct {
+		Kode string `json:"kode" validate:"test_prefix=PT-"`
+	}
+
+	if err := ValidateStruct(request{Kode: "PT-001"}); err != nil {
+		t.Errorf("valid: err = %v", err)
+	}
+	err := ValidateStruct(request{Kode: "CV-001"})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "Harus diawali PT-" {
+		t.Errorf("err = %v, want kode: Harus diawali PT-", err)
+	}
+}